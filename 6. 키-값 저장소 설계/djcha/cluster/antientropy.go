@@ -0,0 +1,287 @@
+package cluster
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kv-store/merkle"
+	"kv-store/store"
+)
+
+// AntiEntropyConfig: 머클 트리 안티 엔트로피 설정
+//
+// 힌트 전달과 읽기 복구로도 못 맞춘 복제본(힌트 만료, 아무도 읽지 않는 키)을
+// 주기적으로 다른 복제본과 머클 트리로 비교해서 다른 키만 주고받아 맞춤
+// 삭제 표시도 값과 똑같이 비교 대상이므로, 한쪽만 지운 키가 다른 쪽 값으로 되살아나지 않음
+type AntiEntropyConfig struct {
+	Interval time.Duration // 비교 주기 (0 이면 주기적으로 돌리지 않음)
+	Buckets  int           // 머클 트리 버킷(리프) 개수 (0 이면 DefaultAntiEntropyBuckets)
+	// Dir: 상대 노드별 머클 트리를 저장해 둘 디렉터리 (비우면 저장하지 않고 비교할 때마다 새로 만듦)
+	// 저장해 두면 데이터가 바뀌지 않은 동안은 다시 만들지 않고, 바뀌었으면 백그라운드에서 새로 만듦
+	// 트리를 처음 만드는 동안은 복구를 멈춤 (ErrTreeRebuilding)
+	Dir string
+}
+
+// ErrTreeRebuilding: 비교할 머클 트리를 아직 만드는 중이라 이번 안티 엔트로피를 건너뜀
+var ErrTreeRebuilding = errors.New("cluster: merkle tree is being rebuilt")
+
+// DefaultAntiEntropyBuckets: 버킷 개수 기본값
+const DefaultAntiEntropyBuckets = 4096
+
+// DefaultAntiEntropyConfig: 10분마다 비교
+var DefaultAntiEntropyConfig = AntiEntropyConfig{
+	Interval: 10 * time.Minute,
+	Buckets:  DefaultAntiEntropyBuckets,
+}
+
+// AntiEntropyStats: 안티 엔트로피 지표
+type AntiEntropyStats struct {
+	Rounds     uint64 `json:"rounds"`      // 다른 노드와 비교한 횟수
+	Buckets    uint64 `json:"buckets"`     // 해시가 달라서 키 목록을 주고받은 버킷 수
+	KeysPulled uint64 `json:"keys_pulled"` // 상대에게서 받아 로컬에 합친 키 수
+	KeysPushed uint64 `json:"keys_pushed"` // 상대에게 보낸 키 수
+	Failed     uint64 `json:"failed"`      // 통신 실패로 중단한 비교 수
+	Paused     uint64 `json:"paused"`      // 머클 트리를 만드는 중이라 건너뛴 비교 수
+}
+
+type antiEntropyCounters struct {
+	rounds, buckets, pulled, pushed, failed, paused atomic.Uint64
+}
+
+func (c *antiEntropyCounters) snapshot() AntiEntropyStats {
+	return AntiEntropyStats{
+		Rounds:     c.rounds.Load(),
+		Buckets:    c.buckets.Load(),
+		KeysPulled: c.pulled.Load(),
+		KeysPushed: c.pushed.Load(),
+		Failed:     c.failed.Load(),
+		Paused:     c.paused.Load(),
+	}
+}
+
+// AntiEntropy: peer 와 같이 맡고 있는 키들을 머클 트리로 비교해서 양쪽을 맞춤
+//
+//  1. 양쪽이 같이 복제하는 키만으로 버킷 머클 트리를 만들고, 상대의 버킷 해시 목록을 받음
+//  2. 루트부터 내려가며 해시가 다른 버킷을 찾음 (같으면 통신 1번으로 끝)
+//  3. 다른 버킷의 키와 형제 값만 받아서 합침 → 로컬에 없는 버전은 저장, 상대에 없는 버전은 보냄
+//
+// 어느 한쪽이라도 트리를 만드는 중이면 비교하지 않고 ErrTreeRebuilding
+func (n *Node) AntiEntropy(ctx context.Context, peer string) error {
+	buckets := n.cfg.AntiEntropy.Buckets
+	local, ok := n.peerTree(peer, buckets)
+	if !ok {
+		n.antiEntropy.paused.Add(1)
+		return ErrTreeRebuilding
+	}
+
+	// 통신마다 제한 시간을 걸어서, 응답을 잃어버린 상대 때문에 주기 작업이 멈추지 않게 함
+	callCtx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+	remote, err := n.transport.MerkleBuckets(callCtx, peer, n.id, buckets)
+	cancel()
+	if errors.Is(err, ErrTreeRebuilding) {
+		n.antiEntropy.paused.Add(1)
+		return err
+	}
+	if err != nil {
+		n.antiEntropy.failed.Add(1)
+		return err
+	}
+	n.antiEntropy.rounds.Add(1)
+	// 저장해 둔 트리가 조금 늦은 버전이어도 됨
+	// 놓친 차이는 다음 비교에서 찾고, 실제로 주고받을 값은 아래에서 지금 저장소로 다시 비교함
+	diff, err := merkle.DiffBucketTree(local, remote)
+	if err != nil || len(diff) == 0 {
+		return err
+	}
+	n.antiEntropy.buckets.Add(uint64(len(diff)))
+
+	callCtx, cancel = context.WithTimeout(ctx, n.cfg.Timeout)
+	theirs, err := n.transport.MerkleEntries(callCtx, peer, n.id, buckets, diff)
+	cancel()
+	if err != nil {
+		n.antiEntropy.failed.Add(1)
+		return err
+	}
+	ours := n.sharedEntries(peer, buckets, diff)
+
+	// 키 순서대로 주고받음 (같은 상태에서는 항상 같은 순서로 메시지를 보내므로 시뮬레이션을 시드로 다시 재현할 수 있음)
+	for _, key := range slices.Sorted(maps.Keys(theirs)) {
+		pulled := false
+		for _, v := range missingVersions(ours[key], theirs[key]) {
+			pulled = n.store.Put(key, v) || pulled
+		}
+		if pulled {
+			n.antiEntropy.pulled.Add(1)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(ours)) {
+		missing := missingVersions(theirs[key], ours[key])
+		if len(missing) == 0 {
+			continue
+		}
+		for _, v := range missing {
+			callCtx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+			err := n.transport.Put(callCtx, peer, key, v)
+			cancel()
+			if err != nil {
+				n.antiEntropy.failed.Add(1)
+				return err
+			}
+		}
+		n.antiEntropy.pushed.Add(1)
+	}
+	return nil
+}
+
+// antiEntropyRound: 이 노드와 키 범위를 같이 맡는 다른 모든 노드와 한 번씩 비교
+func (n *Node) antiEntropyRound(ctx context.Context) {
+	for _, peer := range n.ring.Servers() {
+		if peer == n.id || n.health.isDown(peer) {
+			continue
+		}
+		_ = n.AntiEntropy(ctx, peer)
+	}
+}
+
+// peerTree: peer 와 같이 맡은 키로 만든 버킷 트리 (처음 만드는 중이면 false → 복구를 멈춰야 함)
+// Dir 이 없으면 매번 새로 만듦
+// Dir 이 있으면 저장해 둔 트리를 쓰고, 그 뒤로 데이터가 바뀌었으면 다음 비교를 위해 백그라운드에서 새로 만듦
+func (n *Node) peerTree(peer string, buckets int) (*merkle.Tree, bool) {
+	build := func() *merkle.Tree { return merkle.BuildKeyed(n.sharedDigests(peer), buckets).Tree() }
+	version := n.treeVersion(buckets)
+	d := n.trees.open(peer, buckets, version, build)
+	if d == nil {
+		return build(), true
+	}
+	t, ver, ok := d.Tree()
+	if !ok {
+		return nil, false
+	}
+	if ver != version {
+		d.Refresh(version, build)
+	}
+	return t, true
+}
+
+// treeVersion: 공유 키 트리를 만든 데이터의 버전 (저장소 순번, 링 구성, 복제본 수, 버킷 수)
+// 저장소가 메모리에 있어서 재시작하면 순번이 처음부터 다시 붙으므로,
+// 노드마다 만든 때 정한 임의의 값(epoch)을 섞어 이전 실행에서 저장한 파일은 항상 다시 만들게 함
+func (n *Node) treeVersion(buckets int) uint64 {
+	h := fnv.New64a()
+	var buf []byte
+	buf = binary.LittleEndian.AppendUint64(buf, n.trees.epoch)
+	buf = binary.LittleEndian.AppendUint64(buf, n.store.Seq())
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(n.cfg.N))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(buckets))
+	for _, server := range slices.Sorted(slices.Values(n.ring.Servers())) {
+		buf = append(buf, server...)
+		buf = append(buf, 0)
+	}
+	h.Write(buf)
+	return h.Sum64()
+}
+
+// peerTrees: 상대 노드별로 파일에 저장해 둔 머클 트리
+type peerTrees struct {
+	mu     sync.Mutex
+	dir    string // 비어 있으면 저장하지 않음
+	epoch  uint64
+	byPath map[string]*merkle.DiskTree
+	closed bool
+}
+
+// open: peer, 버킷 수에 맞는 트리 (처음이면 파일을 열거나 백그라운드에서 만들기 시작, 저장하지 않으면 nil)
+func (p *peerTrees) open(peer string, buckets int, version uint64, build func() *merkle.Tree) *merkle.DiskTree {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dir == "" || p.closed {
+		return nil
+	}
+
+	name := strings.NewReplacer(":", "_", "/", "_").Replace(peer) + "-" + strconv.Itoa(buckets) + ".tree"
+	path := filepath.Join(p.dir, name)
+	d, ok := p.byPath[path]
+	if !ok {
+		d = merkle.LoadOrRebuild(path, version, build)
+		p.byPath[path] = d
+	}
+	return d
+}
+
+// close: 모든 트리의 매핑을 해제 (이후에는 저장하지 않고 매번 새로 만듦)
+func (p *peerTrees) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	var errs []error
+	for _, d := range p.byPath {
+		errs = append(errs, d.Close())
+	}
+	p.byPath = nil
+	return errors.Join(errs...)
+}
+
+// sharedKeys: 로컬 키 중 이 노드와 peer 가 둘 다 선호 목록에 있는 키
+func (n *Node) sharedKeys(peer string) []string {
+	var keys []string
+	for _, key := range n.store.Keys() {
+		owners := n.ring.PreferenceList(key, n.cfg.N)
+		if slices.Contains(owners, n.id) && slices.Contains(owners, peer) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// sharedDigests: 머클 트리를 만들 공유 키 목록 (키 → 형제 값 인코딩)
+func (n *Node) sharedDigests(peer string) map[string][]byte {
+	entries := make(map[string][]byte)
+	for _, key := range n.sharedKeys(peer) {
+		if siblings, ok := n.store.Get(key); ok {
+			entries[key] = encodeSiblings(siblings)
+		}
+	}
+	return entries
+}
+
+// sharedEntries: 지정한 버킷에 속하는 공유 키의 형제 값
+func (n *Node) sharedEntries(peer string, buckets int, want []int) map[string][]store.Value {
+	entries := make(map[string][]store.Value)
+	for _, key := range n.sharedKeys(peer) {
+		if _, ok := slices.BinarySearch(want, merkle.BucketOf(key, buckets)); !ok {
+			continue
+		}
+		if siblings, ok := n.store.Get(key); ok {
+			entries[key] = siblings
+		}
+	}
+	return entries
+}
+
+// encodeSiblings: 형제 값 목록을 복제본마다 같은 바이트열로 인코딩 (삭제 표시, 만료 시각 포함)
+// 저장 순서가 복제본마다 다를 수 있으므로 정렬한 뒤 인코딩
+func encodeSiblings(siblings []store.Value) []byte {
+	sorted := slices.Clone(siblings)
+	slices.SortFunc(sorted, func(a, b store.Value) int {
+		return cmp.Or(
+			cmp.Compare(a.Timestamp, b.Timestamp),
+			cmp.Compare(a.Clock.Encode(), b.Clock.Encode()),
+			cmp.Compare(string(a.Data), string(b.Data)),
+		)
+	})
+	b, _ := json.Marshal(sorted)
+	return b
+}
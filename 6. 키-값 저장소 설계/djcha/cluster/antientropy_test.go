@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kv-store/store"
+	"kv-store/vclock"
+)
+
+func TestAntiEntropyWithStoredTrees(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	a, b := tc.nodes[0], tc.nodes[1]
+	dir := t.TempDir()
+	for _, n := range []*Node{a, b} {
+		n.trees.dir = filepath.Join(dir, strings.ReplaceAll(n.ID(), ":", "_"))
+		if err := os.MkdirAll(n.trees.dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		defer n.Close()
+	}
+
+	// 트리를 처음 만드는 동안에는 복구를 건너뛰고(ErrTreeRebuilding), 다 만든 뒤에 맞춤
+	// 저장해 둔 트리가 조금 늦은 버전이어도 다음 비교에서 따라잡음
+	repairs := func(key string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			err := a.AntiEntropy(ctx, b.ID())
+			if err != nil && !errors.Is(err, ErrTreeRebuilding) {
+				t.Fatal(err)
+			}
+			if _, ok := b.Store().Get(key); ok {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s was never repaired (stats %+v)", key, a.Metrics().AntiEntropy)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	a.Store().Put("cfg/a", store.Value{Data: []byte("v1"), Clock: vclock.Clock{a.ID(): {Counter: 1}}})
+	repairs("cfg/a")
+	a.Store().Put("cfg/b", store.Value{Data: []byte("v1"), Clock: vclock.Clock{a.ID(): {Counter: 2}}})
+	repairs("cfg/b")
+
+	name := strings.ReplaceAll(b.ID(), ":", "_") + "-4096.tree"
+	if _, err := os.Stat(filepath.Join(a.trees.dir, name)); err != nil {
+		t.Fatalf("tree for %s was not stored under the data dir: %v", b.ID(), err)
+	}
+}
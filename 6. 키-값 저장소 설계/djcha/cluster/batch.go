@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"kv-store/store"
+	"kv-store/vclock"
+)
+
+// Condition: 조건부 쓰기의 전제 조건 (HTTP If-Match / If-None-Match)
+type Condition struct {
+	Version      vclock.Clock // nil 이 아니면 현재 버전이 정확히 이 버전이어야 함
+	MustExist    bool         // If-Match: *
+	MustNotExist bool         // If-None-Match: *
+}
+
+func (c Condition) empty() bool {
+	return c.Version == nil && !c.MustExist && !c.MustNotExist
+}
+
+// Op: 배치 안의 쓰기 하나
+type Op struct {
+	Key    string
+	Delete bool
+	Data   []byte
+	TTL    time.Duration
+	If     Condition
+}
+
+// PreconditionFailure: 만족하지 못한 전제 조건
+type PreconditionFailure struct {
+	Key     string
+	Reason  string       // "version_mismatch" / "not_found" / "exists"
+	Current vclock.Clock // 현재 버전 (없는 키면 삭제 표시의 버전 또는 빈 시계)
+}
+
+// PreconditionError: 배치의 전제 조건 중 하나 이상이 맞지 않아 아무것도 쓰지 않음
+type PreconditionError struct {
+	Failed []PreconditionFailure
+}
+
+func (e *PreconditionError) Error() string {
+	keys := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		keys[i] = f.Key + " (" + f.Reason + ")"
+	}
+	return "cluster: precondition failed: " + strings.Join(keys, ", ")
+}
+
+// ErrDuplicateKey: 배치 안에 같은 키가 두 번 나옴
+var ErrDuplicateKey = errors.New("cluster: duplicate key in batch")
+
+// Apply: 여러 키의 쓰기/삭제를 전제 조건과 함께 한 번에 적용하고, 키마다 새 버전을 돌려줌
+//
+//  1. 모든 키를 주 복제본에서 정렬된 순서로 잠금 → 다른 Apply 와 겹치지 않음
+//  2. 모든 키의 현재 버전을 읽어 전제 조건 확인 → 하나라도 틀리면 아무것도 쓰지 않고 PreconditionError
+//  3. 현재 버전을 문맥으로 써서 형제 값 없이 덮어씀
+//  4. 중간에 쓰기가 실패하면 이미 쓴 키를 이전 값으로 되돌림 (보상 쓰기)
+//
+// 원자성은 되돌리기(보상 쓰기)로만 보장하므로 격리는 Apply 끼리만 됨
+//   - 읽기는 잠그지 않으므로 적용 중이거나 되돌리는 중인 배치의 일부 키만 바뀐 상태를 볼 수 있음
+//   - Put/Delete 는 잠그지 않으므로 배치의 쓰기와 되돌리기 사이에 끼어들 수 있음
+//     되돌리기는 배치가 쓴 버전만 덮으므로 끼어든 값은 되돌린 값과 형제 값으로 남음
+//
+// 전제 조건 확인이 최신 버전을 보려면 읽기와 쓰기 복제본이 겹쳐야 함 (R + W > N)
+func (n *Node) Apply(ctx context.Context, ops []Op, level Consistency) ([]vclock.Clock, error) {
+	keys := make([]string, len(ops))
+	for i, op := range ops {
+		keys[i] = op.Key
+	}
+	slices.Sort(keys)
+	if len(slices.Compact(slices.Clone(keys))) != len(keys) {
+		return nil, ErrDuplicateKey
+	}
+
+	token := newLockToken()
+	held, err := n.lockKeys(ctx, keys, token)
+	if err != nil {
+		return nil, err
+	}
+	defer n.unlockKeys(held, token)
+
+	before := make([]Result, len(ops))
+	exists := make([]bool, len(ops))
+	var failed []PreconditionFailure
+	for i, op := range ops {
+		before[i], err = n.Get(ctx, op.Key, ReadOptions{Consistency: level})
+		exists[i] = err == nil
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if reason := check(op.If, before[i].Context, exists[i]); reason != "" {
+			failed = append(failed, PreconditionFailure{Key: op.Key, Reason: reason, Current: before[i].Context})
+		}
+	}
+	if len(failed) > 0 {
+		return nil, &PreconditionError{Failed: failed}
+	}
+
+	versions := make([]vclock.Clock, len(ops))
+	for i, op := range ops {
+		v := store.Value{Data: op.Data, Deleted: op.Delete}
+		written, err := n.write(ctx, op.Key, v, WriteOptions{Consistency: level, Context: before[i].Context, TTL: op.TTL})
+		versions[i] = written.Clock
+		if err != nil {
+			// 실패한 쓰기도 일부 복제본에는 들어갔을 수 있으므로 함께 되돌림
+			n.rollback(ctx, ops[:i+1], before[:i+1], exists[:i+1], versions[:i+1], level)
+			return nil, fmt.Errorf("cluster: batch aborted at %q: %w", op.Key, err)
+		}
+	}
+	return versions, nil
+}
+
+// check: 전제 조건을 만족하지 않으면 이유
+func check(c Condition, current vclock.Clock, exists bool) string {
+	switch {
+	case c.empty():
+		return ""
+	case c.MustNotExist && exists:
+		return "exists"
+	case (c.MustExist || c.Version != nil) && !exists:
+		return "not_found"
+	case c.Version != nil && vclock.Compare(current, c.Version) != vclock.Equal:
+		return "version_mismatch"
+	}
+	return ""
+}
+
+// rollback: 이미 적용한 쓰기를 이전 상태로 되돌림
+// 새 버전을 문맥으로 쓰므로 되돌린 값이 방금 쓴 값을 덮어씀 (형제 값이 여러 개였으면 마지막 쓰기 값 하나로 돌아감)
+func (n *Node) rollback(ctx context.Context, ops []Op, before []Result, exists []bool, versions []vclock.Clock, level Consistency) {
+	for i, op := range ops {
+		v := store.Value{Deleted: true}
+		if exists[i] {
+			prev := LastWriteWins(op.Key, before[i].Siblings)
+			v = store.Value{Data: prev.Data, Expires: prev.Expires}
+		}
+		_, _ = n.write(ctx, op.Key, v, WriteOptions{Consistency: level, Context: versions[i]})
+	}
+}
@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"kv-store/vclock"
+)
+
+func TestApplyCompareAndSet(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	a, b := tc.nodes[0], tc.nodes[1]
+
+	versions, err := a.Apply(ctx, []Op{{Key: "doc", Data: []byte("v1"), If: Condition{MustNotExist: true}}}, Default)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	v1 := versions[0]
+
+	// 이미 있으면 생성 실패
+	_, err = b.Apply(ctx, []Op{{Key: "doc", Data: []byte("x"), If: Condition{MustNotExist: true}}}, Default)
+	var pe *PreconditionError
+	if !errors.As(err, &pe) || pe.Failed[0].Reason != "exists" {
+		t.Fatalf("second create: err = %v, want exists precondition failure", err)
+	}
+
+	// 같은 버전으로 두 번 바꾸면 두 번째는 실패
+	if _, err := b.Apply(ctx, []Op{{Key: "doc", Data: []byte("v2"), If: Condition{Version: v1}}}, Default); err != nil {
+		t.Fatalf("CAS with current version: %v", err)
+	}
+	_, err = a.Apply(ctx, []Op{{Key: "doc", Data: []byte("v3"), If: Condition{Version: v1}}}, Default)
+	if !errors.As(err, &pe) || pe.Failed[0].Reason != "version_mismatch" {
+		t.Fatalf("CAS with stale version: err = %v, want version_mismatch", err)
+	}
+	if vclock.Compare(pe.Failed[0].Current, v1) != vclock.After {
+		t.Fatal("failure should report the newer current version")
+	}
+
+	res, err := a.Get(ctx, "doc", ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := siblingData(res); len(got) != 1 || got[0] != "v2" {
+		t.Fatalf("doc = %v, want [v2] without siblings", got)
+	}
+}
+
+func TestApplyConcurrentIncrements(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	if err := tc.nodes[0].Put(ctx, "counter", []byte("0"), WriteOptions{Consistency: All}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 여러 코디네이터가 동시에 읽고 → +1 → CAS, 실패하면 다시 시도
+	// 잠금과 버전 확인이 맞으면 증가분이 하나도 사라지지 않음
+	const workers, each = 6, 5
+	var wg sync.WaitGroup
+	for i := range workers {
+		node := tc.nodes[i%len(tc.nodes)]
+		wg.Go(func() {
+			for done := 0; done < each; {
+				res, err := node.Get(ctx, "counter", ReadOptions{})
+				if err != nil {
+					continue
+				}
+				n, _ := strconv.Atoi(string(res.Siblings[0].Data))
+				op := Op{Key: "counter", Data: []byte(strconv.Itoa(n + 1)), If: Condition{Version: res.Context}}
+				if _, err := node.Apply(ctx, []Op{op}, Default); err == nil {
+					done++
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	res, err := tc.nodes[0].Get(ctx, "counter", ReadOptions{Consistency: All})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := siblingData(res); len(got) != 1 || got[0] != strconv.Itoa(workers*each) {
+		t.Fatalf("counter = %v, want [%d]", got, workers*each)
+	}
+}
+
+func TestApplyBatchIsAllOrNothing(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	node := tc.nodes[0]
+	if err := node.Put(ctx, "a", []byte("1"), WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := []Op{
+		{Key: "a", Data: []byte("2"), If: Condition{MustExist: true}},
+		{Key: "b", Data: []byte("2"), If: Condition{MustExist: true}}, // 없는 키 → 실패
+		{Key: "c", Delete: true, If: Condition{MustExist: true}},      // 없는 키 → 실패
+	}
+	_, err := node.Apply(ctx, ops, Default)
+	var pe *PreconditionError
+	if !errors.As(err, &pe) || len(pe.Failed) != 2 || pe.Failed[0].Key != "b" || pe.Failed[1].Key != "c" {
+		t.Fatalf("err = %v, want precondition failures for b and c", err)
+	}
+	res, err := node.Get(ctx, "a", ReadOptions{})
+	if err != nil || siblingData(res)[0] != "1" {
+		t.Fatalf("a changed by a failed batch: %v %v", siblingData(res), err)
+	}
+
+	ops = []Op{
+		{Key: "a", Delete: true, If: Condition{Version: res.Context}},
+		{Key: "b", Data: []byte("new"), If: Condition{MustNotExist: true}},
+	}
+	if _, err := node.Apply(ctx, ops, Default); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if _, err := node.Get(ctx, "a", ReadOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("a after batch delete: err = %v, want ErrNotFound", err)
+	}
+	if res, err := node.Get(ctx, "b", ReadOptions{}); err != nil || siblingData(res)[0] != "new" {
+		t.Fatalf("b after batch = %v %v", siblingData(res), err)
+	}
+
+	if _, err := node.Apply(ctx, []Op{{Key: "x"}, {Key: "x"}}, Default); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("duplicate keys: err = %v, want ErrDuplicateKey", err)
+	}
+}
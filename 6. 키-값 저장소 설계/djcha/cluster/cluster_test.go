@@ -0,0 +1,257 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"kv-store/ring"
+)
+
+// testCluster: 같은 프로세스 안에서 루프백 주소로 통신하는 노드들
+type testCluster struct {
+	nodes   []*Node
+	servers map[string]*httptest.Server
+	delays  sync.Map // 노드 주소 → 응답 지연 시간
+	down    sync.Map // 노드 주소 → 장애 여부
+}
+
+// startCluster: size 대의 노드를 127.0.0.1 의 임의 포트에 띄움
+func startCluster(t *testing.T, size int, cfg Config) *testCluster {
+	t.Helper()
+
+	tc := &testCluster{servers: make(map[string]*httptest.Server)}
+	r := ring.New(50)
+	var servers []*httptest.Server
+	for range size {
+		srv := httptest.NewUnstartedServer(nil) // 리스너 주소를 먼저 알아야 링을 만들 수 있음
+		servers = append(servers, srv)
+		r.Add(srv.Listener.Addr().String())
+	}
+
+	for _, srv := range servers {
+		tc.serve(t, srv, cfg, r)
+	}
+	return tc
+}
+
+// serve: srv 주소로 노드를 만들어 띄움 (장애/지연 흉내를 거쳐 노드 Handler 로 전달)
+func (tc *testCluster) serve(t *testing.T, srv *httptest.Server, cfg Config, r *ring.Ring) *Node {
+	t.Helper()
+
+	addr := srv.Listener.Addr().String()
+	node, err := NewNode(addr, cfg, r, NewHTTPTransport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := node.Handler()
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d, ok := tc.delays.Load(addr); ok {
+			time.Sleep(d.(time.Duration))
+		}
+		if down, _ := tc.down.Load(addr); down == true {
+			http.Error(w, "node down", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	tc.nodes = append(tc.nodes, node)
+	tc.servers[addr] = srv
+	return node
+}
+
+func (tc *testCluster) node(addr string) *Node {
+	for _, n := range tc.nodes {
+		if n.ID() == addr {
+			return n
+		}
+	}
+	return nil
+}
+
+// stop: 노드 장애 흉내 (모든 요청에 503 응답)
+func (tc *testCluster) stop(addr string) {
+	tc.down.Store(addr, true)
+}
+
+// restart: 장애 노드 복구
+func (tc *testCluster) restart(addr string) {
+	tc.down.Store(addr, false)
+}
+
+// slow: 노드 응답 지연 흉내
+func (tc *testCluster) slow(addr string, delay time.Duration) {
+	tc.delays.Store(addr, delay)
+}
+
+var testConfig = Config{N: 3, R: 2, W: 2, Timeout: 500 * time.Millisecond}
+
+func TestQuorumWriteThenReadFromAnotherCoordinator(t *testing.T) {
+	tc := startCluster(t, 5, testConfig)
+	ctx := context.Background()
+
+	if err := tc.nodes[0].Put(ctx, "user:1", []byte("alice"), WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := tc.nodes[4].Get(ctx, "user:1", ReadOptions{Consistency: Quorum})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Siblings) != 1 || string(v.Siblings[0].Data) != "alice" {
+		t.Fatalf("got %+v, want alice", v.Siblings)
+	}
+
+	// 나머지 복제본 쓰기도 곧 끝나서 선호 목록의 N 대 모두 값을 가져야 함
+	deadline := time.Now().Add(time.Second)
+	for _, addr := range tc.nodes[0].ring.PreferenceList("user:1", testConfig.N) {
+		for {
+			if _, ok := tc.node(addr).Store().Get("user:1"); ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("replica %s never received the write", addr)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+func TestConsistencyLevelsWithOneReplicaDown(t *testing.T) {
+	tc := startCluster(t, 5, testConfig)
+	ctx := context.Background()
+
+	replicas := tc.nodes[0].ring.PreferenceList("order:42", testConfig.N)
+	var coordinator *Node
+	for _, n := range tc.nodes {
+		if n.ID() != replicas[2] {
+			coordinator = n
+			break
+		}
+	}
+	tc.stop(replicas[2])
+
+	if err := coordinator.Put(ctx, "order:42", []byte("paid"), WriteOptions{Consistency: Quorum}); err != nil {
+		t.Fatalf("QUORUM write with one replica down: %v", err)
+	}
+	if _, err := coordinator.Get(ctx, "order:42", ReadOptions{Consistency: One}); err != nil {
+		t.Fatalf("ONE read: %v", err)
+	}
+
+	var qe *QuorumError
+	if err := coordinator.Put(ctx, "order:42", []byte("shipped"), WriteOptions{Consistency: All}); !errors.As(err, &qe) {
+		t.Fatalf("ALL write with one replica down: err = %v, want QuorumError", err)
+	}
+	// 실패가 확정되는 즉시 반환하므로 Acked 는 그 시점까지 도착한 성공 수 (0 ~ 2)
+	if qe.Required != 3 || qe.Acked > 2 {
+		t.Fatalf("QuorumError = %+v, want at most 2/3 acks", qe)
+	}
+}
+
+func TestTimeoutOnSlowReplica(t *testing.T) {
+	cfg := testConfig
+	cfg.Timeout = 100 * time.Millisecond
+	tc := startCluster(t, 3, cfg)
+	ctx := context.Background()
+
+	for _, n := range tc.nodes[1:] {
+		tc.slow(n.ID(), time.Second)
+	}
+
+	// 코디네이터 자신의 로컬 쓰기 1개만 바로 성공
+	if err := tc.nodes[0].Put(ctx, "k", []byte("v"), WriteOptions{Consistency: One}); err != nil {
+		t.Fatalf("ONE write: %v", err)
+	}
+	start := time.Now()
+	err := tc.nodes[0].Put(ctx, "k", []byte("v2"), WriteOptions{Consistency: Quorum})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("QUORUM write to slow replicas: err = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("QUORUM write took %v, want about the 100ms timeout", elapsed)
+	}
+}
+
+func TestReadReturnsNotFound(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	if _, err := tc.nodes[1].Get(context.Background(), "missing", ReadOptions{Consistency: All}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestConcurrentWritesBecomeSiblings(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	all := WriteOptions{Consistency: All}
+
+	// 서로의 쓰기를 모르는 두 클라이언트가 각자 다른 코디네이터로 씀 → 동시 버전
+	if err := tc.nodes[0].Put(ctx, "cart", []byte("apple"), all); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.nodes[1].Put(ctx, "cart", []byte("banana"), all); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := tc.nodes[2].Get(ctx, "cart", ReadOptions{Consistency: All})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := siblingData(res); !slices.Equal(got, []string{"apple", "banana"}) {
+		t.Fatalf("siblings = %v, want [apple banana]", got)
+	}
+
+	// 읽을 때 받은 문맥으로 다시 쓰면 두 형제 값이 하나로 합쳐짐
+	merged := WriteOptions{Consistency: All, Context: res.Context}
+	if err := tc.nodes[2].Put(ctx, "cart", []byte("apple,banana"), merged); err != nil {
+		t.Fatal(err)
+	}
+	res, err = tc.nodes[0].Get(ctx, "cart", ReadOptions{Consistency: All})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := siblingData(res); !slices.Equal(got, []string{"apple,banana"}) {
+		t.Fatalf("after merge write siblings = %v, want [apple,banana]", got)
+	}
+}
+
+func TestResolverPicksOneSibling(t *testing.T) {
+	cfg := testConfig
+	cfg.Resolver = LastWriteWins
+	tc := startCluster(t, 3, cfg)
+	ctx := context.Background()
+	all := WriteOptions{Consistency: All}
+
+	if err := tc.nodes[0].Put(ctx, "k", []byte("first"), all); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.nodes[1].Put(ctx, "k", []byte("second"), all); err != nil {
+		t.Fatal(err)
+	}
+	res, err := tc.nodes[2].Get(ctx, "k", ReadOptions{Consistency: All})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := siblingData(res); !slices.Equal(got, []string{"second"}) {
+		t.Fatalf("resolved = %v, want [second]", got)
+	}
+	// 정리된 값의 시계는 두 형제 값을 모두 포함해야 다음 쓰기가 둘을 덮어씀
+	if len(res.Context) != 2 {
+		t.Fatalf("context = %v, want entries for both coordinators", res.Context)
+	}
+}
+
+func siblingData(res Result) []string {
+	var data []string
+	for _, v := range res.Siblings {
+		data = append(data, string(v.Data))
+	}
+	slices.Sort(data)
+	return data
+}
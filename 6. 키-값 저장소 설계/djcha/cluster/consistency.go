@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Consistency: 요청마다 고를 수 있는 일관성 수준
+// 몇 개의 복제본이 응답해야 요청을 성공으로 볼지 정함
+type Consistency int
+
+const (
+	Default Consistency = iota // 노드 설정(Config.R / Config.W)을 그대로 사용
+	One                        // 복제본 1개
+	Quorum                     // 과반수 (N/2 + 1)
+	All                        // 복제본 N 개 전부
+)
+
+// ParseConsistency: "one", "quorum", "all" (대소문자 무시, 빈 문자열은 Default)
+func ParseConsistency(s string) (Consistency, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return Default, nil
+	case "one":
+		return One, nil
+	case "quorum":
+		return Quorum, nil
+	case "all":
+		return All, nil
+	}
+	return Default, fmt.Errorf("unknown consistency level %q", s)
+}
+
+func (c Consistency) String() string {
+	switch c {
+	case One:
+		return "ONE"
+	case Quorum:
+		return "QUORUM"
+	case All:
+		return "ALL"
+	}
+	return "DEFAULT"
+}
+
+// required: 복제본 n 개 중 몇 개의 응답이 필요한지 (Default 면 설정값 configured 사용)
+func (c Consistency) required(n, configured int) int {
+	switch c {
+	case One:
+		return 1
+	case Quorum:
+		return n/2 + 1
+	case All:
+		return n
+	}
+	return configured
+}
@@ -0,0 +1,119 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"kv-store/crdt"
+	"kv-store/store"
+)
+
+// ErrWrongType: 일반 값이나 다른 자료형의 CRDT 가 저장된 키에 CRDT 연산을 적용하려 함
+var ErrWrongType = errors.New("cluster: key holds a value of a different type")
+
+// UpdateRequest: 복제본에 보내는 CRDT 연산 (내부 API 본문)
+type UpdateRequest struct {
+	Type        crdt.Type     `json:"type"`
+	Op          crdt.Op       `json:"op"`
+	Consistency Consistency   `json:"consistency"`
+	TTL         time.Duration `json:"ttl,omitempty"`
+}
+
+// Update: key 의 CRDT 값에 연산 op 를 적용하고 복제본 N 개에 씀 (키가 없으면 빈 상태에서 시작)
+//
+// 연산은 선호 목록의 복제본 한 대가 자기 로컬 상태 위에서, 자기 ID 를 actor 로 적용함
+// actor 별 카운터/순번은 그 노드만 올리고 그 노드의 저장소에는 항상 최신 값이 있으므로,
+// 여러 코디네이터가 동시에 올려도 증가분이 사라지지 않음 (읽고-고치고-쓰기를 코디네이터에서 하면 사라짐)
+// 다른 복제본과 동시에 쓰인 버전은 저장소가 형제 값 대신 합쳐 둠 (store.Reconcile)
+//
+// 이 노드가 복제본이 아니면 살아 있는 복제본 한 대에게 연산을 넘김
+// 돌려주는 값은 연산을 적용한 복제본의 상태 (다른 복제본에만 있는 동시 연산은 다음 읽기에서 합쳐짐)
+func (n *Node) Update(ctx context.Context, key string, typ crdt.Type, op crdt.Op, opts WriteOptions) (store.Value, error) {
+	// 복제본까지 가기 전에 자료형/연산이 맞는지 빈 상태에 적용해 봄
+	empty, err := crdt.New(typ)
+	if err != nil {
+		return store.Value{}, err
+	}
+	if err := crdt.Apply(empty, n.id, op, 0); err != nil {
+		return store.Value{}, err
+	}
+
+	req := UpdateRequest{Type: typ, Op: op, Consistency: opts.Consistency, TTL: opts.TTL}
+	owners := n.ring.PreferenceList(key, n.cfg.N)
+	if slices.Contains(owners, n.id) {
+		return n.localUpdate(ctx, key, req)
+	}
+
+	// 정상으로 보이는 첫 복제본에 맡김
+	// 응답을 못 받으면 연산이 적용됐는지 알 수 없으므로 다른 복제본에 다시 보내지 않음 (카운터가 두 번 오를 수 있음)
+	slices.SortStableFunc(owners, func(a, b string) int {
+		return boolOrder(n.health.isDown(a), n.health.isDown(b))
+	})
+	v, err := n.transport.Update(ctx, owners[0], key, req)
+	if err != nil {
+		if !errors.Is(err, ErrWrongType) && ctx.Err() == nil {
+			n.health.markDown(owners[0])
+		}
+		return store.Value{}, err
+	}
+	n.health.markUp(owners[0])
+	return v, nil
+}
+
+func boolOrder(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// localUpdate: 로컬 상태에 연산을 적용해서 새 버전으로 저장한 뒤 다른 복제본에 씀
+// 로컬 저장까지는 잠금 안에서 하므로 이 노드의 연산은 항상 직전 연산 결과 위에 적용됨
+func (n *Node) localUpdate(ctx context.Context, key string, req UpdateRequest) (store.Value, error) {
+	n.updates.Lock()
+	siblings, _ := n.store.Get(key)
+	v, err := n.applyUpdate(siblings, req)
+	if err != nil {
+		n.updates.Unlock()
+		return store.Value{}, err
+	}
+	n.store.Put(key, v)
+	n.updates.Unlock()
+
+	return v, n.replicate(ctx, key, v, req.Consistency)
+}
+
+// applyUpdate: 형제 값들의 상태를 합친 뒤 연산을 적용한 새 버전
+// 삭제/만료된 형제 값은 상태에 넣지 않지만 문맥에는 넣어서 새 버전이 덮어쓰게 함
+func (n *Node) applyUpdate(siblings []store.Value, req UpdateRequest) (store.Value, error) {
+	state, err := crdt.New(req.Type)
+	if err != nil {
+		return store.Value{}, err
+	}
+	now := n.now().UnixNano()
+	for _, s := range siblings {
+		if !s.Live(now) {
+			continue
+		}
+		if s.Type != req.Type {
+			return store.Value{}, fmt.Errorf("%w (want %s)", ErrWrongType, req.Type)
+		}
+		other, err := crdt.Decode(s.Type, s.Data)
+		if err != nil {
+			return store.Value{}, err
+		}
+		state.Merge(other)
+	}
+	if err := crdt.Apply(state, n.id, req.Op, now); err != nil {
+		return store.Value{}, err
+	}
+
+	v := store.Value{Type: req.Type, Data: crdt.Encode(state)}
+	return n.stamp(v, WriteOptions{Context: store.Context(siblings), TTL: req.TTL}), nil
+}
@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"kv-store/crdt"
+)
+
+// counterValue: 복제본들을 읽어 합친 PNCounter 값
+func counterValue(t *testing.T, n *Node, key string, level Consistency) int64 {
+	t.Helper()
+	res, err := n.Get(context.Background(), key, ReadOptions{Consistency: level})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Siblings) != 1 {
+		t.Fatalf("%s has %d siblings, want CRDT values merged into one", key, len(res.Siblings))
+	}
+	c, err := crdt.Decode(res.Siblings[0].Type, res.Siblings[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.Value().(int64)
+}
+
+func TestConcurrentCounterUpdatesAreNotLost(t *testing.T) {
+	tc := startCluster(t, 5, testConfig)
+	ctx := context.Background()
+
+	// 복제본이 아닌 노드도 포함해서 여러 코디네이터가 동시에 증가/감소
+	const workers, each = 10, 10
+	var wg sync.WaitGroup
+	for i := range workers {
+		node := tc.nodes[i%len(tc.nodes)]
+		wg.Go(func() {
+			for range each {
+				if _, err := node.Update(ctx, "likes:post1", crdt.TypePNCounter, crdt.Op{Increment: 2}, WriteOptions{}); err != nil {
+					t.Error(err)
+				}
+				if _, err := node.Update(ctx, "likes:post1", crdt.TypePNCounter, crdt.Op{Increment: -1}, WriteOptions{}); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := counterValue(t, tc.nodes[0], "likes:post1", All); got != workers*each {
+		t.Fatalf("counter = %d, want %d", got, workers*each)
+	}
+
+	if err := tc.nodes[0].Put(ctx, "plain", []byte("x"), WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.nodes[1].Update(ctx, "plain", crdt.TypeGCounter, crdt.Op{Increment: 1}, WriteOptions{}); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("update on a plain value: err = %v, want ErrWrongType", err)
+	}
+}
+
+func TestAntiEntropyMergesDivergedSets(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	a, b := tc.nodes[0], tc.nodes[1]
+
+	// 서로 모르는 사이에 두 복제본이 같은 집합을 고침 (분할 중 쓰기 흉내)
+	for node, member := range map[*Node]string{a: "alice", b: "bob"} {
+		v, err := node.applyUpdate(nil, UpdateRequest{Type: crdt.TypeORSet, Op: crdt.Op{Add: []string{member}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.store.Put("team:1", v)
+	}
+
+	if err := a.AntiEntropy(ctx, b.ID()); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range []*Node{a, b} {
+		siblings, _ := node.store.Get("team:1")
+		if len(siblings) != 1 {
+			t.Fatalf("%s kept %d siblings, want one merged set", node.ID(), len(siblings))
+		}
+		set, _ := crdt.Decode(siblings[0].Type, siblings[0].Data)
+		if got := set.Value().([]string); !slices.Equal(got, []string{"alice", "bob"}) {
+			t.Fatalf("%s set = %v, want [alice bob]", node.ID(), got)
+		}
+	}
+	as, _ := a.store.Get("team:1")
+	bs, _ := b.store.Get("team:1")
+	if string(encodeSiblings(as)) != string(encodeSiblings(bs)) {
+		t.Fatal("replicas merged into different encodings; Merkle trees would never agree")
+	}
+}
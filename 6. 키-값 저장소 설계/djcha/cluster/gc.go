@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"kv-store/store"
+)
+
+// GCConfig: 삭제 표시(tombstone)와 만료된 값 정리 설정
+//
+// 삭제 표시를 너무 일찍 지우면, 아직 삭제를 못 받은 복제본의 옛날 값이
+// 안티 엔트로피로 다시 퍼져 지운 키가 되살아남
+// 그래서 유예 기간(GracePeriod)이 지나고, 선호 목록의 모든 복제본이 삭제 표시를 받은 것을 확인한 뒤에만 지움
+type GCConfig struct {
+	GracePeriod time.Duration // 삭제(또는 만료) 후 이 시간이 지나야 정리 대상
+	Interval    time.Duration // 정리 주기 (0 이면 정리하지 않음 → 삭제 표시가 계속 남음)
+}
+
+// DefaultGCConfig: 하루 유예, 1시간마다 정리
+var DefaultGCConfig = GCConfig{
+	GracePeriod: 24 * time.Hour,
+	Interval:    time.Hour,
+}
+
+// GCStats: 정리 지표
+type GCStats struct {
+	Tombstones uint64 `json:"tombstones"` // 마지막 정리 때 본 삭제/만료 키 수
+	Purged     uint64 `json:"purged"`     // 지금까지 완전히 지운 키 수
+	Deferred   uint64 `json:"deferred"`   // 유예 기간은 지났지만 아직 삭제를 못 받은 복제본이 있어 미룬 횟수
+}
+
+type gcCounters struct {
+	tombstones, purged, deferred atomic.Uint64
+}
+
+func (c *gcCounters) snapshot() GCStats {
+	return GCStats{
+		Tombstones: c.tombstones.Load(),
+		Purged:     c.purged.Load(),
+		Deferred:   c.deferred.Load(),
+	}
+}
+
+// CollectGarbage: 유예 기간이 지난 삭제 표시/만료 값 중 모든 복제본이 받은 것을 로컬에서 지움
+// 각 노드가 자기 로컬 저장소만 정리함 (다른 복제본도 각자 같은 확인을 거쳐 지움)
+// 열린 스냅샷, 변경 감시 위치와 Config.History 가 더 이상 필요로 하지 않는 이전 상태도 함께 지움
+func (n *Node) CollectGarbage(ctx context.Context) {
+	n.snapshots.expire()
+	n.watches.expire()
+	defer n.store.Compact()
+	now := n.now()
+	var dead uint64
+	for _, key := range n.store.Keys() {
+		siblings, ok := n.store.Get(key)
+		if !ok {
+			continue
+		}
+		since, isDead := deadSince(siblings, now.UnixNano())
+		if !isDead {
+			continue
+		}
+		dead++
+		if now.Sub(time.Unix(0, since)) < n.cfg.GC.GracePeriod {
+			continue
+		}
+		if !n.seenByAllReplicas(ctx, key, siblings) {
+			n.gc.deferred.Add(1)
+			continue
+		}
+		if n.store.Purge(key, siblings) {
+			n.gc.purged.Add(1)
+			dead--
+		}
+	}
+	n.gc.tombstones.Store(dead)
+}
+
+// deadSince: 형제 값이 모두 삭제/만료됐으면 그 시각 (가장 늦게 죽은 값 기준)
+func deadSince(siblings []store.Value, now int64) (int64, bool) {
+	var since int64
+	for _, v := range siblings {
+		switch {
+		case v.Live(now):
+			return 0, false
+		case v.Deleted:
+			since = max(since, v.Timestamp)
+		default:
+			since = max(since, v.Expires)
+		}
+	}
+	return since, true
+}
+
+// seenByAllReplicas: 선호 목록의 다른 복제본이 모두 이 삭제 표시(또는 그 이후 버전)를 갖고 있는지
+// 복제본이 이미 키를 정리했으면(없으면) 그 복제본은 먼저 같은 확인을 거친 것이므로 통과
+// 한 대라도 응답하지 않으면 옛날 값을 갖고 있을 수 있으므로 false
+func (n *Node) seenByAllReplicas(ctx context.Context, key string, siblings []store.Value) bool {
+	owners := n.ring.PreferenceList(key, n.cfg.N)
+	if !slices.Contains(owners, n.id) {
+		return false // 주인이 아닌 키는 안티 엔트로피 대상이 아니므로 건드리지 않음
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+	defer cancel()
+	for _, node := range owners {
+		if node == n.id {
+			continue
+		}
+		remote, found, err := n.replicaGet(ctx, node, key)
+		if err != nil {
+			return false
+		}
+		if found && len(missingVersions(remote, siblings)) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTTLExpiresOnRead(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	for _, n := range tc.nodes {
+		n.now = clock.Now
+	}
+	ctx := context.Background()
+
+	if err := tc.nodes[0].Put(ctx, "session:1", []byte("token"), WriteOptions{Consistency: All, TTL: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.nodes[1].Get(ctx, "session:1", ReadOptions{}); err != nil {
+		t.Fatalf("read before expiry: %v", err)
+	}
+	clock.advance(time.Minute)
+	if _, err := tc.nodes[1].Get(ctx, "session:1", ReadOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("read after expiry: err = %v, want ErrNotFound", err)
+	}
+}
+
+// 지금 + TTL 이 int64 를 넘는 TTL 은 가장 먼 시각으로 맞춰지고 바로 만료되지 않음
+func TestHugeTTLDoesNotOverflow(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+
+	if err := tc.nodes[0].Put(ctx, "forever", []byte("v"), WriteOptions{Consistency: All, TTL: math.MaxInt64}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.nodes[1].Get(ctx, "forever", ReadOptions{}); err != nil {
+		t.Fatalf("read after a huge TTL: %v", err)
+	}
+}
+
+func TestDeletedKeyIsNotResurrectedByAntiEntropy(t *testing.T) {
+	cfg := testConfig
+	cfg.GC = GCConfig{GracePeriod: time.Hour}
+	tc := startCluster(t, 3, cfg)
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	for _, n := range tc.nodes {
+		n.now = clock.Now
+	}
+	ctx := context.Background()
+
+	replicas := tc.nodes[0].ring.PreferenceList("cart:9", cfg.N)
+	coordinator, stale := tc.node(replicas[0]), replicas[2]
+	if err := coordinator.Put(ctx, "cart:9", []byte("items"), WriteOptions{Consistency: All}); err != nil {
+		t.Fatal(err)
+	}
+
+	// stale 이 꺼진 동안 삭제 → stale 만 옛날 값을 가짐
+	tc.stop(stale)
+	res, err := coordinator.Get(ctx, "cart:9", ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := coordinator.Delete(ctx, "cart:9", WriteOptions{Context: res.Context}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 유예 기간이 지나도 stale 이 삭제 표시를 받기 전에는 지우지 않음
+	clock.advance(2 * time.Hour)
+	coordinator.CollectGarbage(ctx)
+	tc.restart(stale)
+	coordinator.CollectGarbage(ctx)
+	if _, ok := coordinator.Store().Get("cart:9"); !ok {
+		t.Fatal("tombstone purged before every replica saw it")
+	}
+	if got := coordinator.Metrics().GC; got.Deferred != 2 || got.Purged != 0 {
+		t.Fatalf("gc stats = %+v, want 2 deferred and nothing purged", got)
+	}
+
+	// 안티 엔트로피가 삭제 표시를 stale 로 보냄 (옛날 값이 거꾸로 퍼지지 않음)
+	if err := coordinator.AntiEntropy(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.node(stale).Get(ctx, "cart:9", ReadOptions{Consistency: All}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("read after anti-entropy: err = %v, want ErrNotFound", err)
+	}
+	if got := coordinator.Metrics().AntiEntropy; got.Rounds != 1 || got.KeysPushed != 1 {
+		t.Fatalf("anti-entropy stats = %+v, want one round pushing one key", got)
+	}
+
+	// 모든 복제본이 삭제 표시를 가졌으므로 각자 정리
+	for _, addr := range replicas {
+		tc.node(addr).CollectGarbage(ctx)
+	}
+	for _, addr := range replicas {
+		if _, ok := tc.node(addr).Store().Get("cart:9"); ok {
+			t.Fatalf("replica %s still holds cart:9 after gc", addr)
+		}
+	}
+
+	// 정리한 뒤 다시 비교해도 키가 되살아나지 않음
+	for _, a := range replicas {
+		for _, b := range replicas {
+			if a != b {
+				if err := tc.node(a).AntiEntropy(ctx, b); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	for _, addr := range replicas {
+		if _, err := tc.node(addr).Get(ctx, "cart:9", ReadOptions{Consistency: All}); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("cart:9 resurrected on %s: err = %v", addr, err)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"kv-store/store"
+)
+
+// Handler: 다른 노드(코디네이터)가 보내는 복제본 요청을 처리하는 내부 API
+//
+//	PUT /internal/kv/{key}             JSON store.Value → 204
+//	GET /internal/kv/{key}?as_of=      → 200 JSON []store.Value (형제 값 목록) / 404 / 410 (as_of 시점이 이미 정리됨)
+//	PUT /internal/hints/{owner}/{key}  JSON store.Value → 204 / 507 (힌트 저장 공간 가득 참)
+//	GET /internal/keys?from=&limit=    → 200 JSON []string (Scan 용 로컬 키 목록)
+//	POST /internal/range               JSON RangeRequest → 200 JSON []RangeEntry / 410 (스냅샷이 닫힘) / 422 (AsOf 시점이 이미 정리됨)
+//	GET /internal/merkle/{peer}?buckets=     → 200 JSON 버킷 해시 목록 (peer 와 같이 맡은 키 기준)
+//	POST /internal/merkle/{peer}?buckets=    JSON 버킷 번호 목록 → 200 JSON 키 → 형제 값
+//	POST /internal/crdt/{key}          JSON UpdateRequest → 200 JSON store.Value (연산을 적용한 새 버전) / 409 (자료형이 다름)
+//	PUT /internal/locks/{key}?token=&lease=  → 204 / 409 (다른 토큰이 잡고 있음)
+//	DELETE /internal/locks/{key}?token=      → 204
+//	PUT /internal/members/{member}?state=    → 204 (들어오는/나가는 노드 상태 알림)
+//	POST /internal/stream              JSON StreamRequest → 200 JSON StreamPage (들어오는 노드가 맡을 키)
+//	POST /internal/watch               JSON WatchRequest → 200 JSON WatchResponse (변경이 없으면 wait_ms 동안 기다림) / 422 (From 이 이미 정리됨)
+//	POST /internal/heartbeat/{from}    → 204 (박동을 쓰지 않으면 404)
+//	GET /internal/metrics              → 200 JSON Metrics
+func (n *Node) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("PUT /internal/kv/{key}", func(w http.ResponseWriter, r *http.Request) {
+		var v store.Value
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		n.store.Put(r.PathValue("key"), v)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /internal/kv/{key}", func(w http.ResponseWriter, r *http.Request) {
+		var siblings []store.Value
+		var ok bool
+		if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+			at, err := strconv.ParseInt(asOf, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid as_of", http.StatusBadRequest)
+				return
+			}
+			if siblings, ok, err = n.store.GetAsOf(r.PathValue("key"), time.Unix(0, at)); err != nil {
+				http.Error(w, err.Error(), http.StatusGone)
+				return
+			}
+		} else {
+			siblings, ok = n.localGet(r.PathValue("key"))
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(siblings)
+	})
+
+	mux.HandleFunc("PUT /internal/hints/{owner}/{key}", func(w http.ResponseWriter, r *http.Request) {
+		var v store.Value
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := n.hints.add(r.PathValue("owner"), r.PathValue("key"), v, n.now()); err != nil {
+			http.Error(w, err.Error(), http.StatusInsufficientStorage)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /internal/keys", func(w http.ResponseWriter, r *http.Request) {
+		from, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 32)
+		if err != nil {
+			http.Error(w, "invalid from", http.StatusBadRequest)
+			return
+		}
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		keys := n.localKeys(uint32(from), limit)
+		if keys == nil {
+			keys = []string{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(keys)
+	})
+
+	mux.HandleFunc("POST /internal/range", func(w http.ResponseWriter, r *http.Request) {
+		var req RangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Limit <= 0 {
+			http.Error(w, "invalid range request", http.StatusBadRequest)
+			return
+		}
+		entries, err := n.localRange(req)
+		switch {
+		case errors.Is(err, store.ErrCompacted):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+		if entries == nil {
+			entries = []RangeEntry{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+
+	mux.HandleFunc("POST /internal/crdt/{key}", func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		v, err := n.localUpdate(r.Context(), r.PathValue("key"), req)
+		switch {
+		case errors.Is(err, ErrWrongType):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	})
+
+	mux.HandleFunc("GET /internal/merkle/{peer}", func(w http.ResponseWriter, r *http.Request) {
+		buckets, err := strconv.Atoi(r.URL.Query().Get("buckets"))
+		if err != nil || buckets <= 0 {
+			http.Error(w, "invalid buckets", http.StatusBadRequest)
+			return
+		}
+		tree, ok := n.peerTree(r.PathValue("peer"), buckets)
+		if !ok {
+			http.Error(w, ErrTreeRebuilding.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(tree.Leaves())
+	})
+
+	mux.HandleFunc("POST /internal/merkle/{peer}", func(w http.ResponseWriter, r *http.Request) {
+		buckets, err := strconv.Atoi(r.URL.Query().Get("buckets"))
+		if err != nil || buckets <= 0 {
+			http.Error(w, "invalid buckets", http.StatusBadRequest)
+			return
+		}
+		var want []int
+		if err := json.NewDecoder(r.Body).Decode(&want); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		slices.Sort(want)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(n.sharedEntries(r.PathValue("peer"), buckets, want))
+	})
+
+	mux.HandleFunc("PUT /internal/locks/{key}", func(w http.ResponseWriter, r *http.Request) {
+		lease, err := time.ParseDuration(r.URL.Query().Get("lease"))
+		if err != nil || lease <= 0 {
+			http.Error(w, "invalid lease", http.StatusBadRequest)
+			return
+		}
+		if err := n.locks.lock(r.PathValue("key"), r.URL.Query().Get("token"), lease); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("DELETE /internal/locks/{key}", func(w http.ResponseWriter, r *http.Request) {
+		n.locks.unlock(r.PathValue("key"), r.URL.Query().Get("token"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("PUT /internal/members/{member}", func(w http.ResponseWriter, r *http.Request) {
+		state, err := ParseMemberState(r.URL.Query().Get("state"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n.SetMember(r.PathValue("member"), state)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /internal/stream", func(w http.ResponseWriter, r *http.Request) {
+		var req StreamRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Target == "" || req.Limit <= 0 {
+			http.Error(w, "invalid stream request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(n.localStream(req))
+	})
+
+	mux.HandleFunc("POST /internal/watch", func(w http.ResponseWriter, r *http.Request) {
+		var req WatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" || req.Wait < 0 {
+			http.Error(w, "invalid watch request", http.StatusBadRequest)
+			return
+		}
+		resp, err := n.localWatch(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+
+	mux.HandleFunc("POST /internal/heartbeat/{from}", func(w http.ResponseWriter, r *http.Request) {
+		if n.detector == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n.detector.Heartbeat(r.PathValue("from"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /internal/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(n.Metrics())
+	})
+
+	return mux
+}
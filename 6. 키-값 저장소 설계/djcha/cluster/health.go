@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"kv-store/detector"
+)
+
+// 요청이 실패한 노드를 장애로 보고 건너뛰는 시간
+// 이 시간이 지나면 다시 요청을 보내 보고, 그 사이엔 링의 다음 정상 노드가 대신 받음
+const downBackoff = 2 * time.Second
+
+// health: 노드별 장애 여부
+// 요청이 실패하면 장애(down), 요청이 성공하면 정상(up)으로 기록
+// 장애 감지기가 있으면 phi 가 임계값을 넘은 노드도 장애로 봄
+type health struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	down     map[string]time.Time // 노드 → 장애로 기록한 시각
+	detector *detector.Detector   // 박동을 쓰지 않으면 nil
+}
+
+func newHealth(now func() time.Time) *health {
+	return &health{now: now, down: make(map[string]time.Time)}
+}
+
+func (h *health) markDown(node string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.down[node] = h.now()
+}
+
+func (h *health) markUp(node string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.down, node)
+}
+
+func (h *health) isDown(node string) bool {
+	if h.detector != nil && !h.detector.IsAvailable(node) {
+		return true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	since, ok := h.down[node]
+	return ok && h.now().Sub(since) < downBackoff
+}
@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kv-store/detector"
+)
+
+// HeartbeatConfig: 노드끼리 주고받는 박동(heartbeat)과 장애 감지기 설정
+//
+// 요청 실패만 보고 장애를 판단하면 요청이 없는 동안엔 장애를 모르고, 한 번의 실패에도 바로 장애로 봄
+// 박동을 주기적으로 주고받으면 파이 누적 장애 감지기가 노드별 의심 수준을 계속 계산해 줌
+type HeartbeatConfig struct {
+	Interval time.Duration   // 박동 주기 (0 이면 박동을 쓰지 않고 요청 실패로만 장애 판단)
+	Detector detector.Config // Now 는 노드 시계로 덮어씀
+}
+
+// DefaultHeartbeatConfig: 1초마다 박동, phi 8 을 넘으면 장애
+var DefaultHeartbeatConfig = HeartbeatConfig{
+	Interval: time.Second,
+	Detector: detector.DefaultConfig(),
+}
+
+// PeerStatus: 장애 감지기가 본 다른 노드의 상태
+type PeerStatus struct {
+	Phi float64 `json:"phi"` // 의심 수준
+	Up  bool    `json:"up"`
+}
+
+// 장애에서 복구된 노드 알림을 쌓아 둘 개수 (넘치면 버림 → 주기적인 힌트 전달이 처리)
+const recoveredBacklog = 64
+
+// initDetector: 장애 감지기 생성
+// 노드가 복구되면 Run 루프가 그 노드 몫의 힌트를 바로 전달하도록 알림
+func (n *Node) initDetector() {
+	cfg := n.cfg.Heartbeat.Detector
+	cfg.Now = func() time.Time { return n.now() }
+	n.detector = detector.New(cfg)
+	n.health.detector = n.detector
+	n.recovered = make(chan string, recoveredBacklog)
+
+	n.detector.Subscribe(func(e detector.Event) {
+		if !e.Up {
+			return
+		}
+		n.health.markUp(e.Node)
+		select {
+		case n.recovered <- e.Node:
+		default:
+		}
+	})
+}
+
+// sendHeartbeats: 링의 다른 모든 노드에 박동을 보내고, 받은 박동 기준으로 장애 여부 다시 계산
+func (n *Node) sendHeartbeats(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, peer := range n.ring.Servers() {
+		if peer == n.id {
+			continue
+		}
+		wg.Go(func() {
+			callCtx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+			defer cancel()
+			_ = n.transport.Heartbeat(callCtx, peer, n.id)
+		})
+	}
+	wg.Wait()
+	n.detector.Check()
+}
+
+// peers: 노드별 의심 수준 (박동을 쓰지 않으면 nil)
+func (n *Node) peers() map[string]PeerStatus {
+	if n.detector == nil {
+		return nil
+	}
+	peers := make(map[string]PeerStatus)
+	for node, phi := range n.detector.Suspicion() {
+		peers[node] = PeerStatus{Phi: phi, Up: !n.health.isDown(node)}
+	}
+	return peers
+}
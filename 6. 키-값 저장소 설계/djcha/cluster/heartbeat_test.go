@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock: 모든 노드가 함께 쓰는 가짜 시계
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// beatAll: 시계를 1초 움직이고 silent 를 뺀 모든 노드가 서로에게 박동을 보낸 것처럼 기록
+func beatAll(tc *testCluster, clock *testClock, silent string) {
+	clock.advance(time.Second)
+	for _, from := range tc.nodes {
+		if from.ID() == silent {
+			continue
+		}
+		for _, to := range tc.nodes {
+			if to != from {
+				to.detector.Heartbeat(from.ID())
+			}
+		}
+	}
+	for _, n := range tc.nodes {
+		n.detector.Check()
+	}
+}
+
+func TestFailureDetectorRoutesAroundSilentNode(t *testing.T) {
+	cfg := testConfig
+	cfg.Hints = DefaultHintConfig
+	cfg.Heartbeat = HeartbeatConfig{Interval: time.Hour, Detector: DefaultHeartbeatConfig.Detector}
+	tc := startCluster(t, 4, cfg)
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	for _, n := range tc.nodes {
+		n.now = clock.Now
+	}
+
+	candidates := tc.nodes[0].ring.PreferenceList("user:7", 4)
+	owner, substitute := candidates[1], candidates[3]
+	coordinator := tc.node(candidates[0])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, n := range tc.nodes {
+		go n.Run(ctx)
+	}
+
+	for range 10 {
+		beatAll(tc, clock, "")
+	}
+	// owner 는 요청에는 응답하지만 박동이 끊김 (네트워크 분할 등) → 장애 감지기가 장애로 판단
+	for range 5 {
+		beatAll(tc, clock, owner)
+	}
+	if coordinator.Metrics().Peers[owner].Up {
+		t.Fatalf("owner still up after missed heartbeats: %+v", coordinator.Metrics().Peers[owner])
+	}
+
+	// 요청 실패를 겪지 않고도 owner 를 건너뛰고 대체 노드에 힌트와 함께 씀
+	if err := coordinator.Put(ctx, "user:7", []byte("v1"), WriteOptions{Consistency: All}); err != nil {
+		t.Fatalf("ALL write with silent owner: %v", err)
+	}
+	if _, ok := tc.node(owner).Store().Get("user:7"); ok {
+		t.Fatal("write was sent to a node the failure detector marked down")
+	}
+	if pending := tc.node(substitute).Metrics().Hints.PendingByOwner[owner]; pending != 1 {
+		t.Fatalf("substitute pending hints for owner = %d, want 1", pending)
+	}
+
+	// 박동이 다시 오면 복구 알림 → 힌트 전달 주기(10초)를 기다리지 않고 바로 전달
+	beatAll(tc, clock, "")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := tc.node(owner).Store().Get("user:7"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("hint was not handed off after the owner came back up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !coordinator.Metrics().Peers[owner].Up {
+		t.Fatal("owner should be up after its heartbeat resumed")
+	}
+}
@@ -0,0 +1,239 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"kv-store/store"
+)
+
+// ErrHintStoreFull: 힌트 저장 공간(개수 또는 크기 상한)이 가득 참
+var ErrHintStoreFull = errors.New("cluster: hint store full")
+
+// HintConfig: 느슨한 정족수(Sloppy Quorum)와 단서 후 임시 위탁(Hinted Handoff) 설정
+//
+// 복제본 노드가 죽어 있으면 링에서 그다음 정상 노드가 쓰기를 대신 받고,
+// "원래 주인은 누구인지" 힌트를 남겨 둠. 주인이 살아나면 힌트를 전달하고 지움
+type HintConfig struct {
+	Enabled  bool          // false 면 엄격한 정족수 (선호 목록 N 대에만 씀)
+	MaxHints int           // 보관할 최대 힌트 개수
+	MaxBytes int           // 보관할 힌트 데이터 총 크기 (Byte)
+	TTL      time.Duration // 이 시간 안에 전달 못 한 힌트는 버림 (이후엔 머클 트리 안티 엔트로피로 복구)
+	Interval time.Duration // 힌트 전달 재시도 주기
+}
+
+// DefaultHintConfig: 힌트 기본 설정
+var DefaultHintConfig = HintConfig{
+	Enabled:  true,
+	MaxHints: 100000,
+	MaxBytes: 64 << 20,
+	TTL:      3 * time.Hour,
+	Interval: 10 * time.Second,
+}
+
+// HintStats: 힌트 지표
+type HintStats struct {
+	Pending        int            `json:"pending"`          // 전달 대기 중인 힌트 개수
+	PendingBytes   int            `json:"pending_bytes"`    // 전달 대기 중인 힌트 크기
+	PendingByOwner map[string]int `json:"pending_by_owner"` // 원래 주인별 대기 개수
+	Stored         uint64         `json:"stored"`           // 지금까지 받은 힌트
+	Delivered      uint64         `json:"delivered"`        // 주인에게 전달 완료
+	Expired        uint64         `json:"expired"`          // TTL 이 지나 버림
+	Dropped        uint64         `json:"dropped"`          // 저장 공간이 가득 차 거절
+}
+
+type hint struct {
+	id      uint64
+	key     string
+	value   store.Value
+	created time.Time
+	size    int
+}
+
+// hintStore: 다른 노드 대신 받아 둔 쓰기 (원래 주인별로 보관)
+type hintStore struct {
+	mu      sync.Mutex
+	cfg     HintConfig
+	byOwner map[string][]hint
+	seq     uint64
+	count   int
+	bytes   int
+	stats   HintStats
+}
+
+func newHintStore(cfg HintConfig) *hintStore {
+	return &hintStore{cfg: cfg, byOwner: make(map[string][]hint)}
+}
+
+func hintSize(key string, v store.Value) int {
+	size := len(key) + len(v.Data) + 8
+	for node := range v.Clock {
+		size += len(node) + 16
+	}
+	return size
+}
+
+// add: 힌트 저장 (상한을 넘으면 거절 → 대신 받은 노드가 쓰기 실패로 응답)
+func (h *hintStore) add(owner, key string, v store.Value, now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	size := hintSize(key, v)
+	if h.count+1 > h.cfg.MaxHints || h.bytes+size > h.cfg.MaxBytes {
+		h.stats.Dropped++
+		return ErrHintStoreFull
+	}
+	h.seq++
+	h.byOwner[owner] = append(h.byOwner[owner], hint{id: h.seq, key: key, value: v, created: now, size: size})
+	h.count++
+	h.bytes += size
+	h.stats.Stored++
+	return nil
+}
+
+// values: 대신 받아 둔 키의 값들 (이 노드가 읽기 요청을 받았을 때 함께 돌려줌)
+func (h *hintStore) values(key string) []store.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var values []store.Value
+	for _, hints := range h.byOwner {
+		for _, ht := range hints {
+			if ht.key == key {
+				values = append(values, ht.value)
+			}
+		}
+	}
+	return values
+}
+
+// expire: TTL 이 지난 힌트 삭제
+func (h *hintStore) expire(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for owner, hints := range h.byOwner {
+		kept := hints[:0]
+		for _, ht := range hints {
+			if now.Sub(ht.created) >= h.cfg.TTL {
+				h.count--
+				h.bytes -= ht.size
+				h.stats.Expired++
+				continue
+			}
+			kept = append(kept, ht)
+		}
+		h.setOwner(owner, kept)
+	}
+}
+
+// owners: 힌트가 남아 있는 원래 주인 목록
+func (h *hintStore) owners() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	owners := make([]string, 0, len(h.byOwner))
+	for owner := range h.byOwner {
+		owners = append(owners, owner)
+	}
+	slices.Sort(owners)
+	return owners
+}
+
+// pending: 주인에게 전달할 힌트 목록 (복사본)
+func (h *hintStore) pending(owner string) []hint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.byOwner[owner])
+}
+
+// delivered: 전달이 끝난 힌트 삭제
+// 전달하는 동안 새 힌트가 들어오거나 만료로 지워졌을 수 있으므로 id 로 찾아서 지움
+func (h *hintStore) delivered(owner string, done hint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hints := h.byOwner[owner]
+	for i, ht := range hints {
+		if ht.id == done.id {
+			h.count--
+			h.bytes -= ht.size
+			h.stats.Delivered++
+			h.setOwner(owner, slices.Delete(hints, i, i+1))
+			return
+		}
+	}
+}
+
+func (h *hintStore) setOwner(owner string, hints []hint) {
+	if len(hints) == 0 {
+		delete(h.byOwner, owner)
+		return
+	}
+	h.byOwner[owner] = hints
+}
+
+func (h *hintStore) snapshot() HintStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := h.stats
+	stats.Pending = h.count
+	stats.PendingBytes = h.bytes
+	stats.PendingByOwner = make(map[string]int, len(h.byOwner))
+	for owner, hints := range h.byOwner {
+		stats.PendingByOwner[owner] = len(hints)
+	}
+	return stats
+}
+
+// deliverHints: 힌트를 원래 주인에게 전달
+func (n *Node) deliverHints(ctx context.Context) {
+	n.hints.expire(n.now())
+
+	for _, owner := range n.hints.owners() {
+		n.deliverHintsTo(ctx, owner)
+	}
+}
+
+// deliverHintsTo: owner 몫의 힌트 전달
+// 주인이 아직 죽어 있으면 첫 전달에서 실패하므로 다음 주기(또는 복구 알림)에 다시 시도
+func (n *Node) deliverHintsTo(ctx context.Context, owner string) {
+	for _, ht := range n.hints.pending(owner) {
+		callCtx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+		err := n.transport.Put(callCtx, owner, ht.key, ht.value)
+		cancel()
+		if err != nil {
+			n.health.markDown(owner)
+			return
+		}
+		n.health.markUp(owner)
+		n.hints.delivered(owner, ht)
+	}
+}
+
+// spares: 복제본 대신 쓰기를 받을 후보 (선호 목록 N 대 다음부터 링 순서대로)
+// 여러 복제본의 쓰기 고루틴이 나눠 쓰므로 같은 후보가 두 번 뽑히지 않도록 잠금
+type spares struct {
+	mu     sync.Mutex
+	nodes  []string
+	health *health
+}
+
+// next: 장애로 기록되지 않은 다음 후보
+func (s *spares) next() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.nodes) > 0 {
+		node := s.nodes[0]
+		s.nodes = s.nodes[1:]
+		if !s.health.isDown(node) {
+			return node, true
+		}
+	}
+	return "", false
+}
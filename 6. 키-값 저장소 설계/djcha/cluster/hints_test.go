@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"kv-store/store"
+)
+
+func TestSloppyQuorumWritesWhileReplicaDown(t *testing.T) {
+	cfg := testConfig
+	cfg.Hints = DefaultHintConfig
+	tc := startCluster(t, 4, cfg)
+	ctx := context.Background()
+
+	// 4대 중 키의 복제본 3대가 아닌 나머지 1대가 대신 받아야 함
+	candidates := tc.nodes[0].ring.PreferenceList("session:9", 4)
+	owner, substitute := candidates[1], candidates[3]
+	coordinator := tc.node(candidates[0])
+	tc.stop(owner)
+
+	if err := coordinator.Put(ctx, "session:9", []byte("token"), WriteOptions{Consistency: All}); err != nil {
+		t.Fatalf("ALL write with one replica down: %v", err)
+	}
+	stats := tc.node(substitute).Metrics().Hints
+	if stats.Pending != 1 || stats.PendingByOwner[owner] != 1 {
+		t.Fatalf("substitute hint stats = %+v, want 1 pending for %s", stats, owner)
+	}
+	if _, ok := tc.node(owner).Store().Get("session:9"); ok {
+		t.Fatal("down replica should not have received the write")
+	}
+
+	// 대신 받은 노드도 읽기에 답하므로 ALL 읽기도 성공
+	res, err := coordinator.Get(ctx, "session:9", ReadOptions{Consistency: All})
+	if err != nil {
+		t.Fatalf("ALL read with one replica down: %v", err)
+	}
+	if got := siblingData(res); !slices.Equal(got, []string{"token"}) {
+		t.Fatalf("read = %v, want [token]", got)
+	}
+
+	// 주인이 아직 죽어 있으면 전달 실패 → 힌트 유지
+	tc.node(substitute).deliverHints(ctx)
+	if pending := tc.node(substitute).Metrics().Hints.Pending; pending != 1 {
+		t.Fatalf("pending = %d while owner down, want 1", pending)
+	}
+
+	tc.restart(owner)
+	tc.node(substitute).deliverHints(ctx)
+	if _, ok := tc.node(owner).Store().Get("session:9"); !ok {
+		t.Fatal("hint was not handed off to the recovered owner")
+	}
+	stats = tc.node(substitute).Metrics().Hints
+	if stats.Pending != 0 || stats.Delivered != 1 {
+		t.Fatalf("after handoff stats = %+v, want 0 pending / 1 delivered", stats)
+	}
+}
+
+func TestHintStoreLimitsAndExpiry(t *testing.T) {
+	hints := newHintStore(HintConfig{Enabled: true, MaxHints: 2, MaxBytes: 1 << 10, TTL: time.Minute, Interval: time.Second})
+	now := time.Unix(1_700_000_000, 0)
+	v := store.Value{Data: []byte("v")}
+
+	for i, key := range []string{"a", "b"} {
+		if err := hints.add("owner", key, v, now.Add(time.Duration(i)*30*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := hints.add("owner", "c", v, now); !errors.Is(err, ErrHintStoreFull) {
+		t.Fatalf("third hint: err = %v, want ErrHintStoreFull", err)
+	}
+	if err := hints.add("owner", "big", store.Value{Data: make([]byte, 2<<10)}, now); !errors.Is(err, ErrHintStoreFull) {
+		t.Fatalf("oversized hint: err = %v, want ErrHintStoreFull", err)
+	}
+
+	hints.expire(now.Add(time.Minute))
+	stats := hints.snapshot()
+	if stats.Pending != 1 || stats.Expired != 1 || stats.Dropped != 2 {
+		t.Fatalf("stats = %+v, want 1 pending / 1 expired / 2 dropped", stats)
+	}
+}
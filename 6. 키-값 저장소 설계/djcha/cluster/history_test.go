@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"kv-store/store"
+)
+
+func TestPointInTimeReads(t *testing.T) {
+	cfg := testConfig
+	cfg.History = 10 * time.Minute
+	tc := startCluster(t, 3, cfg)
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	for _, n := range tc.nodes {
+		n.now = clock.Now
+	}
+	ctx := context.Background()
+	node := tc.nodes[0]
+
+	write := func(key, data string) {
+		t.Helper()
+		res, _ := node.Get(ctx, key, ReadOptions{})
+		if err := node.Put(ctx, key, []byte(data), WriteOptions{Consistency: All, Context: res.Context}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("from", "100")
+	write("to", "0")
+	clock.advance(time.Minute)
+	before := clock.Now()
+	clock.advance(time.Minute)
+	// 이체: 두 키를 차례로 바꿈
+	write("from", "70")
+	write("to", "30")
+
+	// 같은 시각으로 읽으면 두 키가 같은 시점 (반복 가능한 읽기)
+	for _, tc := range []struct{ key, want string }{{"from", "100"}, {"to", "0"}} {
+		res, err := node.Get(ctx, tc.key, ReadOptions{AsOf: before})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := siblingData(res); !slices.Equal(got, []string{tc.want}) {
+			t.Errorf("%s as of before = %v, want [%s]", tc.key, got, tc.want)
+		}
+	}
+	page, err := tc.nodes[1].Range(ctx, RangeOptions{AsOf: before})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range page.Entries {
+		got = append(got, e.Key+"="+string(e.Siblings[0].Data))
+	}
+	if want := []string{"from=100", "to=0"}; !slices.Equal(got, want) {
+		t.Errorf("range as of before = %v, want %v", got, want)
+	}
+
+	// 보존 기간이 지나 정리되면 그 시점은 읽을 수 없음
+	clock.advance(time.Hour)
+	for _, n := range tc.nodes {
+		n.CollectGarbage(ctx)
+	}
+	if _, err := node.Get(ctx, "from", ReadOptions{AsOf: before}); !errors.Is(err, store.ErrCompacted) {
+		t.Fatalf("read past retention: err = %v, want ErrCompacted", err)
+	}
+	if res, err := node.Get(ctx, "from", ReadOptions{}); err != nil || siblingData(res)[0] != "70" {
+		t.Fatalf("current from = %v %v, want 70", siblingData(res), err)
+	}
+}
@@ -0,0 +1,144 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLocked: 다른 조건부 쓰기가 키를 잡고 있음
+var ErrLocked = errors.New("cluster: key is locked by another conditional write")
+
+// lockLease: 키 잠금 유지 시간
+// 잠근 코디네이터가 풀지 못하고 죽어도 이 시간이 지나면 다른 코디네이터가 잠글 수 있음
+const lockLease = 10 * time.Second
+
+// lockRetry: 잠금을 얻지 못했을 때 다시 시도하는 간격
+const lockRetry = 5 * time.Millisecond
+
+// lockTable: 이 노드가 주(primary) 복제본인 키의 잠금
+// 토큰이 같으면 같은 코디네이터의 재시도로 보고 다시 잠글 수 있음
+type lockTable struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	locks map[string]lockEntry
+}
+
+type lockEntry struct {
+	token   string
+	expires time.Time
+}
+
+func newLockTable(now func() time.Time) *lockTable {
+	return &lockTable{now: now, locks: make(map[string]lockEntry)}
+}
+
+func (t *lockTable) lock(key, token string, lease time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if held, ok := t.locks[key]; ok && held.token != token && now.Before(held.expires) {
+		return ErrLocked
+	}
+	t.locks[key] = lockEntry{token: token, expires: now.Add(lease)}
+	return nil
+}
+
+func (t *lockTable) unlock(key, token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if held, ok := t.locks[key]; ok && held.token == token {
+		delete(t.locks, key)
+	}
+}
+
+func newLockToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// lockPrimary: 잠금을 맡을 노드 = 선호 목록에서 장애로 기록되지 않은 첫 노드
+// 주 복제본이 죽어 있는 동안엔 다음 노드가 잠금을 맡으므로, 그 사이 복구된 노드와 잠금이 갈라질 수 있음
+func (n *Node) lockPrimary(key string) string {
+	owners := n.ring.PreferenceList(key, n.cfg.N)
+	for _, node := range owners {
+		if !n.health.isDown(node) {
+			return node
+		}
+	}
+	return owners[0]
+}
+
+// heldLock: 잡은 잠금과 그 잠금을 맡은 노드 (풀 때도 같은 노드에 보내야 함)
+type heldLock struct {
+	key  string
+	node string
+}
+
+// lockKeys: keys 를 (정렬된 순서로) 모두 잠금
+// 모든 코디네이터가 같은 순서로 잠그므로 서로 기다리다 멈추는(교착) 일이 없음
+// 잠금이 풀리길 기다리다 제한 시간이 지나면 잡았던 잠금을 풀고 ErrLocked
+func (n *Node) lockKeys(ctx context.Context, keys []string, token string) ([]heldLock, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+	defer cancel()
+
+	held := make([]heldLock, 0, len(keys))
+	for _, key := range keys {
+		node := n.lockPrimary(key)
+		waited := false
+		for {
+			err := n.replicaLock(ctx, node, key, token)
+			if err == nil {
+				break
+			}
+			if waited && ctx.Err() != nil {
+				// 잠금을 기다리다 다시 묻는 도중에 제한 시간이 지난 것 (잠금을 맡은 노드가 죽은 게 아님)
+				err = ErrLocked
+			}
+			if !errors.Is(err, ErrLocked) || ctx.Err() != nil {
+				n.unlockKeys(held, token)
+				return nil, err
+			}
+			waited = true
+			select {
+			case <-time.After(lockRetry):
+			case <-ctx.Done():
+				n.unlockKeys(held, token)
+				return nil, ErrLocked
+			}
+		}
+		held = append(held, heldLock{key: key, node: node})
+	}
+	return held, nil
+}
+
+// unlockKeys: 잠금 해제 (실패해도 임대 시간이 지나면 풀리므로 오류는 무시)
+func (n *Node) unlockKeys(held []heldLock, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.Timeout)
+	defer cancel()
+
+	for _, l := range held {
+		n.replicaUnlock(ctx, l.node, l.key, token)
+	}
+}
+
+func (n *Node) replicaLock(ctx context.Context, node, key, token string) error {
+	if node == n.id {
+		return n.locks.lock(key, token, lockLease)
+	}
+	return n.transport.Lock(ctx, node, key, token, lockLease)
+}
+
+func (n *Node) replicaUnlock(ctx context.Context, node, key, token string) {
+	if node == n.id {
+		n.locks.unlock(key, token)
+		return
+	}
+	_ = n.transport.Unlock(ctx, node, key, token)
+}
@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// MemberState: 링에 들어오거나 나가는 중인 노드의 상태
+//
+// 링만 바꾸면 키의 주인이 바뀌는 순간 새 주인에게는 데이터가 없으므로,
+// 데이터를 옮기는 동안에는 옛 주인과 새 주인이 같이 쓰기를 받고 읽기는 데이터가 있는 쪽에서 함
+type MemberState string
+
+const (
+	MemberNormal  MemberState = "normal"  // 읽기/쓰기 모두 받음
+	MemberJoining MemberState = "joining" // 링에 있어서 쓰기는 받지만, 자기 범위를 다 받기 전이라 읽기는 받지 않음
+	MemberLeaving MemberState = "leaving" // 아직 읽기/쓰기를 받지만, 쓰기는 나간 뒤의 주인에게도 같이 보냄
+	MemberRemoved MemberState = "removed" // 링에서 빠짐
+)
+
+// ParseMemberState: 문자열을 MemberState 로 변환
+func ParseMemberState(s string) (MemberState, error) {
+	switch st := MemberState(s); st {
+	case MemberNormal, MemberJoining, MemberLeaving, MemberRemoved:
+		return st, nil
+	}
+	return "", fmt.Errorf("cluster: unknown member state %q", s)
+}
+
+// members: 이 노드가 알고 있는 들어오는/나가는 중인 노드 (normal 인 노드는 넣지 않음)
+type members struct {
+	mu     sync.RWMutex
+	states map[string]MemberState
+}
+
+func (m *members) state(node string) MemberState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if st, ok := m.states[node]; ok {
+		return st
+	}
+	return MemberNormal
+}
+
+func (m *members) snapshot() map[string]MemberState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.states) == 0 {
+		return nil
+	}
+	return maps.Clone(m.states)
+}
+
+// SetMember: member 의 상태를 바꾸고 링에 반영 (다른 노드의 알림도 여기로 들어옴)
+func (n *Node) SetMember(member string, state MemberState) {
+	n.members.mu.Lock()
+	defer n.members.mu.Unlock()
+
+	switch state {
+	case MemberJoining, MemberLeaving:
+		n.ring.Add(member)
+		n.members.states[member] = state
+	case MemberNormal:
+		n.ring.Add(member)
+		delete(n.members.states, member)
+	case MemberRemoved:
+		n.ring.Remove(member)
+		delete(n.members.states, member)
+	}
+}
+
+// announce: 링의 다른 모든 노드에 member 의 상태를 알림 (한 대라도 실패하면 에러)
+// 나가는 노드는 자기를 뺀 뒤에도 알려야 하므로 알릴 대상은 바꾸기 전에 정함
+func (n *Node) announce(ctx context.Context, member string, state MemberState) error {
+	peers := slices.DeleteFunc(n.ring.Servers(), func(node string) bool { return node == n.id })
+	n.SetMember(member, state)
+
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Go(func() {
+			callCtx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+			defer cancel()
+			if err := n.transport.Membership(callCtx, peer, member, state); err != nil {
+				errs[i] = fmt.Errorf("announce %s %s to %s: %w", member, state, peer, err)
+			}
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeReplicas: 쓰기를 보낼 노드와 (느슨한 정족수용) 나머지 후보
+// 선호 목록 N 대 중 들어오거나 나가는 중인 노드가 있으면, 그 노드 몫으로 링의 다음 normal 노드에도 씀
+//   - 들어오는 노드: 자리를 내준 옛 주인이 이동이 끝날 때까지 계속 최신 값을 받아 읽기에 응답할 수 있음
+//   - 나가는 노드: 나간 뒤의 새 주인이 이동 중에 들어온 쓰기도 받음
+func (n *Node) writeReplicas(key string) (owners, spare []string) {
+	candidates := n.ring.PreferenceList(key, len(n.ring.Servers()))
+	owners = slices.Clone(candidates[:min(n.cfg.N, len(candidates))])
+	rest := candidates[len(owners):]
+
+	extra := 0
+	for _, node := range owners {
+		if n.members.state(node) != MemberNormal {
+			extra++
+		}
+	}
+	for len(rest) > 0 && extra > 0 {
+		node := rest[0]
+		rest = rest[1:]
+		if n.members.state(node) == MemberNormal {
+			owners = append(owners, node)
+			extra--
+		}
+	}
+	return owners, rest
+}
+
+// readable: 읽기를 보낼 수 있는 노드인지 (자기 범위를 다 받기 전인 노드는 제외)
+func (n *Node) readable(node string) bool {
+	return n.members.state(node) != MemberJoining
+}
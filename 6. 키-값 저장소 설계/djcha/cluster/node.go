@@ -0,0 +1,573 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"kv-store/detector"
+	"kv-store/merkle"
+	"kv-store/ring"
+	"kv-store/store"
+	"kv-store/vclock"
+)
+
+var (
+	// ErrNotFound: 응답한 복제본 중 어느 곳에도 키가 없음
+	ErrNotFound = errors.New("cluster: key not found")
+	// ErrNotEnoughReplicas: 링에 있는 서버 수가 요구 응답 수보다 적음
+	ErrNotEnoughReplicas = errors.New("cluster: not enough replicas")
+)
+
+// QuorumError: 제한 시간 안에 필요한 만큼의 복제본 응답을 받지 못함
+type QuorumError struct {
+	Op       string // "put" / "get"
+	Required int    // 필요한 응답 수
+	Acked    int    // 실제로 성공한 응답 수
+	Err      error  // 마지막 실패 원인 (제한 시간 초과면 context.DeadlineExceeded)
+}
+
+func (e *QuorumError) Error() string {
+	return fmt.Sprintf("cluster: %s quorum not reached (%d/%d acks): %v", e.Op, e.Acked, e.Required, e.Err)
+}
+
+func (e *QuorumError) Unwrap() error {
+	return e.Err
+}
+
+// Config: 다이나모 방식의 N/R/W 설정
+// R + W > N 이면 읽기와 쓰기 복제본 집합이 최소 1개 겹치므로 방금 쓴 값을 읽을 수 있음
+type Config struct {
+	N       int           // 키마다 저장할 복제본 개수
+	R       int           // 읽기 성공에 필요한 응답 수 (Consistency 가 Default 일 때)
+	W       int           // 쓰기 성공에 필요한 응답 수 (Consistency 가 Default 일 때)
+	Timeout time.Duration // 복제본 응답을 기다리는 최대 시간
+
+	// Resolver: 읽을 때 형제 값이 여러 개면 하나로 정리 (nil 이면 형제 값을 모두 돌려줌)
+	Resolver Resolver
+	// MaxClockEntries: 벡터 시계에 남길 최대 노드 수 (0 이면 DefaultMaxClockEntries)
+	MaxClockEntries int
+	// Hints: 느슨한 정족수와 힌트 전달 설정 (Enabled 가 false 면 엄격한 정족수)
+	Hints HintConfig
+	// ReadRepairChance: 읽기 중 이 확률(0 ~ 1)로 복제본 응답을 비교해 뒤처진 복제본을 복구
+	ReadRepairChance float64
+	// Heartbeat: 박동과 파이 누적 장애 감지기 설정 (Interval 이 0 이면 요청 실패로만 장애 판단)
+	Heartbeat HeartbeatConfig
+	// AntiEntropy: 머클 트리로 다른 복제본과 비교하는 주기 (Interval 이 0 이면 AntiEntropy 를 직접 불러야 함)
+	AntiEntropy AntiEntropyConfig
+	// GC: 삭제 표시 정리 설정 (Interval 이 0 이면 정리하지 않음)
+	// 정리할 때 History 가 지난 이전 상태도 함께 지움
+	GC GCConfig
+	// History: 덮어써진 이전 상태를 남겨 두는 기간 (ReadOptions.AsOf 로 이 기간 안의 시점을 읽을 수 있음)
+	History time.Duration
+	// Stream: 노드가 들어오거나 나갈 때 키 범위를 옮기는 설정 (PageKeys 가 0 이면 DefaultStreamConfig.PageKeys)
+	Stream StreamConfig
+}
+
+// DefaultMaxClockEntries: 벡터 시계 항목 수 기본 상한
+// 코디네이터가 바뀔 때마다 항목이 늘어나므로 상한을 넘으면 가장 오래된 항목부터 잘라냄
+const DefaultMaxClockEntries = 10
+
+func (c Config) validate() error {
+	switch {
+	case c.N < 1:
+		return fmt.Errorf("N must be at least 1, got %d", c.N)
+	case c.R < 1 || c.R > c.N:
+		return fmt.Errorf("R must be between 1 and N(%d), got %d", c.N, c.R)
+	case c.W < 1 || c.W > c.N:
+		return fmt.Errorf("W must be between 1 and N(%d), got %d", c.N, c.W)
+	case c.Timeout <= 0:
+		return fmt.Errorf("timeout must be positive, got %v", c.Timeout)
+	case c.ReadRepairChance < 0 || c.ReadRepairChance > 1:
+		return fmt.Errorf("read repair chance must be between 0 and 1, got %v", c.ReadRepairChance)
+	case c.Hints.Enabled && (c.Hints.MaxHints <= 0 || c.Hints.MaxBytes <= 0 || c.Hints.TTL <= 0 || c.Hints.Interval <= 0):
+		return fmt.Errorf("hint limits, TTL and interval must be positive")
+	case c.AntiEntropy.Interval < 0 || c.AntiEntropy.Buckets < 0:
+		return fmt.Errorf("anti-entropy interval and buckets must not be negative")
+	case c.History < 0:
+		return fmt.Errorf("history retention must not be negative, got %v", c.History)
+	case c.GC.Interval < 0 || c.GC.GracePeriod < 0:
+		return fmt.Errorf("gc interval and grace period must not be negative")
+	case c.GC.Interval > 0 && c.Hints.Enabled && c.GC.GracePeriod <= c.Hints.TTL:
+		// 유예 기간이 짧으면 아직 전달 안 된 힌트(옛날 값)가 정리된 키를 되살릴 수 있음
+		return fmt.Errorf("gc grace period (%v) must be longer than hint TTL (%v)", c.GC.GracePeriod, c.Hints.TTL)
+	case c.Stream.PageKeys < 0 || c.Stream.BytesPerSecond < 0 || c.Stream.Retries < 0 || c.Stream.RetryDelay < 0:
+		return fmt.Errorf("stream page size, rate, retries and retry delay must not be negative")
+	case c.Heartbeat.Interval < 0:
+		return fmt.Errorf("heartbeat interval must not be negative, got %v", c.Heartbeat.Interval)
+	case c.Heartbeat.Interval > 0 && c.Heartbeat.Detector.Threshold <= 0:
+		return fmt.Errorf("failure detector threshold must be positive, got %v", c.Heartbeat.Detector.Threshold)
+	}
+	return nil
+}
+
+// WriteOptions: 쓰기 요청 옵션
+type WriteOptions struct {
+	Consistency Consistency
+	// Context: 클라이언트가 직전에 읽을 때 받은 시계 (Result.Context)
+	// 넘기면 그때 읽은 형제 값들을 모두 덮어쓰고, 비워 두면 기존 값과 동시 버전(형제)이 됨
+	Context vclock.Clock
+	// TTL: 이 시간이 지나면 읽을 때 없는 키로 취급 (0 이면 만료 없음)
+	TTL time.Duration
+}
+
+// ReadOptions: 읽기 요청 옵션
+type ReadOptions struct {
+	Consistency Consistency
+	// AsOf: 주면 그 시각에 복제본들이 갖고 있던 값을 읽음 (Config.History 안의 시각만 가능)
+	// 같은 AsOf 로 여러 키를 읽으면 그 사이 쓰기와 상관없이 같은 결과 (반복 가능한 읽기)
+	AsOf time.Time
+}
+
+// Result: 읽기 결과
+type Result struct {
+	Siblings []store.Value // 동시에 쓰인 값들 (Resolver 를 쓰면 항상 1개)
+	Context  vclock.Clock  // 모든 형제 값의 시계를 합친 것 → 다음 쓰기의 WriteOptions.Context 로 사용
+}
+
+// Node: 클러스터의 노드 한 대
+//   - 복제본 역할: 자기 로컬 저장소에 키를 저장 (Handler 로 내부 요청 처리)
+//   - 코디네이터 역할: 클라이언트 요청을 받아 선호 목록의 복제본 N 개에 전달하고 R/W 개 응답을 모음
+//
+// 어느 노드든 코디네이터가 될 수 있으므로 마스터가 없음 (SPOF 없음)
+type Node struct {
+	id          string
+	cfg         Config
+	ring        *ring.Ring
+	store       *store.Store
+	transport   Transport
+	health      *health
+	detector    *detector.Detector // 박동을 쓰지 않으면 nil
+	recovered   chan string        // 장애 감지기가 복구로 판단한 노드
+	hints       *hintStore
+	locks       *lockTable
+	snapshots   *rangeSnapshots
+	watches     *watchLeases
+	updates     sync.Mutex // CRDT 연산을 로컬 상태 위에서 하나씩 적용
+	members     members
+	transfers   transfers
+	repairs     readRepairCounters
+	antiEntropy antiEntropyCounters
+	trees       peerTrees
+	gc          gcCounters
+	now         func() time.Time
+	random      func() float64
+}
+
+// NewNode: id 는 다른 노드가 이 노드에 접근하는 주소 (링에 등록된 이름과 같아야 함)
+func NewNode(id string, cfg Config, r *ring.Ring, t Transport) (*Node, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.MaxClockEntries == 0 {
+		cfg.MaxClockEntries = DefaultMaxClockEntries
+	}
+	if cfg.AntiEntropy.Buckets == 0 {
+		cfg.AntiEntropy.Buckets = DefaultAntiEntropyBuckets
+	}
+	if cfg.Stream.PageKeys == 0 {
+		cfg.Stream.PageKeys = DefaultStreamConfig.PageKeys
+	}
+	n := &Node{
+		id:        id,
+		cfg:       cfg,
+		ring:      r,
+		transport: t,
+		hints:     newHintStore(cfg.Hints),
+		members:   members{states: make(map[string]MemberState)},
+		transfers: transfers{byID: make(map[string]*TransferStatus)},
+		trees:     peerTrees{dir: cfg.AntiEntropy.Dir, epoch: rand.Uint64(), byPath: make(map[string]*merkle.DiskTree)},
+		now:       time.Now,
+		random:    rand.Float64,
+	}
+	n.store = store.NewWithOptions(store.Options{Retention: cfg.History, Now: func() time.Time { return n.now() }})
+	n.health = newHealth(func() time.Time { return n.now() })
+	n.locks = newLockTable(func() time.Time { return n.now() })
+	n.snapshots = newRangeSnapshots(n.store, func() time.Time { return n.now() })
+	n.watches = newWatchLeases(func() time.Time { return n.now() })
+	if cfg.Heartbeat.Interval > 0 {
+		n.initDetector()
+	}
+	if cfg.AntiEntropy.Dir != "" {
+		if err := os.MkdirAll(cfg.AntiEntropy.Dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// Run: 백그라운드 작업 (박동, 힌트 전달, 안티 엔트로피, 삭제 표시 정리) 을 ctx 가 끝날 때까지 실행
+// 장애 감지기가 노드 복구를 알리면 주기를 기다리지 않고 그 노드 몫의 힌트를 바로 전달
+func (n *Node) Run(ctx context.Context) {
+	var heartbeats, deliveries, syncs, collections <-chan time.Time
+	if n.detector != nil {
+		ticker := time.NewTicker(n.cfg.Heartbeat.Interval)
+		defer ticker.Stop()
+		heartbeats = ticker.C
+	}
+	if n.cfg.Hints.Enabled {
+		ticker := time.NewTicker(n.cfg.Hints.Interval)
+		defer ticker.Stop()
+		deliveries = ticker.C
+	}
+	if n.cfg.AntiEntropy.Interval > 0 {
+		ticker := time.NewTicker(n.cfg.AntiEntropy.Interval)
+		defer ticker.Stop()
+		syncs = ticker.C
+	}
+	if n.cfg.GC.Interval > 0 {
+		ticker := time.NewTicker(n.cfg.GC.Interval)
+		defer ticker.Stop()
+		collections = ticker.C
+	}
+
+	for {
+		select {
+		case <-heartbeats:
+			n.sendHeartbeats(ctx)
+		case <-deliveries:
+			n.deliverHints(ctx)
+		case <-syncs:
+			n.antiEntropyRound(ctx)
+		case <-collections:
+			n.CollectGarbage(ctx)
+		case owner := <-n.recovered:
+			if n.cfg.Hints.Enabled {
+				n.deliverHintsTo(ctx, owner)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close: 파일에 저장해 둔 머클 트리를 닫음 (Run 을 끝낸 뒤에 부름)
+func (n *Node) Close() error {
+	return n.trees.close()
+}
+
+// Metrics: 노드 지표
+type Metrics struct {
+	Keys        int                    `json:"keys"`
+	Hints       HintStats              `json:"hints"`
+	ReadRepair  ReadRepairStats        `json:"read_repair"`
+	AntiEntropy AntiEntropyStats       `json:"anti_entropy"`
+	GC          GCStats                `json:"gc"`
+	Peers       map[string]PeerStatus  `json:"peers,omitempty"`     // 박동을 쓸 때만
+	Members     map[string]MemberState `json:"members,omitempty"`   // 들어오거나 나가는 중인 노드
+	Transfers   []TransferStatus       `json:"transfers,omitempty"` // 키 범위 이동 진행 상황
+}
+
+func (n *Node) Metrics() Metrics {
+	return Metrics{
+		Keys:        n.store.Len(),
+		Hints:       n.hints.snapshot(),
+		ReadRepair:  n.repairs.snapshot(),
+		AntiEntropy: n.antiEntropy.snapshot(),
+		GC:          n.gc.snapshot(),
+		Peers:       n.peers(),
+		Members:     n.members.snapshot(),
+		Transfers:   n.Transfers(),
+	}
+}
+
+// ID: 노드 주소
+func (n *Node) ID() string {
+	return n.id
+}
+
+// Store: 이 노드의 로컬 저장소
+func (n *Node) Store() *store.Store {
+	return n.store
+}
+
+// Put: 키를 복제본 N 개에 쓰고, W 개(또는 Consistency 에 따른 개수)가 성공하면 반환
+// 나머지 복제본에 대한 쓰기는 반환 뒤에도 제한 시간까지 계속 진행됨
+//
+// 새 버전의 시계 = 클라이언트가 넘긴 문맥(Context) + 코디네이터(이 노드) 카운터 1 증가
+func (n *Node) Put(ctx context.Context, key string, data []byte, opts WriteOptions) error {
+	_, err := n.write(ctx, key, store.Value{Data: data}, opts)
+	return err
+}
+
+// Delete: 키를 지움
+// 값을 바로 지우면 아직 옛날 값을 가진 복제본이 복구 과정에서 되살리므로,
+// 삭제 표시(tombstone)를 새 버전으로 써서 이전 값들을 덮어씀
+func (n *Node) Delete(ctx context.Context, key string, opts WriteOptions) error {
+	_, err := n.write(ctx, key, store.Value{Deleted: true}, opts)
+	return err
+}
+
+// write: v 에 버전(시계, 시각, 만료)을 붙여 복제본들에 쓰고, 버전을 붙인 값을 돌려줌
+func (n *Node) write(ctx context.Context, key string, v store.Value, opts WriteOptions) (store.Value, error) {
+	v = n.stamp(v, opts)
+	return v, n.replicate(ctx, key, v, opts.Consistency)
+}
+
+// stamp: v 에 이 노드가 처리한 새 버전을 붙임 (시계 = 문맥 + 이 노드 카운터 1 증가)
+func (n *Node) stamp(v store.Value, opts WriteOptions) store.Value {
+	now := n.now().UnixNano()
+	v.Timestamp = now
+	v.Clock = opts.Context.Increment(n.id, now).Prune(n.cfg.MaxClockEntries)
+	if opts.TTL > 0 {
+		// 만료 시각이 int64 를 넘으면 음수가 되어 바로 만료되므로 가장 먼 시각으로 맞춤
+		v.Expires = now + min(int64(opts.TTL), math.MaxInt64-now)
+	}
+	return v
+}
+
+// replicate: 버전을 붙인 v 를 복제본 N 개에 쓰고 level 에 따른 개수가 성공하면 반환
+func (n *Node) replicate(ctx context.Context, key string, v store.Value, level Consistency) error {
+	owners, rest := n.writeReplicas(key)
+	need := level.required(n.cfg.N, n.cfg.W)
+	if need > len(owners) {
+		return ErrNotEnoughReplicas
+	}
+
+	spare := &spares{nodes: rest, health: n.health}
+	replies := n.fanOut(ctx, owners, func(ctx context.Context, owner string) reply {
+		return reply{node: owner, err: n.writeReplica(ctx, owner, key, v, spare)}
+	})
+	_, err := n.await(ctx, "put", replies, len(owners), need)
+	return err
+}
+
+// writeReplica: 복제본 owner 에 씀
+// 느슨한 정족수를 켜 두었으면 owner 가 죽어 있거나 쓰기에 실패했을 때
+// 링의 다음 정상 노드에 "owner 의 값" 이라는 힌트와 함께 대신 씀
+func (n *Node) writeReplica(ctx context.Context, owner, key string, v store.Value, spare *spares) error {
+	var err error
+	if !n.health.isDown(owner) {
+		if err = n.replicaPut(ctx, owner, key, v); err == nil {
+			n.health.markUp(owner)
+			return nil
+		}
+		n.health.markDown(owner)
+	}
+	if !n.cfg.Hints.Enabled {
+		if err == nil {
+			err = fmt.Errorf("replica %s is down", owner)
+		}
+		return err
+	}
+
+	for ctx.Err() == nil {
+		substitute, ok := spare.next()
+		if !ok {
+			break
+		}
+		if err = n.replicaPutHint(ctx, substitute, owner, key, v); err == nil {
+			return nil
+		}
+		if !errors.Is(err, ErrHintStoreFull) {
+			n.health.markDown(substitute)
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("replica %s is down and no substitute is available", owner)
+	}
+	return err
+}
+
+// Get: 복제본 N 개에 모두 물어보고, R 개(또는 Consistency 에 따른 개수)가 응답하면
+// 응답들의 형제 값을 합쳐 반환 (이전 버전은 버리고 동시 버전만 남김)
+// ReadRepairChance 확률로 나머지 응답까지 모아 뒤처진 복제본을 비동기로 복구
+func (n *Node) Get(ctx context.Context, key string, opts ReadOptions) (Result, error) {
+	replicas := n.readReplicas(key)
+	pointInTime := !opts.AsOf.IsZero()
+	if pointInTime {
+		// 지난 시점의 값은 힌트가 아니라 원래 주인의 저장소에만 남아 있음
+		replicas = n.ring.PreferenceList(key, n.cfg.N)
+	}
+	need := opts.Consistency.required(n.cfg.N, n.cfg.R)
+	if need > len(replicas) {
+		return Result{}, ErrNotEnoughReplicas
+	}
+
+	replies := n.fanOut(ctx, replicas, func(ctx context.Context, node string) reply {
+		var siblings []store.Value
+		var found bool
+		var err error
+		if pointInTime {
+			siblings, found, err = n.replicaGetAt(ctx, node, key, opts.AsOf)
+		} else {
+			siblings, found, err = n.replicaGet(ctx, node, key)
+		}
+		return reply{node: node, siblings: siblings, found: found, err: err}
+	})
+	got, err := n.await(ctx, "get", replies, len(replicas), need)
+	if err != nil {
+		return Result{}, err
+	}
+	if !pointInTime && n.sampleReadRepair() {
+		go n.readRepair(key, got, replies)
+	}
+
+	var siblings []store.Value
+	for _, r := range got {
+		siblings = store.Merge(siblings, r.siblings)
+	}
+	// 삭제 표시와 만료된 값은 돌려주지 않지만, 문맥에는 넣어서 다음 쓰기가 이들을 덮어쓰게 함
+	now := n.now().UnixNano()
+	if pointInTime {
+		now = opts.AsOf.UnixNano()
+	}
+	live := slices.DeleteFunc(slices.Clone(siblings), func(v store.Value) bool { return !v.Live(now) })
+	if len(live) == 0 {
+		// 삭제된 키여도 문맥은 돌려줌 → 다시 쓸 때 삭제 표시를 덮어써서 형제로 남지 않음
+		return Result{Context: store.Context(siblings)}, ErrNotFound
+	}
+
+	res := Result{Siblings: live, Context: store.Context(siblings)}
+	siblings = live
+	if len(siblings) > 1 && n.cfg.Resolver != nil {
+		// 정리된 값은 모든 형제 값을 본 버전이므로 합친 시계를 붙여 줌
+		resolved := n.cfg.Resolver(key, siblings)
+		resolved.Clock = res.Context
+		res.Siblings = []store.Value{resolved}
+	}
+	return res, nil
+}
+
+// readReplicas: 읽기를 보낼 노드 N 대 (자기 범위를 아직 다 받지 못한 들어오는 노드는 건너뜀)
+// 느슨한 정족수를 쓰면 죽은 복제본 대신 쓰기를 받았을 노드(링의 다음 정상 노드)에 물어봄
+func (n *Node) readReplicas(key string) []string {
+	candidates := slices.DeleteFunc(n.ring.PreferenceList(key, len(n.ring.Servers())), func(node string) bool {
+		return !n.readable(node)
+	})
+	if !n.cfg.Hints.Enabled {
+		return candidates[:min(n.cfg.N, len(candidates))]
+	}
+
+	replicas := make([]string, 0, n.cfg.N)
+	for _, node := range candidates {
+		if len(replicas) < n.cfg.N && !n.health.isDown(node) {
+			replicas = append(replicas, node)
+		}
+	}
+	// 정상 노드가 모자라면 장애로 기록된 선호 목록 노드로 채워서라도 시도
+	for _, node := range candidates[:min(n.cfg.N, len(candidates))] {
+		if len(replicas) < n.cfg.N && !slices.Contains(replicas, node) {
+			replicas = append(replicas, node)
+		}
+	}
+	return replicas
+}
+
+// 자기 자신이 복제본이면 네트워크를 거치지 않고 로컬 저장소를 바로 사용
+func (n *Node) replicaPut(ctx context.Context, node, key string, v store.Value) error {
+	if node == n.id {
+		n.store.Put(key, v)
+		return nil
+	}
+	return n.transport.Put(ctx, node, key, v)
+}
+
+func (n *Node) replicaPutHint(ctx context.Context, node, owner, key string, v store.Value) error {
+	if node == n.id {
+		return n.hints.add(owner, key, v, n.now())
+	}
+	return n.transport.PutHint(ctx, node, owner, key, v)
+}
+
+func (n *Node) replicaGet(ctx context.Context, node, key string) ([]store.Value, bool, error) {
+	if node == n.id {
+		siblings, ok := n.localGet(key)
+		return siblings, ok, nil
+	}
+	v, found, err := n.transport.Get(ctx, node, key)
+	if err == nil {
+		n.health.markUp(node)
+	} else if ctx.Err() == nil {
+		n.health.markDown(node)
+	}
+	return v, found, err
+}
+
+func (n *Node) replicaGetAt(ctx context.Context, node, key string, at time.Time) ([]store.Value, bool, error) {
+	if node == n.id {
+		return n.store.GetAsOf(key, at)
+	}
+	return n.transport.GetAt(ctx, node, key, at)
+}
+
+// localGet: 로컬 저장소 값 + 다른 노드 대신 받아 둔 힌트 값
+func (n *Node) localGet(key string) ([]store.Value, bool) {
+	siblings, ok := n.store.Get(key)
+	if hinted := n.hints.values(key); len(hinted) > 0 {
+		siblings = store.Merge(siblings, hinted)
+		ok = true
+	}
+	return siblings, ok
+}
+
+// reply: 복제본 하나의 응답
+type reply struct {
+	node     string
+	siblings []store.Value
+	found    bool
+	keys     []string     // Scan 응답
+	entries  []RangeEntry // Range 응답
+	err      error
+}
+
+// fanOut: 모든 복제본에 동시에 요청을 보냄
+// 호출자가 W/R 개 응답만 받고 먼저 반환해도 나머지 요청이 취소되지 않도록
+// 호출자 context 의 취소와 분리하고, 제한 시간만 따로 걸어 줌
+// 모든 요청이 끝나면 채널을 닫으므로 나머지 응답이 필요하면 range 로 끝까지 읽으면 됨
+func (n *Node) fanOut(ctx context.Context, replicas []string, call func(context.Context, string) reply) <-chan reply {
+	callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), n.cfg.Timeout)
+	replies := make(chan reply, len(replicas)) // 아무도 안 읽어도 고루틴이 막히지 않도록 버퍼를 둠
+
+	var wg sync.WaitGroup
+	for _, node := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			replies <- call(callCtx, node)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(replies)
+		cancel()
+	}()
+	return replies
+}
+
+// await: 성공 응답이 need 개 모일 때까지 대기
+// 실패가 쌓여 남은 응답을 다 받아도 need 개가 안 되거나, 제한 시간이 지나면 QuorumError
+func (n *Node) await(ctx context.Context, op string, replies <-chan reply, total, need int) ([]reply, error) {
+	timer := time.NewTimer(n.cfg.Timeout)
+	defer timer.Stop()
+
+	var ok []reply
+	failed := 0
+	for len(ok) < need {
+		select {
+		case r, open := <-replies:
+			if !open {
+				return ok, &QuorumError{Op: op, Required: need, Acked: len(ok), Err: errors.New("no more replies")}
+			}
+			if r.err == nil {
+				ok = append(ok, r)
+				continue
+			}
+			failed++
+			if total-failed < need {
+				return ok, &QuorumError{Op: op, Required: need, Acked: len(ok), Err: r.err}
+			}
+		case <-timer.C:
+			return ok, &QuorumError{Op: op, Required: need, Acked: len(ok), Err: context.DeadlineExceeded}
+		case <-ctx.Done():
+			return ok, &QuorumError{Op: op, Required: need, Acked: len(ok), Err: ctx.Err()}
+		}
+	}
+	return ok, nil
+}
@@ -0,0 +1,390 @@
+package cluster
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"kv-store/store"
+)
+
+var (
+	// ErrInvalidToken: 이어 읽기 토큰을 해석할 수 없음
+	ErrInvalidToken = errors.New("cluster: invalid range token")
+	// ErrTokenExpired: 토큰이 가리키는 스냅샷이 이미 닫힘 (오래 쉬었거나 노드가 재시작됨) → 처음부터 다시
+	ErrTokenExpired = errors.New("cluster: range token expired")
+)
+
+// 범위 조회 한 페이지의 기본/최대 키 수와, 다음 페이지 요청이 없을 때 스냅샷을 붙잡아 두는 시간
+const (
+	DefaultRangeLimit = 100
+	MaxRangeLimit     = 1000
+	rangeSnapshotTTL  = time.Minute
+)
+
+// RangeOptions: 키 순서 범위 조회 옵션
+type RangeOptions struct {
+	Start string // 이 키 이상 (비우면 처음부터)
+	End   string // 이 키 미만 (비우면 끝까지)
+	// Prefix: 주면 Start/End 대신 이 접두사로 시작하는 키만
+	Prefix string
+	// Delimiter: 주면 Prefix 뒤에서 처음 나오는 구분자까지가 같은 키들을 하나로 묶어 Prefixes 로 돌려줌
+	// 예) Prefix "", Delimiter ":" → "user:1", "user:2", "order:9" 대신 "order:", "user:" (네임스페이스 목록)
+	Delimiter string
+	Reverse   bool
+	Limit     int // 0 이면 DefaultRangeLimit
+	// AsOf: 주면 지금이 아니라 그 시각의 스냅샷을 읽음 (Config.History 안의 시각만 가능, 일관된 백업 등)
+	AsOf time.Time
+	// Token: 이전 페이지의 RangePage.Next. 주면 Limit 외의 옵션은 토큰에 담긴 첫 페이지의 옵션을 그대로 씀
+	Token string
+}
+
+// Entry: 범위 조회 결과의 키 하나 (Get 의 Result 와 같은 형태)
+type Entry struct {
+	Key string
+	Result
+}
+
+// RangePage: 범위 조회 한 페이지
+type RangePage struct {
+	Entries  []Entry
+	Prefixes []string // Delimiter 로 묶인 접두사 (키와 섞어서 순서대로 세면 Limit 개)
+	Next     string   // 다음 페이지 토큰 (비어 있으면 끝)
+}
+
+// RangeRequest: 코디네이터가 각 노드에 보내는 범위 조회 요청 (토큰에도 그대로 담김)
+type RangeRequest struct {
+	Snapshot  string `json:"snapshot"` // 노드마다 열어 둔 스냅샷 이름
+	First     bool   `json:"first,omitempty"`
+	Start     string `json:"start,omitempty"`
+	End       string `json:"end,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	Delimiter string `json:"delimiter,omitempty"`
+	Reverse   bool   `json:"reverse,omitempty"`
+	Limit     int    `json:"limit"`
+	AsOf      int64  `json:"as_of"` // 첫 페이지 시각 (UnixNano). 만료(TTL) 판단 기준
+	// PointInTime: 스냅샷을 지금이 아니라 AsOf 시각으로 엶
+	PointInTime bool `json:"point_in_time,omitempty"`
+}
+
+// RangeEntry: 노드 하나가 돌려준 키 (Prefix 면 Delimiter 로 묶인 접두사)
+type RangeEntry struct {
+	Key      string        `json:"key"`
+	Siblings []store.Value `json:"siblings,omitempty"`
+	Prefix   bool          `json:"prefix,omitempty"`
+}
+
+// rangeToken: 다음 페이지 토큰 (base64url JSON)
+type rangeToken struct {
+	RangeRequest
+	After       string `json:"after"`                  // 마지막으로 돌려준 키/접두사
+	AfterPrefix bool   `json:"after_prefix,omitempty"` // After 가 묶인 접두사인지
+}
+
+// Range: 클러스터 전체 키를 키 순서(또는 역순)로 한 페이지 읽음
+//
+// 첫 페이지를 읽을 때 모든 노드가 저장소 스냅샷을 열고, 같은 토큰으로 이어 읽는 동안 그 스냅샷만 봄
+// 그래서 순회 도중 들어온 쓰기(새 키, 값 변경, 삭제)는 중간부터 섞여 나오지 않음
+// 스냅샷은 노드마다 첫 요청을 받은 시점이고, rangeSnapshotTTL 동안 다음 페이지 요청이 없으면 닫힘 (ErrTokenExpired)
+//
+// 각 노드가 범위의 앞에서부터 Limit 개씩 보내면 코디네이터가 합쳐서 다시 앞에서 Limit 개를 고름
+// 삭제 표시도 함께 받아 합치므로, 한 복제본에만 남은 옛날 값이 되살아나 보이지 않음
+// Scan 과 같이 응답하지 않은 노드가 N-1 대 이하면 결과가 완전함 (힌트로만 남은 값은 보이지 않음)
+func (n *Node) Range(ctx context.Context, opts RangeOptions) (RangePage, error) {
+	limit := cmp.Or(opts.Limit, DefaultRangeLimit)
+	if limit < 0 || limit > MaxRangeLimit {
+		return RangePage{}, errors.New("cluster: range limit out of bounds")
+	}
+
+	var tok rangeToken
+	if opts.Token != "" {
+		var err error
+		if tok, err = decodeRangeToken(opts.Token); err != nil {
+			return RangePage{}, err
+		}
+	} else {
+		tok.RangeRequest = RangeRequest{
+			Snapshot:  newLockToken(),
+			First:     true,
+			Start:     opts.Start,
+			End:       opts.End,
+			Prefix:    opts.Prefix,
+			Delimiter: opts.Delimiter,
+			Reverse:   opts.Reverse,
+			AsOf:      n.now().UnixNano(),
+		}
+		if !opts.AsOf.IsZero() {
+			tok.AsOf, tok.PointInTime = opts.AsOf.UnixNano(), true
+		}
+		if opts.Prefix != "" {
+			tok.Start, tok.End = opts.Prefix, store.PrefixEnd(opts.Prefix)
+		}
+	}
+	req := tok.narrow()
+	req.Limit = limit
+
+	servers := n.ring.Servers()
+	replies := n.fanOut(ctx, servers, func(ctx context.Context, node string) reply {
+		entries, err := n.replicaRange(ctx, node, req)
+		return reply{node: node, entries: entries, err: err}
+	})
+	got, err := n.await(ctx, "range", replies, len(servers), max(len(servers)-n.cfg.N+1, 1))
+	if err != nil {
+		return RangePage{}, err
+	}
+	for r := range replies {
+		if r.err == nil {
+			got = append(got, r)
+		}
+	}
+
+	merged := make(map[string]*RangeEntry)
+	full := false // 어느 노드라도 Limit 개를 꽉 채웠으면 뒤에 더 있을 수 있음
+	for _, r := range got {
+		full = full || len(r.entries) >= limit
+		for _, e := range r.entries {
+			if m, ok := merged[e.Key]; ok {
+				m.Siblings = store.Merge(m.Siblings, e.Siblings)
+				m.Prefix = m.Prefix || e.Prefix
+			} else {
+				merged[e.Key] = &e
+			}
+		}
+	}
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	if req.Reverse {
+		slices.Reverse(keys)
+	}
+	if len(keys) > limit {
+		keys, full = keys[:limit], true
+	}
+
+	var page RangePage
+	for _, key := range keys {
+		e := merged[key]
+		if e.Prefix {
+			page.Prefixes = append(page.Prefixes, key)
+			continue
+		}
+		if res, ok := n.liveResult(key, e.Siblings, req.AsOf); ok {
+			page.Entries = append(page.Entries, Entry{Key: key, Result: res})
+		}
+	}
+	if full && len(keys) > 0 {
+		last := merged[keys[len(keys)-1]]
+		next := rangeToken{RangeRequest: tok.RangeRequest, After: last.Key, AfterPrefix: last.Prefix}
+		next.First = false
+		page.Next = next.encode()
+	}
+	return page, nil
+}
+
+// liveResult: 합친 형제 값 중 asOf 시점에 읽을 수 있는 값 (Get 과 같은 방식으로 정리)
+func (n *Node) liveResult(key string, siblings []store.Value, asOf int64) (Result, bool) {
+	live := slices.DeleteFunc(slices.Clone(siblings), func(v store.Value) bool { return !v.Live(asOf) })
+	if len(live) == 0 {
+		return Result{}, false
+	}
+	res := Result{Siblings: live, Context: store.Context(siblings)}
+	if len(live) > 1 && n.cfg.Resolver != nil {
+		resolved := n.cfg.Resolver(key, live)
+		resolved.Clock = res.Context
+		res.Siblings = []store.Value{resolved}
+	}
+	return res, true
+}
+
+// narrow: 토큰의 마지막 위치 다음부터 읽도록 범위를 좁힌 요청
+func (t rangeToken) narrow() RangeRequest {
+	req := t.RangeRequest
+	if t.After == "" && !t.AfterPrefix {
+		return req
+	}
+	switch {
+	case req.Reverse:
+		req.End = t.After // 묶인 접두사의 키도 모두 접두사 이상이므로 그대로 상한으로 씀
+	case t.AfterPrefix:
+		req.Start = store.PrefixEnd(t.After)
+	default:
+		req.Start = t.After + "\x00"
+	}
+	return req
+}
+
+func (t rangeToken) encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeRangeToken(s string) (rangeToken, error) {
+	var t rangeToken
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &t) != nil || t.Snapshot == "" {
+		return rangeToken{}, ErrInvalidToken
+	}
+	return t, nil
+}
+
+func (n *Node) replicaRange(ctx context.Context, node string, req RangeRequest) ([]RangeEntry, error) {
+	if node == n.id {
+		return n.localRange(req)
+	}
+	return n.transport.Range(ctx, node, req)
+}
+
+// localRange: 로컬 스냅샷에서 범위의 앞(역순이면 뒤)에서부터 최대 Limit 개
+// 다른 복제본과 합쳐야 하므로 삭제/만료된 키도 그대로 보냄
+func (n *Node) localRange(req RangeRequest) ([]RangeEntry, error) {
+	snap, err := n.snapshots.get(req)
+	if err != nil || snap == nil {
+		return nil, err
+	}
+
+	var entries []RangeEntry
+	start, end := req.Start, req.End
+	for len(entries) < req.Limit {
+		rolled := false
+		for key, siblings := range snap.Range(start, end, req.Reverse) {
+			group, ok := commonPrefix(key, req.Prefix, req.Delimiter)
+			if !ok {
+				entries = append(entries, RangeEntry{Key: key, Siblings: siblings})
+				if len(entries) == req.Limit {
+					break
+				}
+				continue
+			}
+			// 묶인 접두사는 한 번만 내보내고, 그 접두사의 키들은 건너뛰고 다시 시작
+			if hasLive(snap, group, req.AsOf) {
+				entries = append(entries, RangeEntry{Key: group, Prefix: true})
+			}
+			if req.Reverse {
+				end = group
+			} else {
+				start = store.PrefixEnd(group)
+			}
+			rolled = true
+			break
+		}
+		if !rolled || (!req.Reverse && start == "") {
+			break
+		}
+	}
+
+	// 남은 범위에 키가 하나도 없으면 다음 페이지도 비어 있으므로 스냅샷을 바로 닫음
+	// (몇 개라도 보냈으면 코디네이터가 잘라 내고 다음 페이지에 다시 물을 수 있으므로 lease 동안 유지)
+	if len(entries) == 0 {
+		n.snapshots.finish(req.Snapshot)
+	}
+	return entries, nil
+}
+
+// commonPrefix: key 를 Delimiter 로 묶어야 하면 묶인 접두사 (prefix + 첫 구분자까지)
+func commonPrefix(key, prefix, delimiter string) (string, bool) {
+	if delimiter == "" || !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	i := strings.Index(key[len(prefix):], delimiter)
+	if i < 0 {
+		return "", false
+	}
+	return key[:len(prefix)+i+len(delimiter)], true
+}
+
+// hasLive: 스냅샷에서 group 으로 시작하는 키 중 읽을 수 있는 값이 하나라도 있는지
+func hasLive(snap *store.Snapshot, group string, asOf int64) bool {
+	for _, siblings := range snap.Prefix(group, false) {
+		if slices.ContainsFunc(siblings, func(v store.Value) bool { return v.Live(asOf) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeSnapshots: 범위 조회 중인 스냅샷 (이름 → 스냅샷)
+// 다음 페이지 요청이 rangeSnapshotTTL 안에 없으면 닫음
+type rangeSnapshots struct {
+	mu    sync.Mutex
+	store *store.Store
+	open  map[string]*leasedSnapshot
+	now   func() time.Time
+}
+
+type leasedSnapshot struct {
+	snap    *store.Snapshot // 다 읽어서 닫았으면 nil (lease 동안은 빈 결과로 응답)
+	expires time.Time
+}
+
+func newRangeSnapshots(s *store.Store, now func() time.Time) *rangeSnapshots {
+	return &rangeSnapshots{store: s, open: make(map[string]*leasedSnapshot), now: now}
+}
+
+// get: 요청의 스냅샷을 찾음. 첫 페이지면 새로 엶 (PointInTime 이면 AsOf 시각으로)
+// 이미 다 읽은 스냅샷이면 nil
+func (r *rangeSnapshots) get(req RangeRequest) (*store.Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.expireLocked(now)
+	ls, ok := r.open[req.Snapshot]
+	switch {
+	case !ok && req.First:
+		snap, err := r.openSnapshot(req)
+		if err != nil {
+			return nil, err
+		}
+		ls = &leasedSnapshot{snap: snap}
+		r.open[req.Snapshot] = ls
+	case !ok:
+		return nil, ErrTokenExpired
+	}
+	ls.expires = now.Add(rangeSnapshotTTL)
+	return ls.snap, nil
+}
+
+// expire: lease 가 지난 스냅샷을 닫음 (닫지 않으면 저장소가 그 시점 이후의 이전 상태를 계속 남김)
+func (r *rangeSnapshots) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expireLocked(r.now())
+}
+
+func (r *rangeSnapshots) expireLocked(now time.Time) {
+	for name, ls := range r.open {
+		if now.After(ls.expires) {
+			if ls.snap != nil {
+				ls.snap.Close()
+			}
+			delete(r.open, name)
+		}
+	}
+}
+
+func (r *rangeSnapshots) openSnapshot(req RangeRequest) (*store.Snapshot, error) {
+	if req.PointInTime {
+		return r.store.SnapshotAsOf(time.Unix(0, req.AsOf))
+	}
+	return r.store.Snapshot(), nil
+}
+
+// finish: 스냅샷을 닫되 lease 동안은 이름을 남겨 둠 (다음 페이지 요청에 만료 대신 빈 결과)
+func (r *rangeSnapshots) finish(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ls, ok := r.open[name]; ok && ls.snap != nil {
+		ls.snap.Close()
+		ls.snap = nil
+	}
+}
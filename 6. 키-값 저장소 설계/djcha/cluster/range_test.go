@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// rangeAll: 토큰을 따라가며 모든 페이지를 읽음 (between 은 페이지 사이마다 호출)
+func rangeAll(t *testing.T, node *Node, opts RangeOptions, between func()) (keys, prefixes []string) {
+	t.Helper()
+	for {
+		page, err := node.Range(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page.Entries {
+			keys = append(keys, e.Key+"="+string(e.Siblings[0].Data))
+		}
+		prefixes = append(prefixes, page.Prefixes...)
+		if page.Next == "" {
+			return keys, prefixes
+		}
+		opts.Token = page.Next
+		if between != nil {
+			between()
+		}
+	}
+}
+
+// deleteAll: 현재 버전을 문맥으로 모든 복제본에서 삭제
+func deleteAll(t *testing.T, node *Node, key string) {
+	t.Helper()
+	res, err := node.Get(context.Background(), key, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Delete(context.Background(), key, WriteOptions{Consistency: All, Context: res.Context}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRangePagesAreSnapshotConsistent(t *testing.T) {
+	tc := startCluster(t, 4, testConfig)
+	ctx := context.Background()
+	for _, key := range []string{"user:1", "user:2", "user:3", "user:4", "user:5", "order:1"} {
+		if err := tc.nodes[0].Put(ctx, key, []byte("old"), WriteOptions{Consistency: All}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleteAll(t, tc.nodes[0], "user:3")
+
+	// 페이지 사이에 새 키 추가, 아직 안 읽은 키 변경/삭제 → 이번 순회에는 보이지 않아야 함
+	writes := 0
+	between := func() {
+		writes++
+		if writes > 1 {
+			return
+		}
+		w := WriteOptions{Consistency: All}
+		_ = tc.nodes[1].Put(ctx, "user:0", []byte("new"), w)
+		_ = tc.nodes[1].Put(ctx, "user:6", []byte("new"), w)
+		res, _ := tc.nodes[1].Get(ctx, "user:5", ReadOptions{})
+		_ = tc.nodes[1].Put(ctx, "user:5", []byte("new"), WriteOptions{Consistency: All, Context: res.Context})
+		res, _ = tc.nodes[1].Get(ctx, "user:4", ReadOptions{})
+		_ = tc.nodes[1].Delete(ctx, "user:4", WriteOptions{Consistency: All, Context: res.Context})
+	}
+	keys, _ := rangeAll(t, tc.nodes[2], RangeOptions{Prefix: "user:", Limit: 2}, between)
+	if want := []string{"user:1=old", "user:2=old", "user:4=old", "user:5=old"}; !slices.Equal(keys, want) {
+		t.Fatalf("paged range = %v, want %v", keys, want)
+	}
+
+	// 새로 시작한 순회는 바뀐 내용을 봄 (역순)
+	keys, _ = rangeAll(t, tc.nodes[3], RangeOptions{Prefix: "user:", Reverse: true, Limit: 2}, nil)
+	if want := []string{"user:6=new", "user:5=new", "user:2=old", "user:1=old", "user:0=new"}; !slices.Equal(keys, want) {
+		t.Fatalf("reverse range = %v, want %v", keys, want)
+	}
+}
+
+func TestRangeListsNamespaces(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	for _, key := range []string{"user:1", "user:2", "order:1", "order:2", "config", "session:1"} {
+		if err := tc.nodes[0].Put(ctx, key, []byte("v"), WriteOptions{Consistency: All}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleteAll(t, tc.nodes[0], "session:1")
+
+	keys, prefixes := rangeAll(t, tc.nodes[1], RangeOptions{Delimiter: ":", Limit: 1}, nil)
+	if want := []string{"config=v"}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+	// 삭제된 키만 있는 session: 은 나오지 않음
+	if want := []string{"order:", "user:"}; !slices.Equal(prefixes, want) {
+		t.Errorf("prefixes = %v, want %v", prefixes, want)
+	}
+
+	_, prefixes = rangeAll(t, tc.nodes[1], RangeOptions{Delimiter: ":", Reverse: true}, nil)
+	if want := []string{"user:", "order:"}; !slices.Equal(prefixes, want) {
+		t.Errorf("reverse prefixes = %v, want %v", prefixes, want)
+	}
+
+	if _, err := tc.nodes[1].Range(ctx, RangeOptions{Token: "garbage"}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("bad token: err = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+
+	"kv-store/store"
+)
+
+// ReadRepairStats: 읽기 복구 지표
+// 안티 엔트로피(머클 트리 비교)를 따로 돌리지 않아도 평소 읽기만으로 복제본이 수렴하는지 확인하는 용도
+type ReadRepairStats struct {
+	Sampled   uint64 `json:"sampled"`   // 복구 검사 대상으로 뽑힌 읽기 수 (ReadRepairChance 확률)
+	Triggered uint64 `json:"triggered"` // 뒤처진 복제본을 발견해 복구를 시작한 읽기 수
+	Repaired  uint64 `json:"repaired"`  // 최신 값으로 다시 쓴 복제본 수
+	Failed    uint64 `json:"failed"`    // 다시 쓰기에 실패한 복제본 수
+}
+
+type readRepairCounters struct {
+	sampled, triggered, repaired, failed atomic.Uint64
+}
+
+func (c *readRepairCounters) snapshot() ReadRepairStats {
+	return ReadRepairStats{
+		Sampled:   c.sampled.Load(),
+		Triggered: c.triggered.Load(),
+		Repaired:  c.repaired.Load(),
+		Failed:    c.failed.Load(),
+	}
+}
+
+// sampleReadRepair: 이번 읽기에서 복구 검사를 할지 확률로 결정
+func (n *Node) sampleReadRepair() bool {
+	if n.cfg.ReadRepairChance <= 0 || n.random() >= n.cfg.ReadRepairChance {
+		return false
+	}
+	n.repairs.sampled.Add(1)
+	return true
+}
+
+// readRepair: 읽기 응답을 비교해서 뒤처진 복제본에 최신 버전을 다시 써 줌
+// 클라이언트에는 R 개 응답으로 이미 답했으므로, 나머지 응답까지 기다린 뒤 비동기로 실행
+func (n *Node) readRepair(key string, got []reply, rest <-chan reply) {
+	responses := got
+	for r := range rest {
+		if r.err == nil {
+			responses = append(responses, r)
+		}
+	}
+
+	var newest []store.Value
+	for _, r := range responses {
+		newest = store.Merge(newest, r.siblings)
+	}
+	if len(newest) == 0 {
+		return
+	}
+
+	// 힌트로 대신 답한 노드는 키의 주인이 아니므로 복구 대상에서 제외
+	owners := n.ring.PreferenceList(key, n.cfg.N)
+	type staleReplica struct {
+		node    string
+		missing []store.Value
+	}
+	var stale []staleReplica
+	for _, r := range responses {
+		if !slices.Contains(owners, r.node) {
+			continue
+		}
+		if missing := missingVersions(r.siblings, newest); len(missing) > 0 {
+			stale = append(stale, staleReplica{node: r.node, missing: missing})
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+	n.repairs.triggered.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.Timeout)
+	defer cancel()
+	for _, s := range stale {
+		var err error
+		for _, v := range s.missing {
+			if err = n.replicaPut(ctx, s.node, key, v); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			n.repairs.failed.Add(1)
+			continue
+		}
+		n.repairs.repaired.Add(1)
+	}
+}
+
+// missingVersions: 복제본이 가진 형제 값(have)에 없는 최신 버전들
+func missingVersions(have, newest []store.Value) []store.Value {
+	var missing []store.Value
+	for _, v := range newest {
+		if _, changed := store.Reconcile(have, v); changed {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeStale: 복제본 하나를 장애로 만든 채 새 값을 써서 그 복제본만 옛날 값을 갖게 함
+func writeStale(t *testing.T, tc *testCluster, key string) (coordinator *Node, stale string) {
+	t.Helper()
+	ctx := context.Background()
+
+	replicas := tc.nodes[0].ring.PreferenceList(key, testConfig.N)
+	coordinator, stale = tc.node(replicas[0]), replicas[2]
+	if err := coordinator.Put(ctx, key, []byte("v1"), WriteOptions{Consistency: All}); err != nil {
+		t.Fatal(err)
+	}
+	tc.stop(stale)
+	res, err := coordinator.Get(ctx, key, ReadOptions{Consistency: Quorum})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 읽기의 나머지 응답(stale 실패)까지 처리되어 stale 이 장애로 기록될 때까지 대기
+	// 그래야 다음 쓰기가 stale 을 건너뛰어, 복구 뒤에 늦게 도착하는 쓰기가 없음
+	for deadline := time.Now().Add(time.Second); !coordinator.health.isDown(stale); {
+		if time.Now().After(deadline) {
+			t.Fatal("stale replica never marked down")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := coordinator.Put(ctx, key, []byte("v2"), WriteOptions{Consistency: Quorum, Context: res.Context}); err != nil {
+		t.Fatal(err)
+	}
+	tc.restart(stale)
+	return coordinator, stale
+}
+
+func TestReadRepairUpdatesStaleReplica(t *testing.T) {
+	cfg := testConfig
+	cfg.ReadRepairChance = 1
+	tc := startCluster(t, 3, cfg)
+	coordinator, stale := writeStale(t, tc, "profile:7")
+
+	res, err := coordinator.Get(context.Background(), "profile:7", ReadOptions{Consistency: All})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := siblingData(res); !slices.Equal(got, []string{"v2"}) {
+		t.Fatalf("read = %v, want [v2]", got)
+	}
+
+	// 복구는 비동기이므로 잠시 기다림
+	deadline := time.Now().Add(time.Second)
+	for {
+		siblings, _ := tc.node(stale).Store().Get("profile:7")
+		// 복구 지표는 복제본에 쓴 뒤에 올라가므로 둘 다 확인
+		if len(siblings) == 1 && string(siblings[0].Data) == "v2" && coordinator.Metrics().ReadRepair.Repaired > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stale replica still has %v", siblings)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stats := coordinator.Metrics().ReadRepair
+	if stats.Sampled != 2 || stats.Triggered != 1 || stats.Repaired != 1 {
+		t.Fatalf("read repair stats = %+v, want 2 sampled / 1 triggered / 1 repaired", stats)
+	}
+}
+
+func TestReadRepairDisabled(t *testing.T) {
+	tc := startCluster(t, 3, testConfig) // ReadRepairChance = 0
+	coordinator, stale := writeStale(t, tc, "profile:8")
+
+	if _, err := coordinator.Get(context.Background(), "profile:8", ReadOptions{Consistency: All}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	siblings, _ := tc.node(stale).Store().Get("profile:8")
+	if len(siblings) != 1 || string(siblings[0].Data) != "v1" {
+		t.Fatalf("replica without read repair = %v, want still v1", siblings)
+	}
+	if stats := coordinator.Metrics().ReadRepair; stats != (ReadRepairStats{}) {
+		t.Fatalf("read repair stats = %+v, want zero", stats)
+	}
+}
@@ -0,0 +1,24 @@
+package cluster
+
+import (
+	"bytes"
+
+	"kv-store/store"
+)
+
+// Resolver: 동시에 쓰인 형제 값들을 값 하나로 정리하는 함수
+// 애플리케이션이 직접 만들어 넣을 수도 있음 (예: 장바구니는 두 목록의 합집합)
+type Resolver func(key string, siblings []store.Value) store.Value
+
+// LastWriteWins: 쓰기 시각(Timestamp)이 가장 늦은 값을 고름
+// 시각까지 같으면 데이터 바이트를 비교해서, 어느 노드에서 해결하든 같은 값을 고르도록 함
+func LastWriteWins(key string, siblings []store.Value) store.Value {
+	winner := siblings[0]
+	for _, v := range siblings[1:] {
+		if v.Timestamp > winner.Timestamp ||
+			(v.Timestamp == winner.Timestamp && bytes.Compare(v.Data, winner.Data) > 0) {
+			winner = v
+		}
+	}
+	return winner
+}
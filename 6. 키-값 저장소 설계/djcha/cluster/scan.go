@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"kv-store/ring"
+	"kv-store/store"
+)
+
+// Scan: 클러스터 전체 키를 링 해시 순서로 훑음 (레디스 SCAN 과 같은 커서 방식)
+//
+// 커서는 "다음에 볼 해시 값" 이고 0 에서 시작해서 0 이 돌아오면 끝
+// 키를 해시 순서로 돌려주므로 스캔하는 동안 다른 키가 추가/삭제돼도
+// 처음부터 끝까지 있던 키는 빠짐없이 한 번 이상 나옴
+// 해시가 같은 키는 한 페이지에 모두 담으므로 count 보다 조금 많이 돌려줄 수 있음
+//
+// 키는 N 대에 복제되어 있으므로 응답하지 않은 노드가 N-1 대 이하면 결과가 완전함
+// 그래도 W < N 으로 쓴 키가 아직 일부에만 있을 수 있어 제한 시간 안의 응답은 모두 모음
+func (n *Node) Scan(ctx context.Context, cursor uint64, count int) ([]string, uint64, error) {
+	if cursor > uint64(^uint32(0)) {
+		return nil, 0, fmt.Errorf("cluster: invalid scan cursor %d", cursor)
+	}
+	if count <= 0 {
+		count = 10
+	}
+	from := uint32(cursor)
+
+	servers := n.ring.Servers()
+	replies := n.fanOut(ctx, servers, func(ctx context.Context, node string) reply {
+		keys, err := n.replicaKeys(ctx, node, from, count)
+		return reply{node: node, keys: keys, err: err}
+	})
+	got, err := n.await(ctx, "scan", replies, len(servers), max(len(servers)-n.cfg.N+1, 1))
+	if err != nil {
+		return nil, 0, err
+	}
+	for r := range replies {
+		if r.err == nil {
+			got = append(got, r)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var keys []string
+	for _, r := range got {
+		for _, key := range r.keys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	keys = pageByHash(keys, count)
+	if len(keys) < count {
+		return keys, 0, nil
+	}
+	next := uint64(ring.Hash(keys[len(keys)-1])) + 1
+	if next > uint64(^uint32(0)) {
+		next = 0
+	}
+	return keys, next, nil
+}
+
+// localKeys: 로컬 저장소에서 해시가 from 이상인 읽을 수 있는 키를 해시 순서로 최대 limit 개
+func (n *Node) localKeys(from uint32, limit int) []string {
+	now := n.now().UnixNano()
+	var keys []string
+	for _, key := range n.store.Keys() {
+		if ring.Hash(key) < from {
+			continue
+		}
+		siblings, _ := n.store.Get(key)
+		if slices.ContainsFunc(siblings, func(v store.Value) bool { return v.Live(now) }) {
+			keys = append(keys, key)
+		}
+	}
+	return pageByHash(keys, limit)
+}
+
+func (n *Node) replicaKeys(ctx context.Context, node string, from uint32, limit int) ([]string, error) {
+	if node == n.id {
+		return n.localKeys(from, limit), nil
+	}
+	return n.transport.Keys(ctx, node, from, limit)
+}
+
+// pageByHash: (해시, 키) 순으로 정렬해서 앞에서 limit 개
+// 마지막 키와 해시가 같은 키는 잘리지 않도록 함께 포함 (커서가 해시 단위이므로)
+func pageByHash(keys []string, limit int) []string {
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(ring.Hash(a), ring.Hash(b)), cmp.Compare(a, b))
+	})
+	if len(keys) <= limit {
+		return keys
+	}
+	end := limit
+	last := ring.Hash(keys[limit-1])
+	for end < len(keys) && ring.Hash(keys[end]) == last {
+		end++
+	}
+	return keys[:end]
+}
@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestScanCoversClusterWithOneNodeDown(t *testing.T) {
+	tc := startCluster(t, 4, testConfig)
+	ctx := context.Background()
+	coordinator := tc.nodes[0]
+
+	var want []string
+	for i := range 60 {
+		key := fmt.Sprintf("item:%d", i)
+		if err := coordinator.Put(ctx, key, []byte("v"), WriteOptions{Consistency: All}); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, key)
+	}
+	res, err := coordinator.Get(ctx, "item:0", ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := coordinator.Delete(ctx, "item:0", WriteOptions{Consistency: All, Context: res.Context}); err != nil {
+		t.Fatal(err)
+	}
+	want = want[1:]
+
+	// 복제본이 3대씩 있으므로 1대가 죽어도 모든 키가 나와야 함
+	tc.stop(tc.nodes[2].ID())
+
+	var got []string
+	var cursor uint64
+	for {
+		keys, next, err := coordinator.Scan(ctx, cursor, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, keys...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("scan returned %d keys, want %d (deleted key must be skipped)", len(got), len(want))
+	}
+}
@@ -0,0 +1,773 @@
+package cluster
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"kv-store/ring"
+	"kv-store/store"
+	"kv-store/vclock"
+)
+
+// 결정적 장애 주입 시뮬레이션
+//
+// 시드 하나로 클러스터, 작업(쓰기/읽기/삭제), 장애(분할, 크래시), 네트워크(유실, 중복, 지연)를 모두 정함
+//   - 시계: testing/synctest 의 가상 시계. 제한 시간, 재시도, 힌트 전달 주기가 모두 실제로 기다리지 않고 흘러감
+//   - 네트워크: 노드 사이 HTTP 요청을 실제로 보내지 않고 simulation 이 큐에 모았다가 한 번에 하나씩 전달
+//     (노드의 내부 API Handler 를 그대로 부르므로 실제 배포와 같은 코드 경로)
+//   - 크래시: 프로세스가 멈춘 것처럼 그 노드로 오가는 메시지를 모두 버림
+//     장애를 걷어 낼 때 노드를 NewNode 로 새로 만들어 다시 띄움
+//     저장소와 힌트 큐는 디스크에 남는다고 보고 옮기고, 나머지(잠금, 장애 기록, 처리 중이던 요청)는 모두 잃음
+//     크래시 전의 노드(이전 실행)가 늦게 보내거나 받는 메시지는 버리고, 그 노드가 코디네이터였던 쓰기는 성공으로 치지 않음
+//
+// 작업이 끝나면 장애를 모두 걷어 내고 힌트 전달과 안티 엔트로피를 돌린 뒤 불변식을 확인
+//   - 성공 응답을 받은 쓰기는 사라지지 않음 (모든 복제본에 그 버전 또는 그 이후 버전이 있음)
+//   - 복제본이 수렴함 (키마다 선호 목록 N 대의 형제 값이 같음)
+//   - 맡겨 둔 힌트가 모두 전달됨
+//
+// 실패하면 시드와 마지막 기록을 출력하므로 같은 시드로 다시 실행해 그대로 재현할 수 있음
+//
+//	go test ./cluster -run 'TestSimulation$' -sim.seed=42 -v
+var (
+	simSeed = flag.Uint64("sim.seed", 0, "실행할 시뮬레이션 시드 (0 이면 1 부터 -sim.runs 개)")
+	simRuns = flag.Int("sim.runs", 5, "시드를 주지 않았을 때 실행할 시뮬레이션 수 (더 많은 시드를 돌릴 때 늘림)")
+)
+
+// simOptions: 시뮬레이션 규모와 장애 정도
+type simOptions struct {
+	Nodes, Keys, Ops int
+	Config           Config
+	Drop, Duplicate  float64       // 메시지마다 버리거나 두 번 보낼 확률
+	MaxDelay         time.Duration // 메시지 지연 상한 (0 ~ MaxDelay 균등 분포)
+	OpInterval       time.Duration // 작업 사이 평균 간격 (지수 분포)
+	FaultInterval    time.Duration // 장애를 바꾸는 평균 간격 (0 이면 장애 없음)
+}
+
+var defaultSimOptions = simOptions{
+	Nodes: 5,
+	Keys:  6,
+	Ops:   120,
+	Config: Config{
+		N: 3, R: 2, W: 2,
+		Timeout:          200 * time.Millisecond,
+		Hints:            DefaultHintConfig,
+		ReadRepairChance: 0.3,
+	},
+	Drop:          0.05,
+	Duplicate:     0.05,
+	MaxDelay:      30 * time.Millisecond,
+	OpInterval:    50 * time.Millisecond,
+	FaultInterval: time.Second,
+}
+
+func TestSimulation(t *testing.T) {
+	seeds := []uint64{*simSeed}
+	if *simSeed == 0 {
+		runs := *simRuns
+		if testing.Short() {
+			runs = min(runs, 1)
+		}
+		seeds = seeds[:0]
+		for seed := range uint64(runs) {
+			seeds = append(seeds, seed+1)
+		}
+	}
+	for _, seed := range seeds {
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			res := simulate(t, seed, defaultSimOptions)
+			if len(res.violations) > 0 {
+				t.Errorf("%d invariant violations:\n  %s\nlast events:\n  %s\nreplay: go test ./cluster -run 'TestSimulation$' -sim.seed=%d -v",
+					len(res.violations), strings.Join(res.violations, "\n  "), strings.Join(tail(res.trace, 40), "\n  "), seed)
+			}
+			t.Logf("%d events, %d acked writes", len(res.trace), res.acked)
+		})
+	}
+}
+
+func TestSimulationReplaysFromSeed(t *testing.T) {
+	opts := defaultSimOptions
+	opts.Ops = 40
+	a, b := simulate(t, 7, opts), simulate(t, 7, opts)
+	if a.fingerprint() == b.fingerprint() {
+		return
+	}
+	for i := range min(len(a.trace), len(b.trace)) {
+		if a.trace[i] != b.trace[i] {
+			t.Fatalf("runs diverged at event %d:\n  %s\n  %s", i, a.trace[i], b.trace[i])
+		}
+	}
+	t.Fatalf("runs recorded %d and %d events", len(a.trace), len(b.trace))
+}
+
+// 장애를 자주 바꿔서 크래시한 노드를 새로 만들어 다시 띄우는 경우가 여러 번 생기게 함
+func TestSimulationRestartsNodes(t *testing.T) {
+	opts := defaultSimOptions
+	opts.FaultInterval = 300 * time.Millisecond
+	res := simulate(t, 11, opts)
+	if len(res.violations) > 0 {
+		t.Fatalf("%d invariant violations:\n  %s", len(res.violations), strings.Join(res.violations, "\n  "))
+	}
+	restarts := 0
+	for _, line := range res.trace {
+		if strings.Contains(line, " restart ") {
+			restarts++
+		}
+	}
+	if restarts < 2 {
+		t.Fatalf("only %d restarts; the run does not exercise rebuilding nodes", restarts)
+	}
+}
+
+// simResult: 시뮬레이션 한 번의 결과
+type simResult struct {
+	violations []string
+	trace      []string
+	acked      int
+}
+
+// fingerprint: 실행 기록 전체의 해시 (같은 시드면 같아야 함)
+func (r simResult) fingerprint() [32]byte {
+	return sha256.Sum256([]byte(strings.Join(r.trace, "\n")))
+}
+
+func tail(lines []string, n int) []string {
+	return lines[max(len(lines)-n, 0):]
+}
+
+// simulate: seed 로 시뮬레이션 한 번을 가상 시계 안에서 실행
+func simulate(t *testing.T, seed uint64, opts simOptions) simResult {
+	var res simResult
+	synctest.Test(t, func(t *testing.T) {
+		s := newSimulation(t, seed, opts)
+		s.workload()
+		s.heal()
+		res = simResult{violations: s.check(), trace: s.trace, acked: s.ackedWrites()}
+	})
+	return res
+}
+
+// simulation: 가상 네트워크와 일정표
+// trace, pending, agenda, rng 는 시뮬레이션 고루틴만 고침 (노드 고루틴은 inbox, results 에만 넣음)
+type simulation struct {
+	opts     simOptions
+	seed     uint64
+	rng      *rand.Rand
+	start    time.Time
+	nodes    []*Node
+	byID     map[string]*Node
+	handlers map[string]http.Handler
+	r        *ring.Ring
+
+	mu       sync.Mutex
+	inbox    []*simMessage // 노드가 보낸 뒤 아직 일정에 넣지 않은 메시지
+	results  []simOpResult // 끝난 작업 (시뮬레이션 고루틴이 기록에 옮김)
+	busy     map[string]bool
+	inflight int
+
+	pending []*simMessage // 전달 시각 순서
+	agenda  []simAction   // 실행 시각 순서
+	seq     uint64
+	crashed map[string]bool
+	gen     map[string]int // 노드별 실행 번호 (다시 띄울 때마다 증가)
+	group   map[string]int // 분할된 쪽 번호 (같은 번호끼리만 통신)
+	drop    float64
+	dup     float64
+	writes  []simWrite
+	trace   []string
+}
+
+// simMessage: 노드 사이 요청 또는 응답 하나
+type simMessage struct {
+	seq         uint64
+	at          time.Time
+	from, to    string
+	gen         int // 요청을 보낸 노드의 실행 번호 (응답이면 받을 노드의 실행 번호)
+	method, uri string
+	body        []byte
+	status      int // 응답이면 HTTP 상태 코드, 요청이면 0
+	header      http.Header
+	reply       chan simReply // 요청을 보낸 고루틴이 응답을 기다리는 채널
+}
+
+type simReply struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (m *simMessage) String() string {
+	if m.status != 0 {
+		return fmt.Sprintf("%s→%s %d (%s %s)", m.from, m.to, m.status, m.method, m.uri)
+	}
+	return fmt.Sprintf("%s→%s %s %s", m.from, m.to, m.method, m.uri)
+}
+
+// compareMessages: 같은 순간 보낸 메시지들을 고루틴 실행 순서와 상관없이 늘 같은 순서로 정렬
+func compareMessages(a, b *simMessage) int {
+	return cmp.Or(
+		cmp.Compare(a.from, b.from),
+		cmp.Compare(a.to, b.to),
+		cmp.Compare(a.gen, b.gen),
+		cmp.Compare(a.status, b.status),
+		cmp.Compare(a.method, b.method),
+		cmp.Compare(a.uri, b.uri),
+		bytes.Compare(a.body, b.body),
+	)
+}
+
+// simAction: 정해진 시각에 시뮬레이션 고루틴에서 실행할 일 (작업 시작, 장애, 백그라운드 작업)
+type simAction struct {
+	at   time.Time
+	seq  uint64
+	name string
+	run  func()
+}
+
+// simWrite: 클라이언트 쓰기 하나 (acked 면 성공 응답을 받음)
+type simWrite struct {
+	op    int
+	key   string
+	value store.Value
+	acked bool
+}
+
+type simOpResult struct {
+	op    int
+	coord string    // 코디네이터
+	gen   int       // 작업을 시작할 때 코디네이터의 실행 번호
+	write *simWrite // 읽기면 nil
+	err   error
+}
+
+// simLink: 노드 하나가 다른 노드에 보내는 HTTP 요청을 시뮬레이션 네트워크로 보냄
+type simLink struct {
+	s    *simulation
+	from string
+	gen  int
+}
+
+func (l simLink) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	m := &simMessage{
+		from: l.from, to: req.URL.Host, gen: l.gen,
+		method: req.Method, uri: req.URL.RequestURI(), body: body,
+		reply: make(chan simReply, 1),
+	}
+	l.s.send(m)
+	select {
+	case r := <-m.reply:
+		return &http.Response{StatusCode: r.status, Header: r.header, Body: io.NopCloser(bytes.NewReader(r.body)), Request: req}, nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+func newSimulation(t *testing.T, seed uint64, opts simOptions) *simulation {
+	s := &simulation{
+		opts:     opts,
+		seed:     seed,
+		rng:      rand.New(rand.NewPCG(seed, 0)),
+		start:    time.Now(),
+		byID:     make(map[string]*Node),
+		handlers: make(map[string]http.Handler),
+		r:        ring.New(20),
+		busy:     make(map[string]bool),
+		crashed:  make(map[string]bool),
+		gen:      make(map[string]int),
+		group:    make(map[string]int),
+		drop:     opts.Drop,
+		dup:      opts.Duplicate,
+	}
+	for i := range opts.Nodes {
+		s.r.Add(fmt.Sprintf("n%d", i+1))
+	}
+	for _, id := range s.r.Servers() {
+		n, err := s.startNode(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.nodes = append(s.nodes, n)
+	}
+	return s
+}
+
+// startNode: id 노드를 지금 실행 번호로 새로 만들어 네트워크에 연결
+func (s *simulation) startNode(id string) (*Node, error) {
+	gen := s.gen[id]
+	n, err := NewNode(id, s.opts.Config, s.r, &HTTPTransport{Client: &http.Client{Transport: simLink{s: s, from: id, gen: gen}}})
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	random := rand.New(rand.NewPCG(s.seed, uint64(slices.Index(s.r.Servers(), id)+1)|uint64(gen)<<32))
+	n.random = func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return random.Float64()
+	}
+	s.byID[id] = n
+	s.handlers[id] = n.Handler()
+	return n, nil
+}
+
+// restart: 크래시한 노드를 새로 만들어 다시 띄움 (저장소와 힌트 큐만 이전 실행에서 옮김)
+func (s *simulation) restart(id string) {
+	old := s.byID[id]
+	s.gen[id]++
+	n, err := s.startNode(id)
+	if err != nil {
+		panic(err)
+	}
+	s.nodes[slices.Index(s.nodes, old)] = n
+
+	for _, key := range old.store.Keys() {
+		siblings, _ := old.store.Get(key)
+		for _, v := range siblings {
+			n.store.Put(key, v)
+		}
+	}
+	old.hints.mu.Lock()
+	hints := 0
+	for _, owner := range slices.Sorted(maps.Keys(old.hints.byOwner)) {
+		for _, ht := range old.hints.byOwner[owner] {
+			if err := n.hints.add(owner, ht.key, ht.value, ht.created); err == nil {
+				hints++
+			}
+		}
+	}
+	old.hints.mu.Unlock()
+	s.logf("restart %s from disk: %d keys, %d hints", id, n.store.Len(), hints)
+}
+
+func (s *simulation) send(m *simMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inbox = append(s.inbox, m)
+}
+
+func (s *simulation) logf(format string, args ...any) {
+	s.trace = append(s.trace, fmt.Sprintf("%9.3fs ", time.Since(s.start).Seconds())+fmt.Sprintf(format, args...))
+}
+
+// at: d 뒤에 실행할 일을 일정표에 넣음
+func (s *simulation) at(d time.Duration, name string, run func()) {
+	s.seq++
+	s.agenda = append(s.agenda, simAction{at: time.Now().Add(d), seq: s.seq, name: name, run: run})
+	slices.SortStableFunc(s.agenda, func(a, b simAction) int {
+		return cmp.Or(a.at.Compare(b.at), cmp.Compare(a.seq, b.seq))
+	})
+}
+
+// spawn: 노드 쪽 작업을 고루틴으로 실행 (끝날 때까지 inflight 로 셈)
+func (s *simulation) spawn(f func()) {
+	s.mu.Lock()
+	s.inflight++
+	s.mu.Unlock()
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			s.inflight--
+			s.mu.Unlock()
+		}()
+		f()
+	}()
+}
+
+func (s *simulation) idle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inflight == 0
+}
+
+// run: done 이 true 가 되거나 d 가 지날 때까지 메시지와 일정을 처리
+// 매 단계 모든 노드 고루틴이 멈출 때까지 기다린 뒤(synctest.Wait) 하나씩 처리하므로 순서가 시드로만 정해짐
+func (s *simulation) run(d time.Duration, done func() bool) {
+	const tick = 5 * time.Millisecond // 노드가 보낸 메시지를 일정에 넣는 최대 간격
+	end := time.Now().Add(d)
+	for {
+		synctest.Wait()
+		s.collect()
+		now := time.Now()
+		switch {
+		case len(s.pending) > 0 && !s.pending[0].at.After(now):
+			m := s.pending[0]
+			s.pending = s.pending[1:]
+			s.deliver(m)
+			continue
+		case len(s.agenda) > 0 && !s.agenda[0].at.After(now):
+			a := s.agenda[0]
+			s.agenda = s.agenda[1:]
+			a.run()
+			continue
+		case done != nil && done() || !now.Before(end):
+			return
+		}
+		next := end
+		if len(s.pending) > 0 {
+			next = minTime(next, s.pending[0].at)
+		}
+		if len(s.agenda) > 0 {
+			next = minTime(next, s.agenda[0].at)
+		}
+		time.Sleep(min(next.Sub(now), tick))
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// collect: 노드가 보낸 메시지를 정해진 순서로 정렬해 유실/중복/지연을 정하고, 끝난 작업을 기록
+func (s *simulation) collect() {
+	s.mu.Lock()
+	inbox, results := s.inbox, s.results
+	s.inbox, s.results = nil, nil
+	s.mu.Unlock()
+
+	slices.SortFunc(results, func(a, b simOpResult) int { return cmp.Compare(a.op, b.op) })
+	for _, r := range results {
+		// 코디네이터가 그사이 크래시했으면 응답이 클라이언트에 닿지 못함 (성공했어도 성공으로 치지 않음)
+		if (s.crashed[r.coord] || r.gen != s.gen[r.coord]) && r.write != nil && r.write.acked {
+			r.write.acked = false
+			r.err = errors.New("coordinator crashed before replying")
+		}
+		switch {
+		case r.write == nil && r.err != nil:
+			s.logf("op %d read failed: %v", r.op, r.err)
+		case r.write == nil:
+			s.logf("op %d read ok", r.op)
+		case r.write.acked:
+			s.writes = append(s.writes, *r.write)
+			s.logf("op %d write acked: %s", r.op, describe(r.write.value))
+		default:
+			s.writes = append(s.writes, *r.write)
+			s.logf("op %d write failed: %v", r.op, r.err)
+		}
+	}
+
+	slices.SortStableFunc(inbox, compareMessages)
+	now := time.Now()
+	for _, m := range inbox {
+		if s.rng.Float64() < s.drop {
+			s.logf("%s lost", m)
+			continue
+		}
+		copies := 1
+		if s.rng.Float64() < s.dup {
+			copies = 2
+		}
+		for range copies {
+			c := *m
+			s.seq++
+			c.seq = s.seq
+			c.at = now.Add(time.Duration(s.rng.Int64N(int64(s.opts.MaxDelay) + 1)))
+			s.pending = append(s.pending, &c)
+		}
+	}
+	slices.SortFunc(s.pending, func(a, b *simMessage) int {
+		return cmp.Or(a.at.Compare(b.at), cmp.Compare(a.seq, b.seq))
+	})
+}
+
+// blocked: m 을 지금 전달할 수 없으면 이유
+func (s *simulation) blocked(m *simMessage) string {
+	from, to := m.from, m.to
+	switch {
+	case m.status == 0 && m.gen != s.gen[from], m.status != 0 && m.gen != s.gen[to]:
+		return "restarted"
+	case s.crashed[from] || s.crashed[to]:
+		return "crashed"
+	case s.group[from] != s.group[to]:
+		return "partitioned"
+	}
+	return ""
+}
+
+// deliver: 요청이면 받는 노드의 Handler 를 실행하고 응답을 다시 네트워크로 보냄, 응답이면 기다리는 고루틴에 전달
+func (s *simulation) deliver(m *simMessage) {
+	if reason := s.blocked(m); reason != "" {
+		s.logf("%s dropped (%s)", m, reason)
+		return
+	}
+	s.logf("%s", m)
+	if m.status != 0 {
+		select {
+		case m.reply <- simReply{status: m.status, header: m.header, body: m.body}:
+		default: // 중복 응답 (이미 하나를 받음)
+		}
+		return
+	}
+
+	handler := s.handlers[m.to]
+	s.spawn(func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(m.method, "http://"+m.to+m.uri, bytes.NewReader(m.body)))
+		s.send(&simMessage{
+			from: m.to, to: m.from, gen: m.gen, method: m.method, uri: m.uri,
+			status: rec.Code, header: rec.Header(), body: rec.Body.Bytes(), reply: m.reply,
+		})
+	})
+}
+
+// workload: 작업, 장애, 백그라운드 작업(힌트 전달, 안티 엔트로피)을 일정표에 넣고 실행
+func (s *simulation) workload() {
+	var offset time.Duration
+	for op := range s.opts.Ops {
+		offset += time.Duration(s.rng.ExpFloat64() * float64(s.opts.OpInterval))
+		s.at(offset, "op", func() { s.clientOp(op) })
+	}
+	if s.opts.FaultInterval > 0 {
+		for at := time.Duration(0); at < offset; {
+			at += time.Duration(s.rng.ExpFloat64() * float64(s.opts.FaultInterval))
+			s.at(at, "fault", s.fault)
+		}
+	}
+	for at := s.opts.Config.Hints.Interval / 20; at < offset; at += s.opts.Config.Hints.Interval / 20 {
+		s.at(at, "hints", func() {
+			for _, n := range s.nodes {
+				s.background(n, "hints", n.deliverHints)
+			}
+		})
+	}
+	for at := time.Second; at < offset; at += time.Second {
+		s.at(at, "anti-entropy", func() {
+			n := s.nodes[s.rng.IntN(len(s.nodes))]
+			s.background(n, "anti-entropy", n.antiEntropyRound)
+		})
+	}
+	s.run(offset, nil)
+}
+
+// background: 노드의 주기 작업 실행 (크래시했거나 이전 실행이 안 끝났으면 건너뜀)
+func (s *simulation) background(n *Node, name string, task func(context.Context)) {
+	if s.crashed[n.id] {
+		return
+	}
+	key := fmt.Sprintf("%s#%d/%s", n.id, s.gen[n.id], name)
+	s.mu.Lock()
+	running := s.busy[key]
+	s.busy[key] = true
+	s.mu.Unlock()
+	if running {
+		return
+	}
+	s.logf("%s %s", n.id, name)
+	s.spawn(func() {
+		task(context.Background())
+		s.mu.Lock()
+		s.busy[key] = false
+		s.mu.Unlock()
+	})
+}
+
+// clientOp: 살아 있는 노드 하나를 코디네이터로 골라 작업 하나 실행
+//   - put: 읽지 않고 씀 (동시에 쓴 다른 값과 형제가 됨)
+//   - update, delete: 읽어서 받은 문맥으로 덮어씀
+//   - get: 읽기만 (읽기 복구가 일어날 수 있음)
+func (s *simulation) clientOp(op int) {
+	var alive []*Node
+	for _, n := range s.nodes {
+		if !s.crashed[n.id] {
+			alive = append(alive, n)
+		}
+	}
+	if len(alive) == 0 {
+		s.logf("op %d skipped: every node is down", op)
+		return
+	}
+	coord := alive[s.rng.IntN(len(alive))]
+	key := fmt.Sprintf("k%d", s.rng.IntN(s.opts.Keys))
+	kind := []string{"put", "update", "update", "delete", "get"}[s.rng.IntN(5)]
+	level := []Consistency{Default, One, Quorum, All}[s.rng.IntN(4)]
+	s.logf("op %d %s %s via %s (%s)", op, kind, key, coord.id, level)
+
+	gen := s.gen[coord.id]
+	s.spawn(func() {
+		ctx := context.Background()
+		opts := WriteOptions{Consistency: level}
+		if kind != "put" {
+			res, err := coord.Get(ctx, key, ReadOptions{Consistency: level})
+			if err != nil && !errors.Is(err, ErrNotFound) || kind == "get" {
+				s.finish(simOpResult{op: op, coord: coord.id, gen: gen, err: err})
+				return
+			}
+			opts.Context = res.Context
+		}
+		v := store.Value{Data: fmt.Appendf(nil, "%s@%d", key, op)}
+		if kind == "delete" {
+			v = store.Value{Deleted: true}
+		}
+		stamped, err := coord.write(ctx, key, v, opts)
+		s.finish(simOpResult{op: op, coord: coord.id, gen: gen, write: &simWrite{op: op, key: key, value: stamped, acked: err == nil}, err: err})
+	})
+}
+
+func (s *simulation) finish(r simOpResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = append(s.results, r)
+}
+
+// fault: 장애 상태를 바꿈 (모두 복구 / 둘로 분할 / 노드 하나 크래시, 동시에 최대 N-1 대)
+func (s *simulation) fault() {
+	switch r := s.rng.Float64(); {
+	case r < 0.35:
+		s.clearFaults()
+	case r < 0.65:
+		ids := s.r.Servers()
+		s.rng.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+		cut := 1 + s.rng.IntN(len(ids)-1)
+		for i, id := range ids {
+			s.group[id] = min(i/cut, 1)
+		}
+		minority := slices.Clone(ids[:cut])
+		slices.Sort(minority)
+		s.logf("partition %v from the rest", minority)
+	default:
+		var alive []string
+		for _, id := range s.r.Servers() {
+			if !s.crashed[id] {
+				alive = append(alive, id)
+			}
+		}
+		if len(s.r.Servers())-len(alive) >= s.opts.Config.N-1 {
+			return
+		}
+		id := alive[s.rng.IntN(len(alive))]
+		s.crashed[id] = true
+		s.logf("crash %s", id)
+	}
+}
+
+func (s *simulation) clearFaults() {
+	for _, id := range slices.Sorted(maps.Keys(s.crashed)) {
+		s.restart(id)
+	}
+	clear(s.crashed)
+	clear(s.group)
+	s.logf("heal all partitions and restart crashed nodes")
+}
+
+// heal: 장애와 메시지 유실/중복을 없애고, 남은 작업이 끝난 뒤 모든 노드가 힌트를 전달하고 안티 엔트로피를 돌림
+func (s *simulation) heal() {
+	s.agenda = nil
+	s.clearFaults()
+	s.drop, s.dup = 0, 0
+	s.run(time.Minute, s.idle)
+	// 실패로 기록된 노드를 다시 시도하도록 장애 기록이 풀릴 때까지 기다림
+	s.run(downBackoff, nil)
+
+	s.logf("repair")
+	s.spawn(func() {
+		ctx := context.Background()
+		for range 2 {
+			for _, n := range s.nodes {
+				n.deliverHints(ctx)
+			}
+			for _, n := range s.nodes {
+				n.antiEntropyRound(ctx)
+			}
+		}
+	})
+	s.run(time.Hour, s.idle)
+	s.run(3*s.opts.Config.Timeout, nil) // 제한 시간이 걸린 고루틴(늦은 읽기 복구 등)이 모두 끝나도록
+}
+
+// check: 불변식 확인
+func (s *simulation) check() []string {
+	var violations []string
+	for i := range s.opts.Keys {
+		key := fmt.Sprintf("k%d", i)
+		owners := s.r.PreferenceList(key, s.opts.Config.N)
+		states := make(map[string][]store.Value, len(owners))
+		for _, owner := range owners {
+			states[owner], _ = s.byID[owner].store.Get(key)
+		}
+
+		for _, owner := range owners[1:] {
+			if !bytes.Equal(encodeSiblings(states[owner]), encodeSiblings(states[owners[0]])) {
+				violations = append(violations, fmt.Sprintf("%s diverged: %s has %s, %s has %s",
+					key, owners[0], describeAll(states[owners[0]]), owner, describeAll(states[owner])))
+			}
+		}
+		for _, w := range s.writes {
+			if w.key != key || !w.acked {
+				continue
+			}
+			for _, owner := range owners {
+				if !covers(states[owner], w.value) {
+					violations = append(violations, fmt.Sprintf("%s lost acked write op %d (%s) on %s, which has %s",
+						key, w.op, describe(w.value), owner, describeAll(states[owner])))
+				}
+			}
+		}
+	}
+	for _, n := range s.nodes {
+		if pending := n.hints.snapshot().Pending; pending > 0 {
+			violations = append(violations, fmt.Sprintf("%s still holds %d undelivered hints", n.id, pending))
+		}
+	}
+	return violations
+}
+
+func (s *simulation) ackedWrites() int {
+	acked := 0
+	for _, w := range s.writes {
+		if w.acked {
+			acked++
+		}
+	}
+	return acked
+}
+
+// covers: 형제 값 중 v 자체나 v 를 덮어쓴 이후 버전이 있는지
+func covers(siblings []store.Value, v store.Value) bool {
+	return slices.ContainsFunc(siblings, func(s store.Value) bool {
+		switch vclock.Compare(s.Clock, v.Clock) {
+		case vclock.After:
+			return true
+		case vclock.Equal:
+			return s.Timestamp == v.Timestamp && s.Deleted == v.Deleted && bytes.Equal(s.Data, v.Data)
+		}
+		return false
+	})
+}
+
+func describe(v store.Value) string {
+	data := string(v.Data)
+	if v.Deleted {
+		data = "<deleted>"
+	}
+	return data + " " + v.Clock.Encode()
+}
+
+func describeAll(siblings []store.Value) string {
+	out := make([]string, len(siblings))
+	for i, v := range siblings {
+		out[i] = describe(v)
+	}
+	return "[" + strings.Join(out, ", ") + "]"
+}
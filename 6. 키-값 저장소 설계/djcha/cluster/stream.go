@@ -0,0 +1,352 @@
+package cluster
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"kv-store/ring"
+	"kv-store/store"
+)
+
+// StreamConfig: 노드가 들어오거나 나갈 때 키 범위를 옮기는 설정
+type StreamConfig struct {
+	PageKeys       int           // 한 번에 주고받는 키 개수
+	BytesPerSecond int           // 전송 속도 상한 (0 이면 제한 없음) → 옮기는 동안에도 클라이언트 요청을 처리할 여유를 남김
+	Retries        int           // 한 노드와의 전송이 실패했을 때 같은 위치부터 다시 시도하는 횟수
+	RetryDelay     time.Duration // 다시 시도하기 전 대기 시간
+	// Progress: 페이지를 하나 옮길 때마다 진행 상황을 알림 (nil 이면 Metrics/Transfers 로만 확인)
+	Progress func(TransferStatus)
+}
+
+// DefaultStreamConfig: 500 개씩, 속도 제한 없이, 실패하면 1초 뒤 3번까지 다시 시도
+var DefaultStreamConfig = StreamConfig{
+	PageKeys:   500,
+	Retries:    3,
+	RetryDelay: time.Second,
+}
+
+// StreamRequest: 들어오는 노드 Target 이 맡게 될 키를 달라는 요청 (내부 API 본문)
+// 같은 키를 옛 주인 N 대가 모두 보내지 않도록, 옛 선호 목록의 첫 노드가 Source 인 키만 받음
+// Source 가 죽어 있으면 다른 옛 주인에게 같은 Source 로 요청해서 Source 몫을 대신 받음
+type StreamRequest struct {
+	Target string `json:"target"`
+	Source string `json:"source"`
+	From   uint32 `json:"from"` // 이 해시 값부터 (Scan 커서와 같은 방식)
+	Limit  int    `json:"limit"`
+}
+
+// StreamEntry: 옮기는 키 하나 (삭제 표시도 옮겨야 지운 키가 새 주인에게서 되살아나지 않음)
+type StreamEntry struct {
+	Key      string        `json:"key"`
+	Siblings []store.Value `json:"siblings"`
+}
+
+// StreamPage: 한 번에 옮기는 키들
+type StreamPage struct {
+	Entries []StreamEntry `json:"entries"`
+	Next    uint32        `json:"next"` // 다음 요청의 From
+	Done    bool          `json:"done"` // 더 옮길 키가 없음
+}
+
+// TransferStatus: 키 범위 이동 하나의 진행 상황
+type TransferStatus struct {
+	Kind    string    `json:"kind"`   // "join" (받음) / "decommission" (보냄)
+	Peer    string    `json:"peer"`   // 받을 때는 옛 주인, 보낼 때는 새 주인
+	Keys    int       `json:"keys"`   // 옮긴 키 수
+	Bytes   int       `json:"bytes"`  // 옮긴 데이터 크기
+	Cursor  uint32    `json:"cursor"` // 다음에 옮길 해시 위치 (중단되면 여기서 이어감)
+	Done    bool      `json:"done"`
+	Error   string    `json:"error,omitempty"` // 마지막 실패 원인
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+}
+
+// transfers: 진행 중이거나 끝난 이동 (Join/Decommission 을 다시 부르면 여기 남은 커서부터 이어감)
+type transfers struct {
+	mu   sync.Mutex
+	byID map[string]*TransferStatus
+}
+
+// get: kind/peer 이동 상태 (없으면 새로 만듦)
+func (t *transfers) get(kind, peer string, now time.Time) TransferStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := kind + "/" + peer
+	st, ok := t.byID[id]
+	if !ok {
+		st = &TransferStatus{Kind: kind, Peer: peer, Started: now, Updated: now}
+		t.byID[id] = st
+	}
+	return *st
+}
+
+func (t *transfers) set(st TransferStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.byID[st.Kind+"/"+st.Peer] = &st
+}
+
+// Transfers: 키 범위 이동 진행 상황 (종류, 상대 노드 순)
+func (n *Node) Transfers() []TransferStatus {
+	n.transfers.mu.Lock()
+	defer n.transfers.mu.Unlock()
+
+	out := make([]TransferStatus, 0, len(n.transfers.byID))
+	for _, st := range n.transfers.byID {
+		out = append(out, *st)
+	}
+	slices.SortFunc(out, func(a, b TransferStatus) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Peer, b.Peer))
+	})
+	return out
+}
+
+// Join: 이 노드를 클러스터에 넣음
+//
+//  1. 다른 노드들에 "들어오는 중" 을 알림 → 이때부터 쓰기는 받지만 읽기는 옛 주인들이 처리
+//  2. 이 노드가 맡게 될 키 범위를 옛 주인들에게서 받아 저장 (옛 주인마다 자기가 첫 주인인 키만 보냄)
+//  3. 다 받으면 "normal" 을 알림 → 읽기도 받음
+//
+// 중간에 실패하거나 ctx 가 끝나면 에러를 돌려주고, 다시 부르면 받다 만 위치부터 이어서 받음
+func (n *Node) Join(ctx context.Context) error {
+	if err := n.announce(ctx, n.id, MemberJoining); err != nil {
+		return err
+	}
+
+	var sources []string
+	for _, node := range n.ring.Servers() {
+		if node != n.id {
+			sources = append(sources, node)
+		}
+	}
+	for _, source := range sources {
+		if err := n.pullFrom(ctx, source, sources); err != nil {
+			return err
+		}
+	}
+	return n.announce(ctx, n.id, MemberNormal)
+}
+
+// pullFrom: source 가 첫 주인인 키를 받음
+// source 에서 Retries 번 다시 시도해도 실패하면 그 키들의 다른 옛 주인들에게서 같은 위치부터 받음
+// 다른 노드는 source 몫 중 자기도 가진 키만 보내므로 응답한 노드 모두에게서 받아 합침
+func (n *Node) pullFrom(ctx context.Context, source string, peers []string) error {
+	st := n.transfers.get("join", source, n.now())
+	if st.Done {
+		return nil
+	}
+	err := n.transfer(ctx, &st, n.pull(source, source))
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	start, pulled := st, 0
+	for _, peer := range peers {
+		if peer == source {
+			continue
+		}
+		// 대신 받는 진행 상황은 따로 기록 (중간에 실패해도 source 몫의 커서는 그대로)
+		pass := start
+		pass.Peer, pass.Done = peer+" for "+source, false
+		if n.transfer(ctx, &pass, n.pull(peer, source)) != nil {
+			continue
+		}
+		pulled++
+		st.Keys += pass.Keys - start.Keys
+		st.Bytes += pass.Bytes - start.Bytes
+	}
+	if pulled == 0 {
+		return err
+	}
+	st.Done, st.Error, st.Updated = true, "", n.now()
+	n.report(st)
+	return nil
+}
+
+// pull: peer 에게서 source 몫의 키 한 페이지를 받아 저장하는 함수
+func (n *Node) pull(peer, source string) func(context.Context, uint32) (StreamPage, error) {
+	return func(ctx context.Context, from uint32) (StreamPage, error) {
+		page, err := n.transport.Stream(ctx, peer, StreamRequest{Target: n.id, Source: source, From: from, Limit: n.cfg.Stream.PageKeys})
+		if err != nil {
+			return StreamPage{}, err
+		}
+		for _, e := range page.Entries {
+			for _, v := range e.Siblings {
+				n.store.Put(e.Key, v)
+			}
+		}
+		return page, nil
+	}
+}
+
+// Decommission: 이 노드를 클러스터에서 뺌
+//
+//  1. 다른 노드들에 "나가는 중" 을 알림 → 이때부터 쓰기는 나간 뒤의 주인에게도 같이 감
+//  2. 맡아 둔 힌트를 주인에게 전달
+//  3. 이 노드가 빠지면 새로 주인이 되는 노드마다 그 노드 몫의 키를 보냄
+//  4. "removed" 를 알림 → 모든 노드의 링에서 빠짐 (이후 프로세스를 내려도 됨)
+//
+// 중간에 실패하거나 ctx 가 끝나면 에러를 돌려주고, 다시 부르면 보내다 만 위치부터 이어서 보냄
+func (n *Node) Decommission(ctx context.Context) error {
+	if err := n.announce(ctx, n.id, MemberLeaving); err != nil {
+		return err
+	}
+	if n.cfg.Hints.Enabled {
+		n.deliverHints(ctx)
+	}
+
+	after := n.ring.Clone()
+	after.Remove(n.id)
+	for _, target := range after.Servers() {
+		st := n.transfers.get("decommission", target, n.now())
+		if st.Done {
+			continue
+		}
+		err := n.transfer(ctx, &st, func(ctx context.Context, from uint32) (StreamPage, error) {
+			page := n.streamPage(n.ring, after, target, "", from, n.cfg.Stream.PageKeys)
+			for _, e := range page.Entries {
+				for _, v := range e.Siblings {
+					if err := n.transport.Put(ctx, target, e.Key, v); err != nil {
+						return StreamPage{}, err
+					}
+				}
+			}
+			return page, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return n.announce(ctx, n.id, MemberRemoved)
+}
+
+// transfer: 페이지를 하나씩 옮기며 st 의 커서와 진행 상황을 갱신
+// 페이지를 다 옮긴 뒤에만 커서를 넘기므로, 실패하면 그 페이지부터 다시 옮김 (값 합치기는 멱등이라 중복돼도 됨)
+func (n *Node) transfer(ctx context.Context, st *TransferStatus, move func(context.Context, uint32) (StreamPage, error)) error {
+	cfg := n.cfg.Stream
+	limiter := newRateLimiter(cfg.BytesPerSecond, n.now())
+	failures := 0
+	for !st.Done {
+		callCtx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+		page, err := move(callCtx, st.Cursor)
+		cancel()
+		st.Updated = n.now()
+		if err != nil {
+			st.Error = err.Error()
+			n.report(*st)
+			if failures++; failures > cfg.Retries || ctx.Err() != nil {
+				return fmt.Errorf("cluster: %s transfer with %s stopped at %d: %w", st.Kind, st.Peer, st.Cursor, err)
+			}
+			if err := sleep(ctx, cfg.RetryDelay); err != nil {
+				return err
+			}
+			continue
+		}
+
+		failures = 0
+		size := 0
+		for _, e := range page.Entries {
+			size += len(e.Key) + len(encodeSiblings(e.Siblings))
+		}
+		st.Keys += len(page.Entries)
+		st.Bytes += size
+		st.Cursor, st.Done, st.Error = page.Next, page.Done, ""
+		n.report(*st)
+
+		if err := sleep(ctx, limiter.wait(size, n.now())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// report: 진행 상황 저장 + Progress 로 알림
+func (n *Node) report(st TransferStatus) {
+	n.transfers.set(st)
+	if n.cfg.Stream.Progress != nil {
+		n.cfg.Stream.Progress(st)
+	}
+}
+
+// streamPage: 로컬 키 중 해시가 from 이상이고, 링이 before 에서 after 로 바뀌면 target 이 새로 맡게 되는 키
+// source 를 주면 before 선호 목록의 첫 노드가 source 인 키만 (옛 주인 여럿이 같은 키를 보내지 않도록)
+func (n *Node) streamPage(before, after *ring.Ring, target, source string, from uint32, limit int) StreamPage {
+	var keys []string
+	for _, key := range n.store.Keys() {
+		if ring.Hash(key) < from {
+			continue
+		}
+		prev := before.PreferenceList(key, n.cfg.N)
+		if !slices.Contains(after.PreferenceList(key, n.cfg.N), target) || slices.Contains(prev, target) {
+			continue
+		}
+		if source != "" && (len(prev) == 0 || prev[0] != source) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	keys = pageByHash(keys, limit)
+
+	page := StreamPage{Entries: make([]StreamEntry, 0, len(keys)), Done: len(keys) < limit}
+	for _, key := range keys {
+		if siblings, ok := n.store.Get(key); ok {
+			page.Entries = append(page.Entries, StreamEntry{Key: key, Siblings: siblings})
+		}
+	}
+	if !page.Done {
+		next := uint64(ring.Hash(keys[len(keys)-1])) + 1
+		page.Next, page.Done = uint32(next), next > uint64(^uint32(0))
+	}
+	return page
+}
+
+// localStream: 들어오는 노드가 보낸 StreamRequest 처리
+// 이 노드의 링에는 Target 이 이미 들어 있으므로 Target 을 뺀 링을 옛 링으로 씀
+func (n *Node) localStream(req StreamRequest) StreamPage {
+	after := n.ring.Clone()
+	after.Add(req.Target)
+	before := after.Clone()
+	before.Remove(req.Target)
+	return n.streamPage(before, after, req.Target, req.Source, req.From, req.Limit)
+}
+
+// rateLimiter: 보낸 양이 시작 후 지난 시간 × 속도 상한을 넘지 않도록 기다릴 시간 계산
+type rateLimiter struct {
+	rate  int
+	start time.Time
+	sent  int
+}
+
+func newRateLimiter(bytesPerSecond int, now time.Time) *rateLimiter {
+	return &rateLimiter{rate: bytesPerSecond, start: now}
+}
+
+// wait: size 만큼 더 보낸 뒤 기다려야 하는 시간 (속도 제한이 없으면 0)
+func (l *rateLimiter) wait(size int, now time.Time) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	l.sent += size
+	due := l.start.Add(time.Duration(float64(l.sent) / float64(l.rate) * float64(time.Second)))
+	return max(due.Sub(now), 0)
+}
+
+// sleep: d 만큼 기다림 (ctx 가 먼저 끝나면 ctx 에러)
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,134 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"kv-store/ring"
+)
+
+// fill: 키 count 개를 모든 복제본에 씀
+func fill(t *testing.T, n *Node, count int) []string {
+	t.Helper()
+	var keys []string
+	for i := range count {
+		key := fmt.Sprintf("user:%d", i)
+		if err := n.Put(context.Background(), key, []byte(key), WriteOptions{Consistency: All}); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// checkOwners: 모든 키가 링의 선호 목록 노드 N 대에 있는지
+func checkOwners(t *testing.T, tc *testCluster, r *ring.Ring, keys []string) {
+	t.Helper()
+	for _, key := range keys {
+		for _, owner := range r.PreferenceList(key, testConfig.N) {
+			if _, ok := tc.node(owner).Store().Get(key); !ok {
+				t.Fatalf("owner %s is missing %s", owner, key)
+			}
+		}
+	}
+}
+
+func TestJoinStreamsOwnedRanges(t *testing.T) {
+	cfg := testConfig
+	cfg.Stream = StreamConfig{PageKeys: 16}
+	tc := startCluster(t, 4, cfg)
+	keys := fill(t, tc.nodes[0], 200)
+
+	var progress []TransferStatus
+	joinCfg := cfg
+	joinCfg.Stream.Progress = func(st TransferStatus) { progress = append(progress, st) }
+	joiner := tc.serve(t, httptest.NewUnstartedServer(nil), joinCfg, tc.nodes[0].ring.Clone())
+
+	if err := joiner.Join(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range tc.nodes {
+		if !slices.Contains(n.ring.Servers(), joiner.ID()) || n.Metrics().Members != nil {
+			t.Fatalf("%s does not see %s as a normal member", n.ID(), joiner.ID())
+		}
+	}
+	checkOwners(t, tc, joiner.ring, keys)
+
+	// 옛 주인마다 자기가 첫 주인인 키만 보내므로 같은 키를 두 번 받지 않음
+	moved := 0
+	for _, st := range joiner.Transfers() {
+		if !st.Done || st.Kind != "join" {
+			t.Fatalf("transfer %+v, want finished join", st)
+		}
+		moved += st.Keys
+	}
+	owned := 0
+	for _, key := range keys {
+		if slices.Contains(joiner.ring.PreferenceList(key, cfg.N), joiner.ID()) {
+			owned++
+		}
+	}
+	if moved != owned || joiner.Store().Len() != owned {
+		t.Fatalf("moved %d keys, stored %d, want %d", moved, joiner.Store().Len(), owned)
+	}
+	if len(progress) < owned/cfg.Stream.PageKeys {
+		t.Fatalf("got %d progress reports for %d keys in pages of %d", len(progress), owned, cfg.Stream.PageKeys)
+	}
+}
+
+func TestDecommissionResumesAfterTargetFailure(t *testing.T) {
+	cfg := testConfig
+	cfg.Stream = StreamConfig{PageKeys: 8}
+	tc := startCluster(t, 5, cfg)
+	keys := fill(t, tc.nodes[0], 200)
+
+	// 나가는 노드가 빠지면 새로 주인이 되는 키 개수 (노드별)
+	leaving := tc.nodes[4]
+	after := leaving.ring.Clone()
+	after.Remove(leaving.ID())
+	gained := make(map[string]int)
+	for _, key := range keys {
+		for _, owner := range after.PreferenceList(key, cfg.N) {
+			if !slices.Contains(leaving.ring.PreferenceList(key, cfg.N), owner) {
+				gained[owner]++
+			}
+		}
+	}
+
+	// 첫 페이지를 보낸 직후 받는 노드가 멈춤 → 다시 시도하지 않으므로 Decommission 실패
+	var target string
+	leavingCfg := cfg
+	leavingCfg.Stream.Progress = func(st TransferStatus) {
+		if target == "" && st.Keys > 0 && gained[st.Peer] > cfg.Stream.PageKeys {
+			target = st.Peer
+			tc.stop(target)
+		}
+	}
+	leaving.cfg = leavingCfg
+	ctx := context.Background()
+	if err := leaving.Decommission(ctx); err == nil {
+		t.Fatal("decommission succeeded while a new owner was down")
+	}
+	if leaving.members.state(leaving.ID()) != MemberLeaving || !slices.Contains(tc.nodes[0].ring.Servers(), leaving.ID()) {
+		t.Fatal("failed decommission should leave the node in the ring as leaving")
+	}
+
+	tc.restart(target)
+	if err := leaving.Decommission(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range leaving.Transfers() {
+		// 멈춘 곳부터 이어서 보냈으면 보낸 키 수가 정확히 새로 맡은 키 수와 같음
+		if !st.Done || st.Keys != gained[st.Peer] {
+			t.Fatalf("transfer %+v, want done with %d keys", st, gained[st.Peer])
+		}
+	}
+	if slices.Contains(tc.nodes[0].ring.Servers(), leaving.ID()) {
+		t.Fatal("decommissioned node is still in the ring")
+	}
+	tc.nodes = tc.nodes[:4]
+	checkOwners(t, tc, tc.nodes[0].ring, keys)
+}
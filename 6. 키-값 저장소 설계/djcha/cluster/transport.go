@@ -0,0 +1,328 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"kv-store/merkle"
+	"kv-store/store"
+)
+
+// Transport: 코디네이터가 다른 복제본 노드에게 요청을 보내는 통로
+// 노드는 주소(host:port) 문자열로 구분
+type Transport interface {
+	Put(ctx context.Context, node, key string, v store.Value) error
+	// PutHint: 죽은 복제본 owner 대신 node 에 값을 맡김
+	PutHint(ctx context.Context, node, owner, key string, v store.Value) error
+	Get(ctx context.Context, node, key string) ([]store.Value, bool, error)
+	// GetAt: node 가 at 시각에 갖고 있던 형제 값 (이미 정리된 시각이면 store.ErrCompacted)
+	GetAt(ctx context.Context, node, key string, at time.Time) ([]store.Value, bool, error)
+	// Keys: node 의 로컬 키 중 해시가 from 이상인 것을 해시 순서로 최대 limit 개
+	Keys(ctx context.Context, node string, from uint32, limit int) ([]string, error)
+	// Range: node 의 로컬 스냅샷에서 범위 조회 (스냅샷이 닫혔으면 ErrTokenExpired)
+	Range(ctx context.Context, node string, req RangeRequest) ([]RangeEntry, error)
+	// Lock: node 가 맡은 key 잠금을 token 으로 lease 동안 잡음 (다른 토큰이 잡고 있으면 ErrLocked)
+	Lock(ctx context.Context, node, key, token string, lease time.Duration) error
+	// Unlock: token 으로 잡은 key 잠금 해제
+	Unlock(ctx context.Context, node, key, token string) error
+	// MerkleBuckets: node 가 peer 와 같이 맡은 키로 만든 머클 트리의 버킷 해시 목록 (node 가 트리를 만드는 중이면 ErrTreeRebuilding)
+	MerkleBuckets(ctx context.Context, node, peer string, buckets int) ([]merkle.Digest, error)
+	// MerkleEntries: node 가 peer 와 같이 맡은 키 중 want 버킷에 속하는 키의 형제 값
+	MerkleEntries(ctx context.Context, node, peer string, buckets int, want []int) (map[string][]store.Value, error)
+	// Update: 복제본 node 에 CRDT 연산을 맡김 (node 가 자기 상태에 적용하고 다른 복제본에 씀)
+	Update(ctx context.Context, node, key string, req UpdateRequest) (store.Value, error)
+	// Membership: node 에 member 의 상태(들어오는 중, 나가는 중 등)를 알림
+	Membership(ctx context.Context, node, member string, state MemberState) error
+	// Stream: 들어오는 노드가 맡게 될 키를 node 에게서 한 페이지 받음
+	Stream(ctx context.Context, node string, req StreamRequest) (StreamPage, error)
+	// Watch: node 의 로컬 저장소에서 req.From 다음 변경을 롱 폴링으로 받음 (이미 정리된 순번이면 store.ErrCompacted)
+	Watch(ctx context.Context, node string, req WatchRequest) (WatchResponse, error)
+	// Heartbeat: node 에 "from 이 살아 있음" 을 알림
+	Heartbeat(ctx context.Context, node, from string) error
+}
+
+// HTTPTransport: 노드끼리 HTTP/JSON 으로 통신 (별도 프로세스 / 같은 프로세스의 루프백 모두 사용 가능)
+type HTTPTransport struct {
+	Client *http.Client
+}
+
+// NewHTTPTransport: 기본 HTTP 클라이언트를 쓰는 전송 계층 생성
+// 요청별 제한 시간은 코디네이터가 context 로 걸어 줌
+func NewHTTPTransport() *HTTPTransport {
+	return &HTTPTransport{Client: &http.Client{}}
+}
+
+func internalURL(node, key string) string {
+	return "http://" + node + "/internal/kv/" + url.PathEscape(key)
+}
+
+func (t *HTTPTransport) Put(ctx context.Context, node, key string, v store.Value) error {
+	return t.put(ctx, internalURL(node, key), v)
+}
+
+func (t *HTTPTransport) PutHint(ctx context.Context, node, owner, key string, v store.Value) error {
+	err := t.put(ctx, "http://"+node+"/internal/hints/"+url.PathEscape(owner)+"/"+url.PathEscape(key), v)
+	if errors.Is(err, errInsufficientStorage) {
+		return ErrHintStoreFull
+	}
+	return err
+}
+
+// 힌트 저장 공간이 가득 찼다는 응답 (507 Insufficient Storage)
+var errInsufficientStorage = errors.New("insufficient storage")
+
+func (t *HTTPTransport) put(ctx context.Context, target string, v store.Value) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusInsufficientStorage:
+		return errInsufficientStorage
+	}
+	return fmt.Errorf("put %s: unexpected status %d", target, resp.StatusCode)
+}
+
+func (t *HTTPTransport) Get(ctx context.Context, node, key string) ([]store.Value, bool, error) {
+	return t.get(ctx, internalURL(node, key), node, key)
+}
+
+func (t *HTTPTransport) GetAt(ctx context.Context, node, key string, at time.Time) ([]store.Value, bool, error) {
+	return t.get(ctx, fmt.Sprintf("%s?as_of=%d", internalURL(node, key), at.UnixNano()), node, key)
+}
+
+func (t *HTTPTransport) get(ctx context.Context, target, node, key string) ([]store.Value, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var siblings []store.Value
+		if err := json.NewDecoder(resp.Body).Decode(&siblings); err != nil {
+			return nil, false, fmt.Errorf("get %s on %s: %w", key, node, err)
+		}
+		return siblings, true, nil
+	case http.StatusNotFound:
+		return nil, false, nil
+	case http.StatusGone:
+		return nil, false, store.ErrCompacted
+	}
+	return nil, false, fmt.Errorf("get %s on %s: unexpected status %d", key, node, resp.StatusCode)
+}
+
+func (t *HTTPTransport) Keys(ctx context.Context, node string, from uint32, limit int) ([]string, error) {
+	target := fmt.Sprintf("http://%s/internal/keys?from=%d&limit=%d", node, from, limit)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("keys on %s: unexpected status %d", node, resp.StatusCode)
+	}
+	var keys []string
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return nil, fmt.Errorf("keys on %s: %w", node, err)
+	}
+	return keys, nil
+}
+
+func lockURL(node, key, token string) string {
+	return "http://" + node + "/internal/locks/" + url.PathEscape(key) + "?token=" + url.QueryEscape(token)
+}
+
+func (t *HTTPTransport) Lock(ctx context.Context, node, key, token string, lease time.Duration) error {
+	target := lockURL(node, key, token) + "&lease=" + lease.String()
+	return t.lockRequest(ctx, http.MethodPut, target)
+}
+
+func (t *HTTPTransport) Unlock(ctx context.Context, node, key, token string) error {
+	return t.lockRequest(ctx, http.MethodDelete, lockURL(node, key, token))
+}
+
+func (t *HTTPTransport) lockRequest(ctx context.Context, method, target string) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusConflict:
+		return ErrLocked
+	}
+	return fmt.Errorf("%s %s: unexpected status %d", method, target, resp.StatusCode)
+}
+
+func (t *HTTPTransport) Range(ctx context.Context, node string, req RangeRequest) ([]RangeEntry, error) {
+	var entries []RangeEntry
+	err := t.doJSON(ctx, http.MethodPost, "http://"+node+"/internal/range", req, &entries)
+	return entries, err
+}
+
+func merkleURL(node, peer string, buckets int) string {
+	return fmt.Sprintf("http://%s/internal/merkle/%s?buckets=%d", node, url.PathEscape(peer), buckets)
+}
+
+// MerkleBuckets: 상대가 트리를 만드는 중이면(503) ErrTreeRebuilding
+func (t *HTTPTransport) MerkleBuckets(ctx context.Context, node, peer string, buckets int) ([]merkle.Digest, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, merkleURL(node, peer, buckets), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var digests []merkle.Digest
+		err := json.NewDecoder(resp.Body).Decode(&digests)
+		return digests, err
+	case http.StatusServiceUnavailable:
+		return nil, ErrTreeRebuilding
+	}
+	return nil, fmt.Errorf("GET %s: unexpected status %d", req.URL, resp.StatusCode)
+}
+
+func (t *HTTPTransport) MerkleEntries(ctx context.Context, node, peer string, buckets int, want []int) (map[string][]store.Value, error) {
+	var entries map[string][]store.Value
+	err := t.doJSON(ctx, http.MethodPost, merkleURL(node, peer, buckets), want, &entries)
+	return entries, err
+}
+
+func (t *HTTPTransport) Update(ctx context.Context, node, key string, req UpdateRequest) (store.Value, error) {
+	var v store.Value
+	err := t.doJSON(ctx, http.MethodPost, "http://"+node+"/internal/crdt/"+url.PathEscape(key), req, &v)
+	return v, err
+}
+
+func (t *HTTPTransport) Membership(ctx context.Context, node, member string, state MemberState) error {
+	target := "http://" + node + "/internal/members/" + url.PathEscape(member) + "?state=" + url.QueryEscape(string(state))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("membership on %s: unexpected status %d", node, resp.StatusCode)
+	}
+	return nil
+}
+
+func (t *HTTPTransport) Stream(ctx context.Context, node string, req StreamRequest) (StreamPage, error) {
+	var page StreamPage
+	err := t.doJSON(ctx, http.MethodPost, "http://"+node+"/internal/stream", req, &page)
+	return page, err
+}
+
+func (t *HTTPTransport) Watch(ctx context.Context, node string, req WatchRequest) (WatchResponse, error) {
+	var resp WatchResponse
+	err := t.doJSON(ctx, http.MethodPost, "http://"+node+"/internal/watch", req, &resp)
+	return resp, err
+}
+
+// doJSON: JSON 본문을 보내고 200 응답의 JSON 본문을 out 에 읽음
+func (t *HTTPTransport) doJSON(ctx context.Context, method, target string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(out)
+	case http.StatusGone:
+		return ErrTokenExpired
+	case http.StatusUnprocessableEntity:
+		return store.ErrCompacted
+	case http.StatusConflict:
+		return ErrWrongType
+	}
+	return fmt.Errorf("%s %s: unexpected status %d", method, target, resp.StatusCode)
+}
+
+func (t *HTTPTransport) Heartbeat(ctx context.Context, node, from string) error {
+	target := "http://" + node + "/internal/heartbeat/" + url.PathEscape(from)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("heartbeat to %s: unexpected status %d", node, resp.StatusCode)
+	}
+	return nil
+}
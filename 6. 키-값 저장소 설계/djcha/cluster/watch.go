@@ -0,0 +1,353 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"kv-store/store"
+)
+
+// ErrInvalidRevision: 변경 감시 리비전을 해석할 수 없음
+var ErrInvalidRevision = errors.New("cluster: invalid watch revision")
+
+// 변경 감시 설정
+//   - MaxWatchWait: 노드 하나에 보내는 롱 폴링 한 번의 최대 대기 시간
+//   - watchPageEvents: 노드가 한 번에 돌려주는 최대 이벤트 수
+//   - watchLeaseTTL: 다음 폴링이 없을 때 노드가 감시 위치를 붙잡아 두는 시간
+//     (이 시간 안에 다시 물으면 Config.History 가 0 이어도 그 위치부터 이어 볼 수 있음)
+//   - watchRetryDelay: 응답하지 않는 노드에 다시 묻기 전 기다리는 시간
+const (
+	MaxWatchWait    = 30 * time.Second
+	watchPageEvents = 1000
+	watchLeaseTTL   = time.Minute
+	watchRetryDelay = 200 * time.Millisecond
+)
+
+// WatchOptions: 변경 감시 옵션 (Key, Prefix 중 하나만. 둘 다 비우면 모든 키)
+type WatchOptions struct {
+	Key    string
+	Prefix string
+	// Revision: 이전 WatchBatch.Revision. 주면 그 다음 변경부터 보고, 다른 옵션은 리비전에 담긴 것을 그대로 씀
+	Revision string
+}
+
+// WatchEvent: 키 하나의 변경 (삭제면 Result 가 비어 있음)
+type WatchEvent struct {
+	Key  string
+	Type store.EventType
+	Result
+}
+
+// WatchBatch: 한 번에 받은 변경과, 그 다음부터 이어 볼 리비전
+type WatchBatch struct {
+	Events   []WatchEvent
+	Revision string
+}
+
+// WatchRequest: 코디네이터가 각 노드에 보내는 롱 폴링 요청
+type WatchRequest struct {
+	ID     string `json:"id"` // 감시 이름 (노드가 위치를 붙잡아 두는 lease 의 이름)
+	Key    string `json:"key,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	From   uint64 `json:"from"`             // 이 순번 다음부터
+	Latest bool   `json:"latest,omitempty"` // From 대신 노드의 지금 순번 다음부터
+	Wait   int64  `json:"wait_ms"`          // 변경이 없을 때 기다릴 시간
+}
+
+// WatchResponse: 노드 하나의 변경 목록과 다음에 물을 순번
+type WatchResponse struct {
+	Events []store.Event `json:"events"`
+	Rev    uint64        `json:"rev"`
+}
+
+// watchToken: 리비전 (base64url JSON). 노드마다 어디까지 받았는지 기억
+type watchToken struct {
+	ID     string            `json:"id"`
+	Key    string            `json:"key,omitempty"`
+	Prefix string            `json:"prefix,omitempty"`
+	Revs   map[string]uint64 `json:"revs"`
+}
+
+// Watch: 키 하나 또는 접두사 아래 키의 쓰기/삭제를 차례로 받는 감시
+//
+// 모든 노드의 로컬 저장소에 롱 폴링을 걸어 변경을 모음. 저장소에 쓰는 경로는 하나뿐이므로
+// 클라이언트 쓰기뿐 아니라 힌트 전달, 읽기 복구, 안티 엔트로피로 고쳐진 키도 변경으로 보임
+// 같은 쓰기가 복제본마다 한 번씩 오므로, 키마다 지금까지 합친 형제 값이 실제로 바뀐 경우만 내보냄
+//
+// 리비전에는 노드마다 응답을 받은 위치만 담기므로, 이어 보면 아직 응답하지 않았던 복제본의 변경이 다시 올 수 있음
+// (최소 한 번 전달. 키마다 순서는 지켜지므로 마지막으로 받은 상태는 같음)
+// 노드가 그 위치를 이미 정리했으면 (lease 가 지났고 Config.History 도 지남, 또는 재시작) store.ErrCompacted
+type Watch struct {
+	n    *Node
+	mu   sync.Mutex // tok.Revs 를 노드별 폴링 고루틴이 함께 고침
+	tok  watchToken
+	seen map[string][]store.Value // 키 → 지금까지 합친 형제 값
+}
+
+// Watch: 변경 감시를 시작
+// Revision 이 없으면 지금 시점부터 보도록 먼저 모든 노드의 순번을 받아 둠 (응답하지 않은 노드는 첫 폴링 시점부터)
+func (n *Node) Watch(ctx context.Context, opts WatchOptions) (*Watch, error) {
+	w := &Watch{n: n, seen: make(map[string][]store.Value)}
+	if opts.Revision != "" {
+		tok, err := decodeWatchToken(opts.Revision)
+		if err != nil {
+			return nil, err
+		}
+		w.tok = tok
+		return w, nil
+	}
+	if opts.Key != "" && opts.Prefix != "" {
+		return nil, errors.New("cluster: watch either a key or a prefix, not both")
+	}
+	w.tok = watchToken{ID: newLockToken(), Key: opts.Key, Prefix: opts.Prefix, Revs: make(map[string]uint64)}
+
+	servers := n.ring.Servers()
+	replies := n.fanOut(ctx, servers, func(ctx context.Context, node string) reply {
+		req := w.request(node)
+		resp, err := n.replicaWatch(ctx, node, req)
+		if err == nil {
+			w.advance(node, resp.Rev)
+		}
+		return reply{node: node, err: err}
+	})
+	for range replies { // 모든 노드가 응답하거나 제한 시간이 지날 때까지 기다림
+	}
+	return w, nil
+}
+
+// Revision: 지금까지 받은 변경 다음부터 이어 볼 리비전
+func (w *Watch) Revision() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tok.encode()
+}
+
+// Next: 새 변경이 생길 때까지 기다렸다가 모아서 돌려줌
+// ctx 가 끝나면 빈 배치와 ctx 에러 (배치의 Revision 으로 이어 볼 수 있음)
+func (w *Watch) Next(ctx context.Context) (WatchBatch, error) {
+	for {
+		got, err := w.poll(ctx)
+		if err != nil {
+			return WatchBatch{Revision: w.Revision()}, err
+		}
+		var events []WatchEvent
+		for _, e := range got {
+			if ev, ok := w.apply(e); ok {
+				events = append(events, ev)
+			}
+		}
+		if len(events) > 0 {
+			return WatchBatch{Events: events, Revision: w.Revision()}, nil
+		}
+		if ctx.Err() != nil {
+			return WatchBatch{Revision: w.Revision()}, ctx.Err()
+		}
+		// 다른 복제본에서 이미 받은 쓰기만 왔음 → 다시 기다림
+	}
+}
+
+// poll: 모든 노드에 롱 폴링을 걸고, 어느 노드라도 변경을 돌려주면 나머지를 멈추고 그때까지 받은 변경을 모음
+// 응답하지 않는 노드는 watchRetryDelay 뒤 다시 물음 (복구되면 멈췄던 위치부터 이어서 받음)
+func (w *Watch) poll(ctx context.Context) ([]store.Event, error) {
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		events    []store.Event
+		compacted error
+		wg        sync.WaitGroup
+	)
+	ready := make(chan struct{}, 1)
+	signal := func() {
+		select {
+		case ready <- struct{}{}:
+		default:
+		}
+	}
+	for _, node := range w.n.ring.Servers() {
+		wg.Go(func() {
+			for pollCtx.Err() == nil {
+				req := w.request(node)
+				req.Wait = pollWait(pollCtx).Milliseconds()
+				resp, err := w.n.replicaWatch(pollCtx, node, req)
+				switch {
+				case errors.Is(err, store.ErrCompacted):
+					mu.Lock()
+					compacted = fmt.Errorf("watch revision on %s: %w", node, err)
+					mu.Unlock()
+					signal()
+					return
+				case err != nil:
+					_ = sleep(pollCtx, watchRetryDelay)
+					continue
+				}
+				mu.Lock()
+				w.advance(node, resp.Rev)
+				events = append(events, resp.Events...)
+				mu.Unlock()
+				if len(resp.Events) > 0 {
+					signal()
+					return
+				}
+			}
+		})
+	}
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+	}
+	cancel()
+	wg.Wait()
+	return events, compacted
+}
+
+// pollWait: ctx 가 끝나기 전에 응답이 오도록 조금 일찍 끝나는 롱 폴링 대기 시간
+func pollWait(ctx context.Context) time.Duration {
+	wait := MaxWatchWait
+	if deadline, ok := ctx.Deadline(); ok {
+		wait = min(wait, time.Until(deadline)*9/10)
+	}
+	return max(wait, 10*time.Millisecond)
+}
+
+// request: node 에 보낼 다음 폴링 요청 (처음 묻는 노드면 그 노드의 지금 순번부터)
+func (w *Watch) request(node string) WatchRequest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	req := WatchRequest{ID: w.tok.ID, Key: w.tok.Key, Prefix: w.tok.Prefix}
+	rev, ok := w.tok.Revs[node]
+	req.From, req.Latest = rev, !ok
+	return req
+}
+
+func (w *Watch) advance(node string, rev uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tok.Revs[node] = rev
+}
+
+// apply: 노드가 보낸 변경을 키의 합친 상태에 반영하고, 상태가 바뀌었으면 내보낼 이벤트
+func (w *Watch) apply(e store.Event) (WatchEvent, bool) {
+	prev, known := w.seen[e.Key]
+	merged := store.Merge(prev, e.Siblings)
+	if known && bytes.Equal(encodeSiblings(prev), encodeSiblings(merged)) {
+		return WatchEvent{}, false
+	}
+	w.seen[e.Key] = merged
+
+	ev := WatchEvent{Key: e.Key, Type: store.EventDelete}
+	if res, ok := w.n.liveResult(e.Key, merged, w.n.now().UnixNano()); ok {
+		ev.Type, ev.Result = store.EventPut, res
+	}
+	return ev, true
+}
+
+func (t watchToken) encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeWatchToken(s string) (watchToken, error) {
+	var t watchToken
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &t) != nil || t.ID == "" {
+		return watchToken{}, ErrInvalidRevision
+	}
+	if t.Revs == nil {
+		t.Revs = make(map[string]uint64)
+	}
+	return t, nil
+}
+
+func (n *Node) replicaWatch(ctx context.Context, node string, req WatchRequest) (WatchResponse, error) {
+	if node == n.id {
+		return n.localWatch(ctx, req)
+	}
+	return n.transport.Watch(ctx, node, req)
+}
+
+// localWatch: 로컬 저장소에서 From 다음 변경을 받음. 없으면 Wait 동안 기다림
+// 코디네이터가 받았다고 확인한 위치(From)를 watchLeaseTTL 동안 붙잡아 두어, 다음 폴링이 그 사이 정리된 상태 때문에 실패하지 않게 함
+// (이번 응답이 코디네이터에 닿지 않을 수도 있으므로 응답한 위치가 아니라 요청받은 위치를 붙잡음)
+func (n *Node) localWatch(ctx context.Context, req WatchRequest) (WatchResponse, error) {
+	from := req.From
+	if req.Latest {
+		from = n.store.Seq()
+	}
+	match := func(key string) bool { return strings.HasPrefix(key, req.Prefix) }
+	if req.Key != "" {
+		match = func(key string) bool { return key == req.Key }
+	}
+	watcher, err := n.store.Watch(from, match)
+	if err != nil {
+		return WatchResponse{}, err
+	}
+	defer watcher.Close()
+	if pin, err := n.store.SnapshotAt(from); err == nil { // watcher 가 붙잡고 있으므로 실패하지 않음
+		n.watches.keep(req.ID, pin)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, min(time.Duration(req.Wait)*time.Millisecond, MaxWatchWait))
+	defer cancel()
+	events, _ := watcher.Next(waitCtx, watchPageEvents)
+	if events == nil {
+		events = []store.Event{}
+	}
+	return WatchResponse{Events: events, Rev: watcher.Rev()}, nil
+}
+
+// watchLeases: 감시마다 노드가 붙잡아 둔 위치 (이름 → 그 순번의 스냅샷)
+type watchLeases struct {
+	mu   sync.Mutex
+	open map[string]*leasedPosition
+	now  func() time.Time
+}
+
+type leasedPosition struct {
+	pin     *store.Snapshot
+	expires time.Time
+}
+
+func newWatchLeases(now func() time.Time) *watchLeases {
+	return &watchLeases{open: make(map[string]*leasedPosition), now: now}
+}
+
+// keep: id 의 위치를 pin 으로 바꾸고 lease 를 연장 (이전 위치는 닫음)
+func (l *watchLeases) keep(id string, pin *store.Snapshot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.expireLocked(now)
+	if old, ok := l.open[id]; ok {
+		old.pin.Close()
+	}
+	l.open[id] = &leasedPosition{pin: pin, expires: now.Add(watchLeaseTTL)}
+}
+
+// expire: lease 가 지난 위치를 닫음 (닫지 않으면 저장소가 그 위치 이후의 이전 상태를 계속 남김)
+func (l *watchLeases) expire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.expireLocked(l.now())
+}
+
+func (l *watchLeases) expireLocked(now time.Time) {
+	for id, lp := range l.open {
+		if now.After(lp.expires) {
+			lp.pin.Close()
+			delete(l.open, id)
+		}
+	}
+}
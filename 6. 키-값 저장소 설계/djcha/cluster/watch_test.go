@@ -0,0 +1,136 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"kv-store/store"
+	"kv-store/vclock"
+)
+
+// nextBatch: 변경을 한 번 받음 (제한 시간 안에 오지 않으면 실패)
+func nextBatch(t *testing.T, w *Watch) WatchBatch {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	batch, err := w.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return batch
+}
+
+func TestWatchPrefixAcrossReplicas(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	all := WriteOptions{Consistency: All}
+
+	w, err := tc.nodes[0].Watch(ctx, WatchOptions{Prefix: "cfg/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.nodes[1].Put(ctx, "other", []byte("x"), all); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.nodes[1].Put(ctx, "cfg/a", []byte("v1"), all); err != nil {
+		t.Fatal(err)
+	}
+
+	// 세 복제본이 모두 같은 쓰기를 알려도 한 번만 나옴
+	batch := nextBatch(t, w)
+	if len(batch.Events) != 1 || batch.Events[0].Key != "cfg/a" || batch.Events[0].Type != store.EventPut ||
+		string(batch.Events[0].Siblings[0].Data) != "v1" {
+		t.Fatalf("events = %+v, want put cfg/a=v1", batch.Events)
+	}
+
+	if err := tc.nodes[2].Delete(ctx, "cfg/a", WriteOptions{Consistency: All, Context: batch.Events[0].Context}); err != nil {
+		t.Fatal(err)
+	}
+	batch = nextBatch(t, w)
+	if len(batch.Events) != 1 || batch.Events[0].Type != store.EventDelete {
+		t.Fatalf("events = %+v, want delete cfg/a", batch.Events)
+	}
+
+	// 리비전으로 이어 보면 그 뒤의 변경까지 받음
+	// 아직 응답하지 않았던 복제본의 변경은 다시 올 수 있지만, 키마다 순서는 지켜져 마지막 상태가 같음
+	if err := tc.nodes[0].Put(ctx, "cfg/b", []byte("v1"), all); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := tc.nodes[1].Watch(ctx, WatchOptions{Revision: batch.Revision})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := make(map[string]store.EventType)
+	for last["cfg/b"] == "" {
+		for _, e := range nextBatch(t, resumed).Events {
+			last[e.Key] = e.Type
+		}
+	}
+	if last["cfg/b"] != store.EventPut || (last["cfg/a"] != "" && last["cfg/a"] != store.EventDelete) {
+		t.Fatalf("last events after resume = %v, want cfg/b put and cfg/a deleted", last)
+	}
+}
+
+func TestWatchSeesAntiEntropyRepair(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+	healthy, lagging := tc.nodes[0], tc.nodes[1]
+
+	// 뒤처진 복제본만 지켜봄: 그 노드에는 클라이언트 쓰기가 오지 않고 안티 엔트로피로만 값이 들어옴
+	watcher, err := lagging.Store().Watch(lagging.Store().Seq(), func(key string) bool { return key == "cfg/c" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	healthy.Store().Put("cfg/c", store.Value{Data: []byte("v1"), Clock: vclock.Clock{healthy.ID(): {Counter: 1}}})
+	if err := lagging.AntiEntropy(ctx, healthy.ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	events, err := watcher.Next(waitCtx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != store.EventPut || string(events[0].Siblings[0].Data) != "v1" {
+		t.Fatalf("events after repair = %+v, want put cfg/c=v1", events)
+	}
+}
+
+func TestWatchCompactedRevision(t *testing.T) {
+	tc := startCluster(t, 3, testConfig)
+	ctx := context.Background()
+
+	w, err := tc.nodes[0].Watch(ctx, WatchOptions{Key: "cfg/a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := w.Revision()
+
+	// lease 가 지나고 History 도 없으면 다음 쓰기 때 그 위치 이전 상태가 정리됨
+	for _, n := range tc.nodes {
+		n.watches.mu.Lock()
+		n.watches.expireLocked(time.Now().Add(2 * watchLeaseTTL))
+		n.watches.mu.Unlock()
+	}
+	if err := tc.nodes[0].Put(ctx, "cfg/a", []byte("v1"), WriteOptions{Consistency: All}); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := tc.nodes[0].Watch(ctx, WatchOptions{Revision: rev})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := resumed.Next(waitCtx); !errors.Is(err, store.ErrCompacted) {
+		t.Fatalf("Next from compacted revision: err = %v, want ErrCompacted", err)
+	}
+	if _, err := tc.nodes[0].Watch(ctx, WatchOptions{Revision: "not-a-revision"}); !errors.Is(err, ErrInvalidRevision) {
+		t.Fatalf("invalid revision: err = %v", err)
+	}
+}
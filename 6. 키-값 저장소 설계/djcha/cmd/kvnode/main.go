@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"kv-store/cluster"
+	"kv-store/httpapi"
+	"kv-store/raft"
+	"kv-store/raftkv"
+	"kv-store/respapi"
+	"kv-store/ring"
+)
+
+// 키-값 저장소 노드 한 대를 띄우는 프로그램
+//
+// 로컬에서 프로세스 3개로 클러스터 구성 예:
+//
+//	go run ./cmd/kvnode -addr 127.0.0.1:7001 -peers 127.0.0.1:7001,127.0.0.1:7002,127.0.0.1:7003
+//	go run ./cmd/kvnode -addr 127.0.0.1:7002 -peers 127.0.0.1:7001,127.0.0.1:7002,127.0.0.1:7003
+//	go run ./cmd/kvnode -addr 127.0.0.1:7003 -peers 127.0.0.1:7001,127.0.0.1:7002,127.0.0.1:7003
+//
+//	curl -X PUT --data 'hello' '127.0.0.1:7001/kv/greeting?consistency=quorum'
+//	curl '127.0.0.1:7003/kv/greeting?consistency=one'
+//	curl -X PUT -H 'If-Match: "<GET 의 ETag>"' --data 'hi' 127.0.0.1:7002/kv/greeting
+//	curl '127.0.0.1:7001/kv/?prefix=greet&limit=10'
+//	curl '127.0.0.1:7001/kv/greeting?as_of=5m'
+//	curl -X POST --data '{"type":"pncounter","increment":1}' 127.0.0.1:7002/kv/_crdt/likes:post1
+//
+// 설정 변경을 폴링하는 대신 감시 (롱 폴링은 응답의 revision 으로 이어 묻고, SSE 는 연결을 유지):
+//
+//	curl '127.0.0.1:7001/kv/_watch?prefix=config/&timeout=60s'
+//	curl '127.0.0.1:7001/kv/_watch?revision=<이전 응답의 revision>'
+//	curl -N -H 'Accept: text/event-stream' '127.0.0.1:7002/kv/_watch?key=config/db'
+//
+// 이미 떠 있는 클러스터에 노드를 넣을 때는 -join 으로 띄움 (맡게 될 키 범위를 다 받은 뒤부터 읽기를 받음)
+// 노드를 뺄 때는 decommission 요청 → 키 범위를 새 주인들에게 넘긴 뒤 프로세스가 끝남:
+//
+//	go run ./cmd/kvnode -addr 127.0.0.1:7004 -peers 127.0.0.1:7001,127.0.0.1:7002,127.0.0.1:7003 -join -stream-rate 10485760
+//	curl 127.0.0.1:7004/admin/transfers
+//	curl -X POST 127.0.0.1:7002/admin/decommission
+//
+// 노드가 남기는 파일(안티 엔트로피 머클 트리, 래프트 로그)은 -data-dir (기본 data/<addr>) 아래에 둠
+// 머클 트리 파일을 처음 만드는 동안은 그 노드와의 안티 엔트로피 복구를 멈춤
+//
+// -admin-token 을 주면 운영/래프트 엔드포인트(/admin/, /raft/, /strong/members/)는 같은 토큰이 있어야 받음
+// (래프트 노드끼리도 이 토큰을 붙여 보내므로 모든 노드에 같은 값을 줘야 함):
+//
+//	curl -H "Authorization: Bearer $KV_ADMIN_TOKEN" -X POST 127.0.0.1:7002/admin/decommission
+//
+// -resp-addr 를 주면 redis-cli 로도 접근 가능:
+//
+//	go run ./cmd/kvnode -addr 127.0.0.1:7001 -resp-addr 127.0.0.1:6379
+//	redis-cli -p 6379 SET greeting hello EX 60
+//
+// -raft-peers 를 주면 래프트로 복제하는 강한 일관성 모드(/strong/)도 같이 띄움 (설정, 임대처럼 선형화가 필요한 데이터용)
+// 래프트 로그와 스냅샷은 -raft-dir 에 남기고(fsync 한 뒤에 응답), 다시 띄우면 거기서 이어서 시작
+// 나중에 들어갈 노드는 -raft-join 으로 띄운 뒤 리더에게 추가 요청:
+//
+//	go run ./cmd/kvnode -addr 127.0.0.1:7001 -raft-peers 127.0.0.1:7001,127.0.0.1:7002,127.0.0.1:7003
+//	curl -L -X PUT -H 'If-None-Match: *' --data 'worker-1' '127.0.0.1:7002/strong/kv/lease/job?ttl=30s'
+//	go run ./cmd/kvnode -addr 127.0.0.1:7004 -raft-join
+//	curl -H "Authorization: Bearer $KV_ADMIN_TOKEN" -X POST 127.0.0.1:7001/strong/members/127.0.0.1:7004
+func main() {
+	addr := flag.String("addr", "127.0.0.1:7001", "이 노드의 주소 (다른 노드가 접근하는 주소)")
+	peers := flag.String("peers", "127.0.0.1:7001", "클러스터 전체 노드 주소 목록 (쉼표 구분, 자기 자신 포함)")
+	vnodes := flag.Int("vnodes", 100, "서버 1대당 가상 노드 개수")
+	n := flag.Int("n", 3, "복제본 개수 N")
+	r := flag.Int("r", 2, "읽기 정족수 R")
+	w := flag.Int("w", 2, "쓰기 정족수 W")
+	timeout := flag.Duration("timeout", time.Second, "복제본 응답 대기 시간")
+	resolver := flag.String("resolver", "none", "형제 값 정리 방식 (none: 모두 반환, lww: 마지막 쓰기 우선)")
+	hints := flag.Bool("hints", true, "느슨한 정족수 + 힌트 전달 사용 (false 면 엄격한 정족수)")
+	readRepair := flag.Float64("read-repair", 0.1, "읽기 복구 확률 (0 ~ 1)")
+	heartbeat := flag.Duration("heartbeat", time.Second, "박동 주기 (0 이면 장애 감지기 없이 요청 실패로만 장애 판단)")
+	phi := flag.Float64("phi", 8, "장애로 판단할 의심 수준 phi 임계값")
+	antiEntropy := flag.Duration("anti-entropy", cluster.DefaultAntiEntropyConfig.Interval, "머클 트리 안티 엔트로피 주기 (0 이면 사용 안 함)")
+	gcGrace := flag.Duration("gc-grace", cluster.DefaultGCConfig.GracePeriod, "삭제 표시를 지우기 전 유예 기간 (0 이면 삭제 표시를 지우지 않음)")
+	history := flag.Duration("history", 10*time.Minute, "덮어쓴 이전 값을 남겨 두는 기간 (as_of 로 이 기간 안의 시점을 읽을 수 있음)")
+	join := flag.Bool("join", false, "이미 떠 있는 클러스터(-peers)에 들어가는 노드로 띄움 (맡게 될 키 범위를 받은 뒤 읽기를 받음)")
+	streamRate := flag.Int("stream-rate", 0, "노드가 들어오거나 나갈 때 키 범위 전송 속도 상한 (Byte/s, 0 이면 제한 없음)")
+	raftPeers := flag.String("raft-peers", "", "래프트 강한 일관성 모드의 처음 구성 (쉼표 구분, 자기 자신 포함, 비우면 사용 안 함)")
+	raftJoin := flag.Bool("raft-join", false, "이미 있는 래프트 클러스터에 들어갈 노드로 띄움 (리더에서 /strong/members 로 추가해야 함)")
+	raftTick := flag.Duration("raft-tick", 100*time.Millisecond, "래프트 틱 간격 (선거 시간 제한 = 10~20틱)")
+	dataDir := flag.String("data-dir", "", "이 노드의 파일(머클 트리, 래프트 로그)을 둘 디렉터리 (비우면 data/<addr>)")
+	raftDir := flag.String("raft-dir", "", "래프트 로그와 스냅샷을 저장할 디렉터리 (비우면 <data-dir>/raft)")
+	adminToken := flag.String("admin-token", "", "/admin/, /raft/, /strong/members/ 에 요구할 Bearer 토큰 (비우면 검사하지 않음)")
+	respAddr := flag.String("resp-addr", "", "레디스 프로토콜(RESP) 로 받을 주소 (비우면 사용 안 함, 예: 127.0.0.1:6379)")
+	flag.Parse()
+
+	if *dataDir == "" {
+		*dataDir = filepath.Join("data", strings.ReplaceAll(*addr, ":", "_"))
+	}
+
+	hashRing := ring.New(*vnodes)
+	for _, peer := range strings.Split(*peers, ",") {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			hashRing.Add(peer)
+		}
+	}
+	hashRing.Add(*addr)
+
+	cfg := cluster.Config{N: *n, R: *r, W: *w, Timeout: *timeout, ReadRepairChance: *readRepair, History: *history}
+	if *hints {
+		cfg.Hints = cluster.DefaultHintConfig
+	}
+	if *heartbeat > 0 {
+		cfg.Heartbeat = cluster.DefaultHeartbeatConfig
+		cfg.Heartbeat.Interval = *heartbeat
+		cfg.Heartbeat.Detector.FirstInterval = *heartbeat
+		cfg.Heartbeat.Detector.Threshold = *phi
+	}
+	cfg.AntiEntropy.Interval = *antiEntropy
+	cfg.AntiEntropy.Dir = filepath.Join(*dataDir, "merkle")
+	if *gcGrace > 0 {
+		cfg.GC = cluster.DefaultGCConfig
+		cfg.GC.GracePeriod = *gcGrace
+	}
+	cfg.Stream = cluster.DefaultStreamConfig
+	cfg.Stream.BytesPerSecond = *streamRate
+	cfg.Stream.Progress = func(st cluster.TransferStatus) {
+		log.Printf("%s transfer with %s: %d keys, %d bytes, cursor %d, done %v %s", st.Kind, st.Peer, st.Keys, st.Bytes, st.Cursor, st.Done, st.Error)
+	}
+	switch *resolver {
+	case "none":
+	case "lww":
+		cfg.Resolver = cluster.LastWriteWins
+	default:
+		log.Fatalf("unknown resolver %q", *resolver)
+	}
+	node, err := cluster.NewNode(*addr, cfg, hashRing, cluster.NewHTTPTransport())
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	defer node.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/internal/", node.Handler())
+	mux.Handle("/kv/", httpapi.NewHandler(node))
+
+	// 운영용: 키 범위 이동 진행 상황 / 노드 빼기
+	if *adminToken == "" {
+		log.Printf("warning: -admin-token is empty, /admin/, /raft/ and /strong/members/ accept any request")
+	}
+	admin := func(h http.Handler) http.Handler { return requireToken(*adminToken, h) }
+	stop := make(chan os.Signal, 1)
+	mux.Handle("GET /admin/transfers", admin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(node.Transfers())
+	})))
+	mux.Handle("POST /admin/decommission", admin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := node.Decommission(r.Context()); err != nil {
+			// 다시 요청하면 보내다 만 위치부터 이어서 보냄
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		log.Printf("decommissioned, shutting down")
+		select {
+		case stop <- syscall.SIGTERM:
+		default:
+		}
+	})))
+
+	var raftNode *raft.Node
+	if *raftPeers != "" || *raftJoin {
+		var members []string
+		if !*raftJoin {
+			for _, peer := range strings.Split(*raftPeers, ",") {
+				if peer = strings.TrimSpace(peer); peer != "" {
+					members = append(members, peer)
+				}
+			}
+		}
+		dir := *raftDir
+		if dir == "" {
+			dir = filepath.Join(*dataDir, "raft")
+		}
+		raftStorage, err := raft.OpenFileStorage(dir)
+		if err != nil {
+			log.Fatalf("open raft storage: %v", err)
+		}
+		defer raftStorage.Close()
+		transport := raft.NewHTTPTransport()
+		transport.Token = *adminToken
+		raftNode, err = raft.NewNode(raft.Config{
+			ID:              *addr,
+			Peers:           members,
+			SnapshotEntries: 10000,
+			Storage:         raftStorage,
+			Transport:       transport,
+			StateMachine:    raftkv.NewMachine(),
+		})
+		if err != nil {
+			log.Fatalf("invalid raft config: %v", err)
+		}
+		strong := raftkv.NewHandler(raftkv.New(raftNode))
+		mux.Handle("/raft/", admin(raft.Handler(raftNode)))
+		mux.Handle("/strong/", strong)
+		mux.Handle("/strong/members/", admin(strong))
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	runCtx, stopRun := context.WithCancel(context.Background())
+	defer stopRun()
+	go node.Run(runCtx)
+	if raftNode != nil {
+		go raftNode.Run(runCtx, *raftTick)
+	}
+
+	go func() {
+		log.Printf("listening on %s (N=%d R=%d W=%d, %d nodes)", *addr, cfg.N, cfg.R, cfg.W, len(hashRing.Servers()))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %v", err)
+		}
+	}()
+
+	if *join {
+		go func() {
+			for {
+				err := node.Join(runCtx)
+				if err == nil {
+					log.Printf("joined the cluster")
+					return
+				}
+				if runCtx.Err() != nil {
+					return
+				}
+				log.Printf("join: %v (retrying)", err)
+				time.Sleep(cfg.Stream.RetryDelay)
+			}
+		}()
+	}
+
+	var respSrv *respapi.Server
+	if *respAddr != "" {
+		respSrv = respapi.NewServer(node, cluster.Default)
+		go func() {
+			log.Printf("serving RESP on %s", *respAddr)
+			if err := respSrv.ListenAndServe(*respAddr); err != nil && err != respapi.ErrServerClosed {
+				log.Fatalf("resp listen: %v", err)
+			}
+		}()
+	}
+
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(ctx)
+	if respSrv != nil {
+		_ = respSrv.Close()
+	}
+}
+
+// requireToken: Authorization: Bearer <token> 이 맞는 요청만 h 로 넘김 (token 이 비어 있으면 그대로 넘김)
+func requireToken(token string, h http.Handler) http.Handler {
+	if token == "" {
+		return h
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
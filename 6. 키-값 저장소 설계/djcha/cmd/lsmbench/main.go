@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kv-store/lsm"
+)
+
+// LSM 엔진 압축 전략 / 블룸 필터 비교 벤치마크
+//
+// 같은 작업(키를 채운 뒤 읽기 위주로 섞어 돌림)을 전략과 필터 거짓 양성률마다 새 디렉터리에서 실행하고
+// 쓰기 증폭, 읽기 증폭, 필터 적중률, 처리 시간을 CSV 로 출력
+//
+//	go run ./cmd/lsmbench -keys 200000 -ops 1000000 -read-ratio 0.9 -fp 0,0.01,0.001 > lsm.csv
+//
+// 읽기의 -miss-ratio 만큼은 없는 키를 찾음 (필터가 파일을 건너뛰게 해 주는 경우)
+func main() {
+	keys := flag.Int("keys", 100000, "처음에 채우는 키 개수")
+	valueSize := flag.Int("value-size", 100, "값 크기 (Byte)")
+	ops := flag.Int("ops", 500000, "채운 뒤 실행할 작업 수")
+	readRatio := flag.Float64("read-ratio", 0.9, "작업 중 읽기 비율 (나머지는 덮어쓰기)")
+	missRatio := flag.Float64("miss-ratio", 0.2, "읽기 중 없는 키 비율")
+	strategies := flag.String("strategy", "leveled,size-tiered", "압축 전략 (쉼표 구분)")
+	fps := flag.String("fp", "0,0.01,0.001", "블룸 필터 거짓 양성률 (쉼표 구분, 0 이면 필터 없음)")
+	memtable := flag.Int("memtable", 4<<20, "메모리 테이블 크기 (Byte)")
+	rate := flag.Int64("compaction-rate", 0, "압축 I/O 상한 (Byte/s, 0 이면 제한 없음)")
+	dir := flag.String("dir", "", "데이터를 둘 디렉터리 (비우면 임시 디렉터리, 실행마다 지움)")
+	seed := flag.Uint64("seed", 1, "작업을 고르는 난수 시드")
+	out := flag.String("out", "", "CSV 를 쓸 파일 (비우면 표준 출력)")
+	flag.Parse()
+
+	var strategyList []lsm.Strategy
+	for _, name := range strings.Split(*strategies, ",") {
+		switch strings.TrimSpace(name) {
+		case "leveled":
+			strategyList = append(strategyList, lsm.DefaultLeveled)
+		case "size-tiered":
+			strategyList = append(strategyList, lsm.DefaultSizeTiered)
+		default:
+			log.Fatalf("unknown strategy %q", name)
+		}
+	}
+	var fpList []float64
+	for _, s := range strings.Split(*fps, ",") {
+		fp, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || fp < 0 || fp >= 1 {
+			log.Fatalf("invalid -fp %q", s)
+		}
+		fpList = append(fpList, fp)
+	}
+	if *keys <= 0 || *ops < 0 || *valueSize < 0 {
+		log.Fatal("-keys must be positive, -ops and -value-size must not be negative")
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"strategy", "fp", "keys", "ops", "read_ratio", "load_ns", "run_ns",
+		"write_amp", "read_amp", "filter_hit_rate", "filter_fp_rate", "compactions", "tables", "disk_bytes"})
+
+	for _, s := range strategyList {
+		for _, fp := range fpList {
+			cfg := lsm.DefaultConfig
+			cfg.Compaction = s
+			cfg.FilterFalsePositive = fp
+			cfg.MemtableBytes = *memtable
+			cfg.CompactionBytesPerSecond = *rate
+
+			r := bench{keys: *keys, valueSize: *valueSize, ops: *ops, readRatio: *readRatio, missRatio: *missRatio, seed: *seed}
+			st, load, run, err := r.run(*dir, cfg)
+			if err != nil {
+				log.Fatalf("%s fp=%v: %v", s, fp, err)
+			}
+			tables, bytes := 0, int64(0)
+			for _, l := range st.Levels {
+				tables += l.Tables
+				bytes += l.Bytes
+			}
+			_ = cw.Write([]string{s.String(), fmt.Sprint(fp), fmt.Sprint(*keys), fmt.Sprint(*ops), fmt.Sprint(*readRatio),
+				fmt.Sprint(load.Nanoseconds()), fmt.Sprint(run.Nanoseconds()),
+				fmt.Sprintf("%.3f", st.WriteAmplification), fmt.Sprintf("%.3f", st.ReadAmplification),
+				fmt.Sprintf("%.4f", st.FilterHitRate), fmt.Sprintf("%.5f", st.FilterFalsePositiveRate),
+				fmt.Sprint(st.Compactions), fmt.Sprint(tables), fmt.Sprint(bytes)})
+			cw.Flush()
+			log.Printf("%s fp=%v done", s, fp)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type bench struct {
+	keys, valueSize, ops int
+	readRatio, missRatio float64
+	seed                 uint64
+}
+
+// run: 새 디렉터리에서 채우기 → 압축이 끝날 때까지 대기 → 섞어 돌리기 (지표는 채우기부터 모두 셈)
+func (b bench) run(parent string, cfg lsm.Config) (lsm.Stats, time.Duration, time.Duration, error) {
+	dir, err := os.MkdirTemp(parent, "lsmbench-")
+	if err != nil {
+		return lsm.Stats{}, 0, 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := lsm.Open(dir, cfg)
+	if err != nil {
+		return lsm.Stats{}, 0, 0, err
+	}
+	defer db.Close()
+
+	r := rand.New(rand.NewPCG(b.seed, 0))
+	value := make([]byte, b.valueSize)
+	start := time.Now()
+	for i := range b.keys {
+		if err := db.Put(key(i), value); err != nil {
+			return lsm.Stats{}, 0, 0, err
+		}
+	}
+	if err := db.WaitIdle(); err != nil {
+		return lsm.Stats{}, 0, 0, err
+	}
+	load := time.Since(start)
+
+	start = time.Now()
+	for range b.ops {
+		i := r.IntN(b.keys)
+		if r.Float64() >= b.readRatio {
+			if err := db.Put(key(i), value); err != nil {
+				return lsm.Stats{}, 0, 0, err
+			}
+			continue
+		}
+		k := key(i)
+		if r.Float64() < b.missRatio {
+			k += "-missing"
+		}
+		if _, err := db.Get(k); err != nil && !errors.Is(err, lsm.ErrNotFound) {
+			return lsm.Stats{}, 0, 0, err
+		}
+	}
+	run := time.Since(start)
+	return db.Stats(), load, run, nil
+}
+
+// key: 채우는 순서와 키 순서가 다르도록 섞은 키 (순서대로 채우면 압축이 겹치는 범위 없이 끝나서 비교가 안 됨)
+func key(i int) string {
+	return fmt.Sprintf("user-%016x", uint64(i)*0x9e3779b97f4a7c15)
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"kv-store/merkle"
+)
+
+// 안티 엔트로피 동기화 방식 비교 벤치마크
+//
+// 데이터 크기, 다른 필드 개수와 분포, 트리 자식 수를 바꿔 가며
+// 단순 비교 / 이진 머클 트리 / k-진 머클 트리의 비교 횟수, 전송량, 시간을 CSV 로 출력
+// 다른 필드가 많아지면 어느 지점부터 머클 트리가 단순 비교보다 손해인지 볼 수 있음
+//
+//	go run ./cmd/syncbench -fields 1000000 -diffs 1,100,10000,100000 -spread uniform,clustered -fanout 4,16 > sync.csv
+//
+// 전송량 계산 (main 시뮬레이션과 같은 가정)
+//   - 단순 비교: 상대의 모든 필드를 받음 → 필드 수 × 필드 크기
+//   - 머클 트리: 비교한 노드마다 해시 한 쌍(64 Byte) + 다르다고 찾은 필드만 받음 (찾은 수 × 필드 크기)
+//
+// 분포 (-spread)
+//   - single:    가운데 필드 하나만 다름 (-diffs 무시)
+//   - uniform:   전체에 고르게 흩어짐
+//   - clustered: 연속된 필드 clusterSize 개씩 묶음 여러 개로 몰림 (한 구간을 한꺼번에 고친 경우)
+//   - tail:      끝으로 갈수록 많음 (최근에 추가된 데이터만 고친 경우)
+func main() {
+	fields := flag.Int("fields", 1000000, "필드(리프) 개수")
+	fieldSize := flag.Int("field-size", 100, "필드당 데이터 크기 (Byte)")
+	diffs := flag.String("diffs", "1,10,100,1000,10000,100000", "다른 필드 개수 (쉼표 구분, 각각 한 번씩 실행)")
+	spreads := flag.String("spread", "single,uniform,clustered,tail", "다른 필드 분포 (쉼표 구분)")
+	fanouts := flag.String("fanout", "4,16,64", "k-진 머클 트리 자식 수 (쉼표 구분, 이진 트리는 항상 같이 측정)")
+	seed := flag.Uint64("seed", 1, "다른 필드 위치를 고르는 난수 시드")
+	out := flag.String("out", "", "CSV 를 쓸 파일 (비우면 표준 출력)")
+	flag.Parse()
+
+	diffCounts, err := parseInts(*diffs, 1)
+	if err != nil {
+		log.Fatalf("invalid -diffs: %v", err)
+	}
+	karies, err := parseInts(*fanouts, 2)
+	if err != nil {
+		log.Fatalf("invalid -fanout: %v", err)
+	}
+	var spreadList []string
+	for _, s := range strings.Split(*spreads, ",") {
+		s = strings.TrimSpace(s)
+		if !slices.Contains([]string{"single", "uniform", "clustered", "tail"}, s) {
+			log.Fatalf("unknown spread %q", s)
+		}
+		spreadList = append(spreadList, s)
+	}
+	if *fields <= 0 || *fieldSize <= 0 {
+		log.Fatal("-fields and -field-size must be positive")
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"spread", "fields", "diffs", "method", "fanout", "height", "comparisons", "bytes", "build_ns", "sync_ns", "found"})
+
+	base := make([]string, *fields)
+	for i := range base {
+		base[i] = fieldData(*fieldSize, "", i)
+	}
+	for _, spread := range spreadList {
+		for _, count := range diffCounts {
+			if spread == "single" {
+				count = 1
+			}
+			changed := pickDiffs(spread, *fields, count, rand.New(rand.NewPCG(*seed, uint64(count))))
+			other := slices.Clone(base)
+			for _, i := range changed {
+				other[i] = fieldData(*fieldSize, "CHANGED", i)
+			}
+
+			cfg := scenario{spread: spread, fields: *fields, fieldSize: *fieldSize, diffs: len(changed)}
+			rows := []result{naive(base, other, *fieldSize), binary(base, other, *fieldSize)}
+			for _, k := range karies {
+				rows = append(rows, kary(base, other, *fieldSize, k))
+			}
+			for _, r := range rows {
+				if r.found != len(changed) {
+					log.Fatalf("%s found %d differences, want %d", r.method, r.found, len(changed))
+				}
+				_ = cw.Write(cfg.record(r))
+			}
+			cw.Flush()
+			log.Printf("%s diffs=%d done", spread, len(changed))
+
+			if spread == "single" {
+				break
+			}
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// clusterSize: clustered 분포에서 한 묶음의 연속 필드 수
+const clusterSize = 64
+
+// hashPairBytes: 노드 하나를 비교할 때 주고받는 해시 크기 (양쪽 32 Byte)
+const hashPairBytes = 64
+
+type scenario struct {
+	spread                   string
+	fields, fieldSize, diffs int
+}
+
+// result: 동기화 방식 하나의 측정 결과
+type result struct {
+	method      string
+	fanout      int // 단순 비교는 0
+	height      int // 루트에서 리프까지 왕복 횟수 (단순 비교는 0)
+	comparisons int
+	bytes       int
+	build       time.Duration // 양쪽 트리를 만드는 시간
+	sync        time.Duration // 다른 필드를 찾는 시간
+	found       int
+}
+
+func (s scenario) record(r result) []string {
+	return []string{
+		s.spread,
+		strconv.Itoa(s.fields),
+		strconv.Itoa(s.diffs),
+		r.method,
+		strconv.Itoa(r.fanout),
+		strconv.Itoa(r.height),
+		strconv.Itoa(r.comparisons),
+		strconv.Itoa(r.bytes),
+		strconv.FormatInt(r.build.Nanoseconds(), 10),
+		strconv.FormatInt(r.sync.Nanoseconds(), 10),
+		strconv.Itoa(r.found),
+	}
+}
+
+// fieldData: i 번 필드 값 (모든 필드 크기를 size 로 맞춤)
+func fieldData(size int, tag string, i int) string {
+	s := tag + strconv.Itoa(i)
+	if len(s) >= size {
+		return s[:size]
+	}
+	return strings.Repeat("0", size-len(s)) + s
+}
+
+// naive: 상대의 모든 필드를 받아 하나씩 비교
+func naive(a, b []string, fieldSize int) result {
+	start := time.Now()
+	found := 0
+	for i := range a {
+		if a[i] != b[i] {
+			found++
+		}
+	}
+	return result{method: "naive", comparisons: len(a), bytes: len(b) * fieldSize, sync: time.Since(start), found: found}
+}
+
+// binary: 힙 배열 이진 머클 트리 (merkle.Tree)
+func binary(a, b []string, fieldSize int) result {
+	start := time.Now()
+	ta, tb := merkle.BuildStrings(a), merkle.BuildStrings(b)
+	build := time.Since(start)
+
+	start = time.Now()
+	leaves, comparisons, err := merkle.DiffLeaves(ta, tb)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return result{
+		method:      "binary",
+		fanout:      2,
+		height:      treeHeight(len(a), 2),
+		comparisons: comparisons,
+		bytes:       comparisons*hashPairBytes + len(leaves)*fieldSize,
+		build:       build,
+		sync:        time.Since(start),
+		found:       len(leaves),
+	}
+}
+
+// kary: 자식 수가 fanout 인 머클 트리 (merkle.KaryTree)
+func kary(a, b []string, fieldSize, fanout int) result {
+	start := time.Now()
+	ta, err := merkle.BuildKary(leafDigests(a), fanout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tb, _ := merkle.BuildKary(leafDigests(b), fanout)
+	build := time.Since(start)
+
+	start = time.Now()
+	leaves, comparisons, err := merkle.DiffKary(ta, tb)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return result{
+		method:      "kary",
+		fanout:      fanout,
+		height:      ta.Height(),
+		comparisons: comparisons,
+		bytes:       comparisons*hashPairBytes + len(leaves)*fieldSize,
+		build:       build,
+		sync:        time.Since(start),
+		found:       len(leaves),
+	}
+}
+
+func leafDigests(fields []string) []merkle.Digest {
+	out := make([]merkle.Digest, len(fields))
+	for i, f := range fields {
+		out[i] = merkle.HashLeaf([]byte(f))
+	}
+	return out
+}
+
+// treeHeight: 리프 n 개를 자식 fanout 개씩 묶어 루트 하나가 될 때까지의 레벨 수
+func treeHeight(n, fanout int) int {
+	height := 0
+	for ; n > 1; n = (n + fanout - 1) / fanout {
+		height++
+	}
+	return height
+}
+
+// pickDiffs: 분포에 따라 다른 필드 위치 count 개를 고름 (중복 없음, 오름차순)
+func pickDiffs(spread string, n, count int, r *rand.Rand) []int {
+	count = min(count, n)
+	if spread == "single" {
+		return []int{n / 2}
+	}
+
+	picked := make(map[int]bool, count)
+	frontier := n - 1 // tail: 아직 고르지 않은 가장 뒤 칸
+	for len(picked) < count {
+		switch spread {
+		case "uniform":
+			picked[r.IntN(n)] = true
+		case "clustered":
+			start := r.IntN(n)
+			for i := start; i < min(start+clusterSize, n) && len(picked) < count; i++ {
+				picked[i] = true
+			}
+		case "tail":
+			// 끝에서부터의 거리가 지수 분포 (평균 n/20) → 대부분 마지막 5% 근처
+			// 이미 고른 칸이면 아직 고르지 않은 가장 뒤 칸을 고름
+			// count 가 n 에 가까워도 매번 하나씩 늘어나고, frontier 는 앞으로만 가므로 전체 O(n)
+			i := max(n-1-int(r.ExpFloat64()*float64(n)/20), 0)
+			if picked[i] {
+				for picked[frontier] {
+					frontier--
+				}
+				i = frontier
+			}
+			picked[i] = true
+		}
+	}
+	out := make([]int, 0, count)
+	for i := range picked {
+		out = append(out, i)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// parseInts: 쉼표로 구분한 정수 목록 (모두 least 이상)
+func parseInts(s string, least int) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if v < least {
+			return nil, fmt.Errorf("%d is less than %d", v, least)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
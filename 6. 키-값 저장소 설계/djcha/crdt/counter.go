@@ -0,0 +1,68 @@
+package crdt
+
+// GCounter: 증가만 하는 카운터 (actor → 그 actor 가 올린 양)
+// 합칠 때 actor 별로 큰 값을 고르므로, 같은 증가가 여러 번 도착해도 한 번만 셈
+type GCounter map[string]uint64
+
+func (c GCounter) Type() Type { return TypeGCounter }
+
+func (c GCounter) Merge(other CRDT) {
+	for actor, n := range other.(GCounter) {
+		c[actor] = max(c[actor], n)
+	}
+}
+
+// Value: 모든 actor 가 올린 양의 합
+func (c GCounter) Value() any {
+	return c.sum()
+}
+
+func (c GCounter) sum() uint64 {
+	var total uint64
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+func (c GCounter) apply(actor string, op Op, now int64) error {
+	switch {
+	case op.Increment < 0:
+		return invalidOp(TypeGCounter, "cannot decrement (use pncounter)")
+	case op.Value != nil || op.Add != nil || op.Remove != nil || op.Set != nil:
+		return invalidOp(TypeGCounter, "only increment is supported")
+	}
+	c[actor] += uint64(op.Increment)
+	return nil
+}
+
+// PNCounter: 증가/감소 카운터 (증가분 P, 감소분 N 을 GCounter 로 따로 셈)
+type PNCounter struct {
+	P GCounter `json:"p"`
+	N GCounter `json:"n"`
+}
+
+func (c *PNCounter) Type() Type { return TypePNCounter }
+
+func (c *PNCounter) Merge(other CRDT) {
+	o := other.(*PNCounter)
+	c.P.Merge(o.P)
+	c.N.Merge(o.N)
+}
+
+// Value: 증가분 - 감소분
+func (c *PNCounter) Value() any {
+	return int64(c.P.sum() - c.N.sum())
+}
+
+func (c *PNCounter) apply(actor string, op Op, now int64) error {
+	if op.Value != nil || op.Add != nil || op.Remove != nil || op.Set != nil {
+		return invalidOp(TypePNCounter, "only increment is supported")
+	}
+	if op.Increment >= 0 {
+		c.P[actor] += uint64(op.Increment)
+	} else {
+		c.N[actor] += uint64(-op.Increment)
+	}
+	return nil
+}
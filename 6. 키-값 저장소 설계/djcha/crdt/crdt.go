@@ -0,0 +1,161 @@
+// Package crdt: 충돌 없이 합쳐지는 복제 자료형 (Conflict-free Replicated Data Types)
+//
+// 일반 값은 동시에 쓰이면 형제 값이 되어 클라이언트가 정리해야 하고, LWW 로 정리하면 한쪽 쓰기가 사라짐
+// (좋아요 수를 두 노드에서 동시에 +1 하면 +1 만 남음)
+// CRDT 는 상태 두 개를 언제, 어떤 순서로, 몇 번 합쳐도 같은 결과가 나오므로(교환/결합/멱등)
+// 저장소가 형제 값 대신 바로 합쳐 두면 모든 복제본이 같은 값으로 수렴함
+//
+//   - GCounter: 증가만 하는 카운터 (조회수)
+//   - PNCounter: 증가/감소 카운터 (좋아요 / 좋아요 취소)
+//   - LWWRegister: 마지막 쓰기가 이기는 값 하나
+//   - ORSet: 추가/제거가 가능한 집합 (동시에 추가와 제거가 일어나면 추가가 이김)
+//   - Map: 필드 → LWWRegister
+//
+// 연산(Op)을 적용하는 쪽(actor)은 복제본 노드이고, 같은 actor 의 연산은 항상 그 노드의 최신 상태 위에서 적용되어야 함
+// (카운터는 actor 별 값을, ORSet 은 actor 별 순번을 따로 관리하므로)
+package crdt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Type: 자료형 이름 (store.Value.Type 에 저장)
+type Type string
+
+const (
+	TypeGCounter    Type = "gcounter"
+	TypePNCounter   Type = "pncounter"
+	TypeLWWRegister Type = "lww"
+	TypeORSet       Type = "orset"
+	TypeMap         Type = "map"
+)
+
+var (
+	// ErrUnknownType: 지원하지 않는 자료형
+	ErrUnknownType = errors.New("crdt: unknown type")
+	// ErrInvalidOp: 자료형에 맞지 않는 연산 (GCounter 감소, 카운터에 집합 연산 등)
+	ErrInvalidOp = errors.New("crdt: invalid operation for type")
+)
+
+// CRDT: 합칠 수 있는 상태
+type CRDT interface {
+	Type() Type
+	// Merge: other(같은 자료형)를 합침. 교환/결합/멱등
+	Merge(other CRDT)
+	// Value: 클라이언트에게 보여줄 값 (JSON 으로 인코딩 가능)
+	Value() any
+	apply(actor string, op Op, now int64) error
+}
+
+// Op: 클라이언트가 보내는 연산 (자료형에 맞는 필드만 사용)
+//
+//	gcounter, pncounter: {"increment": 5}   (pncounter 는 음수 가능)
+//	lww:                 {"value": "<base64>"}
+//	orset:               {"add": ["a"], "remove": ["b"]}
+//	map:                 {"set": {"field": "<base64>"}, "remove": ["other"]}
+type Op struct {
+	Increment int64             `json:"increment,omitempty"`
+	Value     []byte            `json:"value,omitempty"`
+	Add       []string          `json:"add,omitempty"`
+	Remove    []string          `json:"remove,omitempty"`
+	Set       map[string][]byte `json:"set,omitempty"`
+}
+
+// New: 빈 상태
+func New(t Type) (CRDT, error) {
+	switch t {
+	case TypeGCounter:
+		return GCounter{}, nil
+	case TypePNCounter:
+		return &PNCounter{P: GCounter{}, N: GCounter{}}, nil
+	case TypeLWWRegister:
+		return &LWWRegister{}, nil
+	case TypeORSet:
+		return NewORSet(), nil
+	case TypeMap:
+		return &Map{Fields: make(map[string]LWWRegister)}, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownType, t)
+}
+
+// Decode: Encode 로 만든 바이트열을 상태로 복원
+func Decode(t Type, data []byte) (CRDT, error) {
+	c, err := New(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return c, nil
+	}
+	if gc, ok := c.(GCounter); ok {
+		if err := json.Unmarshal(data, &gc); err != nil {
+			return nil, fmt.Errorf("crdt: decode %s: %w", t, err)
+		}
+		return fillMaps(gc), nil
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("crdt: decode %s: %w", t, err)
+	}
+	return fillMaps(c), nil
+}
+
+// fillMaps: JSON null 로 nil 이 된 맵을 빈 맵으로 바꿈 (nil 맵에 쓰면 패닉)
+func fillMaps(c CRDT) CRDT {
+	switch c := c.(type) {
+	case GCounter:
+		if c == nil {
+			return GCounter{}
+		}
+	case *PNCounter:
+		if c.P == nil {
+			c.P = GCounter{}
+		}
+		if c.N == nil {
+			c.N = GCounter{}
+		}
+	case *ORSet:
+		if c.Clock == nil {
+			c.Clock = make(map[string]uint64)
+		}
+		if c.Elements == nil {
+			c.Elements = make(map[string][]Dot)
+		}
+	case *Map:
+		if c.Fields == nil {
+			c.Fields = make(map[string]LWWRegister)
+		}
+	}
+	return c
+}
+
+// Encode: 상태 → 바이트열
+// 맵 키를 정렬해서 인코딩하므로 같은 상태는 어느 노드에서 인코딩해도 같은 바이트열 (머클 트리 비교에 필요)
+func Encode(c CRDT) []byte {
+	b, _ := json.Marshal(c)
+	return b
+}
+
+// Apply: actor 가 상태 c 에 연산 op 를 적용 (now 는 LWW 에 쓰는 시각, UnixNano)
+func Apply(c CRDT, actor string, op Op, now int64) error {
+	return c.apply(actor, op, now)
+}
+
+// MergeEncoded: 같은 자료형 상태 두 개를 인코딩된 채로 합침
+func MergeEncoded(t Type, a, b []byte) ([]byte, error) {
+	x, err := Decode(t, a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := Decode(t, b)
+	if err != nil {
+		return nil, err
+	}
+	x.Merge(y)
+	return Encode(x), nil
+}
+
+func invalidOp(t Type, why string) error {
+	return fmt.Errorf("%w %s: %s", ErrInvalidOp, t, why)
+}
@@ -0,0 +1,145 @@
+package crdt
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// replicas: 같은 상태에서 시작한 세 복제본에 각자 연산을 적용
+func replicas(t *testing.T, typ Type, ops map[string][]Op) map[string]CRDT {
+	t.Helper()
+	out := make(map[string]CRDT)
+	for i, actor := range []string{"a", "b", "c"} {
+		c, err := New(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, op := range ops[actor] {
+			if err := Apply(c, actor, op, int64(100+i)); err != nil {
+				t.Fatalf("%s apply %+v: %v", actor, op, err)
+			}
+		}
+		out[actor] = c
+	}
+	return out
+}
+
+// mergeInOrder: order 순서로 인코딩/디코딩을 거쳐 합친 결과 (같은 상태를 두 번 합치는 경우 포함)
+func mergeInOrder(t *testing.T, states map[string]CRDT, order ...string) []byte {
+	t.Helper()
+	acc, err := Decode(states[order[0]].Type(), Encode(states[order[0]]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, actor := range order[1:] {
+		other, err := Decode(acc.Type(), Encode(states[actor]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc.Merge(other)
+	}
+	return Encode(acc)
+}
+
+func TestMergeConverges(t *testing.T) {
+	cases := []struct {
+		typ  Type
+		ops  map[string][]Op
+		want any
+	}{
+		{TypeGCounter, map[string][]Op{"a": {{Increment: 2}}, "b": {{Increment: 3}}, "c": {{Increment: 1}, {Increment: 1}}}, uint64(7)},
+		{TypePNCounter, map[string][]Op{"a": {{Increment: 5}}, "b": {{Increment: -2}}, "c": {{Increment: -1}}}, int64(2)},
+		{TypeLWWRegister, map[string][]Op{"a": {{Value: []byte("x")}}, "c": {{Value: []byte("z")}}}, []byte("z")},
+		{TypeORSet, map[string][]Op{"a": {{Add: []string{"x", "y"}}}, "b": {{Add: []string{"y"}}}, "c": {{Add: []string{"z"}}}}, []string{"x", "y", "z"}},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.typ), func(t *testing.T) {
+			states := replicas(t, tc.typ, tc.ops)
+			first := mergeInOrder(t, states, "a", "b", "c")
+			for _, order := range [][]string{{"c", "b", "a"}, {"b", "a", "c", "a", "b"}, {"c", "a", "c", "b"}} {
+				if got := mergeInOrder(t, states, order...); !bytes.Equal(got, first) {
+					t.Fatalf("merge order %v = %s, want %s", order, got, first)
+				}
+			}
+			merged, _ := Decode(tc.typ, first)
+			switch want := tc.want.(type) {
+			case []byte:
+				if got := merged.Value().([]byte); !bytes.Equal(got, want) {
+					t.Fatalf("value = %q, want %q", got, want)
+				}
+			case []string:
+				if got := merged.Value().([]string); !slices.Equal(got, want) {
+					t.Fatalf("value = %v, want %v", got, want)
+				}
+			default:
+				if got := merged.Value(); got != want {
+					t.Fatalf("value = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestORSetAddWinsOverConcurrentRemove(t *testing.T) {
+	a := NewORSet()
+	Apply(a, "a", Op{Add: []string{"member"}}, 0)
+	b, _ := Decode(TypeORSet, Encode(a))
+
+	// a 는 지우고, 그 사실을 모르는 b 는 다시 추가 → 추가가 이김
+	Apply(a, "a", Op{Remove: []string{"member"}}, 0)
+	Apply(b, "b", Op{Add: []string{"member"}}, 0)
+	a.Merge(b)
+	if !a.Contains("member") {
+		t.Fatal("concurrent add should survive the remove")
+	}
+
+	// 합친 상태를 본 뒤에 지우면 지워지고, 옛날 상태가 늦게 도착해도 되살아나지 않음
+	Apply(a, "a", Op{Remove: []string{"member"}}, 0)
+	a.Merge(b)
+	if a.Contains("member") {
+		t.Fatal("observed remove was undone by a stale replica")
+	}
+}
+
+func TestMapFieldRemoveIsNotUndoneByOlderWrite(t *testing.T) {
+	m, _ := New(TypeMap)
+	Apply(m, "a", Op{Set: map[string][]byte{"name": []byte("kim"), "city": []byte("seoul")}}, 10)
+	stale, _ := Decode(TypeMap, Encode(m))
+	Apply(m, "a", Op{Remove: []string{"city"}}, 20)
+	m.Merge(stale)
+
+	got := m.Value().(map[string][]byte)
+	if _, ok := got["city"]; ok || string(got["name"]) != "kim" {
+		t.Fatalf("map = %q, want only name", got)
+	}
+
+	if err := Apply(m, "a", Op{Increment: 1}, 30); err == nil {
+		t.Fatal("increment on a map should be rejected")
+	}
+}
+
+func TestDecodeNullMaps(t *testing.T) {
+	// 다른 노드나 클라이언트가 보낸 null 필드를 디코딩한 상태에 써도 패닉 없이 빈 상태처럼 동작해야 함
+	cases := []struct {
+		typ  Type
+		data string
+		op   Op
+	}{
+		{TypeGCounter, `null`, Op{Increment: 1}},
+		{TypePNCounter, `{"p": null, "n": null}`, Op{Increment: -1}},
+		{TypeORSet, `{"clock": null, "elements": null}`, Op{Add: []string{"x"}}},
+		{TypeMap, `{"fields": null}`, Op{Set: map[string][]byte{"k": []byte("v")}}},
+	}
+	for _, tc := range cases {
+		c, err := Decode(tc.typ, []byte(tc.data))
+		if err != nil {
+			t.Fatalf("decode %s %s: %v", tc.typ, tc.data, err)
+		}
+		if err := Apply(c, "a", tc.op, 1); err != nil {
+			t.Fatalf("%s apply after null decode: %v", tc.typ, err)
+		}
+		empty, _ := New(tc.typ)
+		c.Merge(empty)
+	}
+}
@@ -0,0 +1,91 @@
+package crdt
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// Dot: actor 가 몇 번째로 한 추가인지 (추가마다 고유)
+type Dot struct {
+	Actor   string `json:"a"`
+	Counter uint64 `json:"n"`
+}
+
+func compareDots(a, b Dot) int {
+	return cmp.Or(cmp.Compare(a.Actor, b.Actor), cmp.Compare(a.Counter, b.Counter))
+}
+
+// ORSet: 관찰-제거 집합 (삭제 표시 없는 방식, ORSWOT)
+//
+// 원소마다 그 원소를 추가한 Dot 목록을 두고, Clock 에 actor 별로 본 마지막 Dot 순번을 둠
+// 제거는 지금 보이는 Dot 만 지우므로, 다른 노드에서 동시에 한 추가(모르는 Dot)는 살아남음 (추가가 이김)
+// 합칠 때 한쪽에만 있는 Dot 은 다른 쪽 Clock 이 이미 본 것이면 제거된 것으로, 아니면 새 추가로 판단
+type ORSet struct {
+	Clock    map[string]uint64 `json:"clock"`
+	Elements map[string][]Dot  `json:"elements"`
+}
+
+// NewORSet: 빈 집합
+func NewORSet() *ORSet {
+	return &ORSet{Clock: make(map[string]uint64), Elements: make(map[string][]Dot)}
+}
+
+func (s *ORSet) Type() Type { return TypeORSet }
+
+// seen: Clock 이 d 를 이미 봤는지
+func (s *ORSet) seen(d Dot) bool {
+	return d.Counter <= s.Clock[d.Actor]
+}
+
+func (s *ORSet) Merge(other CRDT) {
+	o := other.(*ORSet)
+	merged := make(map[string][]Dot)
+	for _, e := range slices.Sorted(maps.Keys(s.Elements)) {
+		for _, d := range s.Elements[e] {
+			if slices.Contains(o.Elements[e], d) || !o.seen(d) {
+				merged[e] = append(merged[e], d)
+			}
+		}
+	}
+	for e, dots := range o.Elements {
+		for _, d := range dots {
+			if !slices.Contains(s.Elements[e], d) && !s.seen(d) {
+				merged[e] = append(merged[e], d)
+			}
+		}
+	}
+	for e := range merged {
+		slices.SortFunc(merged[e], compareDots)
+	}
+	for actor, n := range o.Clock {
+		s.Clock[actor] = max(s.Clock[actor], n)
+	}
+	s.Elements = merged
+}
+
+// Value: 원소 목록 (정렬)
+func (s *ORSet) Value() any {
+	return slices.Sorted(maps.Keys(s.Elements))
+}
+
+// Contains: 원소가 집합에 있는지
+func (s *ORSet) Contains(e string) bool {
+	_, ok := s.Elements[e]
+	return ok
+}
+
+func (s *ORSet) apply(actor string, op Op, now int64) error {
+	if op.Increment != 0 || op.Value != nil || op.Set != nil {
+		return invalidOp(TypeORSet, "only add and remove are supported")
+	}
+	for _, e := range op.Remove {
+		delete(s.Elements, e)
+	}
+	for _, e := range op.Add {
+		// 새 Dot 하나가 지금 보이는 Dot 들을 대신함 (이미 본 추가이므로)
+		s.Clock[actor]++
+		s.Elements[e] = []Dot{{Actor: actor, Counter: s.Clock[actor]}}
+	}
+	return nil
+}
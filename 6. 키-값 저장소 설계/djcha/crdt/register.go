@@ -0,0 +1,115 @@
+package crdt
+
+import (
+	"bytes"
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// LWWRegister: 마지막 쓰기가 이기는 값 하나
+// 시각이 같으면 actor, 값 순서로 비교해서 어느 노드에서 합치든 같은 값을 고름
+type LWWRegister struct {
+	Data      []byte `json:"value"`
+	Timestamp int64  `json:"timestamp"` // UnixNano
+	Actor     string `json:"actor"`
+	Deleted   bool   `json:"deleted,omitempty"` // Map 필드 삭제 표시
+}
+
+func (r *LWWRegister) Type() Type { return TypeLWWRegister }
+
+func (r *LWWRegister) Merge(other CRDT) {
+	r.merge(*other.(*LWWRegister))
+}
+
+func (r *LWWRegister) merge(o LWWRegister) {
+	if r.less(o) {
+		*r = o
+	}
+}
+
+func (r LWWRegister) less(o LWWRegister) bool {
+	return cmp.Or(
+		cmp.Compare(r.Timestamp, o.Timestamp),
+		cmp.Compare(r.Actor, o.Actor),
+		bytes.Compare(r.Data, o.Data),
+		boolCompare(r.Deleted, o.Deleted),
+	) < 0
+}
+
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
+
+// Value: 지금 값
+func (r *LWWRegister) Value() any {
+	return r.Data
+}
+
+func (r *LWWRegister) apply(actor string, op Op, now int64) error {
+	if op.Increment != 0 || op.Add != nil || op.Remove != nil || op.Set != nil {
+		return invalidOp(TypeLWWRegister, "only value is supported")
+	}
+	r.set(actor, op.Value, false, now)
+	return nil
+}
+
+// set: 새 값 (시계가 뒤로 간 노드에서 써도 지금 값을 이기도록 시각을 지금 값 이후로 올림)
+func (r *LWWRegister) set(actor string, value []byte, deleted bool, now int64) {
+	*r = LWWRegister{Data: value, Timestamp: max(now, r.Timestamp+1), Actor: actor, Deleted: deleted}
+}
+
+// Map: 필드 → LWWRegister
+// 필드를 지우면 삭제 표시가 남은 레지스터가 되어, 삭제보다 이전에 쓴 값이 늦게 도착해도 되살아나지 않음
+type Map struct {
+	Fields map[string]LWWRegister `json:"fields"`
+}
+
+func (m *Map) Type() Type { return TypeMap }
+
+func (m *Map) Merge(other CRDT) {
+	for field, r := range other.(*Map).Fields {
+		cur, ok := m.Fields[field]
+		if !ok {
+			m.Fields[field] = r
+			continue
+		}
+		cur.merge(r)
+		m.Fields[field] = cur
+	}
+}
+
+// Value: 지워지지 않은 필드 → 값
+func (m *Map) Value() any {
+	fields := make(map[string][]byte)
+	for field, r := range m.Fields {
+		if !r.Deleted {
+			fields[field] = r.Data
+		}
+	}
+	return fields
+}
+
+func (m *Map) apply(actor string, op Op, now int64) error {
+	if op.Increment != 0 || op.Value != nil || op.Add != nil {
+		return invalidOp(TypeMap, "only set and remove are supported")
+	}
+	for _, field := range slices.Sorted(maps.Keys(op.Set)) {
+		r := m.Fields[field]
+		r.set(actor, op.Set[field], false, now)
+		m.Fields[field] = r
+	}
+	for _, field := range op.Remove {
+		if r, ok := m.Fields[field]; ok && !r.Deleted {
+			r.set(actor, nil, true, now)
+			m.Fields[field] = r
+		}
+	}
+	return nil
+}
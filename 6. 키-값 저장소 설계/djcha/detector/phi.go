@@ -0,0 +1,216 @@
+package detector
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Config: 파이 누적 장애 감지기(Phi Accrual Failure Detector) 설정
+//
+// "살았다/죽었다" 를 바로 정하지 않고, 지금까지 박동(heartbeat) 도착 간격의 분포를 보고
+// "이만큼 소식이 없을 확률" 을 의심 수준 phi 로 계산함
+// phi = 1 이면 틀릴 확률 10%, phi = 8 이면 틀릴 확률 1e-8 정도
+type Config struct {
+	Threshold       float64       // phi 가 이 값을 넘으면 장애로 판단 (카산드라 기본값 8)
+	WindowSize      int           // 평균/표준편차를 계산할 최근 도착 간격 표본 수
+	MinStdDev       time.Duration // 표준편차 하한 (박동이 너무 규칙적이면 아주 작은 지연에도 phi 가 치솟음)
+	AcceptablePause time.Duration // 평균 간격에 더해 주는 허용 지연 (GC 멈춤, 잠깐의 네트워크 지연)
+	FirstInterval   time.Duration // 표본이 없을 때 가정하는 첫 박동 간격
+	Now             func() time.Time
+}
+
+// DefaultConfig: 1초 주기 박동 기준 기본 설정
+func DefaultConfig() Config {
+	return Config{
+		Threshold:       8,
+		WindowSize:      1000,
+		MinStdDev:       100 * time.Millisecond,
+		AcceptablePause: 0,
+		FirstInterval:   time.Second,
+		Now:             time.Now,
+	}
+}
+
+// Event: 노드 상태 변화 (정상 → 장애, 장애 → 정상)
+type Event struct {
+	Node string
+	Up   bool
+	Phi  float64
+	At   time.Time
+}
+
+// Detector: 노드별 박동 도착 시각을 기록하고 phi 를 계산
+type Detector struct {
+	mu        sync.Mutex
+	cfg       Config
+	peers     map[string]*peer
+	listeners []func(Event)
+}
+
+type peer struct {
+	last      time.Time
+	intervals []float64 // 최근 도착 간격 (밀리초), 원형 버퍼
+	next      int
+	sum       float64
+	sumSq     float64
+	down      bool
+}
+
+// New: 감지기 생성
+func New(cfg Config) *Detector {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = 1
+	}
+	return &Detector{cfg: cfg, peers: make(map[string]*peer)}
+}
+
+// Subscribe: 상태 변화 이벤트를 받을 함수 등록
+// 감지기 잠금을 푼 뒤 호출하므로 fn 안에서 감지기를 다시 불러도 됨
+func (d *Detector) Subscribe(fn func(Event)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listeners = append(d.listeners, fn)
+}
+
+// Heartbeat: node 로부터 박동 도착
+// 장애로 판단했던 노드면 정상 복귀 이벤트 발생
+func (d *Detector) Heartbeat(node string) {
+	d.mu.Lock()
+	now := d.cfg.Now()
+	p, ok := d.peers[node]
+	if !ok {
+		// 첫 박동: 표본이 없으면 phi 를 계산할 수 없으므로 예상 간격으로 표본 2개를 채워 둠
+		p = &peer{intervals: make([]float64, 0, d.cfg.WindowSize)}
+		first := float64(d.cfg.FirstInterval.Milliseconds())
+		p.add(first-first/4, d.cfg.WindowSize)
+		p.add(first+first/4, d.cfg.WindowSize)
+		d.peers[node] = p
+	} else {
+		p.add(float64(now.Sub(p.last))/float64(time.Millisecond), d.cfg.WindowSize)
+	}
+	p.last = now
+
+	var events []Event
+	if p.down {
+		p.down = false
+		events = append(events, Event{Node: node, Up: true, Phi: 0, At: now})
+	}
+	listeners := slices.Clone(d.listeners)
+	d.mu.Unlock()
+
+	emit(listeners, events)
+}
+
+// Check: 모든 노드의 phi 를 다시 계산해서 임계값을 넘은 노드에 장애 이벤트 발생
+// 박동이 아예 안 오면 Heartbeat 가 불리지 않으므로 주기적으로 호출해야 함
+func (d *Detector) Check() {
+	d.mu.Lock()
+	now := d.cfg.Now()
+	var events []Event
+	for _, node := range d.sortedPeers() {
+		p := d.peers[node]
+		phi := d.phi(p, now)
+		if !p.down && phi > d.cfg.Threshold {
+			p.down = true
+			events = append(events, Event{Node: node, Up: false, Phi: phi, At: now})
+		}
+	}
+	listeners := slices.Clone(d.listeners)
+	d.mu.Unlock()
+
+	emit(listeners, events)
+}
+
+func emit(listeners []func(Event), events []Event) {
+	for _, e := range events {
+		for _, fn := range listeners {
+			fn(e)
+		}
+	}
+}
+
+// Phi: node 의 현재 의심 수준 (박동을 받은 적 없는 노드는 0)
+func (d *Detector) Phi(node string) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.peers[node]
+	if !ok {
+		return 0
+	}
+	return d.phi(p, d.cfg.Now())
+}
+
+// IsAvailable: phi 가 임계값 이하인지 (박동을 받은 적 없는 노드는 정상으로 봄)
+func (d *Detector) IsAvailable(node string) bool {
+	return d.Phi(node) <= d.cfg.Threshold
+}
+
+// Suspicion: 노드별 현재 phi
+func (d *Detector) Suspicion() map[string]float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.cfg.Now()
+	levels := make(map[string]float64, len(d.peers))
+	for node, p := range d.peers {
+		levels[node] = d.phi(p, now)
+	}
+	return levels
+}
+
+// Remove: 클러스터에서 빠진 노드의 기록 삭제
+func (d *Detector) Remove(node string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.peers, node)
+}
+
+func (d *Detector) sortedPeers() []string {
+	nodes := make([]string, 0, len(d.peers))
+	for node := range d.peers {
+		nodes = append(nodes, node)
+	}
+	slices.Sort(nodes)
+	return nodes
+}
+
+// phi 계산 (아카 클러스터 구현과 같은 로지스틱 근사)
+// 도착 간격이 정규분포를 따른다고 보고, 마지막 박동 이후 경과 시간이 그보다 길 확률 P 를 구해 -log10(P)
+func (d *Detector) phi(p *peer, now time.Time) float64 {
+	n := float64(len(p.intervals))
+	mean := p.sum/n + float64(d.cfg.AcceptablePause.Milliseconds())
+	stdDev := math.Sqrt(math.Max(p.sumSq/n-(p.sum/n)*(p.sum/n), 0))
+	stdDev = math.Max(stdDev, float64(d.cfg.MinStdDev.Milliseconds()))
+	if stdDev == 0 {
+		stdDev = 1
+	}
+
+	elapsed := float64(now.Sub(p.last)) / float64(time.Millisecond)
+	y := (elapsed - mean) / stdDev
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
+
+// add: 도착 간격 표본 추가 (창 크기를 넘으면 가장 오래된 표본을 덮어씀)
+func (p *peer) add(interval float64, window int) {
+	if len(p.intervals) < window {
+		p.intervals = append(p.intervals, interval)
+	} else {
+		old := p.intervals[p.next]
+		p.sum -= old
+		p.sumSq -= old * old
+		p.intervals[p.next] = interval
+		p.next = (p.next + 1) % window
+	}
+	p.sum += interval
+	p.sumSq += interval * interval
+}
@@ -0,0 +1,154 @@
+package detector
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock: 테스트가 직접 움직이는 시계
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestDetector() (*Detector, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cfg := DefaultConfig()
+	cfg.Now = clock.Now
+	return New(cfg), clock
+}
+
+// beat: 1초 간격으로 박동 count 번
+func beat(d *Detector, clock *fakeClock, node string, count int) {
+	for range count {
+		clock.advance(time.Second)
+		d.Heartbeat(node)
+	}
+}
+
+func TestPhiStaysLowWithRegularHeartbeats(t *testing.T) {
+	d, clock := newTestDetector()
+	beat(d, clock, "a", 20)
+
+	clock.advance(time.Second)
+	if phi := d.Phi("a"); phi > 1 {
+		t.Fatalf("phi on schedule = %.2f, want <= 1", phi)
+	}
+	if !d.IsAvailable("a") {
+		t.Fatal("node with regular heartbeats should be available")
+	}
+	if !d.IsAvailable("unknown") || d.Phi("unknown") != 0 {
+		t.Fatal("node never heard from should be available with phi 0")
+	}
+}
+
+func TestPhiGrowsWithDelay(t *testing.T) {
+	d, clock := newTestDetector()
+	beat(d, clock, "a", 20)
+
+	// 박동이 늦어질수록 의심 수준은 계속 올라감
+	prev := d.Phi("a")
+	for range 10 {
+		clock.advance(100 * time.Millisecond)
+		phi := d.Phi("a")
+		if phi < prev {
+			t.Fatalf("phi decreased from %.2f to %.2f while heartbeat was late", prev, phi)
+		}
+		prev = phi
+	}
+
+	// 조금 늦은 박동 (1.2초) 은 장애가 아님
+	d.Heartbeat("a")
+	clock.advance(1200 * time.Millisecond)
+	if !d.IsAvailable("a") {
+		t.Fatalf("slightly delayed heartbeat marked down (phi %.2f)", d.Phi("a"))
+	}
+}
+
+func TestDroppedHeartbeatsEmitDownThenUp(t *testing.T) {
+	d, clock := newTestDetector()
+	var events []Event
+	d.Subscribe(func(e Event) { events = append(events, e) })
+
+	beat(d, clock, "a", 20)
+	beat(d, clock, "b", 20)
+
+	// a 의 박동만 3번 연속 유실
+	for range 3 {
+		clock.advance(time.Second)
+		d.Heartbeat("b")
+		d.Check()
+	}
+	if len(events) != 1 || events[0].Node != "a" || events[0].Up {
+		t.Fatalf("events = %+v, want a single down event for a", events)
+	}
+	if events[0].Phi <= DefaultConfig().Threshold {
+		t.Fatalf("down event phi = %.2f, want above threshold", events[0].Phi)
+	}
+	if d.IsAvailable("a") || !d.IsAvailable("b") {
+		t.Fatalf("availability a=%v b=%v, want a down and b up", d.IsAvailable("a"), d.IsAvailable("b"))
+	}
+
+	// 이미 장애인 노드는 다시 알리지 않음
+	clock.advance(time.Second)
+	d.Check()
+	if len(events) != 1 {
+		t.Fatalf("duplicate down event: %+v", events)
+	}
+
+	d.Heartbeat("a")
+	if len(events) != 2 || events[1].Node != "a" || !events[1].Up {
+		t.Fatalf("events = %+v, want an up event for a after its heartbeat", events)
+	}
+	if !d.IsAvailable("a") {
+		t.Fatal("node should be available right after a heartbeat")
+	}
+}
+
+func TestAcceptablePauseDelaysSuspicion(t *testing.T) {
+	strict, clock := newTestDetector()
+	cfg := DefaultConfig()
+	cfg.Now = clock.Now
+	cfg.AcceptablePause = 3 * time.Second
+	lenient := New(cfg)
+
+	for range 20 {
+		clock.advance(time.Second)
+		strict.Heartbeat("a")
+		lenient.Heartbeat("a")
+	}
+	clock.advance(3 * time.Second)
+	if strict.IsAvailable("a") {
+		t.Fatalf("strict detector phi = %.2f after 3s silence, want down", strict.Phi("a"))
+	}
+	if !lenient.IsAvailable("a") {
+		t.Fatalf("detector with 3s acceptable pause phi = %.2f, want up", lenient.Phi("a"))
+	}
+}
+
+func TestWindowDropsOldIntervals(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cfg := DefaultConfig()
+	cfg.Now = clock.Now
+	cfg.WindowSize = 5
+	d := New(cfg)
+
+	// 처음엔 10초 간격이었다가 1초 간격으로 바뀌면, 창이 작으니 곧 1초 기준으로 판단
+	for range 5 {
+		clock.advance(10 * time.Second)
+		d.Heartbeat("a")
+	}
+	beat(d, clock, "a", 5)
+
+	clock.advance(4 * time.Second)
+	if d.IsAvailable("a") {
+		t.Fatalf("phi = %.2f after 4s silence with 1s window, want down", d.Phi("a"))
+	}
+}
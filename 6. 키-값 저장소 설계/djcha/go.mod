@@ -0,0 +1,3 @@
+module kv-store
+
+go 1.25.3
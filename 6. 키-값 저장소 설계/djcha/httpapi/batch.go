@@ -0,0 +1,179 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"kv-store/cluster"
+	"kv-store/vclock"
+)
+
+// 배치 하나에 넣을 수 있는 최대 작업 수와 본문 크기
+const (
+	maxBatchOps  = 1000
+	maxBatchSize = 16 << 20
+)
+
+// batchRequest: POST /kv/_batch 본문
+//
+//	{"ops": [
+//	  {"op": "put", "key": "a", "value": "aGVsbG8=", "if_match": "\"...\""},
+//	  {"op": "put", "key": "b", "value": "d29ybGQ=", "ttl": "30s", "if_none_match": "*"},
+//	  {"op": "delete", "key": "c", "if_match": "*"}
+//	]}
+//
+// value 는 다른 JSON 응답(형제 값 목록)과 같이 base64
+type batchRequest struct {
+	Ops []batchOp `json:"ops"`
+}
+
+type batchOp struct {
+	Op          string `json:"op"` // "put" / "delete"
+	Key         string `json:"key"`
+	Value       []byte `json:"value"`
+	TTL         string `json:"ttl"`
+	IfMatch     string `json:"if_match"`
+	IfNoneMatch string `json:"if_none_match"`
+}
+
+type batchResult struct {
+	Key  string `json:"key"`
+	ETag string `json:"etag"`
+}
+
+// batch: 여러 키의 쓰기/삭제를 전제 조건과 함께 한꺼번에 적용
+// 전제 조건이 하나라도 틀리면 아무것도 쓰지 않고 412 + 실패한 키 목록
+func (h *handler) batch(w http.ResponseWriter, r *http.Request) {
+	level, err := cluster.ParseConsistency(r.URL.Query().Get("consistency"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	var req batchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON body")
+		return
+	}
+	if len(req.Ops) == 0 || len(req.Ops) > maxBatchOps {
+		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("ops must contain 1 to %d entries", maxBatchOps))
+		return
+	}
+
+	ops := make([]cluster.Op, len(req.Ops))
+	for i, o := range req.Ops {
+		op, err := o.toOp()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("ops[%d]: %v", i, err))
+			return
+		}
+		ops[i] = op
+	}
+
+	versions, err := h.node.Apply(r.Context(), ops, level)
+	if err != nil {
+		writeClusterError(w, err)
+		return
+	}
+	results := make([]batchResult, len(ops))
+	for i, op := range ops {
+		results[i] = batchResult{Key: op.Key, ETag: etag(versions[i])}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+func (o batchOp) toOp() (cluster.Op, error) {
+	if o.Key == "" {
+		return cluster.Op{}, errors.New("key is required")
+	}
+	ttl, err := parseTTL(o.TTL)
+	if err != nil {
+		return cluster.Op{}, err
+	}
+	cond, err := condition(o.IfMatch, o.IfNoneMatch)
+	if err != nil {
+		return cluster.Op{}, err
+	}
+
+	op := cluster.Op{Key: o.Key, TTL: ttl}
+	if cond != nil {
+		op.If = *cond
+	}
+	switch o.Op {
+	case "put":
+		if len(o.Value) > maxValueSize {
+			return cluster.Op{}, errors.New("value too large (max 1MiB)")
+		}
+		op.Data = o.Value
+	case "delete":
+		op.Delete = true
+	default:
+		return cluster.Op{}, fmt.Errorf("unknown op %q (want put or delete)", o.Op)
+	}
+	return op, nil
+}
+
+// etag: 버전(벡터 시계) → ETag 헤더 값
+func etag(version vclock.Clock) string {
+	return `"` + version.Encode() + `"`
+}
+
+// parseCondition: If-Match / If-None-Match 헤더 (둘 다 없으면 nil)
+func parseCondition(header http.Header) (*cluster.Condition, error) {
+	return condition(header.Get("If-Match"), header.Get("If-None-Match"))
+}
+
+func condition(ifMatch, ifNoneMatch string) (*cluster.Condition, error) {
+	ifMatch, ifNoneMatch = strings.TrimSpace(ifMatch), strings.TrimSpace(ifNoneMatch)
+	if ifMatch == "" && ifNoneMatch == "" {
+		return nil, nil
+	}
+
+	var cond cluster.Condition
+	switch {
+	case ifMatch == "*":
+		cond.MustExist = true
+	case ifMatch != "":
+		version, err := vclock.Decode(strings.Trim(ifMatch, `"`))
+		if err != nil {
+			return nil, errors.New("invalid If-Match ETag")
+		}
+		cond.Version = version
+	}
+	switch ifNoneMatch {
+	case "":
+	case "*":
+		cond.MustNotExist = true
+	default:
+		return nil, errors.New("only If-None-Match: * is supported")
+	}
+	return &cond, nil
+}
+
+func parseTTL(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(s)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid ttl %q", s)
+	}
+	return ttl, nil
+}
+
+// writePreconditionFailed: 412 + 전제 조건이 틀린 키와 현재 ETag
+func writePreconditionFailed(w http.ResponseWriter, pe *cluster.PreconditionError) {
+	failed := make([]map[string]string, len(pe.Failed))
+	for i, f := range pe.Failed {
+		failed[i] = map[string]string{"key": f.Key, "reason": f.Reason, "etag": etag(f.Current)}
+	}
+	writeJSON(w, http.StatusPreconditionFailed, map[string]any{
+		"error":   "precondition_failed",
+		"message": pe.Error(),
+		"failed":  failed,
+	})
+}
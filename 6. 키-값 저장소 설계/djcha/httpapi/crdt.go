@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"kv-store/cluster"
+	"kv-store/crdt"
+	"kv-store/store"
+)
+
+// crdtRequest: POST /kv/_crdt/{key} 본문
+//
+//	{"type": "pncounter", "increment": -1}
+//	{"type": "orset", "add": ["user:1"], "remove": ["user:2"]}
+//	{"type": "map", "set": {"name": "a2lt"}, "remove": ["city"]}
+//	{"type": "lww", "value": "aGVsbG8="}
+//
+// 바이트 값(value, set)은 다른 JSON 응답과 같이 base64
+type crdtRequest struct {
+	Type crdt.Type `json:"type"`
+	crdt.Op
+}
+
+// crdtValue: CRDT 값 응답 ({"type": ..., "value": ...})
+type crdtValue struct {
+	Type  crdt.Type `json:"type"`
+	Value any       `json:"value"`
+}
+
+// updateCRDT: CRDT 값에 연산 적용 → 200 적용한 복제본의 값 + ETag
+// 다른 자료형이나 일반 값이 있는 키면 409
+func (h *handler) updateCRDT(w http.ResponseWriter, r *http.Request) {
+	level, err := cluster.ParseConsistency(r.URL.Query().Get("consistency"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	ttl, err := parseTTL(r.URL.Query().Get("ttl"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	var req crdtRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxValueSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON body")
+		return
+	}
+
+	v, err := h.node.Update(r.Context(), r.PathValue("key"), req.Type, req.Op, cluster.WriteOptions{Consistency: level, TTL: ttl})
+	switch {
+	case errors.Is(err, crdt.ErrUnknownType), errors.Is(err, crdt.ErrInvalidOp):
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	case errors.Is(err, cluster.ErrWrongType):
+		writeError(w, http.StatusConflict, "wrong_type", err.Error())
+		return
+	case err != nil:
+		writeClusterError(w, err)
+		return
+	}
+	value, err := decodeCRDT(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	w.Header().Set("ETag", etag(v.Clock))
+	writeJSON(w, http.StatusOK, value)
+}
+
+func decodeCRDT(v store.Value) (crdtValue, error) {
+	c, err := crdt.Decode(v.Type, v.Data)
+	if err != nil {
+		return crdtValue{}, err
+	}
+	return crdtValue{Type: v.Type, Value: c.Value()}, nil
+}
@@ -0,0 +1,248 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"kv-store/cluster"
+	"kv-store/store"
+	"kv-store/vclock"
+)
+
+// 값 하나의 최대 크기
+const maxValueSize = 1 << 20
+
+// 버전 문맥(벡터 시계)을 주고받는 헤더
+// 읽을 때 받은 값을 그대로 다음 쓰기에 넣으면, 그때 읽은 형제 값들을 모두 덮어씀
+const contextHeader = "X-Context"
+
+// NewHandler: 클라이언트용 HTTP API
+//
+//	GET    /kv/{key}?consistency=one|quorum|all&as_of=  → 200 값 (원본 바이트) + ETag (버전)
+//	                                              → 300 형제 값이 여러 개면 JSON 목록
+//	PUT    /kv/{key}?consistency=&ttl=30s        본문 = 값, X-Context 헤더 = 읽을 때 받은 문맥 → 204
+//	DELETE /kv/{key}?consistency=                → 204 / 404
+//	GET    /kv/?prefix=&start=&end=&reverse=&delimiter=&limit=&token=  → 200 키 순서 범위 조회 (JSON)
+//	POST   /kv/_batch?consistency=               JSON 쓰기/삭제 목록을 한 번에 적용 → 200 / 412
+//	POST   /kv/_crdt/{key}?consistency=&ttl=     JSON CRDT 연산 (카운터 증가, 집합 추가/제거 등) → 200 / 409
+//	GET    /kv/_watch?key=|prefix=&revision=&timeout=  키/접두사 변경 감시 (롱 폴링, Accept: text/event-stream 이면 SSE)
+//
+// as_of 를 주면 그 시점의 값을 읽음 (RFC 3339 시각 또는 "5m" 처럼 지금부터 거슬러 올라갈 시간)
+// 노드의 History 보존 기간보다 오래된 시점이면 410
+//
+// PUT / DELETE 에 If-Match: "<ETag>" 를 주면 그 버전일 때만 씀 (compare-and-set)
+// If-Match: * 는 키가 있을 때만, If-None-Match: * 는 키가 없을 때만 씀
+// 조건이 맞지 않으면 412 와 함께 실패한 키와 현재 ETag 를 돌려줌
+//
+// CRDT 값(카운터, 집합, 맵)은 동시에 고쳐도 형제 값 없이 합쳐지고, GET 이 {"type": ..., "value": ...} JSON 으로 돌려줌
+//
+// 요청을 받은 노드가 코디네이터가 되어 복제본들에 전달
+func NewHandler(node *cluster.Node) http.Handler {
+	h := &handler{node: node}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /kv/{$}", h.list)
+	mux.HandleFunc("GET /kv/{key}", h.get)
+	mux.HandleFunc("PUT /kv/{key}", h.put)
+	mux.HandleFunc("DELETE /kv/{key}", h.delete)
+	mux.HandleFunc("POST /kv/_batch", h.batch)
+	mux.HandleFunc("POST /kv/_crdt/{key}", h.updateCRDT)
+	mux.HandleFunc("GET /kv/_watch", h.watch)
+	return mux
+}
+
+type handler struct {
+	node *cluster.Node
+}
+
+func (h *handler) get(w http.ResponseWriter, r *http.Request) {
+	level, err := cluster.ParseConsistency(r.URL.Query().Get("consistency"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	asOf, err := parseAsOf(r.URL.Query().Get("as_of"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	res, err := h.node.Get(r.Context(), r.PathValue("key"), cluster.ReadOptions{Consistency: level, AsOf: asOf})
+	if err != nil {
+		writeClusterError(w, err)
+		return
+	}
+
+	w.Header().Set(contextHeader, res.Context.Encode())
+	w.Header().Set("ETag", etag(res.Context))
+	if len(res.Siblings) > 1 {
+		// 동시에 쓰인 값이 여러 개 → 클라이언트가 직접 합친 뒤 X-Context 를 붙여 다시 써야 함
+		siblings := make([][]byte, len(res.Siblings))
+		for i, v := range res.Siblings {
+			siblings[i] = v.Data
+		}
+		writeJSON(w, http.StatusMultipleChoices, map[string]any{"siblings": siblings})
+		return
+	}
+	if v := res.Siblings[0]; v.Type != "" {
+		value, err := decodeCRDT(v)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, value)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(res.Siblings[0].Data)
+}
+
+// parseAsOf: RFC 3339 시각 또는 지금부터 거슬러 올라갈 시간 ("90s", "5m") (비어 있으면 0 → 지금)
+func parseAsOf(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if ago, err := time.ParseDuration(s); err == nil && ago >= 0 {
+		return time.Now().Add(-ago), nil
+	}
+	at, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid as_of %q (want RFC 3339 time or duration ago)", s)
+	}
+	return at, nil
+}
+
+func (h *handler) put(w http.ResponseWriter, r *http.Request) {
+	level, err := cluster.ParseConsistency(r.URL.Query().Get("consistency"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	ttl, err := parseTTL(r.URL.Query().Get("ttl"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	cond, err := parseCondition(r.Header)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValueSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "too_large", "value too large (max 1MiB)")
+		return
+	}
+
+	key := r.PathValue("key")
+	if cond != nil {
+		h.apply(w, r, cluster.Op{Key: key, Data: data, TTL: ttl, If: *cond}, level)
+		return
+	}
+
+	clock, err := vclock.Decode(r.Header.Get(contextHeader))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid "+contextHeader+" header")
+		return
+	}
+	opts := cluster.WriteOptions{Consistency: level, Context: clock, TTL: ttl}
+	if err := h.node.Put(r.Context(), key, data, opts); err != nil {
+		writeClusterError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) delete(w http.ResponseWriter, r *http.Request) {
+	level, err := cluster.ParseConsistency(r.URL.Query().Get("consistency"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	cond, err := parseCondition(r.Header)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	key := r.PathValue("key")
+	if cond != nil {
+		h.apply(w, r, cluster.Op{Key: key, Delete: true, If: *cond}, level)
+		return
+	}
+
+	// 문맥 없이 지우면 삭제 표시가 기존 값들과 형제가 되므로, 문맥이 없으면 먼저 읽어서 얻음
+	clock, err := vclock.Decode(r.Header.Get(contextHeader))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid "+contextHeader+" header")
+		return
+	}
+	if len(clock) == 0 {
+		res, err := h.node.Get(r.Context(), key, cluster.ReadOptions{Consistency: level})
+		if err != nil {
+			writeClusterError(w, err)
+			return
+		}
+		clock = res.Context
+	}
+	if err := h.node.Delete(r.Context(), key, cluster.WriteOptions{Consistency: level, Context: clock}); err != nil {
+		writeClusterError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apply: 조건부 쓰기 하나 → 204 + 새 ETag
+func (h *handler) apply(w http.ResponseWriter, r *http.Request, op cluster.Op, level cluster.Consistency) {
+	versions, err := h.node.Apply(r.Context(), []cluster.Op{op}, level)
+	if err != nil {
+		writeClusterError(w, err)
+		return
+	}
+	w.Header().Set("ETag", etag(versions[0]))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeClusterError: 코디네이터 오류를 HTTP 상태 코드로 변환
+func writeClusterError(w http.ResponseWriter, err error) {
+	var qe *cluster.QuorumError
+	var pe *cluster.PreconditionError
+	switch {
+	case errors.As(err, &pe):
+		writePreconditionFailed(w, pe)
+	case errors.Is(err, cluster.ErrLocked):
+		writeError(w, http.StatusConflict, "locked", err.Error())
+	case errors.Is(err, store.ErrCompacted):
+		writeError(w, http.StatusGone, "compacted", "requested point in time is older than the retained history")
+	case errors.Is(err, cluster.ErrTokenExpired):
+		writeError(w, http.StatusGone, "token_expired", err.Error())
+	case errors.Is(err, cluster.ErrDuplicateKey), errors.Is(err, cluster.ErrInvalidToken), errors.Is(err, cluster.ErrInvalidRevision):
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+	case errors.Is(err, cluster.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+	case errors.As(err, &qe):
+		writeError(w, http.StatusServiceUnavailable, "quorum_failed", err.Error())
+	case errors.Is(err, cluster.ErrNotEnoughReplicas):
+		writeError(w, http.StatusServiceUnavailable, "unavailable", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, msg string) {
+	writeJSON(w, status, map[string]any{
+		"error":   code,
+		"message": msg,
+	})
+}
@@ -0,0 +1,248 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kv-store/cluster"
+	"kv-store/ring"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	r := ring.New(10)
+	r.Add("node-1")
+	node, err := cluster.NewNode("node-1", cluster.Config{N: 1, R: 1, W: 1, Timeout: time.Second}, r, cluster.NewHTTPTransport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewHandler(node))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, method, url, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestETagCompareAndSet(t *testing.T) {
+	srv := newTestServer(t)
+	url := srv.URL + "/kv/profile"
+
+	if resp := do(t, "PUT", url, "v1", map[string]string{"If-None-Match": "*"}); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("create: status %d", resp.StatusCode)
+	}
+	resp := do(t, "GET", url, "", nil)
+	v1 := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || v1 == "" {
+		t.Fatalf("GET: status %d, ETag %q", resp.StatusCode, v1)
+	}
+
+	resp = do(t, "PUT", url, "v2", map[string]string{"If-Match": v1})
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("ETag") == v1 {
+		t.Fatalf("CAS with current ETag: status %d, new ETag %q", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+
+	resp = do(t, "PUT", url, "v3", map[string]string{"If-Match": v1})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("CAS with stale ETag: status %d, want 412", resp.StatusCode)
+	}
+	var body struct {
+		Failed []map[string]string `json:"failed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Failed) != 1 || body.Failed[0]["key"] != "profile" || body.Failed[0]["reason"] != "version_mismatch" {
+		t.Fatalf("412 body = %+v", body)
+	}
+
+	if resp := do(t, "DELETE", url, "", map[string]string{"If-Match": v1}); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("DELETE with stale ETag: status %d, want 412", resp.StatusCode)
+	}
+	if resp := do(t, "DELETE", url, "", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE: status %d", resp.StatusCode)
+	}
+	if resp := do(t, "GET", url, "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("GET after DELETE: status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	srv := newTestServer(t)
+	do(t, "PUT", srv.URL+"/kv/a", "1", nil)
+
+	// b 의 조건이 틀리므로 a 도 바뀌지 않아야 함
+	resp := do(t, "POST", srv.URL+"/kv/_batch", `{"ops": [
+		{"op": "put", "key": "a", "value": "Mg==", "if_match": "*"},
+		{"op": "delete", "key": "b", "if_match": "*"}
+	]}`, nil)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("batch with failing precondition: status %d, want 412", resp.StatusCode)
+	}
+	resp = do(t, "GET", srv.URL+"/kv/a", "", nil)
+	if data, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusOK || string(data) != "1" {
+		t.Fatalf("GET a after failed batch: status %d, body %q, want 200 1", resp.StatusCode, data)
+	}
+
+	resp = do(t, "POST", srv.URL+"/kv/_batch", `{"ops": [
+		{"op": "put", "key": "a", "value": "Mg==", "if_match": "*"},
+		{"op": "put", "key": "b", "value": "Mw==", "if_none_match": "*", "ttl": "1m"}
+	]}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("batch: status %d", resp.StatusCode)
+	}
+	var body struct {
+		Results []batchResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Results) != 2 || body.Results[1].Key != "b" || body.Results[1].ETag == "" {
+		t.Fatalf("batch results = %+v", body.Results)
+	}
+
+	if resp := do(t, "POST", srv.URL+"/kv/_batch", `{"ops": [{"op": "merge", "key": "a"}]}`, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown op: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestListWithContinuationToken(t *testing.T) {
+	srv := newTestServer(t)
+	for _, key := range []string{"user:1", "user:2", "user:3", "order:1"} {
+		do(t, "PUT", srv.URL+"/kv/"+key, key, nil)
+	}
+
+	var keys []string
+	url := srv.URL + "/kv/?prefix=user:&limit=2&reverse=true"
+	for url != "" {
+		resp := do(t, "GET", url, "", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: status %d", url, resp.StatusCode)
+		}
+		var page struct {
+			Entries   []rangeEntry `json:"entries"`
+			NextToken string       `json:"next_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page.Entries {
+			keys = append(keys, e.Key+"="+string(e.Value))
+		}
+		url = ""
+		if page.NextToken != "" {
+			url = srv.URL + "/kv/?token=" + page.NextToken
+		}
+	}
+	if got, want := strings.Join(keys, ","), "user:3=user:3,user:2=user:2,user:1=user:1"; got != want {
+		t.Fatalf("listed %s, want %s", got, want)
+	}
+
+	if resp := do(t, "GET", srv.URL+"/kv/?token=nope", "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid token: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestCRDTCounter(t *testing.T) {
+	srv := newTestServer(t)
+	url := srv.URL + "/kv/_crdt/views"
+
+	for _, body := range []string{`{"type":"pncounter","increment":5}`, `{"type":"pncounter","increment":-2}`} {
+		if resp := do(t, "POST", url, body, nil); resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST %s: status %d", body, resp.StatusCode)
+		}
+	}
+	resp := do(t, "GET", srv.URL+"/kv/views", "", nil)
+	var got struct {
+		Type  string `json:"type"`
+		Value int64  `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || got.Type != "pncounter" || got.Value != 3 {
+		t.Fatalf("GET views = %d %+v, want pncounter 3", resp.StatusCode, got)
+	}
+
+	if resp := do(t, "POST", url, `{"type":"orset","add":["x"]}`, nil); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("set op on a counter: status %d, want 409", resp.StatusCode)
+	}
+	if resp := do(t, "POST", url, `{"type":"gcounter","increment":-1}`, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("gcounter decrement: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestWatchLongPollAndSSE(t *testing.T) {
+	srv := newTestServer(t)
+
+	type batch struct {
+		Events []struct {
+			Event string `json:"event"`
+			Key   string `json:"key"`
+			Value []byte `json:"value"`
+		} `json:"events"`
+		Revision string `json:"revision"`
+	}
+	poll := func(query string) batch {
+		t.Helper()
+		resp := do(t, "GET", srv.URL+"/kv/_watch?"+query, "", nil)
+		var got batch
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /kv/_watch?%s: status %d, %v", query, resp.StatusCode, err)
+		}
+		return got
+	}
+
+	// 변경이 없으면 timeout 뒤 빈 목록과 지금 리비전
+	idle := poll("prefix=cfg/&timeout=50ms")
+	if len(idle.Events) != 0 || idle.Revision == "" {
+		t.Fatalf("idle watch = %+v, want no events and a revision", idle)
+	}
+	do(t, "PUT", srv.URL+"/kv/cfg%2Fdb", "primary", nil)
+	if got := poll("revision=" + idle.Revision); len(got.Events) != 1 || got.Events[0].Event != "put" ||
+		got.Events[0].Key != "cfg/db" || string(got.Events[0].Value) != "primary" {
+		t.Fatalf("watch after put = %+v", got)
+	}
+
+	// SSE: Last-Event-ID 로 같은 리비전부터 이어 봄
+	resp := do(t, "GET", srv.URL+"/kv/_watch", "", map[string]string{"Accept": "text/event-stream", "Last-Event-ID": idle.Revision})
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var lines []string
+	buf := make([]byte, 4096)
+	for !strings.Contains(strings.Join(lines, ""), "\nid: ") {
+		n, err := resp.Body.Read(buf)
+		if err != nil {
+			t.Fatalf("read stream: %v (got %q)", err, lines)
+		}
+		lines = append(lines, string(buf[:n]))
+	}
+	if stream := strings.Join(lines, ""); !strings.HasPrefix(stream, "event: put\ndata: {") || !strings.Contains(stream, `"key":"cfg/db"`) {
+		t.Fatalf("stream = %q", stream)
+	}
+
+	if resp := do(t, "GET", srv.URL+"/kv/_watch?key=a&prefix=b", "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("key and prefix: status %d, want 400", resp.StatusCode)
+	}
+}
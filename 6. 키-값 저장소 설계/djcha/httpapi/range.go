@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"kv-store/cluster"
+	"kv-store/crdt"
+)
+
+// rangeEntry: 범위 조회 결과의 키 하나
+// 값이 하나면 value, 동시에 쓰인 값이 여러 개면 siblings (GET /kv/{key} 의 300 응답과 같음)
+// CRDT 값이면 type 과 crdt (GET /kv/{key} 의 value 와 같은 값)
+type rangeEntry struct {
+	Key      string    `json:"key"`
+	Value    []byte    `json:"value,omitempty"`
+	Siblings [][]byte  `json:"siblings,omitempty"`
+	Type     crdt.Type `json:"type,omitempty"`
+	CRDT     any       `json:"crdt,omitempty"`
+	ETag     string    `json:"etag"`
+}
+
+// list: 키 순서 범위 조회
+//
+//	GET /kv/?prefix=user:&limit=100
+//	GET /kv/?start=a&end=m&reverse=true
+//	GET /kv/?delimiter=:                      → prefixes 에 네임스페이스 목록
+//	GET /kv/?token=<next_token>               → 다음 페이지 (첫 페이지와 같은 스냅샷)
+//	GET /kv/?as_of=2024-05-01T10:00:00Z       → 그 시점의 스냅샷 (일관된 백업)
+//
+// 응답: {"entries": [...], "prefixes": [...], "next_token": "..."} (next_token 이 없으면 끝)
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := cluster.RangeOptions{
+		Start:     q.Get("start"),
+		End:       q.Get("end"),
+		Prefix:    q.Get("prefix"),
+		Delimiter: q.Get("delimiter"),
+		Token:     q.Get("token"),
+	}
+	asOf, err := parseAsOf(q.Get("as_of"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	opts.AsOf = asOf
+	if s := q.Get("reverse"); s != "" {
+		reverse, err := strconv.ParseBool(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "invalid reverse")
+			return
+		}
+		opts.Reverse = reverse
+	}
+	if s := q.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit <= 0 || limit > cluster.MaxRangeLimit {
+			writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("limit must be between 1 and %d", cluster.MaxRangeLimit))
+			return
+		}
+		opts.Limit = limit
+	}
+
+	page, err := h.node.Range(r.Context(), opts)
+	if err != nil {
+		writeClusterError(w, err)
+		return
+	}
+	entries := make([]rangeEntry, len(page.Entries))
+	for i, e := range page.Entries {
+		entries[i] = newRangeEntry(e.Key, e.Result)
+	}
+	body := map[string]any{"entries": entries}
+	if len(page.Prefixes) > 0 {
+		body["prefixes"] = page.Prefixes
+	}
+	if page.Next != "" {
+		body["next_token"] = page.Next
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// newRangeEntry: 읽은 결과를 응답 형태로 (값 하나, 형제 값 목록, CRDT 값)
+func newRangeEntry(key string, res cluster.Result) rangeEntry {
+	e := rangeEntry{Key: key, ETag: etag(res.Context)}
+	if len(res.Siblings) == 1 {
+		e.Value = res.Siblings[0].Data
+		if res.Siblings[0].Type == "" {
+			return e
+		}
+		if v, err := decodeCRDT(res.Siblings[0]); err == nil {
+			e.Value, e.Type, e.CRDT = nil, v.Type, v.Value
+		}
+		return e
+	}
+	for _, v := range res.Siblings {
+		e.Siblings = append(e.Siblings, v.Data)
+	}
+	return e
+}
@@ -0,0 +1,159 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"kv-store/cluster"
+	"kv-store/store"
+)
+
+// 롱 폴링 기본/최대 대기 시간과, SSE 연결이 끊기지 않도록 빈 주석을 보내는 간격
+const (
+	defaultWatchTimeout = 30 * time.Second
+	maxWatchTimeout     = 5 * time.Minute
+	sseKeepAlive        = 15 * time.Second
+)
+
+// watchEvent: 변경 하나 (event 가 delete 면 값 없음)
+type watchEvent struct {
+	Event store.EventType `json:"event"`
+	rangeEntry
+}
+
+// watch: 키/접두사 변경 감시
+//
+//	GET /kv/_watch?key=cfg/db                 → 변경이 생길 때까지 기다렸다가 {"events": [...], "revision": "..."}
+//	GET /kv/_watch?prefix=cfg/&timeout=60s    → timeout 동안 변경이 없으면 events 가 빈 목록
+//	GET /kv/_watch?revision=<revision>        → 그 리비전 다음 변경부터 (key/prefix 는 리비전에 담긴 것을 씀)
+//
+// Accept: text/event-stream 이면 연결을 유지하며 SSE 로 보냄
+//
+//	event: put | delete
+//	data: {"event": "put", "key": ..., "value": ..., "etag": ...}
+//	id: <revision>                            (배치의 마지막 변경에만. Last-Event-ID 헤더로 이어 봄)
+//
+// 리비전이 이미 정리됐으면 410 compacted (SSE 는 event: error 를 보내고 끊음) → 새로 감시를 시작해야 함
+// 같은 변경이 다시 올 수 있으므로 (최소 한 번 전달) 받는 쪽은 키의 마지막 상태만 반영하면 됨
+func (h *handler) watch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := cluster.WatchOptions{Key: q.Get("key"), Prefix: q.Get("prefix"), Revision: q.Get("revision")}
+	if opts.Key != "" && opts.Prefix != "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "watch either a key or a prefix, not both")
+		return
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		opts.Revision = id
+	}
+	timeout := defaultWatchTimeout
+	if s := q.Get("timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 || d > maxWatchTimeout {
+			writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("timeout must be a duration up to %v", maxWatchTimeout))
+			return
+		}
+		timeout = d
+	}
+
+	watch, err := h.node.Watch(r.Context(), opts)
+	if err != nil {
+		writeWatchError(w, err)
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.streamWatch(w, r, watch)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	batch, err := watch.Next(ctx)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		writeWatchError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"events": watchEvents(batch), "revision": batch.Revision})
+}
+
+// streamWatch: 변경을 SSE 로 계속 보냄 (클라이언트가 끊을 때까지)
+func (h *handler) streamWatch(w http.ResponseWriter, r *http.Request, watch *cluster.Watch) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	for {
+		ctx, cancel := context.WithTimeout(r.Context(), sseKeepAlive)
+		batch, err := watch.Next(ctx)
+		cancel()
+		switch {
+		case r.Context().Err() != nil:
+			return
+		case errors.Is(err, context.DeadlineExceeded):
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case err != nil:
+			code := "internal_error"
+			if errors.Is(err, store.ErrCompacted) {
+				code = "compacted"
+			}
+			b, _ := json.Marshal(map[string]any{"error": code, "message": err.Error()})
+			_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", b)
+			_ = rc.Flush()
+			return
+		default:
+			err = writeSSE(w, batch)
+		}
+		if err != nil || rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// writeSSE: 배치를 SSE 이벤트로 씀. 배치를 다 받기 전에 끊겨도 빠지는 변경이 없도록 리비전은 마지막 이벤트에만 붙임
+func writeSSE(w http.ResponseWriter, batch cluster.WatchBatch) error {
+	events := watchEvents(batch)
+	for i, e := range events {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n", e.Event, b); err != nil {
+			return err
+		}
+		if i == len(events)-1 {
+			if _, err := fmt.Fprintf(w, "id: %s\n", batch.Revision); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprint(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func watchEvents(batch cluster.WatchBatch) []watchEvent {
+	events := make([]watchEvent, len(batch.Events))
+	for i, e := range batch.Events {
+		events[i] = watchEvent{Event: e.Type, rangeEntry: rangeEntry{Key: e.Key}}
+		if e.Type == store.EventPut {
+			events[i].rangeEntry = newRangeEntry(e.Key, e.Result)
+		}
+	}
+	return events
+}
+
+// writeWatchError: 감시 오류 (정리된 리비전은 새로 시작하라는 뜻으로 410)
+func writeWatchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrCompacted) {
+		writeError(w, http.StatusGone, "compacted", "watch revision is older than the retained history; start a new watch")
+		return
+	}
+	writeClusterError(w, err)
+}
@@ -0,0 +1,94 @@
+package lsm
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+)
+
+// ErrCorruptFilter: 블룸 필터 인코딩이 틀림
+var ErrCorruptFilter = errors.New("lsm: corrupt bloom filter")
+
+// Bloom: "이 키는 확실히 없음" 을 디스크를 읽지 않고 알려 주는 블룸 필터
+//   - MayContain 이 false 면 키가 절대 없음 → 그 SSTable 은 읽지 않고 건너뜀
+//   - true 면 있을 수도 있음 (거짓 양성률만큼은 없는 키에도 true)
+//
+// 키 해시 하나에서 해시 두 개를 만들고, 둘을 섞어 k 개의 위치를 만드는 방식 (Kirsch-Mitzenmacher)
+type Bloom struct {
+	bits []byte
+	k    int
+}
+
+// NewBloom: 키 n 개를 넣었을 때 거짓 양성률이 fpRate 가 되도록 크기를 정한 빈 필터
+//   - 비트 수 m = -n·ln(p) / (ln 2)²
+//   - 해시 개수 k = (m/n)·ln 2
+func NewBloom(n int, fpRate float64) *Bloom {
+	n = max(n, 1)
+	fpRate = min(max(fpRate, 1e-9), 0.5)
+	m := int(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	return &Bloom{bits: make([]byte, (m+7)/8), k: min(max(k, 1), 30)}
+}
+
+// Add: 키 추가
+func (b *Bloom) Add(key string) {
+	b.addHash(bloomHash(key))
+}
+
+// MayContain: 키가 있을 수도 있으면 true (false 면 확실히 없음)
+func (b *Bloom) MayContain(key string) bool {
+	return b.mayContainHash(bloomHash(key))
+}
+
+func (b *Bloom) addHash(h uint64) {
+	m := uint64(len(b.bits) * 8)
+	h1, h2 := h, mix(h^0x9e3779b97f4a7c15)|1
+	for i := range uint64(b.k) {
+		pos := (h1 + i*h2) % m
+		b.bits[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+func (b *Bloom) mayContainHash(h uint64) bool {
+	m := uint64(len(b.bits) * 8)
+	h1, h2 := h, mix(h^0x9e3779b97f4a7c15)|1
+	for i := range uint64(b.k) {
+		pos := (h1 + i*h2) % m
+		if b.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode: 비트 배열 뒤에 해시 개수 1 Byte 를 붙인 인코딩 (SSTable 에 그대로 저장)
+func (b *Bloom) Encode() []byte {
+	return append(append(make([]byte, 0, len(b.bits)+1), b.bits...), byte(b.k))
+}
+
+// DecodeBloom: Encode 로 만든 필터 복원
+func DecodeBloom(data []byte) (*Bloom, error) {
+	if len(data) < 2 || data[len(data)-1] == 0 || data[len(data)-1] > 30 {
+		return nil, ErrCorruptFilter
+	}
+	return &Bloom{bits: data[:len(data)-1], k: int(data[len(data)-1])}, nil
+}
+
+// bloomHash: 프로세스가 바뀌어도 같은 값이 나오는 64 비트 해시 (파일에 저장한 필터를 다시 써야 하므로)
+// FNV-1a 는 상위 비트가 고르지 않아서 한 번 더 섞음
+func bloomHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return mix(h.Sum64())
+}
+
+// mix: splitmix64 마무리 단계 (입력 비트를 출력 전체에 고르게 퍼뜨림)
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
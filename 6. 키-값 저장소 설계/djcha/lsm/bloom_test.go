@@ -0,0 +1,39 @@
+package lsm
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	for _, p := range []float64{0.1, 0.01, 0.001} {
+		const n = 20000
+		b := NewBloom(n, p)
+		for i := range n {
+			b.Add("key-" + strconv.Itoa(i))
+		}
+		decoded, err := DecodeBloom(b.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range n {
+			if !decoded.MayContain("key-" + strconv.Itoa(i)) {
+				t.Fatalf("p=%v: false negative for key-%d", p, i)
+			}
+		}
+
+		fp := 0
+		for i := range n {
+			if decoded.MayContain("missing-" + strconv.Itoa(i)) {
+				fp++
+			}
+		}
+		if rate := float64(fp) / n; rate > 1.5*p {
+			t.Errorf("p=%v: measured false positive rate %.4f", p, rate)
+		}
+	}
+
+	if _, err := DecodeBloom([]byte{1}); err != ErrCorruptFilter {
+		t.Fatalf("err = %v, want ErrCorruptFilter", err)
+	}
+}
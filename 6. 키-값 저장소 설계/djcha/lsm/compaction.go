@@ -0,0 +1,385 @@
+package lsm
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Strategy: 어떤 SSTable 들을 언제 합칠지 정하는 압축 전략
+//   - Leveled: 레벨마다 키 범위가 겹치지 않게 유지 → 점 조회가 레벨당 테이블 하나만 봄 (읽기 위주에 유리, 쓰기 증폭이 큼)
+//   - SizeTiered: 비슷한 크기의 테이블이 쌓이면 합침 → 쓰기 증폭이 작음 (대신 조회가 여러 테이블을 봐야 해서 읽기 증폭이 큼)
+//
+// 전략을 바꿔서 다시 열어도 됨 (크기 계층은 L0 만 합치고, 이미 있는 아래 레벨은 그대로 읽음)
+type Strategy interface {
+	String() string
+	// plan: 지금 테이블 구성에서 할 압축 하나 (할 게 없으면 nil)
+	// cursors[l] 은 레벨 l 에서 마지막으로 합친 테이블의 가장 큰 키 (레벨 압축이 돌아가며 고르도록)
+	plan(levels [][]*table, cursors []string) *compaction
+}
+
+// Leveled: LevelDB/RocksDB 방식 레벨 압축
+//   - L0: 메모리 테이블을 내린 테이블 (서로 키 범위가 겹침). L0Tables 개가 쌓이면 전부 L1 과 합침
+//   - L1 이하: 레벨 안에서는 키 범위가 겹치지 않음. 레벨 크기가 상한(L1 은 BaseBytes, 아래로 갈수록 Multiplier 배)을 넘으면
+//     테이블 하나를 골라 아래 레벨의 겹치는 테이블과 합침
+type Leveled struct {
+	L0Tables   int   // L0 에 이만큼 쌓이면 L1 로 합침
+	BaseBytes  int64 // L1 크기 상한
+	Multiplier int   // 아래 레벨로 갈 때마다 크기 상한 배수
+	TableBytes int64 // 압축 결과를 이 크기 단위 테이블로 나눔
+	Levels     int   // 레벨 수 (마지막 레벨은 더 내려가지 않음)
+}
+
+// DefaultLeveled: L0 4 개, L1 10 MB, 10 배씩, 테이블 2 MB, 7 레벨
+var DefaultLeveled = Leveled{
+	L0Tables:   4,
+	BaseBytes:  10 << 20,
+	Multiplier: 10,
+	TableBytes: 2 << 20,
+	Levels:     7,
+}
+
+func (l Leveled) String() string { return "leveled" }
+
+func (l Leveled) withDefaults() Leveled {
+	d := DefaultLeveled
+	if l.L0Tables > 0 {
+		d.L0Tables = l.L0Tables
+	}
+	if l.BaseBytes > 0 {
+		d.BaseBytes = l.BaseBytes
+	}
+	if l.Multiplier > 1 {
+		d.Multiplier = l.Multiplier
+	}
+	if l.TableBytes > 0 {
+		d.TableBytes = l.TableBytes
+	}
+	if l.Levels > 1 {
+		d.Levels = l.Levels
+	}
+	return d
+}
+
+// maxBytes: 레벨 level(≥1) 의 크기 상한
+func (l Leveled) maxBytes(level int) int64 {
+	size := l.BaseBytes
+	for range level - 1 {
+		size *= int64(l.Multiplier)
+	}
+	return size
+}
+
+// plan: 상한 대비 가장 많이 넘친 레벨을 아래 레벨로 합침
+func (l Leveled) plan(levels [][]*table, cursors []string) *compaction {
+	l = l.withDefaults()
+	best, bestScore := -1, 0.0
+	for level := 0; level < min(len(levels), l.Levels-1); level++ {
+		var score float64
+		if level == 0 {
+			score = float64(len(levels[0])) / float64(l.L0Tables)
+		} else {
+			score = float64(levelBytes(levels[level])) / float64(l.maxBytes(level))
+		}
+		if score >= 1 && score > bestScore {
+			best, bestScore = level, score
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+
+	var upper []*table
+	if best == 0 {
+		upper = slices.Clone(levels[0])
+	} else {
+		// 지난번에 합친 곳 다음 테이블 (끝까지 갔으면 처음부터)
+		tables := levels[best]
+		i := slices.IndexFunc(tables, func(t *table) bool { return t.meta.smallest > cursors[best] })
+		upper = []*table{tables[max(i, 0)]}
+	}
+	smallest, largest := keyRange(upper)
+	var lower []*table
+	if best+1 < len(levels) {
+		lower = overlapping(levels[best+1], smallest, largest)
+	}
+	c := &compaction{level: best, output: best + 1, inputs: [][]*table{upper, lower}, tableBytes: l.TableBytes}
+
+	// 겹치는 게 없는 테이블 하나는 다시 쓰지 않고 아래 레벨로 옮기기만 함 (쓰기 증폭 없음)
+	if len(upper) == 1 && len(lower) == 0 {
+		c.move = true
+		return c
+	}
+	smallest, largest = keyRange(append(slices.Clone(upper), lower...))
+	c.dropTombstones = true
+	for _, tables := range levels[min(best+2, len(levels)):] {
+		if len(overlapping(tables, smallest, largest)) > 0 {
+			c.dropTombstones = false // 더 아래에 지운 키의 옛 값이 남아 있을 수 있음
+		}
+	}
+	return c
+}
+
+// SizeTiered: Cassandra 방식 크기 계층 압축
+// 모든 테이블을 L0 에 두고, 만든 순서로 이웃한 테이블 중 크기가 비슷한(평균의 BucketLow ~ BucketHigh 배) 것이
+// MinThreshold 개 이상 모이면 최대 MaxThreshold 개를 하나로 합침
+// 이웃한 테이블끼리만 합치므로 L0 의 최신 순서가 유지됨 (조회가 처음 찾은 값에서 멈출 수 있음)
+type SizeTiered struct {
+	MinThreshold int
+	MaxThreshold int
+	BucketLow    float64
+	BucketHigh   float64
+	MinBytes     int64 // 이보다 작은 테이블끼리는 크기와 상관없이 같은 묶음
+}
+
+// DefaultSizeTiered: 4 ~ 32 개, 평균의 0.5 ~ 1.5 배, 1 MB 미만은 한 묶음
+var DefaultSizeTiered = SizeTiered{
+	MinThreshold: 4,
+	MaxThreshold: 32,
+	BucketLow:    0.5,
+	BucketHigh:   1.5,
+	MinBytes:     1 << 20,
+}
+
+func (s SizeTiered) String() string { return "size-tiered" }
+
+func (s SizeTiered) withDefaults() SizeTiered {
+	d := DefaultSizeTiered
+	if s.MinThreshold > 1 {
+		d.MinThreshold = s.MinThreshold
+	}
+	if s.MaxThreshold > 0 {
+		d.MaxThreshold = max(s.MaxThreshold, d.MinThreshold)
+	}
+	if s.BucketLow > 0 {
+		d.BucketLow = s.BucketLow
+	}
+	if s.BucketHigh > 0 {
+		d.BucketHigh = s.BucketHigh
+	}
+	if s.MinBytes > 0 {
+		d.MinBytes = s.MinBytes
+	}
+	return d
+}
+
+// plan: 비슷한 크기 묶음 중 평균이 가장 작은 것을 합침 (작은 테이블일수록 빨리 합쳐서 조회할 테이블 수를 줄임)
+func (s SizeTiered) plan(levels [][]*table, _ []string) *compaction {
+	s = s.withDefaults()
+	if len(levels) == 0 {
+		return nil
+	}
+	tables := levels[0]
+	similar := func(size int64, total int64, n int) bool {
+		avg := float64(total) / float64(n)
+		if size < s.MinBytes && avg < float64(s.MinBytes) {
+			return true
+		}
+		return float64(size) >= s.BucketLow*avg && float64(size) <= s.BucketHigh*avg
+	}
+
+	bestStart, bestEnd, bestAvg := -1, -1, 0.0
+	for start := 0; start < len(tables); {
+		end, total := start+1, tables[start].meta.size
+		for end < len(tables) && similar(tables[end].meta.size, total, end-start) {
+			total += tables[end].meta.size
+			end++
+		}
+		if n := end - start; n >= s.MinThreshold {
+			if avg := float64(total) / float64(n); bestStart < 0 || avg < bestAvg {
+				bestStart, bestEnd, bestAvg = start, end, avg
+			}
+		}
+		start = end
+	}
+	if bestStart < 0 {
+		return nil
+	}
+	// 너무 많으면 오래된 쪽부터 MaxThreshold 개
+	bestStart = max(bestStart, bestEnd-s.MaxThreshold)
+	return &compaction{
+		level:  0,
+		output: 0,
+		inputs: [][]*table{slices.Clone(tables[bestStart:bestEnd])},
+		// 가장 오래된 테이블까지 합치면 지운 키의 옛 값이 더 남아 있을 곳이 없음 (아래 레벨이 없을 때)
+		dropTombstones: bestEnd == len(tables) && levelsBelowEmpty(levels, 0),
+	}
+}
+
+// compaction: 압축 작업 하나
+type compaction struct {
+	level, output  int
+	inputs         [][]*table // [입력 레벨, 결과 레벨] 에서 고른 테이블 (크기 계층은 하나)
+	tableBytes     int64      // 결과를 나누는 크기 (0 이면 나누지 않음)
+	dropTombstones bool
+	move           bool // 다시 쓰지 않고 레벨만 옮김
+}
+
+func (c *compaction) all() []*table {
+	return slices.Concat(c.inputs...)
+}
+
+func (c *compaction) String() string {
+	var nums []string
+	for _, t := range c.all() {
+		nums = append(nums, fmt.Sprint(t.num))
+	}
+	return fmt.Sprintf("L%d→L%d [%s]", c.level, c.output, strings.Join(nums, " "))
+}
+
+// apply: levels 에서 입력 테이블을 빼고 결과 테이블을 넣은 새 목록
+func (c *compaction) apply(levels [][]*table, outputs []*table) [][]*table {
+	next := make([][]*table, max(len(levels), c.output+1))
+	for i := range levels {
+		next[i] = slices.Clone(levels[i])
+	}
+	inputs := c.all()
+	if c.level == 0 && c.output == 0 {
+		// 크기 계층: 합친 자리에 그대로 넣음 (그동안 새로 내린 테이블은 앞에 있음)
+		at := slices.Index(next[0], inputs[0])
+		next[0] = slices.DeleteFunc(next[0], func(t *table) bool { return slices.Contains(inputs, t) })
+		next[0] = slices.Insert(next[0], at, outputs...)
+		return next
+	}
+	for i := range next {
+		next[i] = slices.DeleteFunc(next[i], func(t *table) bool { return slices.Contains(inputs, t) })
+	}
+	next[c.output] = append(next[c.output], outputs...)
+	slices.SortFunc(next[c.output], func(a, b *table) int { return strings.Compare(a.meta.smallest, b.meta.smallest) })
+	return next
+}
+
+func levelBytes(tables []*table) int64 {
+	var size int64
+	for _, t := range tables {
+		size += t.meta.size
+	}
+	return size
+}
+
+func keyRange(tables []*table) (smallest, largest string) {
+	for i, t := range tables {
+		if i == 0 || t.meta.smallest < smallest {
+			smallest = t.meta.smallest
+		}
+		if i == 0 || t.meta.largest > largest {
+			largest = t.meta.largest
+		}
+	}
+	return smallest, largest
+}
+
+func overlapping(tables []*table, smallest, largest string) []*table {
+	var out []*table
+	for _, t := range tables {
+		if t.meta.overlaps(smallest, largest) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func levelsBelowEmpty(levels [][]*table, level int) bool {
+	for _, tables := range levels[level+1:] {
+		if len(tables) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeIter: 여러 테이블을 키 순서로 합쳐 읽음 (같은 키는 순번이 가장 큰 것 하나만)
+type mergeIter struct {
+	h   iterHeap
+	cur entry
+	err error
+}
+
+func newMergeIter(iters []*tableIter) *mergeIter {
+	m := &mergeIter{}
+	for _, it := range iters {
+		if it.advance() {
+			m.h = append(m.h, it)
+		} else if it.err != nil {
+			m.err = it.err
+		}
+	}
+	heap.Init(&m.h)
+	return m
+}
+
+func (m *mergeIter) advance() bool {
+	if m.err != nil || len(m.h) == 0 {
+		return false
+	}
+	m.cur = m.h[0].cur
+	// 같은 키의 옛 버전은 모두 건너뜀
+	for len(m.h) > 0 && m.h[0].cur.key == m.cur.key {
+		it := m.h[0]
+		if it.advance() {
+			heap.Fix(&m.h, 0)
+		} else {
+			if it.err != nil {
+				m.err = it.err
+				return false
+			}
+			heap.Pop(&m.h)
+		}
+	}
+	return true
+}
+
+type iterHeap []*tableIter
+
+func (h iterHeap) Len() int { return len(h) }
+func (h iterHeap) Less(i, j int) bool {
+	if h[i].cur.key != h[j].cur.key {
+		return h[i].cur.key < h[j].cur.key
+	}
+	return h[i].cur.seq > h[j].cur.seq
+}
+func (h iterHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *iterHeap) Push(x any)   { *h = append(*h, x.(*tableIter)) }
+func (h *iterHeap) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+// throttle: 압축이 읽고 쓴 양이 시작 후 지난 시간 × 속도 상한을 넘지 않도록 기다림
+// 속도 상한은 매번 rate() 로 읽으므로 압축 중에 SetCompactionRate 로 바꿔도 바로 반영됨
+type throttle struct {
+	rate  func() int64
+	start time.Time
+	done  int64
+}
+
+func newThrottle(rate func() int64) *throttle {
+	return &throttle{rate: rate, start: time.Now()}
+}
+
+func (t *throttle) wait(ctx context.Context, n int) error {
+	t.done += int64(n)
+	rate := t.rate()
+	if rate <= 0 {
+		t.start, t.done = time.Now(), 0 // 제한이 없던 동안 처리한 양은 나중에 제한이 걸려도 셈하지 않음
+		return nil
+	}
+	due := t.start.Add(time.Duration(float64(t.done) / float64(rate) * float64(time.Second)))
+	d := time.Until(due)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,754 @@
+// Package lsm: 디스크 기반 LSM 트리 키-값 엔진
+//
+// 쓰기는 WAL 에 남긴 뒤 메모리 테이블에 넣고, 메모리 테이블이 차면 정렬해서 SSTable 파일(L0)로 내림
+// 쌓인 SSTable 은 백그라운드에서 압축 전략(Leveled / SizeTiered)에 따라 합쳐서 옛 버전과 삭제 표시를 정리함
+// 읽기는 메모리 테이블 → L0 (최신 순) → L1 … 순서로 보고 처음 찾은 버전을 돌려줌
+// SSTable 마다 블룸 필터가 있어서 키가 없는 파일은 읽지 않고 건너뜀
+//
+// 디렉터리 구성
+//   - MANIFEST: 지금 쓰는 SSTable 목록 (레벨별)
+//   - NNNNNN.sst: SSTable
+//   - NNNNNN.log: 아직 SSTable 로 내리지 않은 쓰기의 WAL
+package lsm
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrNotFound: 키가 없음 (지워진 키 포함)
+	ErrNotFound = errors.New("lsm: key not found")
+	// ErrClosed: 닫힌 DB 를 씀
+	ErrClosed = errors.New("lsm: db is closed")
+)
+
+// Config: 엔진 설정
+type Config struct {
+	MemtableBytes int      // 메모리 테이블이 이 크기를 넘으면 SSTable 로 내림
+	BlockBytes    int      // SSTable 블록 크기 (점 조회 한 번에 읽는 양)
+	Compaction    Strategy // 압축 전략 (nil 이면 DefaultLeveled)
+	// FilterFalsePositive: SSTable 블룸 필터의 거짓 양성률 (0 이면 필터를 만들지 않음)
+	// 낮출수록 키당 필터 크기가 커지고(1% ≈ 9.6 bit, 0.1% ≈ 14.4 bit) 없는 키를 찾느라 읽는 파일이 줄어듦
+	FilterFalsePositive float64
+	// CompactionBytesPerSecond: 압축이 읽고 쓰는 양의 상한 (0 이면 제한 없음) → 압축 중에도 조회에 디스크 여유를 남김
+	// 열린 뒤에는 SetCompactionRate 로 바꿈
+	CompactionBytesPerSecond int64
+	SyncWrites               bool // 쓰기마다 WAL 을 fsync (끄면 OS 가 버퍼를 내리기 전에 죽었을 때 마지막 쓰기를 잃을 수 있음)
+}
+
+// DefaultConfig: 메모리 테이블 4 MB, 블록 4 KB, 레벨 압축, 거짓 양성률 1%, 압축 속도 제한 없음
+var DefaultConfig = Config{
+	MemtableBytes:       4 << 20,
+	BlockBytes:          4 << 10,
+	Compaction:          DefaultLeveled,
+	FilterFalsePositive: 0.01,
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.MemtableBytes <= 0:
+		return fmt.Errorf("memtable size must be positive, got %d", c.MemtableBytes)
+	case c.BlockBytes <= 0:
+		return fmt.Errorf("block size must be positive, got %d", c.BlockBytes)
+	case c.FilterFalsePositive < 0 || c.FilterFalsePositive >= 1:
+		return fmt.Errorf("filter false positive rate must be in [0, 1), got %v", c.FilterFalsePositive)
+	}
+	return nil
+}
+
+// DB: 디렉터리 하나를 쓰는 LSM 트리 (여러 고루틴에서 같이 써도 됨)
+type DB struct {
+	dir      string
+	cfg      Config
+	strategy Strategy
+
+	mu       sync.Mutex
+	changed  *sync.Cond // 메모리 테이블을 내렸거나 압축 하나가 끝남
+	mem      *memtable
+	imm      *memtable // 내리는 중인 메모리 테이블 (없으면 nil)
+	log      *wal
+	immLog   uint64 // imm 의 WAL 번호
+	current  *version
+	cursors  []string // 레벨마다 마지막으로 합친 키 (Strategy.plan 참고)
+	seq      uint64
+	nextFile uint64
+	err      error // 메모리 테이블을 내리다 실패 (이후 쓰기는 모두 실패)
+	compErr  error // 마지막 압축 실패 (다음 플러시 후 다시 시도)
+	busy     bool  // 압축 중
+	closed   bool
+
+	rate        atomic.Int64
+	flushKick   chan struct{}
+	compactKick chan struct{}
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	stats       counters
+}
+
+// version: 어느 시점의 SSTable 목록
+// 조회가 도는 동안 압축이 끝나도 읽던 파일이 지워지지 않도록 참조 수를 셈 (refs 는 DB.mu 로 보호)
+type version struct {
+	levels [][]*table
+	refs   int
+}
+
+// memtable: 아직 SSTable 로 내리지 않은 쓰기 (키마다 마지막 버전만)
+type memtable struct {
+	entries map[string]entry
+	size    int
+}
+
+func newMemtable() *memtable {
+	return &memtable{entries: make(map[string]entry)}
+}
+
+func (m *memtable) put(e entry) {
+	if old, ok := m.entries[e.key]; ok {
+		m.size -= old.size()
+	}
+	m.entries[e.key] = e
+	m.size += e.size()
+}
+
+func (m *memtable) sorted() []entry {
+	return slices.SortedFunc(maps.Values(m.entries), func(a, b entry) int { return strings.Compare(a.key, b.key) })
+}
+
+// Open: dir 의 DB 를 엶 (없으면 만듦)
+// 지난번에 내리지 못한 WAL 은 다시 읽어서 SSTable 로 내리고, 목록에 없는 파일(끝나지 못한 압축 결과)은 지움
+func Open(dir string, cfg Config) (*DB, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	m, _, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{
+		dir:         dir,
+		cfg:         cfg,
+		strategy:    cmp.Or[Strategy](cfg.Compaction, DefaultLeveled),
+		mem:         newMemtable(),
+		nextFile:    max(m.NextFile, 1),
+		flushKick:   make(chan struct{}, 1),
+		compactKick: make(chan struct{}, 1),
+	}
+	db.changed = sync.NewCond(&db.mu)
+	db.rate.Store(cfg.CompactionBytesPerSecond)
+
+	levels, err := db.openTables(m)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := db.cleanup(m, levels)
+	if err != nil {
+		closeTables(levels)
+		return nil, err
+	}
+	for _, tables := range levels {
+		for _, t := range tables {
+			db.seq = max(db.seq, t.meta.maxSeq)
+		}
+	}
+
+	// 남은 WAL 을 번호 순서대로 다시 읽어서 한 테이블로 내림
+	for _, num := range logs {
+		err := replayWAL(walName(dir, num), func(e entry) {
+			db.mem.put(e)
+			db.seq = max(db.seq, e.seq)
+		})
+		if err != nil {
+			closeTables(levels)
+			return nil, err
+		}
+	}
+	if len(db.mem.entries) > 0 {
+		num := db.nextFile
+		db.nextFile++
+		t, err := db.writeMemtable(db.mem, num)
+		if err != nil {
+			closeTables(levels)
+			return nil, err
+		}
+		levels = prependL0(levels, t)
+		db.mem = newMemtable()
+	}
+
+	num := db.nextFile
+	db.nextFile++
+	if db.log, err = createWAL(dir, num, cfg.SyncWrites); err != nil {
+		closeTables(levels)
+		return nil, err
+	}
+	if err := db.writeManifest(levels, num); err != nil {
+		db.log.close()
+		closeTables(levels)
+		return nil, err
+	}
+	for _, old := range logs {
+		os.Remove(walName(dir, old))
+	}
+	db.install(levels)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	db.cancel = cancel
+	db.wg.Go(func() { db.flushLoop(ctx) })
+	db.wg.Go(func() { db.compactLoop(ctx) })
+	kick(db.compactKick)
+	return db, nil
+}
+
+// openTables: MANIFEST 에 있는 SSTable 을 모두 엶
+func (db *DB) openTables(m manifest) ([][]*table, error) {
+	levels := make([][]*table, len(m.Levels))
+	for i, nums := range m.Levels {
+		for _, num := range nums {
+			t, err := openTable(tableName(db.dir, num), num)
+			if err != nil {
+				closeTables(levels)
+				return nil, err
+			}
+			levels[i] = append(levels[i], t)
+		}
+	}
+	return levels, nil
+}
+
+// cleanup: 목록에 없는 SSTable, 이미 내린 WAL, 임시 파일을 지우고 다시 읽을 WAL 번호를 돌려줌
+func (db *DB) cleanup(m manifest, levels [][]*table) ([]uint64, error) {
+	live := make(map[uint64]bool)
+	for _, tables := range levels {
+		for _, t := range tables {
+			live[t.num] = true
+		}
+	}
+	names, err := os.ReadDir(db.dir)
+	if err != nil {
+		return nil, err
+	}
+	var logs []uint64
+	for _, de := range names {
+		name := de.Name()
+		if strings.HasPrefix(name, manifestName+".tmp-") {
+			os.Remove(filepath.Join(db.dir, name))
+			continue
+		}
+		base, ext, ok := strings.Cut(name, ".")
+		num, err := strconv.ParseUint(base, 10, 64)
+		if !ok || err != nil {
+			continue
+		}
+		db.nextFile = max(db.nextFile, num+1)
+		switch {
+		case ext == "sst" && !live[num], ext == "log" && num < m.Log:
+			os.Remove(filepath.Join(db.dir, name))
+		case ext == "log":
+			logs = append(logs, num)
+		}
+	}
+	slices.Sort(logs)
+	return logs, nil
+}
+
+// Put: 키에 값을 씀
+func (db *DB) Put(key string, value []byte) error {
+	return db.write(entry{key: key, value: slices.Clone(value)})
+}
+
+// Delete: 키를 지움 (삭제 표시를 쓰고, 압축이 더 아래에 옛 값이 없다고 확인할 때 사라짐)
+func (db *DB) Delete(key string) error {
+	return db.write(entry{key: key, deleted: true})
+}
+
+func (db *DB) write(e entry) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if err := db.makeRoom(false); err != nil {
+		return err
+	}
+	db.seq++
+	e.seq = db.seq
+	if err := db.log.append(e); err != nil {
+		return err
+	}
+	db.mem.put(e)
+	db.stats.user.Add(int64(len(e.key) + len(e.value)))
+	return nil
+}
+
+// makeRoom: 메모리 테이블이 찼으면(force 면 비어 있지 않으면) 새 메모리 테이블로 바꾸고 내리기 시작
+// 앞의 메모리 테이블을 아직 내리는 중이면 끝날 때까지 기다림 (쓰기 지연)
+func (db *DB) makeRoom(force bool) error {
+	for {
+		switch {
+		case db.closed:
+			return ErrClosed
+		case db.err != nil:
+			return db.err
+		case !force && db.mem.size < db.cfg.MemtableBytes, force && len(db.mem.entries) == 0:
+			return nil
+		case db.imm != nil:
+			db.stats.stalls.Add(1)
+			db.changed.Wait()
+			continue
+		}
+
+		num := db.nextFile
+		db.nextFile++
+		log, err := createWAL(db.dir, num, db.cfg.SyncWrites)
+		if err != nil {
+			return err
+		}
+		if err := db.log.close(); err != nil {
+			log.close()
+			os.Remove(walName(db.dir, num))
+			return err
+		}
+		db.imm, db.immLog = db.mem, db.log.num
+		db.mem, db.log = newMemtable(), log
+		kick(db.flushKick)
+		return nil
+	}
+}
+
+// Flush: 메모리 테이블을 지금 SSTable 로 내리고 끝날 때까지 기다림
+func (db *DB) Flush() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if err := db.makeRoom(true); err != nil {
+		return err
+	}
+	for db.imm != nil && db.err == nil && !db.closed {
+		db.changed.Wait()
+	}
+	return db.err
+}
+
+// WaitIdle: 내리는 중인 메모리 테이블과 할 압축이 모두 없어질 때까지 기다림 (압축이 실패했으면 그 에러)
+func (db *DB) WaitIdle() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for {
+		switch {
+		case db.closed:
+			return ErrClosed
+		case db.err != nil:
+			return db.err
+		case db.imm != nil || db.busy:
+		case db.compErr != nil:
+			return db.compErr
+		case db.strategy.plan(db.current.levels, db.cursorsFor(db.current.levels)) == nil:
+			return nil
+		}
+		db.changed.Wait()
+	}
+}
+
+// SetCompactionRate: 압축 I/O 속도 상한 변경 (0 이면 제한 없음, 진행 중인 압축에도 바로 반영)
+func (db *DB) SetCompactionRate(bytesPerSecond int64) {
+	db.rate.Store(bytesPerSecond)
+}
+
+// Get: 키의 최신 값 (없거나 지워졌으면 ErrNotFound)
+func (db *DB) Get(key string) ([]byte, error) {
+	db.stats.gets.Add(1)
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return nil, ErrClosed
+	}
+	for _, m := range []*memtable{db.mem, db.imm} {
+		if m == nil {
+			continue
+		}
+		if e, ok := m.entries[key]; ok {
+			db.mu.Unlock()
+			return found(e)
+		}
+	}
+	v := db.current
+	v.refs++
+	db.mu.Unlock()
+	defer db.release(v)
+
+	for level, tables := range v.levels {
+		candidates := tables
+		if level > 0 {
+			// L1 이하는 키 범위가 겹치지 않으므로 키를 담을 수 있는 테이블은 많아야 하나
+			i, _ := slices.BinarySearchFunc(tables, key, func(t *table, key string) int {
+				return strings.Compare(t.meta.largest, key)
+			})
+			candidates = tables[i:min(i+1, len(tables))]
+		}
+		for _, t := range candidates {
+			if key < t.meta.smallest || key > t.meta.largest {
+				continue
+			}
+			if t.filter != nil {
+				db.stats.filterChecks.Add(1)
+				if !t.filter.MayContain(key) {
+					db.stats.filterNegatives.Add(1)
+					continue
+				}
+			}
+			e, ok, read, err := t.get(key)
+			if read {
+				db.stats.blockReads.Add(1)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return found(e)
+			}
+			if t.filter != nil {
+				db.stats.filterFalsePositives.Add(1)
+			}
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func found(e entry) ([]byte, error) {
+	if e.deleted {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(e.value), nil
+}
+
+// Close: 백그라운드 작업을 멈추고 파일을 닫음
+// 메모리 테이블은 내리지 않음 (WAL 에 있으므로 다음에 열 때 다시 읽음), 진행 중인 압축은 버림
+func (db *DB) Close() error {
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return nil
+	}
+	db.closed = true
+	db.changed.Broadcast()
+	db.mu.Unlock()
+
+	db.cancel()
+	db.wg.Wait()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	err := db.log.close()
+	db.releaseLocked(db.current)
+	return err
+}
+
+// flushLoop: 가득 찬 메모리 테이블을 L0 SSTable 로 내림
+func (db *DB) flushLoop(ctx context.Context) {
+	for {
+		select {
+		case <-db.flushKick:
+		case <-ctx.Done():
+			return
+		}
+
+		db.mu.Lock()
+		imm := db.imm
+		db.mu.Unlock()
+		if imm == nil {
+			continue
+		}
+		num := db.newFileNum()
+
+		t, err := db.writeMemtable(imm, num)
+		db.mu.Lock()
+		if err == nil {
+			levels := prependL0(db.current.levels, t)
+			if err = db.writeManifest(levels, db.log.num); err == nil {
+				db.install(levels)
+				os.Remove(walName(db.dir, db.immLog))
+				db.imm = nil
+			} else {
+				discard([]*table{t})
+			}
+		}
+		if err != nil {
+			db.err = fmt.Errorf("lsm: flush memtable: %w", err)
+		}
+		db.changed.Broadcast()
+		db.mu.Unlock()
+		kick(db.compactKick)
+	}
+}
+
+// writeMemtable: 메모리 테이블을 키 순서로 SSTable 하나에 씀 (삭제 표시도 그대로 씀)
+func (db *DB) writeMemtable(m *memtable, num uint64) (*table, error) {
+	tw, err := newTableWriter(tableName(db.dir, num), db.cfg, func(n int) error {
+		db.stats.flushed.Add(int64(n))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range m.sorted() {
+		if err := tw.add(e); err != nil {
+			tw.abort()
+			return nil, err
+		}
+	}
+	return tw.finish(num)
+}
+
+// compactLoop: 전략이 고른 압축을 하나씩 실행 (할 게 없으면 플러시를 기다림)
+func (db *DB) compactLoop(ctx context.Context) {
+	for {
+		db.mu.Lock()
+		v := db.current
+		c := db.strategy.plan(v.levels, db.cursorsFor(v.levels))
+		db.busy = c != nil
+		if c == nil {
+			db.changed.Broadcast()
+			db.mu.Unlock()
+			select {
+			case <-db.compactKick:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		v.refs++
+		db.compErr = nil
+		db.mu.Unlock()
+
+		outputs, err := db.compact(ctx, c)
+		db.mu.Lock()
+		if err == nil {
+			levels := c.apply(db.current.levels, outputs)
+			if err = db.writeManifest(levels, db.logNumLocked()); err == nil {
+				db.install(levels)
+				db.cursors = db.cursorsFor(levels)
+				_, db.cursors[c.level] = keyRange(c.inputs[0])
+				if c.move {
+					db.stats.moves.Add(1)
+				} else {
+					db.stats.compactions.Add(1)
+				}
+			}
+		}
+		if err != nil && !c.move {
+			discard(outputs)
+		}
+		db.releaseLocked(v)
+		db.busy = false
+		db.changed.Broadcast()
+		db.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			db.mu.Lock()
+			db.compErr = fmt.Errorf("lsm: compaction %v: %w", c, err)
+			db.changed.Broadcast()
+			db.mu.Unlock()
+			// 같은 압축을 곧바로 다시 시도하지 않고 다음 플러시까지 기다림
+			select {
+			case <-db.compactKick:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// compact: 입력 테이블을 합쳐 새 테이블을 씀 (옛 버전과, 더 아래에 옛 값이 없는 삭제 표시는 버림)
+func (db *DB) compact(ctx context.Context, c *compaction) ([]*table, error) {
+	if c.move {
+		return c.inputs[0], nil
+	}
+	th := newThrottle(db.rate.Load)
+	onRead := func(n int) error {
+		db.stats.compactRead.Add(int64(n))
+		return th.wait(ctx, n)
+	}
+	onWrite := func(n int) error {
+		db.stats.compactWritten.Add(int64(n))
+		return th.wait(ctx, n)
+	}
+
+	var iters []*tableIter
+	for _, t := range c.all() {
+		iters = append(iters, t.iter(onRead))
+	}
+	m := newMergeIter(iters)
+
+	var (
+		outputs []*table
+		tw      *tableWriter
+		num     uint64
+		err     error
+	)
+	for m.advance() {
+		e := m.cur
+		if e.deleted && c.dropTombstones {
+			continue
+		}
+		if tw == nil {
+			num = db.newFileNum()
+			if tw, err = newTableWriter(tableName(db.dir, num), db.cfg, onWrite); err != nil {
+				discard(outputs)
+				return nil, err
+			}
+		}
+		if err := tw.add(e); err != nil {
+			tw.abort()
+			discard(outputs)
+			return nil, err
+		}
+		if c.tableBytes > 0 && tw.estimatedSize() >= c.tableBytes {
+			t, err := tw.finish(num)
+			tw = nil
+			if err != nil {
+				discard(outputs)
+				return nil, err
+			}
+			outputs = append(outputs, t)
+		}
+	}
+	if m.err != nil {
+		if tw != nil {
+			tw.abort()
+		}
+		discard(outputs)
+		return nil, m.err
+	}
+	if tw != nil {
+		t, err := tw.finish(num)
+		if err != nil {
+			discard(outputs)
+			return nil, err
+		}
+		outputs = append(outputs, t)
+	}
+	return outputs, nil
+}
+
+func (db *DB) newFileNum() uint64 {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	num := db.nextFile
+	db.nextFile++
+	return num
+}
+
+// logNumLocked: 아직 SSTable 로 내리지 않은 가장 오래된 WAL 번호
+func (db *DB) logNumLocked() uint64 {
+	if db.imm != nil {
+		return db.immLog
+	}
+	return db.log.num
+}
+
+// cursorsFor: 레벨 수에 맞춘 cursors
+func (db *DB) cursorsFor(levels [][]*table) []string {
+	for len(db.cursors) < len(levels)+1 {
+		db.cursors = append(db.cursors, "")
+	}
+	return db.cursors
+}
+
+func (db *DB) writeManifest(levels [][]*table, log uint64) error {
+	m := manifest{NextFile: db.nextFile, Log: log, Levels: make([][]uint64, len(levels))}
+	for i, tables := range levels {
+		m.Levels[i] = []uint64{}
+		for _, t := range tables {
+			m.Levels[i] = append(m.Levels[i], t.num)
+		}
+	}
+	return writeManifest(db.dir, m)
+}
+
+// install: levels 를 지금 버전으로 바꿈 (빠진 테이블은 마지막 조회가 끝나면 지워짐)
+func (db *DB) install(levels [][]*table) {
+	v := &version{levels: levels, refs: 1}
+	live := make(map[*table]bool)
+	for _, tables := range levels {
+		for _, t := range tables {
+			t.ref()
+			live[t] = true
+		}
+	}
+	old := db.current
+	db.current = v
+	if old == nil {
+		return
+	}
+	for _, tables := range old.levels {
+		for _, t := range tables {
+			if !live[t] {
+				t.obsolete.Store(true)
+			}
+		}
+	}
+	db.releaseLocked(old)
+}
+
+func (db *DB) release(v *version) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.releaseLocked(v)
+}
+
+func (db *DB) releaseLocked(v *version) {
+	v.refs--
+	if v.refs > 0 {
+		return
+	}
+	for _, tables := range v.levels {
+		for _, t := range tables {
+			t.unref()
+		}
+	}
+}
+
+func prependL0(levels [][]*table, t *table) [][]*table {
+	next := make([][]*table, max(len(levels), 1))
+	copy(next, levels)
+	next[0] = append([]*table{t}, next[0]...)
+	return next
+}
+
+// discard: 목록에 넣지 못한 압축 결과를 닫고 지움
+func discard(tables []*table) {
+	for _, t := range tables {
+		t.obsolete.Store(true)
+		t.ref()
+		t.unref()
+	}
+}
+
+// closeTables: 버전에 넣기 전에 연 테이블을 닫음 (Open 실패)
+func closeTables(levels [][]*table) {
+	for _, tables := range levels {
+		for _, t := range tables {
+			t.f.Close()
+		}
+	}
+}
+
+func kick(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,344 @@
+package lsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// 파일이 여러 개 생기고 압축이 여러 번 돌도록 아주 작게 잡은 설정
+func smallConfig(s Strategy) Config {
+	return Config{
+		MemtableBytes:       4 << 10,
+		BlockBytes:          256,
+		Compaction:          s,
+		FilterFalsePositive: 0.01,
+	}
+}
+
+var testStrategies = []Strategy{
+	Leveled{L0Tables: 2, BaseBytes: 16 << 10, Multiplier: 4, TableBytes: 8 << 10},
+	SizeTiered{MinThreshold: 3, MaxThreshold: 8, MinBytes: 4 << 10},
+}
+
+func open(t *testing.T, dir string, cfg Config) *DB {
+	t.Helper()
+	db, err := Open(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// check: DB 의 모든 키가 model 과 같은지
+func check(t *testing.T, db *DB, model map[string][]byte, keys int) {
+	t.Helper()
+	for i := range keys {
+		key := fmt.Sprintf("key-%04d", i)
+		got, err := db.Get(key)
+		want, ok := model[key]
+		switch {
+		case !ok && !errors.Is(err, ErrNotFound):
+			t.Fatalf("%s: got %q, %v; want not found", key, got, err)
+		case ok && (err != nil || string(got) != string(want)):
+			t.Fatalf("%s: got %q, %v; want %q", key, got, err, want)
+		}
+	}
+}
+
+func TestDBMatchesModelAcrossCompactionsAndReopen(t *testing.T) {
+	for _, s := range testStrategies {
+		t.Run(s.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := smallConfig(s)
+			db := open(t, dir, cfg)
+			rng := rand.New(rand.NewPCG(1, 2))
+			model := make(map[string][]byte)
+			const keys = 500
+
+			for i := range 6000 {
+				key := fmt.Sprintf("key-%04d", rng.IntN(keys))
+				if rng.IntN(5) == 0 {
+					if err := db.Delete(key); err != nil {
+						t.Fatal(err)
+					}
+					delete(model, key)
+					continue
+				}
+				value := fmt.Appendf(nil, "%s@%d", key, i)
+				if err := db.Put(key, value); err != nil {
+					t.Fatal(err)
+				}
+				model[key] = value
+			}
+			if err := db.WaitIdle(); err != nil {
+				t.Fatal(err)
+			}
+			check(t, db, model, keys)
+
+			st := db.Stats()
+			if st.Compactions == 0 {
+				t.Fatalf("no compactions ran: %+v", st)
+			}
+			if _, ok := s.(Leveled); ok {
+				checkLevelsDisjoint(t, db)
+			}
+
+			// 메모리 테이블에 남은 쓰기는 WAL 에서 다시 읽음
+			if err := db.Close(); err != nil {
+				t.Fatal(err)
+			}
+			db = open(t, dir, cfg)
+			defer db.Close()
+			check(t, db, model, keys)
+		})
+	}
+}
+
+// checkLevelsDisjoint: 레벨 압축에서 L1 이하 테이블의 키 범위가 겹치지 않는지
+func checkLevelsDisjoint(t *testing.T, db *DB) {
+	t.Helper()
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for level, tables := range db.current.levels {
+		if level == 0 {
+			continue
+		}
+		for i := 1; i < len(tables); i++ {
+			if tables[i-1].meta.largest >= tables[i].meta.smallest {
+				t.Fatalf("L%d tables %d and %d overlap", level, tables[i-1].num, tables[i].num)
+			}
+		}
+	}
+}
+
+func TestDeletedKeysStayDeletedAfterCompaction(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir, smallConfig(testStrategies[0]))
+	defer db.Close()
+
+	for round := range 3 {
+		for i := range 300 {
+			key := fmt.Sprintf("key-%04d", i)
+			if round == 1 && i%2 == 0 {
+				if err := db.Delete(key); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			if round == 2 && i%2 == 0 {
+				continue // 지운 키를 다시 쓰지 않음 → 옛 값이 되살아나면 안 됨
+			}
+			if err := db.Put(key, fmt.Appendf(nil, "%d", round)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := db.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.WaitIdle(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 300 {
+		_, err := db.Get(fmt.Sprintf("key-%04d", i))
+		if i%2 == 0 && !errors.Is(err, ErrNotFound) {
+			t.Fatalf("deleted key-%04d came back: %v", i, err)
+		}
+		if i%2 == 1 && err != nil {
+			t.Fatalf("key-%04d: %v", i, err)
+		}
+	}
+}
+
+// 같은 쓰기에 대해 레벨 압축은 쓰기 증폭이 크고, 필터는 없는 키 조회 대부분을 걸러 냄
+// 압축 시점이 매번 같도록 메모리 테이블을 직접 내리고 압축이 끝날 때까지 기다림
+func TestStatsCompareStrategies(t *testing.T) {
+	stats := make(map[string]Stats)
+	for _, s := range testStrategies {
+		cfg := smallConfig(s)
+		cfg.MemtableBytes = 1 << 20
+		db := open(t, t.TempDir(), cfg)
+		for i := range 20000 {
+			key := fmt.Sprintf("key-%05d", (i*7919)%4000)
+			if err := db.Put(key, fmt.Appendf(nil, "value-%d", i)); err != nil {
+				t.Fatal(err)
+			}
+			if i%200 == 199 {
+				if err := db.Flush(); err != nil {
+					t.Fatal(err)
+				}
+				if err := db.WaitIdle(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		for i := range 4000 {
+			if _, err := db.Get(fmt.Sprintf("key-%05d", i)); err != nil {
+				t.Fatal(err)
+			}
+			// 테이블 키 범위 안에 있지만 없는 키 → 필터가 걸러야 함
+			if _, err := db.Get(fmt.Sprintf("key-%05d-missing", i)); !errors.Is(err, ErrNotFound) {
+				t.Fatal(err)
+			}
+		}
+		stats[s.String()] = db.Stats()
+		db.Close()
+	}
+
+	leveled, tiered := stats["leveled"], stats["size-tiered"]
+	t.Logf("leveled: write amp %.2f, read amp %.2f, filter hit %.3f, fp %.4f",
+		leveled.WriteAmplification, leveled.ReadAmplification, leveled.FilterHitRate, leveled.FilterFalsePositiveRate)
+	t.Logf("size-tiered: write amp %.2f, read amp %.2f, filter hit %.3f, fp %.4f",
+		tiered.WriteAmplification, tiered.ReadAmplification, tiered.FilterHitRate, tiered.FilterFalsePositiveRate)
+
+	if leveled.WriteAmplification <= tiered.WriteAmplification {
+		t.Errorf("leveled write amplification %.2f <= size-tiered %.2f", leveled.WriteAmplification, tiered.WriteAmplification)
+	}
+	for name, st := range stats {
+		if st.WriteAmplification < 1 {
+			t.Errorf("%s: write amplification %.2f < 1", name, st.WriteAmplification)
+		}
+		// 있는 키는 블록 하나, 없는 키는 거의 0 → 조회 두 번에 블록 하나 남짓
+		if st.ReadAmplification > 0.6 {
+			t.Errorf("%s: read amplification %.2f", name, st.ReadAmplification)
+		}
+		if st.FilterHitRate < 0.4 {
+			t.Errorf("%s: filter hit rate %.3f", name, st.FilterHitRate)
+		}
+		if st.FilterFalsePositiveRate > 0.03 {
+			t.Errorf("%s: filter false positive rate %.4f", name, st.FilterFalsePositiveRate)
+		}
+	}
+}
+
+// 압축이 테이블을 바꾸는 동안에도 조회가 읽던 파일이 지워지지 않아야 함
+func TestReadsDuringCompaction(t *testing.T) {
+	db := open(t, t.TempDir(), smallConfig(testStrategies[0]))
+	defer db.Close()
+	for i := range 200 {
+		if err := db.Put(fmt.Sprintf("key-%04d", i), []byte("v0")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := db.Get(fmt.Sprintf("key-%04d", i%200)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+	for round := 1; round <= 30; round++ {
+		for i := range 200 {
+			if err := db.Put(fmt.Sprintf("key-%04d", i), fmt.Appendf(nil, "v%d", round)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	close(done)
+	wg.Wait()
+	if err := db.WaitIdle(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.Get("key-0000"); err != nil || string(got) != "v30" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+}
+
+func TestFilterDisabledReadsEveryCandidate(t *testing.T) {
+	cfg := smallConfig(SizeTiered{MinThreshold: 100})
+	cfg.FilterFalsePositive = 0
+	db := open(t, t.TempDir(), cfg)
+	defer db.Close()
+	for i := range 2000 {
+		if err := db.Put(fmt.Sprintf("key-%05d", i), []byte("value")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 100 {
+		db.Get(fmt.Sprintf("key-%05da", i)) // 테이블 범위 안이지만 없는 키
+	}
+	if st := db.Stats(); st.FilterChecks != 0 || st.BlockReads == 0 {
+		t.Fatalf("filter checks %d, block reads %d", st.FilterChecks, st.BlockReads)
+	}
+}
+
+func TestThrottleLimitsCompactionRate(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		rate := int64(1 << 20)
+		th := newThrottle(func() int64 { return rate })
+		start := time.Now()
+		for range 64 {
+			if err := th.wait(context.Background(), 64<<10); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// 4 MB 를 1 MB/s 로
+		if elapsed := time.Since(start); elapsed != 4*time.Second {
+			t.Fatalf("elapsed %v, want 4s", elapsed)
+		}
+
+		// 제한을 풀면 바로 진행
+		rate = 0
+		start = time.Now()
+		for range 64 {
+			th.wait(context.Background(), 64<<10)
+		}
+		if elapsed := time.Since(start); elapsed != 0 {
+			t.Fatalf("unthrottled elapsed %v", elapsed)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		rate = 1
+		if err := th.wait(ctx, 1<<20); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want deadline exceeded", err)
+		}
+	})
+}
+
+func TestSizeTieredMergesOnlyNeighbours(t *testing.T) {
+	mk := func(num uint64, size int64) *table {
+		return &table{num: num, meta: tableMeta{size: size}}
+	}
+	// 최신 순: 작은 테이블 3 개, 큰 테이블, 작은 테이블 2 개
+	l0 := []*table{mk(9, 10<<10), mk(8, 11<<10), mk(7, 9<<10), mk(6, 400<<10), mk(5, 10<<10), mk(4, 10<<10)}
+	s := SizeTiered{MinThreshold: 3, MinBytes: 1 << 10}
+	c := s.plan([][]*table{l0}, nil)
+	if c == nil {
+		t.Fatal("no compaction planned")
+	}
+	var nums []uint64
+	for _, t := range c.all() {
+		nums = append(nums, t.num)
+	}
+	if !slices.Equal(nums, []uint64{9, 8, 7}) || c.dropTombstones {
+		t.Fatalf("inputs %v, drop tombstones %v", nums, c.dropTombstones)
+	}
+
+	merged := mk(10, 30<<10)
+	next := c.apply([][]*table{l0}, []*table{merged})
+	if got := next[0]; len(got) != 4 || got[0] != merged || got[1] != l0[3] {
+		t.Fatalf("merged table not placed where its inputs were")
+	}
+}
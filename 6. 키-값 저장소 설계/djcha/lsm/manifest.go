@@ -0,0 +1,58 @@
+package lsm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const manifestName = "MANIFEST"
+
+// manifest: 지금 쓰는 SSTable 목록 (레벨별, 읽는 순서대로)
+// 플러시나 압축이 끝날 때마다 통째로 새로 씀 → 목록에 없는 .sst 는 끝나지 못한 작업의 결과이므로 열 때 지움
+type manifest struct {
+	NextFile uint64     `json:"next_file"` // 다음에 쓸 파일 번호
+	Log      uint64     `json:"log"`       // 이 번호 이상의 WAL 만 아직 SSTable 로 내리지 않은 쓰기
+	Levels   [][]uint64 `json:"levels"`    // 레벨마다 테이블 번호 (L0 은 최신 순, 나머지는 키 순)
+}
+
+func readManifest(dir string) (manifest, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if os.IsNotExist(err) {
+		return manifest{}, false, nil
+	}
+	if err != nil {
+		return manifest{}, false, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return manifest{}, false, err
+	}
+	return m, true, nil
+}
+
+// writeManifest: 임시 파일에 쓰고 fsync 한 뒤 이름을 바꿈 (중간에 죽어도 예전 목록이나 새 목록만 남음)
+func writeManifest(dir string, m manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, manifestName+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 이름을 바꾼 뒤에는 이미 없으므로 무시됨
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, manifestName))
+}
@@ -0,0 +1,479 @@
+package lsm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrCorruptTable: SSTable 형식이 틀리거나 체크섬이 맞지 않음
+var ErrCorruptTable = errors.New("lsm: corrupt sstable")
+
+// SSTable 파일 형식
+//
+//	블록... | 인덱스 | 블룸 필터 | 푸터(40)
+//	블록: 항목... | CRC32(4)
+//	항목: 키 길이 | 키 | 순번 | 종류(값/삭제) | 값 길이 | 값   (길이와 순번은 uvarint)
+//	인덱스: 가장 작은 키 | 가장 큰 키 | 순번 범위 | 항목 수 | 블록마다 (마지막 키, 위치, 크기)
+//	푸터: 인덱스 위치 | 인덱스 크기 | 필터 위치 | 필터 크기 (리틀 엔디안 uint64) | 매직(8)
+//
+// 인덱스와 필터는 열 때 메모리에 올려 두고 블록만 필요할 때 읽음 → 점 조회는 블록 하나만 읽음
+const (
+	tableMagic      = "KVLSMTB1"
+	tableFooterSize = 40
+)
+
+const (
+	kindValue  byte = 1
+	kindDelete byte = 2
+)
+
+// entry: 키의 한 버전 (삭제 표시 포함)
+type entry struct {
+	key     string
+	value   []byte
+	seq     uint64
+	deleted bool
+}
+
+func (e entry) size() int {
+	return len(e.key) + len(e.value) + 16
+}
+
+// blockHandle: 블록 하나의 위치와 마지막 키
+type blockHandle struct {
+	last   string
+	offset int64
+	length int64 // CRC 포함
+}
+
+// tableMeta: 테이블의 키/순번 범위 (겹치는 테이블을 찾고 읽는 순서를 정할 때 씀)
+type tableMeta struct {
+	smallest, largest string
+	minSeq, maxSeq    uint64
+	entries           int
+	size              int64
+}
+
+func (m tableMeta) overlaps(smallest, largest string) bool {
+	return m.smallest <= largest && smallest <= m.largest
+}
+
+// table: 열린 SSTable (읽기 전용)
+// 압축으로 쓸모없어진 뒤에도 읽는 중인 버전이 남아 있을 수 있으므로 참조 수가 0 이 되면 닫고 지움
+type table struct {
+	num    uint64
+	path   string
+	f      *os.File
+	meta   tableMeta
+	index  []blockHandle
+	filter *Bloom // 필터 없이 만든 테이블이면 nil
+
+	refs     atomic.Int32
+	obsolete atomic.Bool
+}
+
+func tableName(dir string, num uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%06d.sst", num))
+}
+
+// tableWriter: 정렬된 항목을 받아 SSTable 파일을 씀
+type tableWriter struct {
+	path      string
+	f         *os.File
+	w         *bufio.Writer
+	cfg       Config
+	offset    int64
+	block     []byte
+	blockLast string
+	index     []blockHandle
+	hashes    []uint64
+	meta      tableMeta
+	onWrite   func(n int) error // 블록을 쓸 때마다 (압축 I/O 제한과 지표)
+}
+
+func newTableWriter(path string, cfg Config, onWrite func(n int) error) (*tableWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &tableWriter{path: path, f: f, w: bufio.NewWriter(f), cfg: cfg, onWrite: onWrite}, nil
+}
+
+// add: 항목 추가 (키 순서대로, 키마다 한 번씩 불러야 함)
+func (tw *tableWriter) add(e entry) error {
+	if tw.meta.entries == 0 {
+		tw.meta.smallest, tw.meta.minSeq = e.key, e.seq
+	}
+	tw.meta.largest = e.key
+	tw.meta.minSeq = min(tw.meta.minSeq, e.seq)
+	tw.meta.maxSeq = max(tw.meta.maxSeq, e.seq)
+	tw.meta.entries++
+	tw.hashes = append(tw.hashes, bloomHash(e.key))
+
+	tw.block = appendEntry(tw.block, e)
+	tw.blockLast = e.key
+	if len(tw.block) >= tw.cfg.BlockBytes {
+		return tw.flushBlock()
+	}
+	return nil
+}
+
+// estimatedSize: 지금까지 쓴 크기 (압축 결과를 TableBytes 로 나눌 때 씀)
+func (tw *tableWriter) estimatedSize() int64 {
+	return tw.offset + int64(len(tw.block))
+}
+
+func (tw *tableWriter) flushBlock() error {
+	if len(tw.block) == 0 {
+		return nil
+	}
+	tw.block = binary.LittleEndian.AppendUint32(tw.block, crc32.ChecksumIEEE(tw.block))
+	if err := tw.write(tw.block); err != nil {
+		return err
+	}
+	tw.index = append(tw.index, blockHandle{last: tw.blockLast, offset: tw.offset - int64(len(tw.block)), length: int64(len(tw.block))})
+	tw.block = tw.block[:0]
+	return nil
+}
+
+func (tw *tableWriter) write(b []byte) error {
+	if _, err := tw.w.Write(b); err != nil {
+		return err
+	}
+	tw.offset += int64(len(b))
+	if tw.onWrite != nil {
+		return tw.onWrite(len(b))
+	}
+	return nil
+}
+
+// finish: 남은 블록, 인덱스, 필터, 푸터를 쓰고 fsync 한 뒤 읽기용으로 다시 엶
+func (tw *tableWriter) finish(num uint64) (*table, error) {
+	if err := tw.flushBlock(); err != nil {
+		tw.abort()
+		return nil, err
+	}
+
+	indexOff := tw.offset
+	if err := tw.write(encodeIndex(tw.meta, tw.index)); err != nil {
+		tw.abort()
+		return nil, err
+	}
+	filterOff := tw.offset
+	if tw.cfg.FilterFalsePositive > 0 {
+		bloom := NewBloom(len(tw.hashes), tw.cfg.FilterFalsePositive)
+		for _, h := range tw.hashes {
+			bloom.addHash(h)
+		}
+		if err := tw.write(bloom.Encode()); err != nil {
+			tw.abort()
+			return nil, err
+		}
+	}
+	footer := make([]byte, 0, tableFooterSize)
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(indexOff))
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(filterOff-indexOff))
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(filterOff))
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(tw.offset-filterOff))
+	footer = append(footer, tableMagic...)
+	if err := tw.write(footer); err != nil {
+		tw.abort()
+		return nil, err
+	}
+
+	if err := tw.w.Flush(); err != nil {
+		tw.abort()
+		return nil, err
+	}
+	if err := tw.f.Sync(); err != nil {
+		tw.abort()
+		return nil, err
+	}
+	if err := tw.f.Close(); err != nil {
+		os.Remove(tw.path)
+		return nil, err
+	}
+	return openTable(tw.path, num)
+}
+
+// abort: 쓰던 파일을 버림
+func (tw *tableWriter) abort() {
+	tw.f.Close()
+	os.Remove(tw.path)
+}
+
+// openTable: SSTable 파일을 열어 인덱스와 필터를 메모리에 올림
+func openTable(path string, num uint64) (*table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := readTable(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	t.num, t.path = num, path
+	return t, nil
+}
+
+func readTable(f *os.File) (*table, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < tableFooterSize {
+		return nil, ErrCorruptTable
+	}
+	footer := make([]byte, tableFooterSize)
+	if _, err := f.ReadAt(footer, size-tableFooterSize); err != nil {
+		return nil, err
+	}
+	if string(footer[32:]) != tableMagic {
+		return nil, ErrCorruptTable
+	}
+	indexOff := int64(binary.LittleEndian.Uint64(footer[0:]))
+	indexLen := int64(binary.LittleEndian.Uint64(footer[8:]))
+	filterOff := int64(binary.LittleEndian.Uint64(footer[16:]))
+	filterLen := int64(binary.LittleEndian.Uint64(footer[24:]))
+	if indexOff < 0 || indexLen < 0 || filterLen < 0 || indexOff+indexLen != filterOff || filterOff+filterLen != size-tableFooterSize {
+		return nil, ErrCorruptTable
+	}
+
+	buf := make([]byte, indexLen+filterLen)
+	if _, err := f.ReadAt(buf, indexOff); err != nil {
+		return nil, err
+	}
+	meta, index, err := decodeIndex(buf[:indexLen])
+	if err != nil {
+		return nil, err
+	}
+	meta.size = size
+	t := &table{f: f, meta: meta, index: index}
+	if filterLen > 0 {
+		if t.filter, err = DecodeBloom(buf[indexLen:]); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// get: 키가 들어 있을 수 있는 블록 하나를 읽어서 찾음 (블록을 읽었으면 read = true)
+func (t *table) get(key string) (e entry, found, read bool, err error) {
+	i, _ := slices.BinarySearchFunc(t.index, key, func(h blockHandle, key string) int {
+		return strings.Compare(h.last, key)
+	})
+	if i == len(t.index) {
+		return entry{}, false, false, nil
+	}
+	block, err := t.readBlock(t.index[i])
+	if err != nil {
+		return entry{}, false, true, err
+	}
+	for len(block) > 0 {
+		var e entry
+		if e, block, err = decodeEntry(block); err != nil {
+			return entry{}, false, true, err
+		}
+		if e.key == key {
+			return e, true, true, nil
+		}
+		if e.key > key {
+			break
+		}
+	}
+	return entry{}, false, true, nil
+}
+
+// readBlock: 블록을 읽고 체크섬을 확인 (CRC 를 뗀 내용)
+func (t *table) readBlock(h blockHandle) ([]byte, error) {
+	buf := make([]byte, h.length)
+	if _, err := t.f.ReadAt(buf, h.offset); err != nil {
+		return nil, err
+	}
+	if len(buf) < 4 {
+		return nil, ErrCorruptTable
+	}
+	data, sum := buf[:len(buf)-4], binary.LittleEndian.Uint32(buf[len(buf)-4:])
+	if crc32.ChecksumIEEE(data) != sum {
+		return nil, ErrCorruptTable
+	}
+	return data, nil
+}
+
+// ref, unref: 테이블을 담은 버전 수 (마지막 버전이 풀렸을 때 쓸모없어진 테이블이면 파일을 지움)
+func (t *table) ref() {
+	t.refs.Add(1)
+}
+
+func (t *table) unref() {
+	if t.refs.Add(-1) > 0 {
+		return
+	}
+	t.f.Close()
+	if t.obsolete.Load() {
+		os.Remove(t.path)
+	}
+}
+
+// tableIter: 테이블 전체를 키 순서로 읽는 반복자 (압축 입력)
+type tableIter struct {
+	t      *table
+	next   int    // 다음에 읽을 블록
+	block  []byte // 현재 블록에서 아직 읽지 않은 부분
+	cur    entry
+	err    error
+	onRead func(n int) error
+}
+
+func (t *table) iter(onRead func(n int) error) *tableIter {
+	return &tableIter{t: t, onRead: onRead}
+}
+
+// advance: 다음 항목으로 이동 (끝이거나 에러면 false)
+func (it *tableIter) advance() bool {
+	for len(it.block) == 0 {
+		if it.err != nil || it.next == len(it.t.index) {
+			return false
+		}
+		h := it.t.index[it.next]
+		it.next++
+		if it.block, it.err = it.t.readBlock(h); it.err != nil {
+			return false
+		}
+		if it.onRead != nil {
+			if it.err = it.onRead(int(h.length)); it.err != nil {
+				return false
+			}
+		}
+	}
+	it.cur, it.block, it.err = decodeEntry(it.block)
+	return it.err == nil
+}
+
+func appendEntry(b []byte, e entry) []byte {
+	b = binary.AppendUvarint(b, uint64(len(e.key)))
+	b = append(b, e.key...)
+	b = binary.AppendUvarint(b, e.seq)
+	if e.deleted {
+		b = append(b, kindDelete)
+	} else {
+		b = append(b, kindValue)
+	}
+	b = binary.AppendUvarint(b, uint64(len(e.value)))
+	return append(b, e.value...)
+}
+
+func decodeEntry(b []byte) (entry, []byte, error) {
+	var e entry
+	key, b, ok := readBytes(b)
+	if !ok {
+		return e, nil, ErrCorruptTable
+	}
+	e.key = string(key)
+	seq, n := binary.Uvarint(b)
+	if n <= 0 || len(b) < n+1 {
+		return e, nil, ErrCorruptTable
+	}
+	e.seq = seq
+	switch b[n] {
+	case kindValue:
+	case kindDelete:
+		e.deleted = true
+	default:
+		return e, nil, ErrCorruptTable
+	}
+	value, b, ok := readBytes(b[n+1:])
+	if !ok {
+		return e, nil, ErrCorruptTable
+	}
+	if !e.deleted {
+		e.value = slices.Clone(value)
+	}
+	return e, b, nil
+}
+
+// readBytes: uvarint 길이 + 내용
+func readBytes(b []byte) ([]byte, []byte, bool) {
+	l, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) < l {
+		return nil, nil, false
+	}
+	return b[n : n+int(l)], b[n+int(l):], true
+}
+
+func encodeIndex(meta tableMeta, index []blockHandle) []byte {
+	var b []byte
+	b = binary.AppendUvarint(b, uint64(len(meta.smallest)))
+	b = append(b, meta.smallest...)
+	b = binary.AppendUvarint(b, uint64(len(meta.largest)))
+	b = append(b, meta.largest...)
+	b = binary.AppendUvarint(b, meta.minSeq)
+	b = binary.AppendUvarint(b, meta.maxSeq)
+	b = binary.AppendUvarint(b, uint64(meta.entries))
+	b = binary.AppendUvarint(b, uint64(len(index)))
+	for _, h := range index {
+		b = binary.AppendUvarint(b, uint64(len(h.last)))
+		b = append(b, h.last...)
+		b = binary.AppendUvarint(b, uint64(h.offset))
+		b = binary.AppendUvarint(b, uint64(h.length))
+	}
+	return b
+}
+
+func decodeIndex(b []byte) (tableMeta, []blockHandle, error) {
+	var meta tableMeta
+	smallest, b, ok := readBytes(b)
+	if !ok {
+		return meta, nil, ErrCorruptTable
+	}
+	largest, b, ok := readBytes(b)
+	if !ok {
+		return meta, nil, ErrCorruptTable
+	}
+	meta.smallest, meta.largest = string(smallest), string(largest)
+
+	var nums [4]uint64
+	for i := range nums {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return meta, nil, ErrCorruptTable
+		}
+		nums[i], b = v, b[n:]
+	}
+	meta.minSeq, meta.maxSeq, meta.entries = nums[0], nums[1], int(nums[2])
+	if nums[3] > uint64(len(b)) {
+		return meta, nil, ErrCorruptTable
+	}
+
+	index := make([]blockHandle, 0, nums[3])
+	for range nums[3] {
+		last, rest, ok := readBytes(b)
+		if !ok {
+			return meta, nil, ErrCorruptTable
+		}
+		offset, n := binary.Uvarint(rest)
+		if n <= 0 {
+			return meta, nil, ErrCorruptTable
+		}
+		length, m := binary.Uvarint(rest[n:])
+		if m <= 0 || length < 4 {
+			return meta, nil, ErrCorruptTable
+		}
+		index = append(index, blockHandle{last: string(last), offset: int64(offset), length: int64(length)})
+		b = rest[n+m:]
+	}
+	if len(b) != 0 {
+		return meta, nil, ErrCorruptTable
+	}
+	return meta, index, nil
+}
@@ -0,0 +1,90 @@
+package lsm
+
+import "sync/atomic"
+
+// Stats: 압축 전략과 필터를 조정할 때 보는 지표
+//   - 쓰기 증폭: 디스크에 쓴 양(플러시 + 압축) / 사용자가 쓴 양. 레벨 압축이 크기 계층보다 큼
+//   - 읽기 증폭: 조회 한 번에 읽은 SSTable 블록 수 평균. 필터가 없는 키의 파일을 걸러 줄수록 작아짐
+//   - 필터 적중률: 필터를 본 횟수 중 "없음" 으로 파일을 건너뛴 비율
+//   - 필터 거짓 양성률: 키가 없는 파일 중 필터가 걸러 내지 못해 블록을 읽은 비율 (설정한 FilterFalsePositive 근처여야 함)
+type Stats struct {
+	Strategy string       `json:"strategy"`
+	Levels   []LevelStats `json:"levels"`
+
+	UserBytes            int64   `json:"user_bytes"`             // Put/Delete 로 받은 키 + 값 크기
+	FlushBytes           int64   `json:"flush_bytes"`            // 메모리 테이블을 내리며 쓴 양
+	CompactionReadBytes  int64   `json:"compaction_read_bytes"`  // 압축이 읽은 양
+	CompactionWriteBytes int64   `json:"compaction_write_bytes"` // 압축이 쓴 양
+	Compactions          uint64  `json:"compactions"`            // 테이블을 다시 쓴 압축 수
+	Moves                uint64  `json:"moves"`                  // 다시 쓰지 않고 레벨만 옮긴 수
+	WriteStalls          uint64  `json:"write_stalls"`           // 앞 메모리 테이블을 내리는 동안 쓰기가 기다린 횟수
+	WriteAmplification   float64 `json:"write_amplification"`
+
+	Gets              uint64  `json:"gets"`
+	BlockReads        uint64  `json:"block_reads"` // 조회가 디스크에서 읽은 블록 수
+	ReadAmplification float64 `json:"read_amplification"`
+
+	FilterChecks            uint64  `json:"filter_checks"`
+	FilterNegatives         uint64  `json:"filter_negatives"`       // 필터가 "없음" 이라고 해서 건너뛴 파일
+	FilterFalsePositives    uint64  `json:"filter_false_positives"` // 필터는 통과했지만 키가 없던 파일
+	FilterHitRate           float64 `json:"filter_hit_rate"`
+	FilterFalsePositiveRate float64 `json:"filter_false_positive_rate"`
+
+	CompactionError string `json:"compaction_error,omitempty"` // 마지막 압축 실패
+}
+
+// LevelStats: 레벨 하나의 테이블 수와 크기
+type LevelStats struct {
+	Tables int   `json:"tables"`
+	Bytes  int64 `json:"bytes"`
+}
+
+type counters struct {
+	user, flushed, compactRead, compactWritten          atomic.Int64
+	compactions, moves, stalls                          atomic.Uint64
+	gets, blockReads                                    atomic.Uint64
+	filterChecks, filterNegatives, filterFalsePositives atomic.Uint64
+}
+
+// Stats: 지금까지의 지표 (열린 뒤부터 셈)
+func (db *DB) Stats() Stats {
+	c := &db.stats
+	st := Stats{
+		Strategy:             db.strategy.String(),
+		UserBytes:            c.user.Load(),
+		FlushBytes:           c.flushed.Load(),
+		CompactionReadBytes:  c.compactRead.Load(),
+		CompactionWriteBytes: c.compactWritten.Load(),
+		Compactions:          c.compactions.Load(),
+		Moves:                c.moves.Load(),
+		WriteStalls:          c.stalls.Load(),
+		Gets:                 c.gets.Load(),
+		BlockReads:           c.blockReads.Load(),
+		FilterChecks:         c.filterChecks.Load(),
+		FilterNegatives:      c.filterNegatives.Load(),
+		FilterFalsePositives: c.filterFalsePositives.Load(),
+	}
+	st.WriteAmplification = ratio(float64(st.FlushBytes+st.CompactionWriteBytes), float64(st.UserBytes))
+	st.ReadAmplification = ratio(float64(st.BlockReads), float64(st.Gets))
+	st.FilterHitRate = ratio(float64(st.FilterNegatives), float64(st.FilterChecks))
+	st.FilterFalsePositiveRate = ratio(float64(st.FilterFalsePositives), float64(st.FilterNegatives+st.FilterFalsePositives))
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.current != nil {
+		for _, tables := range db.current.levels {
+			st.Levels = append(st.Levels, LevelStats{Tables: len(tables), Bytes: levelBytes(tables)})
+		}
+	}
+	if db.compErr != nil {
+		st.CompactionError = db.compErr.Error()
+	}
+	return st
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"time"
+
+	"kv-store/merkle"
 )
 
 // 설정
 const (
 	NumFields     = 10000000 // 시뮬레이션할 필드 개수 (1억 개)
 	FieldDataSize = 100      // 필드당 데이터 크기 (Byte)
+
+	KeyedFields  = 100000 // 키 기반 비교 시나리오의 키 개수
+	KeyedBuckets = 4096   // 키 기반 머클 트리의 버킷(리프) 개수
 )
 
 // 머클 트리 노드 정의: 해시값, 자식 노드, 리프 여부 및 원본 데이터 인덱스 포함
@@ -33,24 +42,38 @@ func CalculateHash(data string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// 동기화 결과: 한쪽에만 있는 필드와 양쪽 값이 다른 필드를 구분해서 보고
+// (어느 방향으로 복사해야 하는지 알 수 있어야 양방향 복구가 가능)
+type SyncReport struct {
+	MissingInA []int // B 에만 있는 필드 → B 에서 A 로 복사
+	MissingInB []int // A 에만 있는 필드 → A 에서 B 로 복사
+	Different  []int // 양쪽에 다 있지만 값이 다른 필드
+}
+
 // 1. 단순 비교 (Naive)
 // 모든 데이터를 네트워크로 전송받아 하나씩 비교한다고 가정하는 방식
-func NaiveSync(recA, recB Record) (int, int, []int) {
+func NaiveSync(recA, recB Record) (int, int, SyncReport) {
 	comparisons := 0
 	transferBytes := 0
-	var diffIndices []int
+	var report SyncReport
 
-	// 전체 필드 개수 * 크기만큼 네트워크 전송이 발생한다고 시뮬레이션
-	transferBytes = len(recA.Fields) * FieldDataSize
+	// 상대(B)의 전체 필드 개수 * 크기만큼 네트워크 전송이 발생한다고 시뮬레이션
+	transferBytes = len(recB.Fields) * FieldDataSize
 
 	// 모든 필드를 처음부터 끝까지 순차적으로 비교 (O(N))
-	for i := 0; i < len(recA.Fields); i++ {
+	// 두 레코드의 필드 개수가 다를 수 있으므로 긴 쪽 기준으로 돌고, 짧은 쪽에 없는 필드는 누락으로 분류
+	for i := 0; i < max(len(recA.Fields), len(recB.Fields)); i++ {
 		comparisons++
-		if recA.Fields[i] != recB.Fields[i] {
-			diffIndices = append(diffIndices, i)
+		switch {
+		case i >= len(recA.Fields):
+			report.MissingInA = append(report.MissingInA, i)
+		case i >= len(recB.Fields):
+			report.MissingInB = append(report.MissingInB, i)
+		case recA.Fields[i] != recB.Fields[i]:
+			report.Different = append(report.Different, i)
 		}
 	}
-	return comparisons, transferBytes, diffIndices
+	return comparisons, transferBytes, report
 }
 
 // 2. 머클 트리 (Merkle Tree) 빌드 함수
@@ -71,14 +94,14 @@ func BuildTree(fields []string) *MerkleNode {
 		for i := 0; i < len(nodes); i += 2 {
 			left := nodes[i]
 			var right *MerkleNode
-			// 오른쪽 자식이 없으면 왼쪽 자식을 복제하여 균형 맞춤
+			// 좌우 자식의 해시를 합쳐 부모 노드의 해시 생성
+			// 오른쪽 자식이 없으면 비워 둠 (왼쪽을 복제하면 필드 개수가 다른 트리와 비교할 때
+			// 복제된 노드가 실제 필드처럼 보여 엉뚱한 인덱스가 다르다고 나옴)
+			parentHash := CalculateHash(left.Hash)
 			if i+1 < len(nodes) {
 				right = nodes[i+1]
-			} else {
-				right = left
+				parentHash = CalculateHash(left.Hash + right.Hash)
 			}
-			// 좌우 자식의 해시를 합쳐 부모 노드의 해시 생성
-			parentHash := CalculateHash(left.Hash + right.Hash)
 			nextLevel = append(nextLevel, &MerkleNode{
 				Hash:     parentHash,
 				Left:     left,
@@ -94,33 +117,82 @@ func BuildTree(fields []string) *MerkleNode {
 
 // 머클 트리 동기화 함수
 // 루트부터 시작해 해시가 다른 경로만 찾아 내려가는 효율적 비교
-func MerkleSync(nodeA, nodeB *MerkleNode, comparisons *int, transferBytes *int) []int {
-	var diffIndices []int
+//
+// 필드 개수가 다르면 트리 높이가 다를 수 있음
+// 리프는 항상 왼쪽부터 채워지므로, 높은 쪽 트리의 가장 왼쪽 부분 트리가 낮은 쪽 트리 전체와 같은 필드 범위를 담당함
+// 그래서 높이가 같아질 때까지 높은 쪽의 왼쪽 가지로 내려가고, 지나친 오른쪽 가지는 반대편에 없는 필드로 분류
+func MerkleSync(nodeA, nodeB *MerkleNode, comparisons *int, transferBytes *int) SyncReport {
+	var report SyncReport
+
+	heightA, heightB := treeHeight(nodeA), treeHeight(nodeB)
+	for ; heightA > heightB; heightA-- {
+		*comparisons++
+		*transferBytes += 64
+		report.MissingInB = append(report.MissingInB, leafIndices(nodeA.Right)...)
+		nodeA = nodeA.Left
+	}
+	for ; heightB > heightA; heightB-- {
+		*comparisons++
+		*transferBytes += 64
+		report.MissingInA = append(report.MissingInA, leafIndices(nodeB.Right)...)
+		nodeB = nodeB.Left
+	}
+
+	merkleSync(nodeA, nodeB, comparisons, transferBytes, &report)
+
+	// 오른쪽 가지를 먼저 모으므로 인덱스 순서대로 정렬
+	slices.Sort(report.MissingInA)
+	slices.Sort(report.MissingInB)
+	return report
+}
+
+// 높이가 같은 두 (부분) 트리를 재귀로 비교
+func merkleSync(nodeA, nodeB *MerkleNode, comparisons *int, transferBytes *int, report *SyncReport) {
+	// 한쪽에만 가지가 있으면 그 아래 필드는 전부 반대편에 없는 필드
+	if nodeA == nil || nodeB == nil {
+		report.MissingInA = append(report.MissingInA, leafIndices(nodeB)...)
+		report.MissingInB = append(report.MissingInB, leafIndices(nodeA)...)
+		return
+	}
+
 	*comparisons++
 	*transferBytes += 64 // 해시값(32byte hex string * 2)만 전송한다고 가정
 
 	// 해시가 같다면 하위 데이터는 완벽히 동일하므로 탐색 중단 (핵심 최적화)
 	if nodeA.Hash == nodeB.Hash {
-		return diffIndices
+		return
 	}
 
 	// 해시가 다른데 리프 노드라면, 이곳이 변경된 데이터임
 	if nodeA.IsLeaf && nodeB.IsLeaf {
-		diffIndices = append(diffIndices, nodeA.FieldIdx)
-		return diffIndices
+		report.Different = append(report.Different, nodeA.FieldIdx)
+		return
 	}
 
 	// 내부 노드의 해시가 다르다면 자식 노드로 내려가 재귀 탐색
-	if nodeA.Left != nil && nodeB.Left != nil {
-		diffs := MerkleSync(nodeA.Left, nodeB.Left, comparisons, transferBytes)
-		diffIndices = append(diffIndices, diffs...)
-	}
-	if nodeA.Right != nil && nodeB.Right != nil {
-		diffs := MerkleSync(nodeA.Right, nodeB.Right, comparisons, transferBytes)
-		diffIndices = append(diffIndices, diffs...)
+	merkleSync(nodeA.Left, nodeB.Left, comparisons, transferBytes, report)
+	merkleSync(nodeA.Right, nodeB.Right, comparisons, transferBytes, report)
+}
+
+// 트리 높이 (리프는 모두 같은 깊이에 있으므로 왼쪽 끝만 따라 내려가면 됨)
+func treeHeight(node *MerkleNode) int {
+	height := 0
+	for node != nil && !node.IsLeaf {
+		node = node.Left
+		height++
 	}
+	return height
+}
 
-	return diffIndices
+// 부분 트리 아래에 있는 모든 필드 인덱스
+func leafIndices(node *MerkleNode) []int {
+	if node == nil {
+		return nil
+	}
+	if node.IsLeaf {
+		return []int{node.FieldIdx}
+	}
+	return append(leafIndices(node.Left), leafIndices(node.Right)...)
 }
 
 func main() {
@@ -145,14 +217,14 @@ func main() {
 
 	// [1] 단순 필드 비교 방식 실행 및 측정
 	startNaive := time.Now()
-	naiveOps, naiveBytes, naiveIndices := NaiveSync(recA, recB)
+	naiveOps, naiveBytes, naiveReport := NaiveSync(recA, recB)
 	durNaive := time.Since(startNaive)
 
 	fmt.Println("[1] 단순 필드 비교")
 	fmt.Printf("   - 비교 연산 횟수 : %d\n", naiveOps)
 	fmt.Printf("   - 네트워크 전송량: %d Bytes\n", naiveBytes)
 	fmt.Printf("   - 소요 시간      : %v\n", durNaive)
-	fmt.Printf("   - 찾은 인덱스    : %v\n", naiveIndices)
+	printReport(naiveReport)
 
 	// [2] 머클 트리 동기화 방식 실행 및 측정
 	fmt.Println("\n[2] 머클 트리 동기화")
@@ -164,14 +236,14 @@ func main() {
 	startMerkle := time.Now()
 	merkleOps := 0
 	merkleBytes := 0
-	merkleIndices := MerkleSync(rootA, rootB, &merkleOps, &merkleBytes)
+	merkleReport := MerkleSync(rootA, rootB, &merkleOps, &merkleBytes)
 	durMerkle := time.Since(startMerkle) // 실제 비교(동기화) 시간 측정
 
 	fmt.Printf("   - 비교 연산 횟수 : %d\n", merkleOps)
 	fmt.Printf("   - 네트워크 전송량: %d Bytes\n", merkleBytes)
 	fmt.Printf("   - 트리 빌드 시간 : %v\n", durBuild)
 	fmt.Printf("   - 동기화 소요 시간: %v\n", durMerkle)
-	fmt.Printf("   - 찾은 인덱스    : %v\n", merkleIndices)
+	printReport(merkleReport)
 
 	// [3] 최종 결과 효율성 비교 출력
 	fmt.Println("\n[3] 최종 결과 비교")
@@ -196,4 +268,91 @@ func main() {
 		"동기화 소요 시간", durNaive, durMerkle, ratioTime)
 	fmt.Println("-------------------------------------------------------------------------------------")
 
+	// [4] 키 개수가 다른 복제본 비교 (키 해시 버킷 머클 트리)
+	// 인덱스 기반 트리는 중간 키가 하나만 빠져도 뒤쪽이 전부 밀리므로, 키 해시로 버킷을 나눈 트리로 비교
+	fmt.Println("\n[4] 키 개수가 다른 복제본 비교")
+	kvA := make(map[string][]byte, KeyedFields)
+	kvB := make(map[string][]byte, KeyedFields)
+	for i := 0; i < KeyedFields; i++ {
+		key := fmt.Sprintf("key-%07d", i)
+		kvA[key] = []byte("CommonData")
+		kvB[key] = []byte("CommonData")
+	}
+	delete(kvB, "key-0000100")               // B 에서 유실된 키
+	kvA["key-only-in-a"] = []byte("NewData") // A 에만 쓰기가 반영된 키
+	kvB["key-only-in-b"] = []byte("NewData") // B 에만 쓰기가 반영된 키
+	kvB["key-0050000"] = []byte("CHANGED")   // 값이 달라진 키
+
+	startKeyed := time.Now()
+	keyedReport, err := merkle.DiffKeyed(merkle.BuildKeyed(kvA, KeyedBuckets), merkle.BuildKeyed(kvB, KeyedBuckets))
+	if err != nil {
+		fmt.Printf("   - 비교 실패: %v\n", err)
+		return
+	}
+	fmt.Printf("   - 키 개수        : A=%d, B=%d (버킷 %d 개)\n", len(kvA), len(kvB), KeyedBuckets)
+	fmt.Printf("   - 비교 연산 횟수 : %d\n", keyedReport.Comparisons)
+	fmt.Printf("   - 네트워크 전송량: %d Bytes\n", keyedReport.TransferBytes)
+	fmt.Printf("   - 소요 시간(빌드 포함): %v\n", time.Since(startKeyed))
+	fmt.Printf("   - A 에 없는 키   : %v\n", keyedReport.MissingInA)
+	fmt.Printf("   - B 에 없는 키   : %v\n", keyedReport.MissingInB)
+	fmt.Printf("   - 값이 다른 키   : %v\n", keyedReport.Different)
+
+	// [5] 키 하나의 포함 증명
+	// 클라이언트는 서버가 공개한 루트 해시만 믿고, 값 하나와 증명만 받아서 검증 (데이터 전체를 받지 않음)
+	fmt.Println("\n[5] 키 하나의 포함 증명")
+	treeA := merkle.BuildKeyed(kvA, KeyedBuckets)
+	proof, err := treeA.Proof("key-0012345")
+	if err != nil {
+		fmt.Printf("   - 증명 생성 실패: %v\n", err)
+		return
+	}
+	encoded, _ := proof.MarshalBinary()
+	fmt.Printf("   - 증명 크기      : %d Bytes (경로 해시 %d 개, 같은 버킷의 키 %d 개)\n", len(encoded), len(proof.Path), len(proof.Neighbors))
+	fmt.Printf("   - 올바른 값 검증 : %v\n", merkle.VerifyProof(treeA.Root(), "key-0012345", kvA["key-0012345"], proof) == nil)
+	fmt.Printf("   - 위조 값 검증   : %v\n", merkle.VerifyProof(treeA.Root(), "key-0012345", []byte("FORGED"), proof) == nil)
+
+	// [6] 재시작할 때 트리를 다시 만들지 않고 파일에서 열기
+	// 데이터 버전이 같으면 메모리 매핑만 하므로 리프 해시를 다시 계산하지 않음
+	fmt.Println("\n[6] 디스크에 저장한 트리 재사용")
+	// 노드가 데이터를 두는 곳(data/) 아래에 저장 (임시 디렉터리는 재시작 사이에 지워질 수 있음)
+	dir := filepath.Join("data", "merkle")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Printf("   - 디렉터리 생성 실패: %v\n", err)
+		return
+	}
+	path := filepath.Join(dir, "demo-a.tree")
+	defer os.Remove(path)
+
+	startRebuild := time.Now()
+	flatA := merkle.BuildStrings(recA.Fields)
+	durRebuild := time.Since(startRebuild)
+	if err := merkle.WriteFile(path, flatA, 1); err != nil {
+		fmt.Printf("   - 저장 실패: %v\n", err)
+		return
+	}
+
+	startOpen := time.Now()
+	stored := merkle.LoadOrRebuild(path, 1, func() *merkle.Tree { return merkle.BuildStrings(recA.Fields) })
+	reused, _, ok := stored.Tree()
+	durOpen := time.Since(startOpen)
+	fmt.Printf("   - 다시 만들기    : %v\n", durRebuild)
+	fmt.Printf("   - 파일 재사용    : %v (바로 사용 가능: %v, 루트 일치: %v)\n", durOpen, ok, ok && reused.Root() == flatA.Root())
+	stored.Close()
+
+	// 데이터가 바뀐 뒤(버전 2) 시작하면 파일을 버리고 백그라운드에서 다시 만듦 → 그동안 복구 보류
+	stored = merkle.LoadOrRebuild(path, 2, func() *merkle.Tree { return merkle.BuildStrings(recB.Fields) })
+	if _, _, ok := stored.Tree(); !ok {
+		fmt.Println("   - 버전이 달라 다시 만드는 중 → 안티 엔트로피 복구 보류")
+	}
+	if _, err := stored.Wait(context.Background()); err == nil {
+		fmt.Println("   - 다시 만들기 완료 → 복구 재개")
+	}
+	stored.Close()
+}
+
+// 동기화 결과 출력 (누락/불일치를 방향별로 구분)
+func printReport(report SyncReport) {
+	fmt.Printf("   - A 에 없는 인덱스: %v\n", report.MissingInA)
+	fmt.Printf("   - B 에 없는 인덱스: %v\n", report.MissingInB)
+	fmt.Printf("   - 값이 다른 인덱스: %v\n", report.Different)
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+
+	"kv-store/merkle"
+)
+
+// 벤치마크 데이터: main() 시뮬레이션과 같은 1천만 개 필드
+// 한 번만 만들어 두고 모든 벤치마크가 공유 (데이터 생성 비용은 측정에서 제외)
+var (
+	benchOnce   sync.Once
+	benchFields []string
+)
+
+func loadBenchFields() []string {
+	benchOnce.Do(func() {
+		benchFields = make([]string, NumFields)
+		for i := range benchFields {
+			benchFields[i] = "CommonData"
+		}
+	})
+	return benchFields
+}
+
+// reportRetained: 빌드가 끝난 뒤에도 트리가 계속 붙잡고 있는 힙 메모리(MB)를 기록
+// (B/op 는 빌드 중 할당한 총량, retained-MB 는 완성된 트리 자체의 크기)
+func reportRetained(b *testing.B, build func() any) {
+	b.Helper()
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	tree := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(tree)
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/(1<<20), "retained-MB")
+}
+
+// 기존 방식: 포인터 노드 + hex 문자열 해시
+// go test -run=^$ -bench=BuildTree -benchtime=3x
+func BenchmarkBuildTreePointer(b *testing.B) {
+	fields := loadBenchFields()
+	b.ReportAllocs()
+	for b.Loop() {
+		BuildTree(fields)
+	}
+	b.StopTimer()
+	reportRetained(b, func() any { return BuildTree(fields) })
+}
+
+// 새 방식: [32]byte 평평한 배열 + 레벨 단위 병렬 빌드
+func BenchmarkBuildTreeFlat(b *testing.B) {
+	fields := loadBenchFields()
+	b.ReportAllocs()
+	for b.Loop() {
+		merkle.BuildStrings(fields)
+	}
+	b.StopTimer()
+	reportRetained(b, func() any { return merkle.BuildStrings(fields) })
+}
+
+// 필드 개수가 다른 두 레코드: 단순 비교와 머클 트리 비교가 같은 결과를 내야 함
+func TestSyncDifferentFieldCounts(t *testing.T) {
+	fields := func(n int) []string {
+		f := make([]string, n)
+		for i := range f {
+			f[i] = "CommonData"
+		}
+		return f
+	}
+
+	for _, tc := range []struct{ lenA, lenB int }{{8, 8}, {5, 9}, {9, 5}, {1, 17}, {100, 37}} {
+		recA, recB := Record{Fields: fields(tc.lenA)}, Record{Fields: fields(tc.lenB)}
+		recB.Fields[min(tc.lenA, tc.lenB)/2] = "CHANGED"
+
+		_, _, naive := NaiveSync(recA, recB)
+		ops, bytes := 0, 0
+		merkle := MerkleSync(BuildTree(recA.Fields), BuildTree(recB.Fields), &ops, &bytes)
+
+		if !slices.Equal(naive.MissingInA, merkle.MissingInA) ||
+			!slices.Equal(naive.MissingInB, merkle.MissingInB) ||
+			!slices.Equal(naive.Different, merkle.Different) {
+			t.Fatalf("len %d/%d: naive %+v, merkle %+v", tc.lenA, tc.lenB, naive, merkle)
+		}
+		if len(naive.Different) != 1 || len(naive.MissingInA)+len(naive.MissingInB) != abs(tc.lenA-tc.lenB) {
+			t.Fatalf("len %d/%d: unexpected report %+v", tc.lenA, tc.lenB, naive)
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
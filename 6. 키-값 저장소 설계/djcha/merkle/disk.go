@@ -0,0 +1,131 @@
+package merkle
+
+import (
+	"context"
+	"sync"
+)
+
+// DiskTree: 데이터 옆 파일에 저장해 두고 재시작할 때 다시 쓰는 트리
+//
+// 시작할 때 파일의 데이터 버전이 지금 데이터와 같으면 리프 해시를 다시 계산하지 않고 그대로 씀
+// 파일이 없거나, 버전이 다르거나, 깨졌으면 백그라운드에서 다시 만들고 파일을 새로 씀
+// 다시 만드는 동안 Tree 는 false 를 돌려주므로, 호출자는 그동안 복구(안티 엔트로피)를 멈춰야 함
+// (다 만들기 전의 트리로 비교하면 멀쩡한 데이터를 다르다고 보고 주고받게 됨)
+// 실행 중에 데이터가 바뀌면 Refresh 로 백그라운드에서 새로 만들고, 그동안은 이전에 다 만든 트리를 그대로 돌려줌
+type DiskTree struct {
+	path       string
+	mu         sync.RWMutex
+	tree       *Tree
+	ver        uint64
+	err        error          // 마지막 저장 실패 (트리는 메모리에서 계속 쓸 수 있음)
+	ready      chan struct{}  // 트리가 준비되면 닫힘
+	old        []*Tree        // 교체된 매핑 트리 (읽는 중일 수 있으므로 Close 할 때 해제)
+	refreshing bool           // Refresh 로 새 트리를 만드는 중
+	bg         sync.WaitGroup // Refresh 고루틴 (Close 가 끝날 때까지 기다림)
+}
+
+// LoadOrRebuild: path 의 트리를 version 기준으로 검증해서 열고, 쓸 수 없으면 build 로 백그라운드에서 다시 만듦
+func LoadOrRebuild(path string, version uint64, build func() *Tree) *DiskTree {
+	d := &DiskTree{path: path, ver: version, ready: make(chan struct{})}
+	if t, err := OpenFile(path, version); err == nil {
+		d.tree = t
+		close(d.ready)
+		return d
+	}
+
+	go func() {
+		t := build()
+		err := WriteFile(path, t, version)
+
+		d.mu.Lock()
+		d.tree, d.err = t, err
+		d.mu.Unlock()
+		close(d.ready)
+	}()
+	return d
+}
+
+// Tree: 준비된 트리와 그 데이터 버전 (다시 만드는 중이면 false → 복구를 멈춰야 함)
+func (d *DiskTree) Tree() (*Tree, uint64, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.tree, d.ver, d.tree != nil
+}
+
+// Wait: 트리가 준비될 때까지 기다림
+func (d *DiskTree) Wait(ctx context.Context) (*Tree, error) {
+	select {
+	case <-d.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.tree, nil
+}
+
+// Err: 다시 만든 트리를 파일에 저장하다 실패한 원인 (다음 재시작 때 또 다시 만들게 됨)
+func (d *DiskTree) Err() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.err
+}
+
+// Replace: 데이터가 version 으로 바뀐 뒤 새로 만든 트리 t 로 교체하고 파일에 저장
+// 저장에 실패해도 메모리의 트리는 교체됨 (파일은 예전 버전으로 남아 재시작 때 다시 만들어짐)
+func (d *DiskTree) Replace(t *Tree, version uint64) error {
+	<-d.ready
+	err := WriteFile(d.path, t, version)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tree != nil && d.tree.mapped != nil {
+		d.old = append(d.old, d.tree)
+	}
+	d.tree, d.ver, d.err = t, version, err
+	return err
+}
+
+// Refresh: 데이터가 version 으로 바뀌었으면 build 로 백그라운드에서 새 트리를 만들어 파일에 저장한 뒤 교체
+// 다 만들 때까지 Tree 는 이전 트리와 그 버전을 돌려줌
+// 처음 트리가 아직 준비되지 않았거나, 이미 새로 만드는 중이거나, 버전이 같으면 아무것도 하지 않음
+// Close 와 동시에 부르면 안 됨
+func (d *DiskTree) Refresh(version uint64, build func() *Tree) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tree == nil || d.refreshing || d.ver == version {
+		return
+	}
+	d.refreshing = true
+	d.bg.Add(1)
+
+	go func() {
+		defer d.bg.Done()
+		t := build()
+		err := WriteFile(d.path, t, version)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.tree.mapped != nil {
+			d.old = append(d.old, d.tree)
+		}
+		d.tree, d.ver, d.err, d.refreshing = t, version, err, false
+	}()
+}
+
+// Close: 다시 만드는 중이면 끝날 때까지 기다린 뒤 매핑을 모두 해제 (이후에는 트리를 쓰면 안 됨)
+func (d *DiskTree) Close() error {
+	<-d.ready
+	d.bg.Wait()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var first error
+	for _, t := range append(d.old, d.tree) {
+		if err := t.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	d.old, d.tree = nil, nil
+	return first
+}
@@ -0,0 +1,143 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+var (
+	// ErrStaleTree: 파일의 트리가 지금 데이터와 다른 버전으로 만든 것 (다시 만들어야 함)
+	ErrStaleTree = errors.New("merkle: tree file is for another data version")
+	// ErrCorruptTree: 트리 파일 형식이 틀리거나 잘림
+	ErrCorruptTree = errors.New("merkle: corrupt tree file")
+)
+
+// 트리 파일 형식
+//
+//	매직(8) | 형식 버전(4) | 예약(4) | 데이터 버전(8) | 리프 수(8) | 루트 해시(32) | 노드 해시(32 × (2×리프 수 - 1))
+//
+// 노드 해시는 Tree.nodes 배열(힙 인덱스 순서)을 그대로 씀 → 열 때 파싱하지 않고 메모리 매핑만 하면 됨
+// 정수는 리틀 엔디안
+const (
+	fileMagic      = "KVMERKLE"
+	fileFormat     = 1
+	fileHeaderSize = 64
+)
+
+// WriteFile: 트리를 path 에 저장 (version 은 트리를 만든 데이터의 버전)
+// 임시 파일에 쓰고 fsync 한 뒤 이름을 바꾸므로, 중간에 죽어도 path 에는 예전 파일이나 새 파일만 남음
+// 이름을 바꾼 뒤 디렉터리도 fsync 해야 전원이 나가도 바뀐 이름이 남음
+func WriteFile(path string, t *Tree, version uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 이름을 바꾼 뒤에는 이미 없으므로 무시됨
+
+	header := make([]byte, fileHeaderSize)
+	copy(header, fileMagic)
+	binary.LittleEndian.PutUint32(header[8:], fileFormat)
+	binary.LittleEndian.PutUint64(header[16:], version)
+	binary.LittleEndian.PutUint64(header[24:], uint64(t.leaves))
+	root := t.Root()
+	copy(header[32:], root[:])
+
+	if _, err := tmp.Write(header); err != nil {
+		tmp.Close()
+		return err
+	}
+	if len(t.nodes) > 0 {
+		if _, err := tmp.Write(unsafe.Slice((*byte)(unsafe.Pointer(&t.nodes[0])), len(t.nodes)*len(Digest{}))); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// OpenFile: WriteFile 로 저장한 트리를 메모리 매핑으로 엶 (리프 해시를 다시 계산하지 않음)
+// 데이터 버전이 version 과 다르면 ErrStaleTree, 형식이 틀리면 ErrCorruptTree
+// 다 쓴 트리는 Close 로 매핑을 해제해야 함 (해제한 뒤에는 트리를 쓰면 안 됨)
+func OpenFile(path string, version uint64) (*Tree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < fileHeaderSize {
+		return nil, ErrCorruptTree
+	}
+	data, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := treeFromFile(data, version)
+	if err != nil {
+		_ = unmapFile(data)
+		return nil, err
+	}
+	return t, nil
+}
+
+func treeFromFile(data []byte, version uint64) (*Tree, error) {
+	if !bytes.Equal(data[:8], []byte(fileMagic)) || binary.LittleEndian.Uint32(data[8:]) != fileFormat {
+		return nil, ErrCorruptTree
+	}
+	if got := binary.LittleEndian.Uint64(data[16:]); got != version {
+		return nil, fmt.Errorf("%w (file %d, data %d)", ErrStaleTree, got, version)
+	}
+	leaves := binary.LittleEndian.Uint64(data[24:])
+	size := uint64(0)
+	if leaves > 0 {
+		size = 2*leaves - 1
+	}
+	if leaves > uint64(len(data)) || uint64(len(data)-fileHeaderSize) != size*uint64(len(Digest{})) {
+		return nil, ErrCorruptTree
+	}
+
+	t := &Tree{leaves: int(leaves), mapped: data}
+	if size > 0 {
+		t.nodes = unsafe.Slice((*Digest)(unsafe.Pointer(&data[fileHeaderSize])), size)
+	}
+	// 헤더의 루트와 노드 배열의 루트가 다르면 파일이 섞였거나 깨진 것
+	if root := t.Root(); !bytes.Equal(root[:], data[32:64]) {
+		return nil, ErrCorruptTree
+	}
+	return t, nil
+}
+
+// Close: OpenFile 로 연 트리의 메모리 매핑 해제 (메모리에서 만든 트리는 아무것도 안 함)
+func (t *Tree) Close() error {
+	if t.mapped == nil {
+		return nil
+	}
+	data := t.mapped
+	t.mapped, t.nodes = nil, nil
+	return unmapFile(data)
+}
@@ -0,0 +1,131 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWriteFileThenOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree")
+	for _, n := range []int{0, 1, 7, 1000} {
+		leaves := make([]string, n)
+		for i := range leaves {
+			leaves[i] = "field-" + strconv.Itoa(i)
+		}
+		built := BuildStrings(leaves)
+		if err := WriteFile(path, built, 42); err != nil {
+			t.Fatal(err)
+		}
+
+		opened, err := OpenFile(path, 42)
+		if err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		if opened.Root() != built.Root() || opened.Len() != n {
+			t.Fatalf("n=%d: reopened tree differs", n)
+		}
+		if diff, _, _ := DiffLeaves(built, opened); len(diff) != 0 {
+			t.Fatalf("n=%d: reopened leaves differ at %v", n, diff)
+		}
+		if err := opened.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := OpenFile(path, 43); !errors.Is(err, ErrStaleTree) {
+			t.Fatalf("n=%d: err = %v, want ErrStaleTree", n, err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, data[:len(data)-1], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFile(path, 42); !errors.Is(err, ErrCorruptTree) {
+		t.Fatalf("truncated file: err = %v, want ErrCorruptTree", err)
+	}
+}
+
+func TestLoadOrRebuildPausesUntilReady(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree")
+	leaves := []string{"a", "b", "c"}
+	release := make(chan struct{})
+	builds := 0
+	build := func() *Tree {
+		builds++
+		<-release
+		return BuildStrings(leaves)
+	}
+
+	d := LoadOrRebuild(path, 1, build)
+	if _, _, ok := d.Tree(); ok {
+		t.Fatal("tree reported ready while rebuilding")
+	}
+	close(release)
+	tree, err := d.Wait(context.Background())
+	if err != nil || tree.Root() != BuildStrings(leaves).Root() || d.Err() != nil {
+		t.Fatalf("rebuilt tree: %v %v", err, d.Err())
+	}
+	d.Close()
+
+	// 같은 데이터 버전으로 다시 시작하면 파일을 그대로 씀
+	d = LoadOrRebuild(path, 1, build)
+	if tree, _, ok := d.Tree(); !ok || tree.Root() != BuildStrings(leaves).Root() || builds != 1 {
+		t.Fatalf("restart rebuilt the tree (builds = %d)", builds)
+	}
+	d.Close()
+
+	// 데이터 버전이 바뀌었으면 다시 만듦
+	d = LoadOrRebuild(path, 2, build)
+	d.Wait(context.Background())
+	if builds != 2 {
+		t.Fatalf("stale tree was reused (builds = %d)", builds)
+	}
+	d.Close()
+}
+
+func TestRefreshKeepsServingOldTree(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree")
+	old, next := BuildStrings([]string{"a"}), BuildStrings([]string{"a", "b"})
+	d := LoadOrRebuild(path, 1, func() *Tree { return old })
+	if _, err := d.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	release := make(chan struct{})
+	builds := 0
+	build := func() *Tree {
+		builds++
+		<-release
+		return next
+	}
+	d.Refresh(2, build)
+	d.Refresh(2, build) // 이미 만드는 중이면 다시 만들지 않음
+
+	// 새로 만드는 동안에는 이전 트리로 계속 비교할 수 있음
+	if tree, ver, ok := d.Tree(); !ok || ver != 1 || tree.Root() != old.Root() {
+		t.Fatalf("tree while refreshing = (%v, %d, %v), want the old tree at version 1", tree, ver, ok)
+	}
+	close(release)
+	for {
+		if tree, ver, _ := d.Tree(); ver == 2 {
+			if tree.Root() != next.Root() || builds != 1 {
+				t.Fatalf("refreshed tree differs (builds = %d)", builds)
+			}
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 새 트리는 파일에도 저장되어 재시작할 때 그대로 씀
+	reopened, err := OpenFile(path, 2)
+	if err != nil || reopened.Root() != next.Root() {
+		t.Fatalf("reopen after refresh: %v", err)
+	}
+	reopened.Close()
+}
@@ -0,0 +1,121 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrShapeMismatch: 리프 개수나 자식 수(fanout)가 다른 두 트리는 같은 위치끼리 비교할 수 없음
+var ErrShapeMismatch = errors.New("merkle: trees have different shapes")
+
+// KaryTree: 내부 노드가 자식을 최대 fanout 개 갖는 머클 트리 (레벨별 배열)
+//
+// 자식이 많을수록 트리가 낮아져 루트에서 리프까지 주고받는 횟수(왕복)는 줄지만,
+// 다른 노드를 만날 때마다 자식 해시 fanout 개를 모두 비교해야 하므로 비교 횟수와 전송량은 늘어남
+//   - levels[0]: 리프 해시, levels[len-1]: 루트 1개
+//   - levels[d] 의 i 번 노드의 자식: levels[d-1][i*fanout : (i+1)*fanout] (마지막 노드는 더 적을 수 있음)
+type KaryTree struct {
+	fanout int
+	levels [][]Digest
+}
+
+// BuildKary: 리프 해시 목록으로 자식 수가 fanout 인 트리 구성
+func BuildKary(leaves []Digest, fanout int) (*KaryTree, error) {
+	if fanout < 2 {
+		return nil, fmt.Errorf("merkle: fanout must be at least 2, got %d", fanout)
+	}
+	t := &KaryTree{fanout: fanout, levels: [][]Digest{slices.Clone(leaves)}}
+	for level := t.levels[0]; len(level) > 1; level = t.levels[len(t.levels)-1] {
+		parents := make([]Digest, (len(level)+fanout-1)/fanout)
+		parallelFor(len(parents), func(lo, hi int) {
+			buf := make([]byte, 0, 1+fanout*sha256.Size)
+			for i := lo; i < hi; i++ {
+				buf = append(buf[:0], internalPrefix)
+				for _, child := range level[i*fanout : min((i+1)*fanout, len(level))] {
+					buf = append(buf, child[:]...)
+				}
+				parents[i] = sha256.Sum256(buf)
+			}
+		})
+		t.levels = append(t.levels, parents)
+	}
+	return t, nil
+}
+
+// Root: 루트 해시 (리프가 없으면 0 으로 채워진 값)
+func (t *KaryTree) Root() Digest {
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		return Digest{}
+	}
+	return top[0]
+}
+
+// Height: 루트에서 리프까지의 간선 수 (비교할 때 주고받는 왕복 횟수)
+func (t *KaryTree) Height() int {
+	return len(t.levels) - 1
+}
+
+// DiffKary: 루트부터 해시가 다른 노드의 자식만 비교하며 내려가 값이 다른 리프 번호를 찾음 (오름차순)
+// comparisons 는 비교한 노드(해시 쌍) 수
+func DiffKary(a, b *KaryTree) (leaves []int, comparisons int, err error) {
+	if a.fanout != b.fanout || len(a.levels[0]) != len(b.levels[0]) {
+		return nil, 0, ErrShapeMismatch
+	}
+	if len(a.levels[0]) == 0 {
+		return nil, 0, nil
+	}
+
+	type pos struct{ level, index int }
+	stack := []pos{{len(a.levels) - 1, 0}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		comparisons++
+		if a.levels[p.level][p.index] == b.levels[p.level][p.index] {
+			continue
+		}
+		if p.level == 0 {
+			leaves = append(leaves, p.index)
+			continue
+		}
+		below := len(a.levels[p.level-1])
+		for c := min((p.index+1)*a.fanout, below) - 1; c >= p.index*a.fanout; c-- {
+			stack = append(stack, pos{p.level - 1, c})
+		}
+	}
+	return leaves, comparisons, nil
+}
+
+// DiffLeaves: 리프 개수가 같은 두 이진 트리를 루트부터 비교해서 값이 다른 리프 번호를 찾음 (오름차순)
+// comparisons 는 비교한 노드(해시 쌍) 수
+func DiffLeaves(a, b *Tree) (leaves []int, comparisons int, err error) {
+	if a.Len() != b.Len() {
+		return nil, 0, ErrShapeMismatch
+	}
+	if a.Len() == 0 {
+		return nil, 0, nil
+	}
+
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		comparisons++
+		if a.nodes[i] == b.nodes[i] {
+			continue
+		}
+		if a.IsLeaf(i) {
+			leaves = append(leaves, a.LeafIndex(i))
+			continue
+		}
+		stack = append(stack, 2*i+2, 2*i+1)
+	}
+	// 힙 인덱싱에서는 리프가 두 레벨에 걸쳐 있어 방문 순서가 리프 번호 순서와 다를 수 있음
+	slices.Sort(leaves)
+	return leaves, comparisons, nil
+}
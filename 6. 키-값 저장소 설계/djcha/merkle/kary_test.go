@@ -0,0 +1,58 @@
+package merkle
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestDiffFindsChangedLeaves(t *testing.T) {
+	for _, n := range []int{1, 5, 64, 1000} {
+		a := make([]string, n)
+		for i := range a {
+			a[i] = "field-" + strconv.Itoa(i)
+		}
+		b := slices.Clone(a)
+		var want []int
+		for _, i := range []int{0, n / 3, n / 2, n - 1} {
+			if !slices.Contains(want, i) {
+				b[i] = "changed"
+				want = append(want, i)
+			}
+		}
+		slices.Sort(want)
+
+		got, _, err := DiffLeaves(BuildStrings(a), BuildStrings(b))
+		if err != nil || !slices.Equal(got, want) {
+			t.Fatalf("n=%d: DiffLeaves = %v, %v, want %v", n, got, err, want)
+		}
+		for _, fanout := range []int{2, 3, 16} {
+			ta, _ := BuildKary(leafDigests(a), fanout)
+			tb, _ := BuildKary(leafDigests(b), fanout)
+			got, _, err := DiffKary(ta, tb)
+			if err != nil || !slices.Equal(got, want) {
+				t.Fatalf("n=%d fanout=%d: DiffKary = %v, %v, want %v", n, fanout, got, err, want)
+			}
+			if same, comparisons, _ := DiffKary(ta, ta); len(same) != 0 || comparisons != 1 {
+				t.Fatalf("n=%d fanout=%d: identical trees took %d comparisons, diff %v", n, fanout, comparisons, same)
+			}
+		}
+	}
+
+	if _, err := BuildKary(nil, 1); err == nil {
+		t.Fatal("fanout 1 should be rejected")
+	}
+	ta, _ := BuildKary(leafDigests([]string{"a", "b"}), 2)
+	tb, _ := BuildKary(leafDigests([]string{"a", "b"}), 4)
+	if _, _, err := DiffKary(ta, tb); err != ErrShapeMismatch {
+		t.Fatalf("err = %v, want ErrShapeMismatch", err)
+	}
+}
+
+func leafDigests(fields []string) []Digest {
+	out := make([]Digest, len(fields))
+	for i, f := range fields {
+		out[i] = HashLeaf([]byte(f))
+	}
+	return out
+}
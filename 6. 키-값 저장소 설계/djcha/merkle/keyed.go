@@ -0,0 +1,199 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"slices"
+	"strings"
+)
+
+// ErrBucketMismatch: 버킷 개수가 다른 두 트리는 모양이 달라 같은 위치끼리 비교할 수 없음
+var ErrBucketMismatch = errors.New("merkle: trees have different bucket counts")
+
+// KeyedTree: 키 해시로 키 공간을 고정된 개수의 버킷(구간)으로 나누고 버킷마다 리프 하나를 두는 머클 트리
+//
+// 필드 위치(인덱스)로 리프를 정하면 키가 하나만 빠져도 뒤쪽 리프가 모두 밀려 전부 다르게 보임
+// 버킷 개수만 같으면 복제본마다 키 개수가 달라도 트리 모양이 항상 같으므로
+// 같은 위치의 노드끼리 비교할 수 있음 (다이나모/카산드라의 안티 엔트로피 방식)
+type KeyedTree struct {
+	tree    *Tree
+	buckets [][]keyDigest // 버킷별 (키, 값 해시) 목록, 키 오름차순 정렬
+}
+
+type keyDigest struct {
+	key    string
+	digest Digest
+}
+
+// BucketOf: 키가 속하는 버킷 번호
+func BucketOf(key string, buckets int) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(buckets))
+}
+
+// BuildKeyed: 키-값 목록으로 버킷 머클 트리 구성
+// 값은 어떤 인코딩이든 상관없음 (값이 같은지만 해시로 판단)
+func BuildKeyed(entries map[string][]byte, buckets int) *KeyedTree {
+	if buckets <= 0 {
+		panic("merkle: bucket count must be positive")
+	}
+
+	kt := &KeyedTree{buckets: make([][]keyDigest, buckets)}
+	for key, value := range entries {
+		b := BucketOf(key, buckets)
+		kt.buckets[b] = append(kt.buckets[b], keyDigest{key: key, digest: HashLeaf(value)})
+	}
+
+	leaves := make([]Digest, buckets)
+	parallelFor(buckets, func(lo, hi int) {
+		for b := lo; b < hi; b++ {
+			slices.SortFunc(kt.buckets[b], func(x, y keyDigest) int { return strings.Compare(x.key, y.key) })
+			leaves[b] = hashBucket(kt.buckets[b])
+		}
+	})
+	kt.tree = BuildDigests(leaves)
+	return kt
+}
+
+// hashBucket: 버킷 안의 (키 길이, 키, 값 해시) 를 순서대로 이어 붙여 리프 해시 생성
+// 키 길이를 앞에 붙여야 "ab"+"c" 와 "a"+"bc" 가 같은 바이트열이 되지 않음
+func hashBucket(entries []keyDigest) Digest {
+	var buf []byte
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, uint64(len(e.key)))
+		buf = append(buf, e.key...)
+		buf = append(buf, e.digest[:]...)
+	}
+	return HashLeaf(buf)
+}
+
+// Root: 루트 해시
+func (kt *KeyedTree) Root() Digest {
+	return kt.tree.Root()
+}
+
+// Buckets: 버킷(리프) 개수
+func (kt *KeyedTree) Buckets() int {
+	return len(kt.buckets)
+}
+
+// BucketDigests: 버킷(리프) 해시 목록
+// 다른 노드에 이것만 보내면 상대가 같은 모양의 트리를 다시 만들어 비교할 수 있음
+func (kt *KeyedTree) BucketDigests() []Digest {
+	return kt.tree.Leaves()
+}
+
+// Tree: 버킷 해시로 만든 머클 트리 (키 목록 없이 트리만 파일에 저장할 때 사용)
+func (kt *KeyedTree) Tree() *Tree {
+	return kt.tree
+}
+
+// DiffBuckets: 상대의 버킷 해시 목록(remote)으로 트리를 만들어
+// 루트부터 해시가 다른 가지만 따라 내려가 값이 다른 버킷 번호를 찾음 (오름차순)
+// 키 목록은 그 버킷들만 주고받으면 됨
+func DiffBuckets(kt *KeyedTree, remote []Digest) ([]int, error) {
+	return DiffBucketTree(kt.tree, remote)
+}
+
+// DiffBucketTree: DiffBuckets 와 같지만 버킷 해시로 만든 트리(파일에서 연 트리 등)로 비교
+func DiffBucketTree(t *Tree, remote []Digest) ([]int, error) {
+	if len(remote) != t.Len() {
+		return nil, ErrBucketMismatch
+	}
+	other := BuildDigests(remote)
+
+	var buckets []int
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if t.Node(i) == other.Node(i) {
+			continue
+		}
+		if t.IsLeaf(i) {
+			buckets = append(buckets, t.LeafIndex(i))
+			continue
+		}
+		stack = append(stack, 2*i+2, 2*i+1)
+	}
+	slices.Sort(buckets)
+	return buckets, nil
+}
+
+// DiffReport: 두 복제본 비교 결과
+// 한쪽에만 있는 키와 값이 다른 키를 따로 알려주므로 양방향으로 복구할 수 있음
+type DiffReport struct {
+	MissingInA []string // B 에만 있는 키 → B 에서 A 로 복사
+	MissingInB []string // A 에만 있는 키 → A 에서 B 로 복사
+	Different  []string // 양쪽에 다 있지만 값이 다른 키 → 버전 비교 후 최신 값으로 맞춤
+
+	Comparisons   int // 비교한 노드(해시) 수
+	TransferBytes int // 비교를 위해 주고받았다고 가정하는 바이트 수
+}
+
+// DiffKeyed: 루트부터 해시가 다른 가지만 따라 내려가 다른 버킷을 찾고,
+// 그 버킷의 키 목록만 주고받아 키 단위로 분류
+func DiffKeyed(a, b *KeyedTree) (DiffReport, error) {
+	var report DiffReport
+	if a.Buckets() != b.Buckets() {
+		return report, ErrBucketMismatch
+	}
+
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		report.Comparisons++
+		report.TransferBytes += 2 * sha256.Size // 양쪽 해시 교환
+		if a.tree.Node(i) == b.tree.Node(i) {
+			continue // 해시가 같으면 그 아래는 모두 같음
+		}
+		if !a.tree.IsLeaf(i) {
+			stack = append(stack, 2*i+2, 2*i+1)
+			continue
+		}
+
+		bucket := a.tree.LeafIndex(i)
+		report.TransferBytes += bucketBytes(a.buckets[bucket]) + bucketBytes(b.buckets[bucket])
+		diffBucket(a.buckets[bucket], b.buckets[bucket], &report)
+	}
+
+	slices.Sort(report.MissingInA)
+	slices.Sort(report.MissingInB)
+	slices.Sort(report.Different)
+	return report, nil
+}
+
+// diffBucket: 정렬된 두 키 목록을 한 번에 훑으며(merge) 키 단위로 분류
+func diffBucket(a, b []keyDigest, report *DiffReport) {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i].key < b[j].key):
+			report.MissingInB = append(report.MissingInB, a[i].key)
+			i++
+		case i == len(a) || b[j].key < a[i].key:
+			report.MissingInA = append(report.MissingInA, b[j].key)
+			j++
+		default:
+			if a[i].digest != b[j].digest {
+				report.Different = append(report.Different, a[i].key)
+			}
+			i++
+			j++
+		}
+	}
+}
+
+func bucketBytes(entries []keyDigest) int {
+	n := 0
+	for _, e := range entries {
+		n += len(e.key) + sha256.Size
+	}
+	return n
+}
@@ -0,0 +1,66 @@
+package merkle
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestDiffKeyedDifferentKeyCounts(t *testing.T) {
+	a := map[string][]byte{}
+	b := map[string][]byte{}
+	for i := range 1000 {
+		key := "key-" + strconv.Itoa(i)
+		a[key] = []byte("v")
+		b[key] = []byte("v")
+	}
+	delete(b, "key-10")
+	delete(b, "key-11")
+	a["only-a"] = []byte("v")
+	b["only-b"] = []byte("v")
+	b["key-500"] = []byte("changed")
+
+	report, err := DiffKeyed(BuildKeyed(a, 64), BuildKeyed(b, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"only-b"}; !slices.Equal(report.MissingInA, want) {
+		t.Errorf("MissingInA = %v, want %v", report.MissingInA, want)
+	}
+	if want := []string{"key-10", "key-11", "only-a"}; !slices.Equal(report.MissingInB, want) {
+		t.Errorf("MissingInB = %v, want %v", report.MissingInB, want)
+	}
+	if want := []string{"key-500"}; !slices.Equal(report.Different, want) {
+		t.Errorf("Different = %v, want %v", report.Different, want)
+	}
+}
+
+func TestDiffKeyedBucketMismatch(t *testing.T) {
+	if _, err := DiffKeyed(BuildKeyed(nil, 8), BuildKeyed(nil, 16)); err != ErrBucketMismatch {
+		t.Fatalf("err = %v, want ErrBucketMismatch", err)
+	}
+}
+
+func TestDiffBucketsFromRemoteDigests(t *testing.T) {
+	a := map[string][]byte{"x": []byte("1"), "y": []byte("1"), "z": []byte("1")}
+	b := map[string][]byte{"x": []byte("1"), "y": []byte("2"), "z": []byte("1"), "w": []byte("1")}
+	local := BuildKeyed(a, 32)
+
+	got, err := DiffBuckets(local, BuildKeyed(b, 32).BucketDigests())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{BucketOf("y", 32), BucketOf("w", 32)}
+	slices.Sort(want)
+	want = slices.Compact(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("DiffBuckets = %v, want %v", got, want)
+	}
+
+	if got, _ := DiffBuckets(local, local.BucketDigests()); len(got) != 0 {
+		t.Fatalf("identical trees differ in buckets %v", got)
+	}
+	if _, err := DiffBuckets(local, BuildKeyed(b, 16).BucketDigests()); err != ErrBucketMismatch {
+		t.Fatalf("err = %v, want ErrBucketMismatch", err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package merkle
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile: 메모리 매핑을 쓸 수 없는 환경에서는 파일 전체를 읽어 옴
+func mapFile(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func unmapFile([]byte) error {
+	return nil
+}
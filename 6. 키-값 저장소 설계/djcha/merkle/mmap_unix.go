@@ -0,0 +1,17 @@
+//go:build unix
+
+package merkle
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile: 파일 전체를 읽기 전용으로 메모리 매핑 (페이지는 접근할 때 운영체제가 읽어 옴)
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,211 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrKeyNotFound: 트리에 없는 키의 포함 증명을 요청함
+	ErrKeyNotFound = errors.New("merkle: key not in tree")
+	// ErrInvalidProof: 증명 인코딩이 깨졌거나 트리 모양과 맞지 않음
+	ErrInvalidProof = errors.New("merkle: invalid proof")
+)
+
+// 증명 인코딩 형식 버전 (형식이 바뀌면 올림)
+const proofVersion = 1
+
+// ProofEntry: 같은 버킷에 있는 다른 키와 그 값의 해시
+type ProofEntry struct {
+	Key    string
+	Digest Digest
+}
+
+// Proof: 키 하나가 루트 해시에 포함되어 있다는 증명 (포함 증명, Inclusion Proof)
+//
+// 버킷 리프 해시는 버킷 안의 모든 (키, 값 해시) 로 만들므로,
+// 리프를 다시 계산하려면 같은 버킷의 다른 키들(Neighbors)도 필요함
+// 그다음 리프에서 루트까지 올라가며 형제 노드 해시(Path)와 합치면 루트가 나와야 함
+//
+// 크기는 버킷 하나의 키 수 + log2(버킷 수) 개 해시 → 전체 데이터를 받지 않고도 값 하나를 검증할 수 있음
+type Proof struct {
+	Buckets   int          // 트리의 버킷 개수 (키가 속한 버킷과 트리 모양을 정함)
+	Neighbors []ProofEntry // 같은 버킷의 다른 키 (키 오름차순)
+	Path      []Digest     // 리프에서 루트 방향으로 형제 노드 해시
+}
+
+// Path: k 번째 리프에서 루트까지 올라가며 만나는 형제 노드 해시 (리프에 가까운 것부터)
+func (t *Tree) Path(k int) []Digest {
+	var path []Digest
+	for i := t.leafBase() + k; i > 0; i = (i - 1) / 2 {
+		path = append(path, t.nodes[sibling(i)])
+	}
+	return path
+}
+
+// sibling: 힙 인덱스 i 번 노드의 형제 (왼쪽 자식은 홀수, 오른쪽 자식은 짝수 인덱스)
+func sibling(i int) int {
+	if i%2 == 1 {
+		return i + 1
+	}
+	return i - 1
+}
+
+// Proof: key 의 포함 증명 (트리에 없는 키면 ErrKeyNotFound)
+func (kt *KeyedTree) Proof(key string) (*Proof, error) {
+	b := BucketOf(key, len(kt.buckets))
+	entries := kt.buckets[b]
+	i, ok := slices.BinarySearchFunc(entries, key, func(e keyDigest, key string) int { return strings.Compare(e.key, key) })
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	p := &Proof{Buckets: len(kt.buckets), Path: kt.tree.Path(b)}
+	for j, e := range entries {
+		if j != i {
+			p.Neighbors = append(p.Neighbors, ProofEntry{Key: e.key, Digest: e.digest})
+		}
+	}
+	return p, nil
+}
+
+// VerifyProof: key 의 값이 value 인 상태가 root 해시를 가진 트리에 들어 있는지 검증
+// 서버가 서명해서 공개한 루트 해시만 믿으면, 클라이언트는 값 하나와 증명만 받아서 확인할 수 있음
+func VerifyProof(root Digest, key string, value []byte, p *Proof) error {
+	if p == nil || p.Buckets <= 0 {
+		return ErrInvalidProof
+	}
+
+	// 버킷 안의 키 목록을 원래 순서대로 되살려서 리프 해시 계산
+	entries := make([]keyDigest, 0, len(p.Neighbors)+1)
+	for _, e := range p.Neighbors {
+		if BucketOf(e.Key, p.Buckets) != BucketOf(key, p.Buckets) {
+			return ErrInvalidProof
+		}
+		entries = append(entries, keyDigest{key: e.Key, digest: e.Digest})
+	}
+	entries = append(entries, keyDigest{key: key, digest: HashLeaf(value)})
+	slices.SortFunc(entries, func(x, y keyDigest) int { return strings.Compare(x.key, y.key) })
+	for j := 1; j < len(entries); j++ {
+		if entries[j].key == entries[j-1].key {
+			return ErrInvalidProof
+		}
+	}
+	cur := hashBucket(entries)
+
+	// 리프에서 루트까지 올라감 (경로 길이가 트리 높이와 맞아야 함)
+	i := p.Buckets - 1 + BucketOf(key, p.Buckets)
+	for _, sib := range p.Path {
+		if i == 0 {
+			return ErrInvalidProof
+		}
+		if i%2 == 1 {
+			cur = HashChildren(&cur, &sib)
+		} else {
+			cur = HashChildren(&sib, &cur)
+		}
+		i = (i - 1) / 2
+	}
+	if i != 0 || cur != root {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// MarshalBinary: 증명을 바이트열로 인코딩
+//
+//	버전(1) | 버킷 수(uvarint) | 이웃 수(uvarint) | (키 길이(uvarint) 키 값 해시(32))... | 경로 길이(uvarint) | 해시(32)...
+func (p *Proof) MarshalBinary() ([]byte, error) {
+	size := 1 + 3*binary.MaxVarintLen64 + len(p.Path)*len(Digest{})
+	for _, e := range p.Neighbors {
+		size += binary.MaxVarintLen64 + len(e.Key) + len(Digest{})
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, proofVersion)
+	buf = binary.AppendUvarint(buf, uint64(p.Buckets))
+	buf = binary.AppendUvarint(buf, uint64(len(p.Neighbors)))
+	for _, e := range p.Neighbors {
+		buf = binary.AppendUvarint(buf, uint64(len(e.Key)))
+		buf = append(buf, e.Key...)
+		buf = append(buf, e.Digest[:]...)
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(p.Path)))
+	for _, d := range p.Path {
+		buf = append(buf, d[:]...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary: MarshalBinary 로 만든 바이트열을 증명으로 되돌림 (형식이 틀리면 ErrInvalidProof)
+func (p *Proof) UnmarshalBinary(data []byte) error {
+	r := proofReader{buf: data}
+	if len(data) == 0 || data[0] != proofVersion {
+		return ErrInvalidProof
+	}
+	r.buf = r.buf[1:]
+
+	buckets := r.uvarint()
+	neighbors := r.uvarint()
+	if r.err != nil || buckets == 0 || buckets > 1<<40 || neighbors > uint64(len(r.buf)) {
+		return ErrInvalidProof
+	}
+	out := Proof{Buckets: int(buckets)}
+	for range neighbors {
+		keyLen := r.uvarint()
+		key := r.bytes(keyLen)
+		digest := r.digest()
+		if r.err != nil {
+			return ErrInvalidProof
+		}
+		out.Neighbors = append(out.Neighbors, ProofEntry{Key: string(key), Digest: digest})
+	}
+	path := r.uvarint()
+	if r.err != nil || path > 64 {
+		return ErrInvalidProof
+	}
+	for range path {
+		out.Path = append(out.Path, r.digest())
+	}
+	if r.err != nil || len(r.buf) != 0 {
+		return ErrInvalidProof
+	}
+	*p = out
+	return nil
+}
+
+// proofReader: 앞에서부터 읽다가 모자라면 err 를 남기고 이후 읽기는 모두 빈 값
+type proofReader struct {
+	buf []byte
+	err error
+}
+
+func (r *proofReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = ErrInvalidProof
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *proofReader) bytes(n uint64) []byte {
+	if r.err != nil || n > uint64(len(r.buf)) {
+		r.err = ErrInvalidProof
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *proofReader) digest() Digest {
+	var d Digest
+	copy(d[:], r.bytes(uint64(len(d))))
+	return d
+}
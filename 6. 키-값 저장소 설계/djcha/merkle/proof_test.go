@@ -0,0 +1,65 @@
+package merkle
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestProofRoundTrip(t *testing.T) {
+	for _, buckets := range []int{1, 2, 7, 64} {
+		entries := map[string][]byte{}
+		for i := range 300 {
+			entries["key-"+strconv.Itoa(i)] = []byte("value-" + strconv.Itoa(i))
+		}
+		kt := BuildKeyed(entries, buckets)
+		root := kt.Root()
+
+		for _, key := range []string{"key-0", "key-137", "key-299"} {
+			p, err := kt.Proof(key)
+			if err != nil {
+				t.Fatalf("buckets=%d: %v", buckets, err)
+			}
+			data, _ := p.MarshalBinary()
+			var decoded Proof
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatalf("buckets=%d: decode: %v", buckets, err)
+			}
+			if err := VerifyProof(root, key, entries[key], &decoded); err != nil {
+				t.Fatalf("buckets=%d %s: %v", buckets, key, err)
+			}
+			if err := VerifyProof(root, key, []byte("forged"), &decoded); !errors.Is(err, ErrInvalidProof) {
+				t.Fatalf("buckets=%d %s: forged value verified (err = %v)", buckets, key, err)
+			}
+			// 잘린 인코딩은 거절
+			if err := new(Proof).UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrInvalidProof) {
+				t.Fatalf("buckets=%d: truncated proof decoded (err = %v)", buckets, err)
+			}
+		}
+	}
+}
+
+func TestProofRejectsTampering(t *testing.T) {
+	entries := map[string][]byte{"a": []byte("1"), "b": []byte("2"), "c": []byte("3"), "d": []byte("4")}
+	kt := BuildKeyed(entries, 8)
+	if _, err := kt.Proof("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("err = %v, want ErrKeyNotFound", err)
+	}
+
+	p, _ := kt.Proof("a")
+	p.Path[0][0] ^= 1
+	if err := VerifyProof(kt.Root(), "a", []byte("1"), p); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("tampered path verified (err = %v)", err)
+	}
+	p, _ = kt.Proof("a")
+	p.Path = p.Path[1:]
+	if err := VerifyProof(kt.Root(), "a", []byte("1"), p); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("short path verified (err = %v)", err)
+	}
+	// 다른 루트(다른 데이터)로는 검증되지 않음
+	entries["b"] = []byte("changed")
+	p, _ = kt.Proof("a")
+	if err := VerifyProof(BuildKeyed(entries, 8).Root(), "a", []byte("1"), p); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("proof verified against another root (err = %v)", err)
+	}
+}
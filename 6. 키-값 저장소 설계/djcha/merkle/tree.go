@@ -0,0 +1,185 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// Digest: SHA-256 해시 원본 바이트 (32 Byte)
+// hex 문자열(64 Byte + 문자열 헤더)로 바꾸지 않고 고정 크기 배열 그대로 보관
+type Digest [32]byte
+
+// 리프 해시와 내부 노드 해시가 서로 같은 값이 나오지 않도록 앞에 붙이는 구분 바이트
+// (리프 데이터를 "자식 해시 2개를 붙인 값"으로 위장하는 공격 방지)
+const (
+	leafPrefix     = 0x00
+	internalPrefix = 0x01
+)
+
+// 이 개수보다 적은 작업은 고루틴을 띄우는 비용이 더 크므로 순차 처리
+const minParallelWork = 4096
+
+// Tree: 포인터 노드 대신 하나의 평평한 배열에 모든 해시를 담는 머클 트리
+//
+// 암묵적 힙 인덱싱(Implicit Heap Indexing)
+//   - i 번 노드의 자식: 2i+1 (왼쪽), 2i+2 (오른쪽) / 부모: (i-1)/2
+//   - 리프가 n 개이면 전체 노드는 2n-1 개 (모든 내부 노드는 자식이 정확히 2개)
+//   - 내부 노드: nodes[0 : n-1], 리프 k: nodes[n-1+k]
+type Tree struct {
+	nodes  []Digest
+	leaves int
+	mapped []byte // OpenFile 로 연 트리면 nodes 가 가리키는 메모리 매핑 영역 (Close 로 해제)
+}
+
+// HashLeaf: 리프 데이터(원본 바이트)의 해시
+func HashLeaf(data []byte) Digest {
+	buf := make([]byte, 0, 1+len(data))
+	buf = append(buf, leafPrefix)
+	buf = append(buf, data...)
+	return sha256.Sum256(buf)
+}
+
+// HashChildren: 두 자식 해시(각 32 Byte)를 이어 붙여 부모 해시 생성
+// 문자열 결합 없이 스택 위의 65 Byte 버퍼에서 바로 계산
+func HashChildren(left, right *Digest) Digest {
+	var buf [1 + 2*sha256.Size]byte
+	buf[0] = internalPrefix
+	copy(buf[1:], left[:])
+	copy(buf[1+sha256.Size:], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+// Build: 바이트 슬라이스 리프 목록으로 트리 구성
+func Build(leaves [][]byte) *Tree {
+	t := newTree(len(leaves))
+	base := t.leafBase()
+	parallelFor(len(leaves), func(lo, hi int) {
+		for k := lo; k < hi; k++ {
+			t.nodes[base+k] = HashLeaf(leaves[k])
+		}
+	})
+	t.buildInternal()
+	return t
+}
+
+// BuildStrings: 문자열 리프 목록으로 트리 구성 (기존 Record.Fields 를 그대로 사용)
+func BuildStrings(leaves []string) *Tree {
+	t := newTree(len(leaves))
+	base := t.leafBase()
+	parallelFor(len(leaves), func(lo, hi int) {
+		// 고루틴(작업 구간)마다 버퍼를 하나만 만들어 재사용 -> 리프마다 할당하지 않음
+		buf := make([]byte, 0, 256)
+		for k := lo; k < hi; k++ {
+			buf = append(buf[:0], leafPrefix)
+			buf = append(buf, leaves[k]...)
+			t.nodes[base+k] = sha256.Sum256(buf)
+		}
+	})
+	t.buildInternal()
+	return t
+}
+
+// BuildDigests: 이미 계산된 리프 해시 목록으로 트리 구성
+func BuildDigests(leaves []Digest) *Tree {
+	t := newTree(len(leaves))
+	copy(t.nodes[t.leafBase():], leaves)
+	t.buildInternal()
+	return t
+}
+
+func newTree(leaves int) *Tree {
+	size := 0
+	if leaves > 0 {
+		size = 2*leaves - 1
+	}
+	return &Tree{nodes: make([]Digest, size), leaves: leaves}
+}
+
+func (t *Tree) leafBase() int {
+	return t.leaves - 1
+}
+
+// buildInternal: 가장 깊은 레벨부터 루트까지 한 레벨씩 올라가며 내부 노드 계산
+// 같은 레벨의 노드끼리는 서로 의존하지 않으므로 여러 고루틴이 나눠서 계산
+func (t *Tree) buildInternal() {
+	internal := t.leaves - 1 // 내부 노드 개수 (인덱스 0 ~ internal-1)
+	if internal <= 0 {
+		return
+	}
+
+	// 깊이 d 레벨의 인덱스 범위: [2^d - 1, 2^(d+1) - 1)
+	for d := bits.Len(uint(internal)) - 1; d >= 0; d-- {
+		lo := 1<<d - 1
+		hi := min(1<<(d+1)-1, internal)
+		parallelFor(hi-lo, func(a, b int) {
+			for i := lo + a; i < lo+b; i++ {
+				t.nodes[i] = HashChildren(&t.nodes[2*i+1], &t.nodes[2*i+2])
+			}
+		})
+	}
+}
+
+// Root: 루트 해시 (리프가 없으면 0 으로 채워진 값)
+func (t *Tree) Root() Digest {
+	if len(t.nodes) == 0 {
+		return Digest{}
+	}
+	return t.nodes[0]
+}
+
+// Len: 리프 개수
+func (t *Tree) Len() int {
+	return t.leaves
+}
+
+// Leaf: k 번째 리프의 해시
+func (t *Tree) Leaf(k int) Digest {
+	return t.nodes[t.leafBase()+k]
+}
+
+// Leaves: 모든 리프의 해시 (리프 번호 순서)
+func (t *Tree) Leaves() []Digest {
+	out := make([]Digest, t.leaves)
+	for k := range out {
+		out[k] = t.Leaf(k)
+	}
+	return out
+}
+
+// Node: 힙 인덱스 i 번 노드의 해시
+func (t *Tree) Node(i int) Digest {
+	return t.nodes[i]
+}
+
+// IsLeaf: 힙 인덱스 i 번 노드가 리프인지 여부
+func (t *Tree) IsLeaf(i int) bool {
+	return i >= t.leafBase()
+}
+
+// LeafIndex: 리프 노드의 힙 인덱스를 리프 번호(k)로 변환
+func (t *Tree) LeafIndex(i int) int {
+	return i - t.leafBase()
+}
+
+// parallelFor: [0, n) 구간을 CPU 개수만큼 나눠 고루틴으로 동시에 처리
+func parallelFor(n int, fn func(lo, hi int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if n < minParallelWork || workers == 1 {
+		fn(0, n)
+		return
+	}
+
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		hi := min(lo+chunk, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(lo, hi)
+		}()
+	}
+	wg.Wait()
+}
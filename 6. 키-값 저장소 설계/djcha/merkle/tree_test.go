@@ -0,0 +1,45 @@
+package merkle
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+// 힙 인덱스 규칙을 그대로 재귀로 따라가며 계산한 기대값
+func referenceNode(leaves []string, i int) Digest {
+	n := len(leaves)
+	if i >= n-1 {
+		return HashLeaf([]byte(leaves[i-(n-1)]))
+	}
+	l, r := referenceNode(leaves, 2*i+1), referenceNode(leaves, 2*i+2)
+	return HashChildren(&l, &r)
+}
+
+func TestBuildMatchesReference(t *testing.T) {
+	// 병렬 경로도 타도록 워커 수를 강제로 늘림
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	for _, n := range []int{1, 2, 3, 7, 8, 1000, 3*minParallelWork + 5} {
+		leaves := make([]string, n)
+		raw := make([][]byte, n)
+		for i := range leaves {
+			leaves[i] = "field-" + strconv.Itoa(i)
+			raw[i] = []byte(leaves[i])
+		}
+
+		want := referenceNode(leaves, 0)
+		if got := BuildStrings(leaves).Root(); got != want {
+			t.Fatalf("n=%d: BuildStrings root mismatch", n)
+		}
+		if got := Build(raw).Root(); got != want {
+			t.Fatalf("n=%d: Build root mismatch", n)
+		}
+	}
+}
+
+func TestEmptyTree(t *testing.T) {
+	if got := BuildStrings(nil).Root(); got != (Digest{}) {
+		t.Fatalf("empty tree root = %x, want zero", got)
+	}
+}
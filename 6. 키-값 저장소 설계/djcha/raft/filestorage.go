@@ -0,0 +1,260 @@
+package raft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// WAL 을 남은 상태 하나로 다시 쓰기 전까지 붙이는 최대 레코드 수
+// 스냅샷을 뜨지 않아도 하트비트마다 붙는 상태 레코드로 파일이 끝없이 커지지 않게 함
+const walCompactRecords = 10000
+
+// FileStorage: 디렉터리에 상태를 남기는 영구 저장소
+//   - snapshot: 마지막 스냅샷 (임시 파일에 쓰고 fsync 한 뒤 이름을 바꿈)
+//   - wal: 바뀐 부분(Update)을 차례로 붙이는 로그. 레코드마다 길이와 CRC 를 붙이고 Save 마다 fsync
+//
+// 스냅샷을 저장하거나 레코드가 많이 쌓이면 WAL 을 지금 상태 하나로 다시 씀
+// 쓰는 중에 죽어서 끝이 잘리거나 깨진 레코드는 Save 가 끝나지 않은 것이므로 열 때 잘라냄
+type FileStorage struct {
+	mu      sync.Mutex
+	dir     string
+	wal     *os.File
+	size    int64 // WAL 에서 온전히 쓴 레코드까지의 크기
+	records int
+	st      PersistentState // 지금까지 저장한 상태 (WAL 을 다시 쓸 때 사용)
+}
+
+// walRecord: WAL 레코드 하나 (스냅샷은 따로 파일에 둠)
+type walRecord struct {
+	HardState
+	From    uint64  `json:"from,omitempty"`
+	Entries []Entry `json:"entries,omitempty"`
+}
+
+// OpenFileStorage: dir 의 상태를 읽어 저장소를 엶 (없으면 빈 상태로 만듦)
+func OpenFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &FileStorage{dir: dir}
+
+	data, err := os.ReadFile(s.path("snapshot"))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &s.st.Snapshot); err != nil {
+			return nil, fmt.Errorf("raft: read snapshot: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	s.wal, err = os.OpenFile(s.path("wal"), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// 새로 만든 WAL 파일이 디렉터리에 남도록 디렉터리도 fsync
+	if err = s.replay(); err == nil {
+		err = syncDir(dir)
+	}
+	if err != nil {
+		s.wal.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStorage) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// replay: WAL 레코드를 차례로 반영하고, 깨진 꼬리는 잘라낸 뒤 끝에서부터 붙이도록 위치를 옮김
+func (s *FileStorage) replay() error {
+	r := bufio.NewReader(s.wal)
+	var offset int64
+	for {
+		var rec walRecord
+		n, err := readRecord(r, &rec)
+		if err != nil {
+			break
+		}
+		offset += n
+		s.records++
+		s.st.apply(Update{HardState: rec.HardState, From: rec.From, Entries: rec.Entries})
+	}
+	if err := s.truncate(offset); err != nil {
+		return err
+	}
+
+	// 스냅샷은 새로 썼는데 WAL 을 다시 쓰기 전에 죽었으면 옛 WAL 에 스냅샷에 들어간 항목이 남아 있음
+	snap := s.st.Snapshot
+	s.st.apply(Update{HardState: s.st.HardState, Snapshot: &snap})
+	if len(s.st.Entries) > 0 && s.st.Entries[0].Index != snap.Index+1 {
+		s.st.Entries = nil
+	}
+	return nil
+}
+
+// readRecord: [길이 4바이트][CRC32 4바이트][JSON] 하나를 읽음 (읽은 바이트 수)
+func readRecord(r io.Reader, rec *walRecord) (int64, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	size, sum := binary.LittleEndian.Uint32(header[:4]), binary.LittleEndian.Uint32(header[4:])
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, err
+	}
+	if crc32.ChecksumIEEE(data) != sum {
+		return 0, errors.New("raft: corrupt WAL record")
+	}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return 0, err
+	}
+	return int64(len(header) + len(data)), nil
+}
+
+func encodeRecord(rec walRecord) ([]byte, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 8, 8+len(data))
+	binary.LittleEndian.PutUint32(buf[:4], uint32(len(data)))
+	binary.LittleEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(data))
+	return append(buf, data...), nil
+}
+
+// Save: 바뀐 부분을 WAL 에 붙이고 fsync (새 스냅샷이 있으면 스냅샷 파일을 쓰고 WAL 을 다시 씀)
+func (s *FileStorage) Save(u Update) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u.Snapshot != nil {
+		data, err := json.Marshal(u.Snapshot)
+		if err != nil {
+			return err
+		}
+		if err := writeFileSync(s.path("snapshot"), data); err != nil {
+			return err
+		}
+		s.st.apply(u)
+		return s.rewrite()
+	}
+
+	buf, err := encodeRecord(walRecord{HardState: u.HardState, From: u.From, Entries: u.Entries})
+	if err != nil {
+		return err
+	}
+	if _, err := s.wal.Write(buf); err != nil {
+		// 반만 쓴 레코드 뒤에 다음 레코드를 붙이면 열 때 거기서 멈추므로 잘라냄
+		return errors.Join(err, s.truncate(s.size))
+	}
+	if err := s.wal.Sync(); err != nil {
+		return errors.Join(err, s.truncate(s.size))
+	}
+	s.st.apply(u)
+	s.size += int64(len(buf))
+	s.records++
+	if s.records >= walCompactRecords {
+		return s.rewrite()
+	}
+	return nil
+}
+
+// rewrite: 지금 상태를 레코드 하나로 새 WAL 에 쓰고 바꿔 끼움
+func (s *FileStorage) rewrite() error {
+	buf, err := encodeRecord(walRecord{
+		HardState: s.st.HardState,
+		From:      s.st.Snapshot.Index + 1,
+		Entries:   s.st.Entries,
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeFileSync(s.path("wal"), buf); err != nil {
+		return err
+	}
+	wal, err := os.OpenFile(s.path("wal"), os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := wal.Seek(int64(len(buf)), io.SeekStart); err != nil {
+		wal.Close()
+		return err
+	}
+	s.wal.Close()
+	s.wal, s.size, s.records = wal, int64(len(buf)), 1
+	return nil
+}
+
+// truncate: WAL 을 size 로 자르고 그 끝에서부터 붙이도록 위치를 옮김
+func (s *FileStorage) truncate(size int64) error {
+	if err := s.wal.Truncate(size); err != nil {
+		return err
+	}
+	if _, err := s.wal.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+	s.size = size
+	return nil
+}
+
+func (s *FileStorage) Load() (PersistentState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.st
+	st.Entries = slices.Clone(st.Entries)
+	st.Snapshot.Peers = slices.Clone(st.Snapshot.Peers)
+	return st, nil
+}
+
+// Close: WAL 파일을 닫음
+func (s *FileStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wal.Close()
+}
+
+// writeFileSync: 임시 파일에 쓰고 fsync 한 뒤 이름을 바꾸고, 디렉터리도 fsync 해서 이름 바꾸기까지 남김
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,93 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPTransport: 노드끼리 HTTP/JSON 으로 메시지를 주고받는 Transport
+// 노드 ID 를 주소(host:port)로 쓰고, 받는 쪽은 Handler 를 /raft/ 에 연결해 둬야 함
+//
+// 노드마다 보낼 메시지 큐와 보내는 고루틴이 하나씩 있어서 Send 가 막히지 않음
+// 큐가 가득 차면(상대가 죽었거나 느림) 새 메시지를 버림 → 래프트가 하트비트 때 다시 보냄
+type HTTPTransport struct {
+	Client  *http.Client
+	Timeout time.Duration // 메시지 하나 보내는 제한 시간
+	Token   string        // 비어 있지 않으면 Authorization: Bearer 로 붙여 보냄 (받는 쪽이 토큰을 검사할 때)
+
+	mu     sync.Mutex
+	queues map[string]chan Message
+}
+
+// 노드별 보낼 메시지 큐 크기
+const httpQueueSize = 256
+
+// NewHTTPTransport: 메시지 하나당 1초 제한으로 보내는 전송 계층
+func NewHTTPTransport() *HTTPTransport {
+	return &HTTPTransport{
+		Client:  &http.Client{},
+		Timeout: time.Second,
+		queues:  make(map[string]chan Message),
+	}
+}
+
+func (t *HTTPTransport) Send(m Message) {
+	t.mu.Lock()
+	q, ok := t.queues[m.To]
+	if !ok {
+		q = make(chan Message, httpQueueSize)
+		t.queues[m.To] = q
+		go t.sendLoop(m.To, q)
+	}
+	t.mu.Unlock()
+
+	select {
+	case q <- m:
+	default:
+	}
+}
+
+// sendLoop: to 로 가는 메시지를 순서대로 보냄 (실패하면 버림)
+func (t *HTTPTransport) sendLoop(to string, q <-chan Message) {
+	for m := range q {
+		body, err := json.Marshal(m)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), t.Timeout)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+to+"/raft/message", bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			if t.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+t.Token)
+			}
+			if resp, err := t.Client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+		cancel()
+	}
+}
+
+// Handler: 다른 노드가 보낸 메시지를 받는 HTTP 핸들러 (POST /raft/message)
+func Handler(n *Node) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /raft/message", func(w http.ResponseWriter, r *http.Request) {
+		var m Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, "invalid message", http.StatusBadRequest)
+			return
+		}
+		if m.To != n.ID() {
+			http.Error(w, "message for "+m.To+" delivered to "+n.ID(), http.StatusMisdirectedRequest)
+			return
+		}
+		n.Step(m)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
@@ -0,0 +1,79 @@
+package raft
+
+import "slices"
+
+// raftLog: 마지막 스냅샷 이후의 로그 항목
+// 인덱스는 1 부터 시작. 스냅샷에 포함된 마지막 항목(snapIndex, snapTerm) 이후 항목만 entries 에 있음
+type raftLog struct {
+	snapIndex uint64
+	snapTerm  uint64
+	entries   []Entry // entries[i].Index == snapIndex + 1 + i
+}
+
+func (l *raftLog) lastIndex() uint64 {
+	return l.snapIndex + uint64(len(l.entries))
+}
+
+func (l *raftLog) lastTerm() uint64 {
+	t, _ := l.term(l.lastIndex())
+	return t
+}
+
+// term: i 번째 항목의 임기 (스냅샷으로 지워졌거나 아직 없으면 false)
+func (l *raftLog) term(i uint64) (uint64, bool) {
+	switch {
+	case i == l.snapIndex:
+		return l.snapTerm, true
+	case i < l.snapIndex || i > l.lastIndex():
+		return 0, false
+	}
+	return l.entries[i-l.snapIndex-1].Term, true
+}
+
+// entry: i 번째 항목 (snapIndex < i <= lastIndex 여야 함)
+func (l *raftLog) entry(i uint64) Entry {
+	return l.entries[i-l.snapIndex-1]
+}
+
+// slice: from 번째부터 최대 limit 개 (복사본)
+func (l *raftLog) slice(from uint64, limit int) []Entry {
+	if from > l.lastIndex() {
+		return nil
+	}
+	rest := l.entries[from-l.snapIndex-1:]
+	return slices.Clone(rest[:min(len(rest), limit)])
+}
+
+func (l *raftLog) append(e Entry) {
+	l.entries = append(l.entries, e)
+}
+
+// merge: 리더가 보낸 항목을 붙임. 같은 인덱스에 임기가 다른 항목이 있으면 그 뒤를 모두 잘라내고 붙임
+// from = 새로 붙이기 시작한 인덱스 (붙인 항목이 없으면 0), 잘라낸 항목이 있으면 truncated
+func (l *raftLog) merge(entries []Entry) (from uint64, truncated bool) {
+	for i, e := range entries {
+		if e.Index <= l.snapIndex {
+			continue
+		}
+		if t, ok := l.term(e.Index); ok {
+			if t == e.Term {
+				continue
+			}
+			l.entries = l.entries[:e.Index-l.snapIndex-1]
+			truncated = true
+		}
+		l.entries = append(l.entries, entries[i:]...)
+		return e.Index, truncated
+	}
+	return 0, false
+}
+
+// compact: index 까지의 항목을 스냅샷으로 대체하고 지움
+func (l *raftLog) compact(index, term uint64) {
+	if index >= l.lastIndex() {
+		l.entries = nil
+	} else if index > l.snapIndex {
+		l.entries = slices.Clone(l.entries[index-l.snapIndex:])
+	}
+	l.snapIndex, l.snapTerm = index, term
+}
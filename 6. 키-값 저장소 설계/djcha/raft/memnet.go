@@ -0,0 +1,116 @@
+package raft
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// MemNetwork: 메모리 안에서 메시지를 주고받는 네트워크 (테스트용 Transport)
+//
+// Send 는 메시지를 큐에 넣기만 하고, Deliver 를 불러야 실제로 전달됨
+// 틱과 전달을 모두 호출하는 쪽이 정하므로 같은 순서로 부르면 항상 같은 결과가 나옴
+// 죽은 노드(Crash)나 다른 분할 그룹으로 가는 메시지는 버림
+type MemNetwork struct {
+	mu      sync.Mutex
+	nodes   map[string]*Node
+	down    map[string]bool
+	group   map[string]int // 분할 그룹 번호 (없으면 0)
+	queue   []Message
+	dropped int
+}
+
+// NewMemNetwork: 빈 메모리 네트워크
+func NewMemNetwork() *MemNetwork {
+	return &MemNetwork{
+		nodes: make(map[string]*Node),
+		down:  make(map[string]bool),
+		group: make(map[string]int),
+	}
+}
+
+// Send: Transport 구현 (큐에 넣음)
+func (net *MemNetwork) Send(m Message) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.queue = append(net.queue, m)
+}
+
+// Add: 노드를 네트워크에 연결 (같은 ID 로 다시 부르면 재시작한 노드로 교체하고 살림)
+func (net *MemNetwork) Add(n *Node) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.nodes[n.ID()] = n
+	delete(net.down, n.ID())
+}
+
+// Crash: 노드를 멈춤 (틱도 받지 않고 오가는 메시지도 버림)
+func (net *MemNetwork) Crash(id string) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.down[id] = true
+}
+
+// Partition: 노드들을 그룹으로 나눔. 다른 그룹끼리는 메시지가 오가지 않음 (목록에 없는 노드는 첫 그룹과 같은 쪽)
+func (net *MemNetwork) Partition(groups ...[]string) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	clear(net.group)
+	for i, g := range groups {
+		for _, id := range g {
+			net.group[id] = i
+		}
+	}
+}
+
+// Heal: 분할 해제
+func (net *MemNetwork) Heal() {
+	net.Partition()
+}
+
+// Dropped: 지금까지 버린 메시지 수
+func (net *MemNetwork) Dropped() int {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	return net.dropped
+}
+
+// Deliver: 큐가 빌 때까지 메시지를 보낸 순서대로 전달 (전달 중 새로 생긴 메시지도 포함)
+func (net *MemNetwork) Deliver() {
+	for {
+		net.mu.Lock()
+		if len(net.queue) == 0 {
+			net.mu.Unlock()
+			return
+		}
+		m := net.queue[0]
+		net.queue = net.queue[1:]
+		to, ok := net.nodes[m.To]
+		if !ok || net.down[m.From] || net.down[m.To] || net.group[m.From] != net.group[m.To] {
+			net.dropped++
+			to = nil
+		}
+		net.mu.Unlock()
+
+		if to != nil {
+			to.Step(m)
+		}
+	}
+}
+
+// Tick: 살아 있는 모든 노드를 ID 순서대로 한 틱씩 움직이고 메시지를 전달
+func (net *MemNetwork) Tick() {
+	net.mu.Lock()
+	var alive []*Node
+	for _, id := range slices.Sorted(maps.Keys(net.nodes)) {
+		if !net.down[id] {
+			alive = append(alive, net.nodes[id])
+		}
+	}
+	net.mu.Unlock()
+
+	for _, n := range alive {
+		n.Tick()
+	}
+	net.Deliver()
+}
@@ -0,0 +1,821 @@
+// Package raft: 강한 일관성(선형화)이 필요한 데이터를 위한 래프트 합의 구현
+//
+// 시간은 Tick 호출 횟수로만 재고, 메시지는 Transport 로 내보내고 Step 으로 받음
+// 그래서 메모리 네트워크(MemNetwork)로 틱과 메시지 전달 순서를 직접 정해서
+// 선거, 분할, 리더 장애를 매번 똑같이 재현하는 테스트를 할 수 있음
+//
+//   - 리더 선출: 선거 시간 제한 동안 리더 소식이 없으면 후보가 되어 과반수 투표를 받음
+//   - 로그 복제: 리더가 항목을 붙이고 과반수에 복제되면 커밋 → 모든 노드가 같은 순서로 상태 기계에 적용
+//   - 스냅샷: 적용한 항목이 SnapshotEntries 개 쌓이면 상태 기계 스냅샷을 뜨고 로그를 잘라냄
+//     뒤처져서 필요한 항목이 이미 잘린 팔로워에게는 스냅샷을 통째로 보냄
+//   - 구성 변경: 한 번에 노드 하나씩 추가/제거 (붙이는 순간부터 새 구성 적용, 커밋 전엔 다음 변경 불가)
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Role: 노드 역할
+type Role int
+
+const (
+	Follower Role = iota
+	Candidate
+	Leader
+)
+
+func (r Role) String() string {
+	switch r {
+	case Candidate:
+		return "candidate"
+	case Leader:
+		return "leader"
+	}
+	return "follower"
+}
+
+// EntryType: 로그 항목 종류
+type EntryType int
+
+const (
+	EntryNormal EntryType = iota // 상태 기계에 적용할 명령
+	EntryConfig                  // 구성 변경 (Data = JSON 노드 목록)
+	EntryNoop                    // 새 리더가 자기 임기 항목을 커밋하려고 붙이는 빈 항목
+)
+
+// Entry: 로그 항목
+type Entry struct {
+	Index uint64    `json:"index"`
+	Term  uint64    `json:"term"`
+	Type  EntryType `json:"type"`
+	Data  []byte    `json:"data,omitempty"`
+}
+
+// Snapshot: 상태 기계 스냅샷 (Index 번째 항목까지 적용한 상태)
+type Snapshot struct {
+	Index uint64   `json:"index"`
+	Term  uint64   `json:"term"`
+	Peers []string `json:"peers"` // Index 시점의 구성
+	Data  []byte   `json:"data,omitempty"`
+}
+
+// MessageType: 노드끼리 주고받는 메시지 종류
+type MessageType int
+
+const (
+	MsgVote     MessageType = iota // 투표 요청 (LogIndex/LogTerm = 후보의 마지막 항목)
+	MsgVoteResp                    // 투표 응답 (Reject 면 거절)
+	MsgApp                         // 항목 추가 + 하트비트 (LogIndex/LogTerm = 바로 앞 항목)
+	MsgAppResp                     // 추가 응답 (Index = 일치하는 마지막 인덱스, 거절이면 팔로워의 마지막 인덱스)
+	MsgSnap                        // 스냅샷 설치
+)
+
+// Message: 노드 사이 메시지
+type Message struct {
+	Type     MessageType `json:"type"`
+	From     string      `json:"from"`
+	To       string      `json:"to"`
+	Term     uint64      `json:"term"`
+	LogIndex uint64      `json:"log_index,omitempty"`
+	LogTerm  uint64      `json:"log_term,omitempty"`
+	Entries  []Entry     `json:"entries,omitempty"`
+	Commit   uint64      `json:"commit,omitempty"`
+	Index    uint64      `json:"index,omitempty"`
+	Reject   bool        `json:"reject,omitempty"`
+	Snapshot *Snapshot   `json:"snapshot,omitempty"`
+}
+
+// Transport: 메시지를 다른 노드에 보내는 통로
+// Send 는 막히지 않아야 하고, 메시지가 사라지거나 늦게 도착해도 됨 (래프트가 다시 보냄)
+type Transport interface {
+	Send(m Message)
+}
+
+// StateMachine: 커밋된 명령을 적용하는 상태 기계
+// 모든 노드가 같은 명령을 같은 순서로 적용하므로 Apply 는 결정적이어야 함 (시각, 난수 사용 금지)
+type StateMachine interface {
+	Apply(cmd []byte) any
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+}
+
+var (
+	// ErrNotLeader: 리더가 아니라 제안을 받을 수 없음 (NotLeaderError 로 리더 주소를 알려줌)
+	ErrNotLeader = errors.New("raft: not the leader")
+	// ErrDropped: 제안한 항목이 리더가 바뀌면서 다른 항목으로 덮어써짐 (적용되지 않음)
+	ErrDropped = errors.New("raft: proposal dropped by a leadership change")
+	// ErrConfigPending: 이전 구성 변경이 아직 커밋되지 않음
+	ErrConfigPending = errors.New("raft: a membership change is already in progress")
+	// ErrInvalidConfChange: 이미 있는 노드를 추가하거나 없는 노드를 제거하려 함
+	ErrInvalidConfChange = errors.New("raft: invalid membership change")
+)
+
+// NotLeaderError: 리더가 아님. Leader 가 비어 있지 않으면 그쪽으로 다시 요청
+type NotLeaderError struct {
+	Leader string
+}
+
+func (e *NotLeaderError) Error() string {
+	if e.Leader == "" {
+		return "raft: not the leader (leader unknown)"
+	}
+	return "raft: not the leader (leader is " + e.Leader + ")"
+}
+
+func (e *NotLeaderError) Is(target error) bool {
+	return target == ErrNotLeader
+}
+
+// Config: 노드 설정
+type Config struct {
+	ID string
+	// Peers: 처음 구성 (자기 포함). 저장소에 구성 항목이나 스냅샷이 있으면 그쪽을 따름
+	// 이미 있는 클러스터에 들어갈 노드는 비워 두고, 리더에서 ChangeMembership 으로 추가
+	Peers []string
+	// ElectionTicks: 리더 소식 없이 이만큼 틱이 지나면 선거 시작 (실제로는 [E, 2E) 사이 임의 값, 기본 10)
+	ElectionTicks int
+	// HeartbeatTicks: 리더가 하트비트를 보내는 틱 간격 (기본 1, ElectionTicks 보다 작아야 함)
+	HeartbeatTicks int
+	// SnapshotEntries: 마지막 스냅샷 이후 적용한 항목이 이만큼 쌓이면 스냅샷 + 로그 정리 (0 이면 하지 않음)
+	SnapshotEntries int
+	// Seed: 선거 시간 제한 난수 시드 (ID 와 섞어서 사용 → 같은 설정이면 항상 같은 순서로 동작)
+	Seed uint64
+
+	Storage      Storage // nil 이면 새 MemoryStorage
+	Transport    Transport
+	StateMachine StateMachine
+}
+
+// 메시지 하나에 담는 최대 항목 수
+const maxEntriesPerMsg = 64
+
+// Result: 제안한 명령을 적용한 결과
+type Result struct {
+	Value any
+	Err   error
+}
+
+// Proposal: 제안한 항목. 적용되면 Done 으로 결과가 옴
+type Proposal struct {
+	Index uint64
+	Term  uint64
+	Done  <-chan Result
+}
+
+type waiter struct {
+	term uint64
+	ch   chan Result
+}
+
+// Status: 노드 상태 (모니터링/디버깅용)
+type Status struct {
+	ID            string   `json:"id"`
+	Role          string   `json:"role"`
+	Term          uint64   `json:"term"`
+	Leader        string   `json:"leader"`
+	Commit        uint64   `json:"commit"`
+	Applied       uint64   `json:"applied"`
+	LastIndex     uint64   `json:"last_index"`
+	SnapshotIndex uint64   `json:"snapshot_index"`
+	Peers         []string `json:"peers"`
+}
+
+// Node: 래프트 노드 한 대
+// 모든 메서드는 여러 고루틴에서 불러도 됨. 보낼 메시지는 상태를 저장한 뒤 잠금 밖에서 보냄
+type Node struct {
+	mu        sync.Mutex
+	id        string
+	cfg       Config
+	sm        StateMachine
+	storage   Storage
+	transport Transport
+	rand      *rand.Rand
+
+	role     Role
+	term     uint64
+	vote     string
+	leader   string
+	log      raftLog
+	snapshot Snapshot // 마지막 스냅샷 (뒤처진 팔로워에게 보냄)
+	commit   uint64
+	applied  uint64
+
+	peers        []string // 현재 구성 (마지막 구성 항목 기준. 커밋 전이어도 적용)
+	confIndex    uint64   // 마지막 구성 항목 인덱스
+	appliedPeers []string // applied 시점의 구성 (스냅샷에 기록)
+
+	electionElapsed  int
+	electionTimeout  int
+	heartbeatElapsed int
+	votes            map[string]bool   // 후보일 때 받은 투표 (true 면 찬성)
+	active           map[string]bool   // 리더일 때 이번 선거 시간 제한 동안 응답한 노드 (check quorum)
+	next             map[string]uint64 // 리더일 때 노드별 다음에 보낼 인덱스
+	match            map[string]uint64 // 리더일 때 노드별 복제가 확인된 마지막 인덱스
+
+	waiters  map[uint64]waiter
+	outbox   []Message
+	dirty    bool   // 저장해야 할 상태가 바뀜
+	unstable uint64 // 마지막 저장 이후 바뀐 가장 앞 로그 인덱스 (0 이면 로그는 그대로)
+	newSnap  bool   // 마지막 저장 이후 스냅샷이 바뀜
+}
+
+// NewNode: 저장소의 상태(스냅샷, 로그)를 읽어 노드를 만들고, 커밋된 항목까지 상태 기계에 적용
+func NewNode(cfg Config) (*Node, error) {
+	if cfg.ElectionTicks == 0 {
+		cfg.ElectionTicks = 10
+	}
+	if cfg.HeartbeatTicks == 0 {
+		cfg.HeartbeatTicks = 1
+	}
+	switch {
+	case cfg.ID == "":
+		return nil, errors.New("raft: ID is required")
+	case cfg.HeartbeatTicks < 0 || cfg.HeartbeatTicks >= cfg.ElectionTicks:
+		return nil, fmt.Errorf("raft: heartbeat ticks (%d) must be positive and less than election ticks (%d)", cfg.HeartbeatTicks, cfg.ElectionTicks)
+	case cfg.SnapshotEntries < 0:
+		return nil, fmt.Errorf("raft: snapshot entries must not be negative, got %d", cfg.SnapshotEntries)
+	case cfg.Transport == nil || cfg.StateMachine == nil:
+		return nil, errors.New("raft: transport and state machine are required")
+	}
+	if cfg.Storage == nil {
+		cfg.Storage = NewMemoryStorage()
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(cfg.ID))
+	n := &Node{
+		id:        cfg.ID,
+		cfg:       cfg,
+		sm:        cfg.StateMachine,
+		storage:   cfg.Storage,
+		transport: cfg.Transport,
+		rand:      rand.New(rand.NewPCG(h.Sum64(), cfg.Seed)),
+		waiters:   make(map[uint64]waiter),
+	}
+
+	st, err := cfg.Storage.Load()
+	if err != nil {
+		return nil, err
+	}
+	n.term, n.vote = st.Term, st.Vote
+	n.appliedPeers = slices.Clone(cfg.Peers)
+	if st.Snapshot.Index > 0 {
+		if err := n.sm.Restore(st.Snapshot.Data); err != nil {
+			return nil, fmt.Errorf("raft: restore snapshot: %w", err)
+		}
+		n.snapshot = st.Snapshot
+		n.log = raftLog{snapIndex: st.Snapshot.Index, snapTerm: st.Snapshot.Term}
+		n.applied, n.commit = st.Snapshot.Index, st.Snapshot.Index
+		n.appliedPeers = slices.Clone(st.Snapshot.Peers)
+	}
+	n.log.entries = st.Entries
+	n.reloadConfig()
+	n.commit = max(n.commit, min(st.Commit, n.log.lastIndex()))
+	n.becomeFollower(n.term, "")
+	n.applyCommitted()
+	return n, nil
+}
+
+// ID: 노드 주소
+func (n *Node) ID() string {
+	return n.id
+}
+
+// Status: 지금 상태
+func (n *Node) Status() Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return Status{
+		ID:            n.id,
+		Role:          n.role.String(),
+		Term:          n.term,
+		Leader:        n.leader,
+		Commit:        n.commit,
+		Applied:       n.applied,
+		LastIndex:     n.log.lastIndex(),
+		SnapshotIndex: n.log.snapIndex,
+		Peers:         slices.Clone(n.peers),
+	}
+}
+
+// Run: interval 마다 Tick 을 ctx 가 끝날 때까지 부름 (실제 서버용, 테스트에서는 Tick 을 직접 부름)
+func (n *Node) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.Tick()
+		}
+	}
+}
+
+// Tick: 논리 시계를 한 칸 움직임 (리더는 하트비트, 나머지는 선거 시간 제한 확인)
+func (n *Node) Tick() {
+	n.mu.Lock()
+	defer n.unlock()
+
+	if n.role == Leader {
+		n.electionElapsed++
+		if n.electionElapsed >= n.cfg.ElectionTicks {
+			n.electionElapsed = 0
+			// check quorum: 선거 시간 제한 동안 과반수의 응답이 없으면 소수 쪽에 갇힌 것이므로 물러남
+			// 팔로워가 리더 소식을 최근에 들었으면 투표 요청을 무시하는 것(Step)은 이 확인이 있어야 안전함
+			if !n.quorum(n.active, true) {
+				n.becomeFollower(n.term, "")
+				return
+			}
+			n.active = map[string]bool{n.id: true}
+		}
+		n.heartbeatElapsed++
+		if n.heartbeatElapsed >= n.cfg.HeartbeatTicks {
+			n.heartbeatElapsed = 0
+			n.broadcastAppend()
+		}
+		return
+	}
+	n.electionElapsed++
+	if n.electionElapsed >= n.electionTimeout && slices.Contains(n.peers, n.id) {
+		n.campaign()
+	}
+}
+
+// Step: 다른 노드가 보낸 메시지 처리
+func (n *Node) Step(m Message) {
+	n.mu.Lock()
+	defer n.unlock()
+
+	switch {
+	case m.Term > n.term:
+		// 리더 소식을 최근에 들었으면 투표 요청을 무시 (제거된 노드가 계속 선거를 일으켜 임기를 올리는 것 방지)
+		// 리더는 과반수와 연결이 끊기면 선거 시간 제한 안에 스스로 물러나므로(check quorum),
+		// 리더가 없어졌는데도 계속 무시해서 선거가 막히는 일은 없음
+		if m.Type == MsgVote && n.leader != "" && n.electionElapsed < n.cfg.ElectionTicks {
+			return
+		}
+		leader := ""
+		if m.Type == MsgApp || m.Type == MsgSnap {
+			leader = m.From
+		}
+		n.becomeFollower(m.Term, leader)
+	case m.Term < n.term:
+		// 옛날 임기의 리더/후보에게 지금 임기를 알려 물러나게 함
+		switch m.Type {
+		case MsgApp, MsgSnap:
+			n.send(Message{Type: MsgAppResp, To: m.From, Reject: true})
+		case MsgVote:
+			n.send(Message{Type: MsgVoteResp, To: m.From, Reject: true})
+		}
+		return
+	}
+	if n.role == Leader {
+		n.active[m.From] = true
+	}
+
+	switch m.Type {
+	case MsgVote:
+		n.handleVote(m)
+	case MsgVoteResp:
+		n.handleVoteResp(m)
+	case MsgApp:
+		n.handleAppend(m)
+	case MsgAppResp:
+		n.handleAppendResp(m)
+	case MsgSnap:
+		n.handleSnapshot(m)
+	}
+}
+
+// Propose: 명령을 로그에 붙임 (리더만 가능). 커밋되어 적용되면 Proposal.Done 으로 결과가 옴
+func (n *Node) Propose(cmd []byte) (Proposal, error) {
+	n.mu.Lock()
+	defer n.unlock()
+
+	if n.role != Leader {
+		return Proposal{}, &NotLeaderError{Leader: n.leader}
+	}
+	return n.propose(EntryNormal, cmd), nil
+}
+
+// Apply: 명령을 제안하고 적용될 때까지 기다려 상태 기계의 결과를 돌려줌
+// 적용 결과를 돌려받은 시점에는 과반수가 명령을 저장했으므로 선형화 가능한 읽기/쓰기가 됨
+func (n *Node) Apply(ctx context.Context, cmd []byte) (any, error) {
+	p, err := n.Propose(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return wait(ctx, p)
+}
+
+// ConfChange: 구성 변경 (노드 하나 추가 또는 제거)
+type ConfChange struct {
+	Node   string
+	Remove bool
+}
+
+// ProposeConfChange: 구성 변경 항목을 붙임 (리더만 가능)
+// 새 구성은 커밋을 기다리지 않고 붙이는 순간부터 씀. 한 번에 하나씩만 바꾸므로 이전/새 구성의 과반수가 항상 겹침
+// 새 리더가 자기 임기 항목을 아직 커밋하지 못했거나 이전 변경이 커밋되지 않았으면 ErrConfigPending
+func (n *Node) ProposeConfChange(cc ConfChange) (Proposal, error) {
+	n.mu.Lock()
+	defer n.unlock()
+
+	if n.role != Leader {
+		return Proposal{}, &NotLeaderError{Leader: n.leader}
+	}
+	if t, _ := n.log.term(n.commit); t != n.term || n.confIndex > n.commit {
+		return Proposal{}, ErrConfigPending
+	}
+	peers := slices.Clone(n.peers)
+	switch i := slices.Index(peers, cc.Node); {
+	case cc.Remove && i < 0:
+		return Proposal{}, fmt.Errorf("%w: %s is not a member", ErrInvalidConfChange, cc.Node)
+	case !cc.Remove && i >= 0:
+		return Proposal{}, fmt.Errorf("%w: %s is already a member", ErrInvalidConfChange, cc.Node)
+	case cc.Remove:
+		peers = slices.Delete(peers, i, i+1)
+	default:
+		peers = append(peers, cc.Node)
+	}
+	data, _ := json.Marshal(peers)
+	return n.propose(EntryConfig, data), nil
+}
+
+// ChangeMembership: 구성 변경을 제안하고 커밋될 때까지 대기
+func (n *Node) ChangeMembership(ctx context.Context, cc ConfChange) error {
+	p, err := n.ProposeConfChange(cc)
+	if err != nil {
+		return err
+	}
+	_, err = wait(ctx, p)
+	return err
+}
+
+func wait(ctx context.Context, p Proposal) (any, error) {
+	select {
+	case r := <-p.Done:
+		return r.Value, r.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// propose: 리더가 자기 로그에 항목을 붙이고 복제 시작
+func (n *Node) propose(typ EntryType, data []byte) Proposal {
+	e := n.appendEntry(typ, data)
+	ch := make(chan Result, 1)
+	n.waiters[e.Index] = waiter{term: e.Term, ch: ch}
+	n.broadcastAppend()
+	n.maybeCommit()
+	return Proposal{Index: e.Index, Term: e.Term, Done: ch}
+}
+
+func (n *Node) appendEntry(typ EntryType, data []byte) Entry {
+	e := Entry{Index: n.log.lastIndex() + 1, Term: n.term, Type: typ, Data: data}
+	n.log.append(e)
+	if typ == EntryConfig {
+		n.reloadConfig()
+	}
+	n.match[n.id] = e.Index
+	n.markUnstable(e.Index)
+	return e
+}
+
+// markUnstable: from 번째부터의 로그 항목을 다음 저장 때 다시 씀
+func (n *Node) markUnstable(from uint64) {
+	if n.unstable == 0 || from < n.unstable {
+		n.unstable = from
+	}
+	n.dirty = true
+}
+
+// unlock: 바뀐 상태를 저장하고 나서 잠금을 풀고 모인 메시지를 보냄
+// 저장에 실패하면 메시지를 버림 (저장하지 않은 투표/항목을 남에게 알리면 안 됨)
+func (n *Node) unlock() {
+	msgs := n.outbox
+	n.outbox = nil
+	if n.dirty {
+		if err := n.storage.Save(n.update()); err != nil {
+			msgs = nil
+		} else {
+			n.dirty, n.unstable, n.newSnap = false, 0, false
+		}
+	}
+	n.mu.Unlock()
+	for _, m := range msgs {
+		n.transport.Send(m)
+	}
+}
+
+// update: 마지막 저장 이후 바뀐 부분 (상태, 새 스냅샷, unstable 이후 로그 항목)
+func (n *Node) update() Update {
+	u := Update{HardState: HardState{Term: n.term, Vote: n.vote, Commit: n.commit}}
+	if n.newSnap {
+		snap := n.snapshot
+		u.Snapshot = &snap
+	}
+	if n.unstable > 0 {
+		u.From = max(n.unstable, n.log.snapIndex+1)
+		u.Entries = n.log.slice(u.From, len(n.log.entries))
+	}
+	return u
+}
+
+func (n *Node) send(m Message) {
+	m.From, m.Term = n.id, n.term
+	n.outbox = append(n.outbox, m)
+}
+
+func (n *Node) becomeFollower(term uint64, leader string) {
+	if term > n.term {
+		n.term, n.vote = term, ""
+		n.dirty = true
+	}
+	n.role = Follower
+	n.leader = leader
+	n.resetElection()
+}
+
+func (n *Node) resetElection() {
+	n.electionElapsed = 0
+	n.electionTimeout = n.cfg.ElectionTicks + n.rand.IntN(n.cfg.ElectionTicks)
+}
+
+// campaign: 임기를 올리고 후보가 되어 투표 요청
+func (n *Node) campaign() {
+	n.role = Candidate
+	n.term++
+	n.vote = n.id
+	n.leader = ""
+	n.dirty = true
+	n.resetElection()
+	n.votes = map[string]bool{n.id: true}
+	if n.quorum(n.votes, true) {
+		n.becomeLeader()
+		return
+	}
+	for _, p := range n.peers {
+		if p != n.id {
+			n.send(Message{Type: MsgVote, To: p, LogIndex: n.log.lastIndex(), LogTerm: n.log.lastTerm()})
+		}
+	}
+}
+
+// quorum: 현재 구성의 과반수가 votes 에서 want 인지
+func (n *Node) quorum(votes map[string]bool, want bool) bool {
+	count := 0
+	for _, p := range n.peers {
+		if v, ok := votes[p]; ok && v == want {
+			count++
+		}
+	}
+	return count > len(n.peers)/2
+}
+
+func (n *Node) becomeLeader() {
+	n.role = Leader
+	n.leader = n.id
+	n.heartbeatElapsed = 0
+	n.electionElapsed = 0
+	n.active = map[string]bool{n.id: true}
+	n.next = make(map[string]uint64)
+	n.match = make(map[string]uint64)
+	for _, p := range n.peers {
+		n.next[p] = n.log.lastIndex() + 1
+	}
+	// 이전 임기 항목은 자기 임기 항목이 커밋될 때 함께 커밋되므로 빈 항목을 바로 붙임
+	n.appendEntry(EntryNoop, nil)
+	n.broadcastAppend()
+	n.maybeCommit()
+}
+
+func (n *Node) handleVote(m Message) {
+	canVote := n.vote == m.From || (n.vote == "" && n.leader == "")
+	upToDate := m.LogTerm > n.log.lastTerm() || (m.LogTerm == n.log.lastTerm() && m.LogIndex >= n.log.lastIndex())
+	if !canVote || !upToDate {
+		n.send(Message{Type: MsgVoteResp, To: m.From, Reject: true})
+		return
+	}
+	n.vote = m.From
+	n.dirty = true
+	n.resetElection()
+	n.send(Message{Type: MsgVoteResp, To: m.From})
+}
+
+func (n *Node) handleVoteResp(m Message) {
+	if n.role != Candidate {
+		return
+	}
+	n.votes[m.From] = !m.Reject
+	switch {
+	case n.quorum(n.votes, true):
+		n.becomeLeader()
+	case n.quorum(n.votes, false):
+		n.becomeFollower(n.term, "")
+	}
+}
+
+func (n *Node) handleAppend(m Message) {
+	n.becomeFollower(n.term, m.From)
+	if m.LogIndex < n.commit {
+		// 이미 커밋된 위치보다 앞이면 커밋된 곳까지는 일치한다고 알려 줌
+		n.send(Message{Type: MsgAppResp, To: m.From, Index: n.commit})
+		return
+	}
+	if t, ok := n.log.term(m.LogIndex); !ok || t != m.LogTerm {
+		n.send(Message{Type: MsgAppResp, To: m.From, Reject: true, Index: n.log.lastIndex()})
+		return
+	}
+
+	from, truncated := n.log.merge(m.Entries)
+	if from > 0 {
+		n.markUnstable(from)
+	}
+	if truncated || slices.ContainsFunc(m.Entries, func(e Entry) bool { return e.Type == EntryConfig }) {
+		n.reloadConfig()
+	}
+	last := m.LogIndex + uint64(len(m.Entries))
+	if m.Commit > n.commit {
+		n.commit = min(m.Commit, last)
+		n.dirty = true
+		n.applyCommitted()
+	}
+	n.send(Message{Type: MsgAppResp, To: m.From, Index: last})
+}
+
+func (n *Node) handleAppendResp(m Message) {
+	if n.role != Leader {
+		return
+	}
+	if m.Reject {
+		// 팔로워의 마지막 인덱스를 넘지 않는 선에서 한 칸씩 물러나며 일치하는 위치를 찾음
+		n.next[m.From] = max(1, min(n.next[m.From]-1, m.Index+1))
+		n.sendAppend(m.From)
+		return
+	}
+	n.match[m.From] = max(n.match[m.From], m.Index)
+	n.next[m.From] = max(n.next[m.From], m.Index+1)
+	if n.maybeCommit() {
+		n.broadcastAppend()
+	} else if n.next[m.From] <= n.log.lastIndex() {
+		n.sendAppend(m.From)
+	}
+}
+
+func (n *Node) handleSnapshot(m Message) {
+	n.becomeFollower(n.term, m.From)
+	if m.Snapshot == nil {
+		return
+	}
+	s := *m.Snapshot
+	if s.Index <= n.commit {
+		n.send(Message{Type: MsgAppResp, To: m.From, Index: n.commit})
+		return
+	}
+	if err := n.sm.Restore(s.Data); err != nil {
+		n.send(Message{Type: MsgAppResp, To: m.From, Reject: true, Index: n.log.lastIndex()})
+		return
+	}
+	if t, ok := n.log.term(s.Index); ok && t == s.Term {
+		n.log.compact(s.Index, s.Term) // 스냅샷 뒤의 항목이 리더와 같으면 남김
+	} else {
+		n.log = raftLog{snapIndex: s.Index, snapTerm: s.Term}
+		n.markUnstable(s.Index + 1) // 저장된 뒤쪽 항목도 지움
+	}
+	n.snapshot = s
+	n.newSnap = true
+	n.commit, n.applied = s.Index, s.Index
+	n.appliedPeers = slices.Clone(s.Peers)
+	n.reloadConfig()
+	n.dirty = true
+	n.send(Message{Type: MsgAppResp, To: m.From, Index: s.Index})
+}
+
+// broadcastAppend: 모든 팔로워에게 밀린 항목(없으면 빈 하트비트)을 보냄
+func (n *Node) broadcastAppend() {
+	for _, p := range n.peers {
+		if p != n.id {
+			n.sendAppend(p)
+		}
+	}
+}
+
+func (n *Node) sendAppend(to string) {
+	next := n.next[to]
+	if next <= n.log.snapIndex {
+		snap := n.snapshot
+		n.send(Message{Type: MsgSnap, To: to, Snapshot: &snap})
+		return
+	}
+	prevTerm, _ := n.log.term(next - 1)
+	n.send(Message{
+		Type:     MsgApp,
+		To:       to,
+		LogIndex: next - 1,
+		LogTerm:  prevTerm,
+		Entries:  n.log.slice(next, maxEntriesPerMsg),
+		Commit:   n.commit,
+	})
+}
+
+// maybeCommit: 현재 구성의 과반수에 복제된 인덱스 중 자기 임기 항목까지 커밋
+// 구성에서 빠지는 중인 리더는 자기 자신을 과반수 계산에 넣지 않음
+func (n *Node) maybeCommit() bool {
+	matched := make([]uint64, 0, len(n.peers))
+	for _, p := range n.peers {
+		matched = append(matched, n.match[p])
+	}
+	if len(matched) == 0 {
+		return false
+	}
+	slices.Sort(matched)
+	q := matched[(len(matched)-1)/2] // 과반수가 가진 가장 큰 인덱스
+	if t, _ := n.log.term(q); q <= n.commit || t != n.term {
+		return false
+	}
+	n.commit = q
+	n.dirty = true
+	n.applyCommitted()
+	return true
+}
+
+// applyCommitted: 커밋됐지만 아직 적용하지 않은 항목을 순서대로 상태 기계에 적용하고 제안자에게 결과를 알림
+func (n *Node) applyCommitted() {
+	for n.applied < n.commit {
+		e := n.log.entry(n.applied + 1)
+		var value any
+		switch e.Type {
+		case EntryNormal:
+			value = n.sm.Apply(e.Data)
+		case EntryConfig:
+			_ = json.Unmarshal(e.Data, &n.appliedPeers)
+		}
+		n.applied = e.Index
+		if w, ok := n.waiters[e.Index]; ok {
+			delete(n.waiters, e.Index)
+			if w.term == e.Term {
+				w.ch <- Result{Value: value}
+			} else {
+				w.ch <- Result{Err: ErrDropped}
+			}
+		}
+	}
+	n.maybeSnapshot()
+
+	// 자기를 뺀 구성이 커밋되면 리더 자리를 내려놓음 (구성에 없으므로 다시 선거에 나서지 않음)
+	if n.role == Leader && !slices.Contains(n.appliedPeers, n.id) && n.confIndex <= n.applied {
+		n.becomeFollower(n.term, "")
+	}
+}
+
+// maybeSnapshot: 적용한 항목이 충분히 쌓였으면 상태 기계 스냅샷을 뜨고 로그를 잘라냄
+func (n *Node) maybeSnapshot() {
+	if n.cfg.SnapshotEntries == 0 || n.applied-n.log.snapIndex < uint64(n.cfg.SnapshotEntries) {
+		return
+	}
+	data, err := n.sm.Snapshot()
+	if err != nil {
+		return // 다음 적용 때 다시 시도
+	}
+	term, _ := n.log.term(n.applied)
+	n.snapshot = Snapshot{Index: n.applied, Term: term, Peers: slices.Clone(n.appliedPeers), Data: data}
+	n.log.compact(n.applied, term)
+	n.newSnap = true
+	n.dirty = true
+}
+
+// reloadConfig: 로그의 마지막 구성 항목(없으면 스냅샷/처음 구성)으로 현재 구성을 다시 정함
+// 구성 항목이 잘려 나갈 수도 있으므로 로그가 바뀔 때마다 다시 계산
+func (n *Node) reloadConfig() {
+	peers, index := n.cfg.Peers, uint64(0)
+	if n.log.snapIndex > 0 {
+		peers = n.snapshot.Peers
+	}
+	for i := len(n.log.entries) - 1; i >= 0; i-- {
+		if e := n.log.entries[i]; e.Type == EntryConfig {
+			_ = json.Unmarshal(e.Data, &peers)
+			index = e.Index
+			break
+		}
+	}
+	n.peers, n.confIndex = slices.Clone(peers), index
+
+	if n.role == Leader {
+		for _, p := range n.peers {
+			if _, ok := n.next[p]; !ok {
+				n.next[p] = n.log.lastIndex() + 1
+			}
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// listMachine: 적용한 명령을 순서대로 모아 두는 상태 기계
+type listMachine struct {
+	mu   sync.Mutex
+	cmds []string
+}
+
+func (m *listMachine) Apply(cmd []byte) any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cmds = append(m.cmds, string(cmd))
+	return len(m.cmds)
+}
+
+func (m *listMachine) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return json.Marshal(m.cmds)
+}
+
+func (m *listMachine) Restore(data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cmds = nil
+	return json.Unmarshal(data, &m.cmds)
+}
+
+func (m *listMachine) list() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.cmds)
+}
+
+type testCluster struct {
+	t        *testing.T
+	net      *MemNetwork
+	ids      []string
+	nodes    map[string]*Node
+	machines map[string]*listMachine
+	storages map[string]*MemoryStorage
+	snapshot int
+}
+
+// newTestCluster: n 대짜리 클러스터 (n1, n2, ...)
+func newTestCluster(t *testing.T, n, snapshotEntries int) *testCluster {
+	t.Helper()
+	tc := &testCluster{
+		t:        t,
+		net:      NewMemNetwork(),
+		nodes:    make(map[string]*Node),
+		machines: make(map[string]*listMachine),
+		storages: make(map[string]*MemoryStorage),
+		snapshot: snapshotEntries,
+	}
+	for i := range n {
+		tc.ids = append(tc.ids, fmt.Sprintf("n%d", i+1))
+	}
+	for _, id := range tc.ids {
+		tc.storages[id] = NewMemoryStorage()
+		tc.start(id, tc.ids)
+	}
+	return tc
+}
+
+// start: 저장소에 남은 상태로 노드를 (다시) 띄움
+func (tc *testCluster) start(id string, peers []string) *Node {
+	tc.t.Helper()
+	if tc.storages[id] == nil {
+		tc.storages[id] = NewMemoryStorage()
+	}
+	sm := &listMachine{}
+	n, err := NewNode(Config{
+		ID:              id,
+		Peers:           peers,
+		SnapshotEntries: tc.snapshot,
+		Storage:         tc.storages[id],
+		Transport:       tc.net,
+		StateMachine:    sm,
+	})
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	tc.nodes[id], tc.machines[id] = n, sm
+	tc.net.Add(n)
+	return n
+}
+
+// leader: ticks 틱 안에 리더가 하나로 정해질 때까지 돌림 (exclude 는 리더 후보에서 뺄 노드)
+func (tc *testCluster) leader(ticks int, exclude ...string) *Node {
+	tc.t.Helper()
+	for range ticks {
+		tc.net.Tick()
+		var leaders []*Node
+		for _, id := range tc.ids {
+			if n := tc.nodes[id]; !slices.Contains(exclude, id) && n.Status().Role == "leader" {
+				leaders = append(leaders, n)
+			}
+		}
+		if len(leaders) == 1 {
+			return leaders[0]
+		}
+	}
+	tc.t.Fatalf("no leader elected within %d ticks", ticks)
+	return nil
+}
+
+// propose: 리더에 명령을 제안하고 적용될 때까지 틱을 돌림
+func (tc *testCluster) propose(leader *Node, cmd string) Result {
+	tc.t.Helper()
+	p, err := leader.Propose([]byte(cmd))
+	if err != nil {
+		tc.t.Fatalf("propose %q: %v", cmd, err)
+	}
+	for range 50 {
+		tc.net.Tick()
+		select {
+		case r := <-p.Done:
+			return r
+		default:
+		}
+	}
+	tc.t.Fatalf("proposal %q was not applied", cmd)
+	return Result{}
+}
+
+func (tc *testCluster) settle(ticks int) {
+	for range ticks {
+		tc.net.Tick()
+	}
+}
+
+func (tc *testCluster) assertApplied(want []string, ids ...string) {
+	tc.t.Helper()
+	for _, id := range ids {
+		if got := tc.machines[id].list(); !slices.Equal(got, want) {
+			tc.t.Errorf("%s applied %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestElectionAndReplication(t *testing.T) {
+	tc := newTestCluster(t, 3, 0)
+	leader := tc.leader(50)
+
+	for _, id := range tc.ids {
+		if st := tc.nodes[id].Status(); st.Leader != leader.ID() {
+			t.Errorf("%s thinks the leader is %q, want %q", id, st.Leader, leader.ID())
+		}
+	}
+	for i, cmd := range []string{"a", "b", "c"} {
+		if r := tc.propose(leader, cmd); r.Err != nil || r.Value != i+1 {
+			t.Fatalf("apply %q = %v, %v", cmd, r.Value, r.Err)
+		}
+	}
+	tc.settle(3)
+	tc.assertApplied([]string{"a", "b", "c"}, tc.ids...)
+
+	for _, id := range tc.ids {
+		if n := tc.nodes[id]; n != leader {
+			_, err := n.Propose([]byte("x"))
+			var nle *NotLeaderError
+			if !errors.As(err, &nle) || nle.Leader != leader.ID() || !errors.Is(err, ErrNotLeader) {
+				t.Errorf("propose on follower %s: err = %v, want NotLeaderError{%s}", id, err, leader.ID())
+			}
+		}
+	}
+}
+
+func TestLeaderCrashAndRestart(t *testing.T) {
+	tc := newTestCluster(t, 3, 0)
+	old := tc.leader(50)
+	tc.propose(old, "a")
+
+	tc.net.Crash(old.ID())
+	leader := tc.leader(100, old.ID())
+	if leader == old {
+		t.Fatal("crashed node is still the leader")
+	}
+	tc.propose(leader, "b")
+
+	// 같은 저장소로 다시 띄우면 커밋된 항목을 다시 적용하고, 놓친 항목은 새 리더에게서 받음
+	restarted := tc.start(old.ID(), tc.ids)
+	if got := tc.machines[old.ID()].list(); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("restarted node replayed %v, want [a]", got)
+	}
+	tc.propose(leader, "c")
+	tc.settle(3)
+	tc.assertApplied([]string{"a", "b", "c"}, tc.ids...)
+	if st := restarted.Status(); st.Role != "follower" || st.Leader != leader.ID() {
+		t.Fatalf("restarted node status = %+v, want follower of %s", st, leader.ID())
+	}
+}
+
+func TestMinorityPartitionCannotCommit(t *testing.T) {
+	tc := newTestCluster(t, 5, 0)
+	old := tc.leader(50)
+	tc.propose(old, "a")
+
+	var majority []string
+	for _, id := range tc.ids {
+		if id != old.ID() && len(majority) < 3 {
+			majority = append(majority, id)
+		}
+	}
+	var minority []string
+	for _, id := range tc.ids {
+		if !slices.Contains(majority, id) {
+			minority = append(minority, id)
+		}
+	}
+	tc.net.Partition(majority, minority)
+
+	// 옛 리더는 소수 쪽에 갇혀서 제안을 커밋하지 못함
+	lost, err := old.Propose([]byte("lost"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leader := tc.leader(100, minority...)
+	tc.propose(leader, "b")
+	select {
+	case r := <-lost.Done:
+		t.Fatalf("minority proposal finished before heal: %+v", r)
+	default:
+	}
+
+	// 분할이 풀리면 옛 리더는 물러나고, 커밋되지 못한 항목은 덮어써짐
+	tc.net.Heal()
+	tc.settle(5)
+	select {
+	case r := <-lost.Done:
+		if !errors.Is(r.Err, ErrDropped) {
+			t.Fatalf("minority proposal result = %+v, want ErrDropped", r)
+		}
+	default:
+		t.Fatal("minority proposal was never resolved")
+	}
+	if st := old.Status(); st.Role != "follower" || st.Leader != leader.ID() {
+		t.Fatalf("old leader status = %+v, want follower of %s", st, leader.ID())
+	}
+	tc.assertApplied([]string{"a", "b"}, tc.ids...)
+}
+
+func TestSnapshotCatchUp(t *testing.T) {
+	tc := newTestCluster(t, 3, 5)
+	leader := tc.leader(50)
+	var lagging string
+	for _, id := range tc.ids {
+		if id != leader.ID() {
+			lagging = id
+			break
+		}
+	}
+	tc.net.Crash(lagging)
+
+	var want []string
+	for i := range 20 {
+		cmd := fmt.Sprint(i)
+		want = append(want, cmd)
+		tc.propose(leader, cmd)
+	}
+	if st := leader.Status(); st.SnapshotIndex == 0 {
+		t.Fatalf("leader did not compact its log: %+v", st)
+	}
+
+	// 뒤처진 노드가 필요한 항목은 이미 잘려 나갔으므로 스냅샷을 받아야 따라잡음
+	tc.start(lagging, tc.ids)
+	tc.settle(5)
+	tc.assertApplied(want, tc.ids...)
+	if st := tc.nodes[lagging].Status(); st.SnapshotIndex == 0 || st.Commit != leader.Status().Commit {
+		t.Fatalf("lagging node status = %+v", st)
+	}
+}
+
+func TestMembershipChange(t *testing.T) {
+	tc := newTestCluster(t, 3, 0)
+	leader := tc.leader(50)
+	tc.propose(leader, "a")
+
+	// 새 노드는 구성 없이 띄우고, 리더에서 추가하면 로그 전체를 받아 따라잡음
+	tc.ids = append(tc.ids, "n4")
+	joined := tc.start("n4", nil)
+	if err := tc.changeMembership(leader, ConfChange{Node: "n4"}); err != nil {
+		t.Fatalf("add n4: %v", err)
+	}
+	tc.propose(leader, "b")
+	tc.settle(3)
+	tc.assertApplied([]string{"a", "b"}, tc.ids...)
+	if st := joined.Status(); len(st.Peers) != 4 {
+		t.Fatalf("n4 peers = %v, want 4 members", st.Peers)
+	}
+
+	// 이전 변경이 커밋된 뒤에만 다음 변경 가능
+	if _, err := leader.ProposeConfChange(ConfChange{Node: "n4"}); err == nil {
+		t.Fatal("adding an existing member should fail")
+	}
+
+	// 리더 자신을 빼면 물러나고 남은 노드 중에서 새 리더가 뽑힘
+	if err := tc.changeMembership(leader, ConfChange{Node: leader.ID(), Remove: true}); err != nil {
+		t.Fatalf("remove leader: %v", err)
+	}
+	removed := leader.ID()
+	next := tc.leader(100, removed)
+	tc.propose(next, "c")
+	tc.settle(3)
+	for _, id := range tc.ids {
+		if id == removed {
+			continue
+		}
+		tc.assertApplied([]string{"a", "b", "c"}, id)
+		if peers := tc.nodes[id].Status().Peers; len(peers) != 3 || slices.Contains(peers, removed) {
+			t.Errorf("%s peers = %v, want 3 members without %s", id, peers, removed)
+		}
+	}
+}
+
+func (tc *testCluster) changeMembership(leader *Node, cc ConfChange) error {
+	p, err := leader.ProposeConfChange(cc)
+	if err != nil {
+		return err
+	}
+	for range 50 {
+		tc.net.Tick()
+		select {
+		case r := <-p.Done:
+			return r.Err
+		default:
+		}
+	}
+	return context.DeadlineExceeded
+}
+
+func TestPartitionedLeaderStepsDown(t *testing.T) {
+	tc := newTestCluster(t, 5, 0)
+	old := tc.leader(50)
+
+	minority := []string{old.ID()}
+	var majority []string
+	for _, id := range tc.ids {
+		switch {
+		case id == old.ID():
+		case len(minority) < 2:
+			minority = append(minority, id)
+		default:
+			majority = append(majority, id)
+		}
+	}
+	tc.net.Partition(majority, minority)
+
+	// 과반수의 응답이 끊긴 리더는 선거 시간 제한 안에 물러나고, 다수 쪽은 새 리더를 뽑음
+	tc.settle(2 * old.cfg.ElectionTicks)
+	if st := old.Status(); st.Role == "leader" {
+		t.Fatalf("leader cut off from the majority is still leading: %+v", st)
+	}
+	leader := tc.leader(100, minority...)
+	tc.propose(leader, "a")
+
+	tc.net.Heal()
+	tc.settle(30)
+	for _, id := range tc.ids {
+		if st := tc.nodes[id].Status(); st.Leader != leader.ID() {
+			t.Errorf("%s follows %q after heal, want %s", id, st.Leader, leader.ID())
+		}
+	}
+	tc.assertApplied([]string{"a"}, tc.ids...)
+}
+
+func TestFileStorageRestart(t *testing.T) {
+	dir := t.TempDir()
+	net := NewMemNetwork()
+	start := func() (*Node, *listMachine, *FileStorage) {
+		t.Helper()
+		storage, err := OpenFileStorage(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sm := &listMachine{}
+		n, err := NewNode(Config{ID: "n1", Peers: []string{"n1"}, SnapshotEntries: 5, Storage: storage, Transport: net, StateMachine: sm})
+		if err != nil {
+			t.Fatal(err)
+		}
+		net.Add(n)
+		return n, sm, storage
+	}
+
+	n, _, storage := start()
+	for range 30 {
+		net.Tick()
+	}
+	var want []string
+	for i := range 12 {
+		cmd := fmt.Sprint(i)
+		want = append(want, cmd)
+		if _, err := n.Apply(context.Background(), []byte(cmd)); err != nil {
+			t.Fatalf("apply %s: %v", cmd, err)
+		}
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 마지막 레코드를 쓰다가 죽은 것처럼 WAL 끝에 깨진 바이트를 붙여도 저장을 마친 상태로 다시 시작
+	f, err := os.OpenFile(filepath.Join(dir, "wal"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0x20, 0, 0, 0, 1, 2})
+	f.Close()
+
+	n, sm, storage := start()
+	defer storage.Close()
+	if got := sm.list(); !slices.Equal(got, want) {
+		t.Fatalf("restarted node replayed %v, want %v", got, want)
+	}
+	if st := n.Status(); st.SnapshotIndex == 0 || st.Term == 0 {
+		t.Fatalf("restarted node status = %+v, want a snapshot and the saved term", st)
+	}
+
+	// 다시 시작한 뒤에 붙인 항목도 남아야 함
+	for range 30 {
+		net.Tick()
+	}
+	if _, err := n.Apply(context.Background(), []byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+	_, sm, storage = start()
+	defer storage.Close()
+	if got := sm.list(); !slices.Equal(got, append(want, "after")) {
+		t.Fatalf("second restart replayed %v, want %v", got, append(want, "after"))
+	}
+}
@@ -0,0 +1,90 @@
+package raft
+
+import (
+	"slices"
+	"sync"
+)
+
+// HardState: 재시작해도 잃으면 안 되는 상태 (응답하기 전에 저장해야 함)
+type HardState struct {
+	Term   uint64 `json:"term"`
+	Vote   string `json:"vote"`
+	Commit uint64 `json:"commit"`
+}
+
+// PersistentState: 저장소에 남기는 전체 상태
+type PersistentState struct {
+	HardState
+	Snapshot Snapshot `json:"snapshot"`
+	Entries  []Entry  `json:"entries"` // 스냅샷 이후 로그
+}
+
+// Update: 마지막 저장 이후 바뀐 부분
+// 로그 전체를 매번 다시 쓰지 않도록 새로 붙었거나 바뀐 항목만 넘김
+type Update struct {
+	HardState
+	// Snapshot: 새 스냅샷 (없으면 nil). 저장하면 Snapshot.Index 까지의 항목은 버림
+	Snapshot *Snapshot
+	// From: 0 이 아니면 From 번째부터의 기존 항목을 지우고 Entries 로 대체 (Entries 가 비어 있으면 잘라내기만 함)
+	From    uint64
+	Entries []Entry
+}
+
+// Storage: 노드 상태를 저장하는 곳
+// 노드는 메시지를 보내기 전에 Save 를 부르므로, Save 가 끝나면 바뀐 부분이 영구히 남아 있어야 함
+type Storage interface {
+	Save(u Update) error
+	Load() (PersistentState, error)
+}
+
+// apply: 저장된 상태에 바뀐 부분을 반영 (스냅샷 먼저, 그다음 항목)
+func (st *PersistentState) apply(u Update) {
+	st.HardState = u.HardState
+	if u.Snapshot != nil {
+		snap := *u.Snapshot
+		snap.Peers = slices.Clone(snap.Peers)
+		st.Snapshot = snap
+		i := slices.IndexFunc(st.Entries, func(e Entry) bool { return e.Index > snap.Index })
+		if i < 0 {
+			i = len(st.Entries)
+		}
+		st.Entries = slices.Clone(st.Entries[i:])
+	}
+	if u.From > 0 {
+		i := slices.IndexFunc(st.Entries, func(e Entry) bool { return e.Index >= u.From })
+		if i < 0 {
+			i = len(st.Entries)
+		}
+		st.Entries = append(st.Entries[:i:i], u.Entries...)
+	}
+}
+
+// MemoryStorage: 메모리 저장소
+// 노드를 새로 만들어도 같은 MemoryStorage 를 넘기면 이전 상태로 다시 시작하므로 테스트에서 재시작 흉내에 사용
+type MemoryStorage struct {
+	mu sync.Mutex
+	st PersistentState
+}
+
+// NewMemoryStorage: 빈 메모리 저장소
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+func (s *MemoryStorage) Save(u Update) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.st.apply(u)
+	return nil
+}
+
+func (s *MemoryStorage) Load() (PersistentState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.st
+	st.Entries = slices.Clone(st.Entries)
+	st.Snapshot.Peers = slices.Clone(st.Snapshot.Peers)
+	return st, nil
+}
@@ -0,0 +1,184 @@
+package raftkv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kv-store/raft"
+)
+
+// 값 하나의 최대 크기
+const maxValueSize = 1 << 20
+
+// NewHandler: 강한 일관성 모드 HTTP API
+//
+//	GET    /strong/kv/{key}           → 200 값 (원본 바이트) + ETag (버전) / 404
+//	PUT    /strong/kv/{key}?ttl=30s   본문 = 값 → 204 + ETag
+//	DELETE /strong/kv/{key}           → 204 / 404
+//	GET    /strong/status             → 래프트 상태 (역할, 임기, 리더, 커밋 인덱스, 구성)
+//	POST   /strong/members/{id}       → 노드 추가 (구성 변경이 커밋되면 204)
+//	DELETE /strong/members/{id}       → 노드 제거
+//
+// PUT / DELETE 에 If-Match: "<ETag>" 를 주면 그 버전일 때만, If-Match: * 는 키가 있을 때만,
+// If-None-Match: * 는 키가 없을 때만 씀. 조건이 맞지 않으면 412 + 현재 ETag
+//
+// 리더가 아닌 노드에 요청하면 307 로 리더에게 보냄 (리더를 모르면 503)
+func NewHandler(kv *KV) http.Handler {
+	h := &handler{kv: kv}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /strong/kv/{key}", h.get)
+	mux.HandleFunc("PUT /strong/kv/{key}", h.put)
+	mux.HandleFunc("DELETE /strong/kv/{key}", h.delete)
+	mux.HandleFunc("GET /strong/status", h.status)
+	mux.HandleFunc("POST /strong/members/{id}", h.member)
+	mux.HandleFunc("DELETE /strong/members/{id}", h.member)
+	return mux
+}
+
+type handler struct {
+	kv *KV
+}
+
+func (h *handler) get(w http.ResponseWriter, r *http.Request) {
+	e, err := h.kv.Get(r.Context(), r.PathValue("key"))
+	if err != nil {
+		writeRaftError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag(e.Version))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(e.Value)
+}
+
+func (h *handler) put(w http.ResponseWriter, r *http.Request) {
+	var opts PutOptions
+	if s := r.URL.Query().Get("ttl"); s != "" {
+		ttl, err := time.ParseDuration(s)
+		if err != nil || ttl <= 0 {
+			writeError(w, http.StatusBadRequest, "bad_request", "invalid ttl "+strconv.Quote(s))
+			return
+		}
+		opts.TTL = ttl
+	}
+	if err := parseCondition(r.Header, &opts); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	value, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValueSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "too_large", "value too large (max 1MiB)")
+		return
+	}
+
+	version, err := h.kv.Put(r.Context(), r.PathValue("key"), value, opts)
+	if err != nil {
+		writeRaftError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) delete(w http.ResponseWriter, r *http.Request) {
+	var opts PutOptions
+	if err := parseCondition(r.Header, &opts); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if opts.IfAbsent {
+		writeError(w, http.StatusBadRequest, "bad_request", "If-None-Match is not supported for DELETE")
+		return
+	}
+	if err := h.kv.Delete(r.Context(), r.PathValue("key"), opts.IfVersion); err != nil {
+		writeRaftError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.kv.Node().Status())
+}
+
+// member: 노드 하나 추가(POST)/제거(DELETE). 구성 변경이 커밋될 때까지 기다림
+func (h *handler) member(w http.ResponseWriter, r *http.Request) {
+	cc := raft.ConfChange{Node: r.PathValue("id"), Remove: r.Method == http.MethodDelete}
+	if err := h.kv.Node().ChangeMembership(r.Context(), cc); err != nil {
+		writeRaftError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseCondition: If-Match / If-None-Match 헤더 → 쓰기 조건
+func parseCondition(header http.Header, opts *PutOptions) error {
+	switch m := strings.TrimSpace(header.Get("If-Match")); m {
+	case "":
+	case "*":
+		opts.IfExists = true
+	default:
+		v, err := strconv.ParseUint(strings.Trim(m, `"`), 10, 64)
+		if err != nil || v == 0 {
+			return errors.New("invalid If-Match ETag")
+		}
+		opts.IfVersion = v
+	}
+	switch strings.TrimSpace(header.Get("If-None-Match")) {
+	case "":
+	case "*":
+		opts.IfAbsent = true
+	default:
+		return errors.New("only If-None-Match: * is supported")
+	}
+	return nil
+}
+
+func etag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// writeRaftError: 래프트/저장소 오류를 HTTP 상태 코드로 변환
+func writeRaftError(w http.ResponseWriter, r *http.Request, err error) {
+	var nle *raft.NotLeaderError
+	var ce *ConflictError
+	switch {
+	case errors.As(err, &nle) && nle.Leader != "":
+		http.Redirect(w, r, "http://"+nle.Leader+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	case errors.Is(err, raft.ErrNotLeader):
+		writeError(w, http.StatusServiceUnavailable, "no_leader", err.Error())
+	case errors.As(err, &ce):
+		w.Header().Set("ETag", etag(ce.Current))
+		writeError(w, http.StatusPreconditionFailed, "precondition_failed", err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+	case errors.Is(err, raft.ErrInvalidConfChange):
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+	case errors.Is(err, raft.ErrConfigPending):
+		writeError(w, http.StatusConflict, "config_pending", err.Error())
+	case errors.Is(err, raft.ErrDropped):
+		writeError(w, http.StatusServiceUnavailable, "leader_changed", err.Error())
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		writeError(w, http.StatusGatewayTimeout, "timeout", "not committed in time (no quorum?)")
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, msg string) {
+	writeJSON(w, status, map[string]any{
+		"error":   code,
+		"message": msg,
+	})
+}
@@ -0,0 +1,78 @@
+package raftkv
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"kv-store/raft"
+)
+
+// KV: 래프트 노드 위의 키-값 연산
+// 모든 연산은 리더에서만 되고, 과반수에 커밋되어 적용된 결과를 돌려줌
+type KV struct {
+	node *raft.Node
+	now  func() time.Time
+}
+
+// New: node 의 상태 기계는 NewMachine 으로 만든 것이어야 함
+func New(node *raft.Node) *KV {
+	return &KV{node: node, now: time.Now}
+}
+
+// Node: 래프트 노드 (상태 조회, 구성 변경용)
+func (kv *KV) Node() *raft.Node {
+	return kv.node
+}
+
+// PutOptions: 쓰기 조건과 만료
+type PutOptions struct {
+	TTL       time.Duration // 0 이면 만료 없음
+	IfVersion uint64        // 0 이 아니면 지금 버전이 이것일 때만 씀 (compare-and-swap)
+	IfExists  bool          // 키가 있을 때만
+	IfAbsent  bool          // 키가 없을 때만
+}
+
+// Get: 선형화 가능한 읽기 (이 읽기 전에 끝난 모든 쓰기가 보임)
+func (kv *KV) Get(ctx context.Context, key string) (Entry, error) {
+	return kv.apply(ctx, command{Op: opGet, Key: key})
+}
+
+// Put: 값을 쓰고 새 버전을 돌려줌 (조건이 안 맞으면 ConflictError)
+func (kv *KV) Put(ctx context.Context, key string, value []byte, opts PutOptions) (uint64, error) {
+	e, err := kv.apply(ctx, command{
+		Op:        opPut,
+		Key:       key,
+		Value:     value,
+		TTL:       int64(opts.TTL),
+		IfVersion: opts.IfVersion,
+		IfExists:  opts.IfExists,
+		IfAbsent:  opts.IfAbsent,
+	})
+	return e.Version, err
+}
+
+// CompareAndSwap: 지금 버전이 version 일 때만 value 로 바꿈
+func (kv *KV) CompareAndSwap(ctx context.Context, key string, version uint64, value []byte) (uint64, error) {
+	return kv.Put(ctx, key, value, PutOptions{IfVersion: version})
+}
+
+// Delete: 키 삭제 (ifVersion 이 0 이 아니면 그 버전일 때만)
+func (kv *KV) Delete(ctx context.Context, key string, ifVersion uint64) error {
+	_, err := kv.apply(ctx, command{Op: opDelete, Key: key, IfVersion: ifVersion})
+	return err
+}
+
+func (kv *KV) apply(ctx context.Context, c command) (Entry, error) {
+	c.Now = kv.now().UnixNano()
+	cmd, err := json.Marshal(c)
+	if err != nil {
+		return Entry{}, err
+	}
+	v, err := kv.node.Apply(ctx, cmd)
+	if err != nil {
+		return Entry{}, err
+	}
+	r := v.(result)
+	return r.entry, r.err
+}
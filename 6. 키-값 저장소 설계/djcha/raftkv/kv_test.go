@@ -0,0 +1,151 @@
+package raftkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kv-store/raft"
+)
+
+// startCluster: 메모리 네트워크 위의 3대 클러스터. 백그라운드에서 틱을 돌리고 리더가 뽑힐 때까지 기다림
+func startCluster(t *testing.T) (leader *KV, followers []*KV) {
+	t.Helper()
+	net := raft.NewMemNetwork()
+	ids := []string{"n1", "n2", "n3"}
+	var kvs []*KV
+	for _, id := range ids {
+		node, err := raft.NewNode(raft.Config{ID: id, Peers: ids, Transport: net, StateMachine: NewMachine(), SnapshotEntries: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		net.Add(node)
+		kvs = append(kvs, New(node))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			net.Tick()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for i, kv := range kvs {
+			if kv.Node().Status().Role == "leader" {
+				return kv, append(kvs[:i:i], kvs[i+1:]...)
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("no leader elected")
+	return nil, nil
+}
+
+func TestLinearizableReadsAndWrites(t *testing.T) {
+	leader, followers := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v1, err := leader.Put(ctx, "config", []byte("a"), PutOptions{IfAbsent: true})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := leader.Put(ctx, "config", []byte("x"), PutOptions{IfAbsent: true}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second create: err = %v, want ErrConflict", err)
+	}
+
+	v2, err := leader.CompareAndSwap(ctx, "config", v1, []byte("b"))
+	if err != nil || v2 <= v1 {
+		t.Fatalf("CAS = %d, %v, want a version after %d", v2, err, v1)
+	}
+	_, err = leader.CompareAndSwap(ctx, "config", v1, []byte("c"))
+	var ce *ConflictError
+	if !errors.As(err, &ce) || ce.Current != v2 {
+		t.Fatalf("stale CAS: err = %v, want conflict reporting version %d", err, v2)
+	}
+
+	e, err := leader.Get(ctx, "config")
+	if err != nil || string(e.Value) != "b" || e.Version != v2 {
+		t.Fatalf("Get = %+v, %v, want b@%d", e, err, v2)
+	}
+	if _, err := followers[0].Get(ctx, "config"); !errors.Is(err, raft.ErrNotLeader) {
+		t.Fatalf("Get on follower: err = %v, want ErrNotLeader", err)
+	}
+
+	if err := leader.Delete(ctx, "config", v1); !errors.Is(err, ErrConflict) {
+		t.Fatalf("delete with stale version: err = %v, want ErrConflict", err)
+	}
+	if err := leader.Delete(ctx, "config", v2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.Get(ctx, "config"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLeaseExpiresByLeaderClock(t *testing.T) {
+	leader, _ := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Unix(1_000, 0)
+	leader.now = func() time.Time { return now }
+	if _, err := leader.Put(ctx, "lease/job", []byte("worker-1"), PutOptions{TTL: 10 * time.Second, IfAbsent: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 만료 전에는 다른 워커가 임대를 잡지 못하고, 만료 뒤에는 잡을 수 있음
+	now = now.Add(9 * time.Second)
+	if _, err := leader.Put(ctx, "lease/job", []byte("worker-2"), PutOptions{TTL: 10 * time.Second, IfAbsent: true}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("acquire before expiry: err = %v, want ErrConflict", err)
+	}
+	now = now.Add(2 * time.Second)
+	if _, err := leader.Put(ctx, "lease/job", []byte("worker-2"), PutOptions{TTL: 10 * time.Second, IfAbsent: true}); err != nil {
+		t.Fatalf("acquire after expiry: %v", err)
+	}
+}
+
+func TestHandlerRedirectsToLeader(t *testing.T) {
+	leader, followers := startCluster(t)
+	srv := httptest.NewServer(NewHandler(followers[0]))
+	defer srv.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/strong/kv/config?ttl=1m", strings.NewReader("v"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	want := fmt.Sprintf("http://%s/strong/kv/config?ttl=1m", leader.Node().ID())
+	if resp.StatusCode != http.StatusTemporaryRedirect || resp.Header.Get("Location") != want {
+		t.Fatalf("PUT on follower = %d Location %q, want 307 to %q", resp.StatusCode, resp.Header.Get("Location"), want)
+	}
+
+	lsrv := httptest.NewServer(NewHandler(leader))
+	defer lsrv.Close()
+	req, _ = http.NewRequest(http.MethodPut, lsrv.URL+"/strong/kv/config", strings.NewReader("v"))
+	req.Header.Set("If-Match", `"42"`)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("PUT with wrong If-Match = %d, want 412", resp.StatusCode)
+	}
+}
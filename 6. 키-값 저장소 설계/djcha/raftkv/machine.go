@@ -0,0 +1,156 @@
+// Package raftkv: 래프트로 복제하는 강한 일관성 키-값 저장소
+//
+// 설정, 임대(lease)처럼 결과적 일관성으로는 안 되는 데이터용
+// 읽기도 쓰기와 똑같이 로그에 넣어 커밋된 뒤 응답하므로, 모든 연산이 하나의 순서로 줄 서는 선형화 가능성을 보장
+// 리더가 아닌 노드에 요청하면 raft.NotLeaderError 로 리더 주소를 알려 줌
+package raftkv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrNotFound: 키가 없음 (만료된 키 포함)
+	ErrNotFound = errors.New("raftkv: key not found")
+	// ErrConflict: 전제 조건(버전, 없어야 함)이 맞지 않음 (ConflictError 로 현재 버전을 알려 줌)
+	ErrConflict = errors.New("raftkv: precondition failed")
+)
+
+// ConflictError: 전제 조건 실패. Current 는 지금 버전 (키가 없으면 0)
+type ConflictError struct {
+	Key     string
+	Current uint64
+}
+
+func (e *ConflictError) Error() string {
+	if e.Current == 0 {
+		return fmt.Sprintf("raftkv: precondition failed for %q (key does not exist)", e.Key)
+	}
+	return fmt.Sprintf("raftkv: precondition failed for %q (current version %d)", e.Key, e.Current)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// Entry: 저장된 값
+type Entry struct {
+	Value   []byte `json:"value"`
+	Version uint64 `json:"version"`           // 마지막으로 바꾼 연산의 리비전 (키마다 단조 증가)
+	Expires int64  `json:"expires,omitempty"` // 만료 시각 (유닉스 나노초, 0 이면 만료 없음)
+}
+
+func (e Entry) live(now int64) bool {
+	return e.Expires == 0 || now < e.Expires
+}
+
+// 명령 종류
+const (
+	opGet    = "get"
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+// command: 로그에 넣는 명령
+// 만료 판단에 쓰는 시각(Now)은 제안한 노드가 넣음 → 모든 복제본이 같은 시각으로 적용해서 결과가 같음
+type command struct {
+	Op        string `json:"op"`
+	Key       string `json:"key"`
+	Value     []byte `json:"value,omitempty"`
+	TTL       int64  `json:"ttl,omitempty"` // 나노초
+	Now       int64  `json:"now"`
+	IfVersion uint64 `json:"if_version,omitempty"` // 0 이 아니면 지금 버전이 이것일 때만
+	IfExists  bool   `json:"if_exists,omitempty"`
+	IfAbsent  bool   `json:"if_absent,omitempty"`
+}
+
+// result: 명령을 적용한 결과 (Apply 의 반환값)
+type result struct {
+	entry Entry
+	err   error
+}
+
+// Machine: raft.StateMachine 구현 (키 → 값)
+type Machine struct {
+	mu       sync.Mutex
+	revision uint64 // 지금까지 적용한 쓰기/삭제 수 (새 버전 번호로 사용)
+	data     map[string]Entry
+}
+
+// NewMachine: 빈 상태 기계
+func NewMachine() *Machine {
+	return &Machine{data: make(map[string]Entry)}
+}
+
+func (m *Machine) Apply(cmd []byte) any {
+	var c command
+	if err := json.Unmarshal(cmd, &c); err != nil {
+		return result{err: fmt.Errorf("raftkv: invalid command: %w", err)}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cur, ok := m.data[c.Key]
+	if ok && !cur.live(c.Now) {
+		delete(m.data, c.Key)
+		cur, ok = Entry{}, false
+	}
+	if c.Op == opGet {
+		if !ok {
+			return result{err: ErrNotFound}
+		}
+		return result{entry: cur}
+	}
+
+	if (c.IfVersion != 0 && cur.Version != c.IfVersion) || (c.IfExists && !ok) || (c.IfAbsent && ok) {
+		return result{err: &ConflictError{Key: c.Key, Current: cur.Version}}
+	}
+	switch c.Op {
+	case opPut:
+		m.revision++
+		e := Entry{Value: c.Value, Version: m.revision}
+		if c.TTL > 0 {
+			e.Expires = c.Now + c.TTL
+		}
+		m.data[c.Key] = e
+		return result{entry: e}
+	case opDelete:
+		if !ok {
+			return result{err: ErrNotFound}
+		}
+		m.revision++
+		delete(m.data, c.Key)
+		return result{}
+	}
+	return result{err: fmt.Errorf("raftkv: unknown op %q", c.Op)}
+}
+
+type machineSnapshot struct {
+	Revision uint64           `json:"revision"`
+	Data     map[string]Entry `json:"data"`
+}
+
+func (m *Machine) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return json.Marshal(machineSnapshot{Revision: m.revision, Data: m.data})
+}
+
+func (m *Machine) Restore(data []byte) error {
+	var s machineSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.Data == nil {
+		s.Data = make(map[string]Entry)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.revision, m.data = s.Revision, s.Data
+	return nil
+}
@@ -0,0 +1,316 @@
+package respapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"kv-store/cluster"
+	"kv-store/store"
+)
+
+// command: 명령 처리 함수와 인자 개수
+// arity 가 양수면 정확히 그 개수, 음수면 최소 -arity 개 (명령 이름 포함)
+type command struct {
+	arity int
+	run   func(s *Server, ctx context.Context, w writer, args [][]byte)
+}
+
+var commands map[string]command
+
+// EX/PX 로 받을 수 있는 최대 값 (초 단위로 받아도 time.Duration 이 넘치지 않도록)
+const maxExpire = int64(math.MaxInt64 / time.Second)
+
+func init() {
+	commands = map[string]command{
+		"ping":   {-1, (*Server).ping},
+		"quit":   {1, nil},
+		"get":    {2, (*Server).get},
+		"set":    {-3, (*Server).set},
+		"del":    {-2, (*Server).del},
+		"exists": {-2, (*Server).exists},
+		"mget":   {-2, (*Server).mget},
+		"mset":   {-3, (*Server).mset},
+		"ttl":    {2, (*Server).ttl},
+		"scan":   {-2, (*Server).scan},
+	}
+}
+
+// execute: 명령 하나 실행 (QUIT 이면 true → 응답 후 연결 종료)
+func (s *Server) execute(ctx context.Context, w writer, args [][]byte) (quit bool) {
+	name := strings.ToLower(string(args[0]))
+	cmd, ok := commands[name]
+	switch {
+	case !ok:
+		w.error("ERR unknown command '" + string(args[0]) + "'")
+	case (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity):
+		w.error("ERR wrong number of arguments for '" + name + "' command")
+	case name == "quit":
+		w.simple("OK")
+		return true
+	default:
+		cmd.run(s, ctx, w, args)
+	}
+	return false
+}
+
+func (s *Server) ping(ctx context.Context, w writer, args [][]byte) {
+	switch len(args) {
+	case 1:
+		w.simple("PONG")
+	case 2:
+		w.bulk(args[1])
+	default:
+		w.error("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+func (s *Server) get(ctx context.Context, w writer, args [][]byte) {
+	v, found, err := s.read(ctx, string(args[1]))
+	switch {
+	case err != nil:
+		writeClusterError(w, err)
+	case !found:
+		w.null()
+	default:
+		w.bulk(v.Data)
+	}
+}
+
+// SET key value [NX | XX] [EX seconds | PX milliseconds]
+func (s *Server) set(ctx context.Context, w writer, args [][]byte) {
+	var nx, xx bool
+	var ttl time.Duration
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToLower(string(args[i])); {
+		case opt == "nx" && !xx:
+			nx = true
+		case opt == "xx" && !nx:
+			xx = true
+		case (opt == "ex" || opt == "px") && ttl == 0 && i+1 < len(args):
+			i++
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil || n <= 0 || n > maxExpire {
+				w.error("ERR invalid expire time in 'set' command")
+				return
+			}
+			ttl = time.Duration(n) * time.Millisecond
+			if opt == "ex" {
+				ttl *= 1000
+			}
+			// 만료 시각(지금 + ttl, 나노초)이 int64 를 넘으면 음수가 되어 바로 사라지므로 거부 (레디스와 같음)
+			if ttl > time.Duration(math.MaxInt64-time.Now().UnixNano()) {
+				w.error("ERR invalid expire time in 'set' command")
+				return
+			}
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	// 레디스 SET 은 기존 값을 덮어쓰므로 먼저 읽어서 문맥을 얻음
+	// NX/XX 확인과 쓰기 사이에 다른 클라이언트가 쓸 수 있음 (다이나모 방식이라 원자적이지 않음)
+	key := string(args[1])
+	res, err := s.node.Get(ctx, key, cluster.ReadOptions{Consistency: s.level})
+	exists := err == nil
+	if err != nil && !errors.Is(err, cluster.ErrNotFound) {
+		writeClusterError(w, err)
+		return
+	}
+	if (nx && exists) || (xx && !exists) {
+		w.null()
+		return
+	}
+
+	opts := cluster.WriteOptions{Consistency: s.level, Context: res.Context, TTL: ttl}
+	if err := s.node.Put(ctx, key, args[2], opts); err != nil {
+		writeClusterError(w, err)
+		return
+	}
+	w.simple("OK")
+}
+
+// DEL key [key ...] → 실제로 지운 키 개수
+func (s *Server) del(ctx context.Context, w writer, args [][]byte) {
+	deleted := 0
+	for _, arg := range args[1:] {
+		key := string(arg)
+		res, err := s.node.Get(ctx, key, cluster.ReadOptions{Consistency: s.level})
+		if errors.Is(err, cluster.ErrNotFound) {
+			continue
+		}
+		if err == nil {
+			err = s.node.Delete(ctx, key, cluster.WriteOptions{Consistency: s.level, Context: res.Context})
+		}
+		if err != nil {
+			writeClusterError(w, err)
+			return
+		}
+		deleted++
+	}
+	w.integer(int64(deleted))
+}
+
+// EXISTS key [key ...] → 있는 키 개수 (같은 키를 여러 번 주면 여러 번 셈)
+func (s *Server) exists(ctx context.Context, w writer, args [][]byte) {
+	count := 0
+	for _, arg := range args[1:] {
+		_, found, err := s.read(ctx, string(arg))
+		if err != nil {
+			writeClusterError(w, err)
+			return
+		}
+		if found {
+			count++
+		}
+	}
+	w.integer(int64(count))
+}
+
+func (s *Server) mget(ctx context.Context, w writer, args [][]byte) {
+	values := make([]*store.Value, len(args)-1)
+	for i, arg := range args[1:] {
+		v, found, err := s.read(ctx, string(arg))
+		if err != nil {
+			writeClusterError(w, err)
+			return
+		}
+		if found {
+			values[i] = &v
+		}
+	}
+
+	w.array(len(values))
+	for _, v := range values {
+		if v == nil {
+			w.null()
+		} else {
+			w.bulk(v.Data)
+		}
+	}
+}
+
+// MSET key value [key value ...]
+// 키마다 따로 쓰므로 중간에 실패하면 앞의 키만 써져 있을 수 있음
+func (s *Server) mset(ctx context.Context, w writer, args [][]byte) {
+	if len(args)%2 != 1 {
+		w.error("ERR wrong number of arguments for 'mset' command")
+		return
+	}
+	for i := 1; i < len(args); i += 2 {
+		key := string(args[i])
+		res, err := s.node.Get(ctx, key, cluster.ReadOptions{Consistency: s.level})
+		if err == nil || errors.Is(err, cluster.ErrNotFound) {
+			err = s.node.Put(ctx, key, args[i+1], cluster.WriteOptions{Consistency: s.level, Context: res.Context})
+		}
+		if err != nil {
+			writeClusterError(w, err)
+			return
+		}
+	}
+	w.simple("OK")
+}
+
+// TTL key → 남은 초 (-2: 키 없음, -1: 만료 없음)
+func (s *Server) ttl(ctx context.Context, w writer, args [][]byte) {
+	res, err := s.node.Get(ctx, string(args[1]), cluster.ReadOptions{Consistency: s.level})
+	if errors.Is(err, cluster.ErrNotFound) {
+		w.integer(-2)
+		return
+	}
+	if err != nil {
+		writeClusterError(w, err)
+		return
+	}
+
+	// 형제 값 중 하나라도 만료가 없으면 키는 계속 남아 있음
+	var expires int64
+	for _, v := range res.Siblings {
+		if v.Expires == 0 {
+			w.integer(-1)
+			return
+		}
+		expires = max(expires, v.Expires)
+	}
+	remaining := time.Until(time.Unix(0, expires))
+	w.integer(int64((remaining + 500*time.Millisecond) / time.Second))
+}
+
+// SCAN cursor [MATCH pattern] [COUNT count]
+// MATCH 는 COUNT 개를 가져온 다음 거르므로 빈 페이지가 나올 수 있음 (레디스와 같음)
+func (s *Server) scan(ctx context.Context, w writer, args [][]byte) {
+	cursor, err := strconv.ParseUint(string(args[1]), 10, 64)
+	if err != nil {
+		w.error("ERR invalid cursor")
+		return
+	}
+	pattern, count := "", 10
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			w.error("ERR syntax error")
+			return
+		}
+		switch strings.ToLower(string(args[i])) {
+		case "match":
+			pattern = string(args[i+1])
+		case "count":
+			count, err = strconv.Atoi(string(args[i+1]))
+			if err != nil || count < 1 {
+				w.error("ERR value is not an integer or out of range")
+				return
+			}
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	keys, next, err := s.node.Scan(ctx, cursor, count)
+	if err != nil {
+		writeClusterError(w, err)
+		return
+	}
+	matched := keys[:0]
+	for _, key := range keys {
+		if pattern == "" || matchGlob(pattern, key) {
+			matched = append(matched, key)
+		}
+	}
+
+	w.array(2)
+	w.bulk([]byte(strconv.FormatUint(next, 10)))
+	w.array(len(matched))
+	for _, key := range matched {
+		w.bulk([]byte(key))
+	}
+}
+
+// read: 키 하나 읽기
+// RESP 는 형제 값을 표현할 수 없으므로 동시에 쓰인 값이 여러 개면 마지막 쓰기 값을 돌려줌
+func (s *Server) read(ctx context.Context, key string) (store.Value, bool, error) {
+	res, err := s.node.Get(ctx, key, cluster.ReadOptions{Consistency: s.level})
+	if errors.Is(err, cluster.ErrNotFound) {
+		return store.Value{}, false, nil
+	}
+	if err != nil {
+		return store.Value{}, false, err
+	}
+	return cluster.LastWriteWins(key, res.Siblings), true, nil
+}
+
+// writeClusterError: 코디네이터 오류를 레디스 오류 응답으로 변환
+func writeClusterError(w writer, err error) {
+	var qe *cluster.QuorumError
+	switch {
+	case errors.As(err, &qe):
+		w.error("TRYAGAIN " + err.Error())
+	case errors.Is(err, cluster.ErrNotEnoughReplicas):
+		w.error("CLUSTERDOWN " + err.Error())
+	default:
+		w.error("ERR " + err.Error())
+	}
+}
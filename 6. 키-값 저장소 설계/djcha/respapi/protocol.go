@@ -0,0 +1,134 @@
+package respapi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// 요청 크기 상한 (값 하나 / 명령 인자 개수)
+const (
+	maxBulkSize = 1 << 20
+	maxArgs     = 1 << 16
+	maxInline   = 64 << 10
+)
+
+// errProtocol: 요청 형식이 RESP 가 아님 → 오류를 보내고 연결을 끊음
+var errProtocol = errors.New("protocol error")
+
+// readCommand: 클라이언트 요청 하나 읽기
+//   - 배열 형식: *2\r\n$3\r\nGET\r\n$1\r\nk\r\n  (redis-cli, go-redis 등 클라이언트 라이브러리)
+//   - 인라인 형식: GET k\r\n                     (telnet, nc 로 직접 칠 때)
+//
+// 빈 줄과 공백뿐인 인라인 명령은 건너뜀 (레디스와 같음)
+func readCommand(r *bufio.Reader) ([][]byte, error) {
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '*' {
+			if args := bytes.Fields(line); len(args) > 0 {
+				return args, nil
+			}
+			continue
+		}
+
+		count, err := strconv.Atoi(string(line[1:]))
+		if err != nil || count > maxArgs {
+			return nil, fmt.Errorf("%w: invalid multibulk length", errProtocol)
+		}
+		if count <= 0 {
+			continue
+		}
+		args := make([][]byte, count)
+		for i := range args {
+			if args[i], err = readBulk(r); err != nil {
+				return nil, err
+			}
+		}
+		return args, nil
+	}
+}
+
+// readBulk: $길이\r\n데이터\r\n
+func readBulk(r *bufio.Reader) ([]byte, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '$' {
+		return nil, fmt.Errorf("%w: expected '$', got '%s'", errProtocol, line)
+	}
+	size, err := strconv.Atoi(string(line[1:]))
+	if err != nil || size < 0 || size > maxBulkSize {
+		return nil, fmt.Errorf("%w: invalid bulk length", errProtocol)
+	}
+
+	buf := make([]byte, size+2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	if buf[size] != '\r' || buf[size+1] != '\n' {
+		return nil, fmt.Errorf("%w: bulk not terminated by CRLF", errProtocol)
+	}
+	return buf[:size], nil
+}
+
+// readLine: \r\n (인라인 명령은 \n 만 있어도 됨) 앞까지
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+		if len(line) > maxInline {
+			return nil, fmt.Errorf("%w: too big inline request", errProtocol)
+		}
+	}
+	line = bytes.TrimSuffix(line[:len(line)-1], []byte("\r"))
+	return line, nil
+}
+
+// writer: RESP2 응답 작성 (버퍼에 모았다가 요청을 다 처리하면 한 번에 보냄)
+type writer struct {
+	*bufio.Writer
+}
+
+func (w writer) simple(s string) {
+	w.WriteString("+" + s + "\r\n")
+}
+
+func (w writer) error(s string) {
+	w.WriteString("-" + s + "\r\n")
+}
+
+func (w writer) integer(n int64) {
+	w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+func (w writer) bulk(b []byte) {
+	w.WriteString("$" + strconv.Itoa(len(b)) + "\r\n")
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+// null: 값 없음 (없는 키, NX/XX 조건 불만족)
+func (w writer) null() {
+	w.WriteString("$-1\r\n")
+}
+
+func (w writer) array(n int) {
+	w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
@@ -0,0 +1,160 @@
+package respapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"kv-store/cluster"
+)
+
+// ErrServerClosed: Close 뒤에 Serve 가 돌려주는 오류
+var ErrServerClosed = errors.New("respapi: server closed")
+
+// Server: 레디스 프로토콜(RESP2) 로 키-값 저장소를 여는 TCP 서버
+// redis-cli, go-redis 같은 기존 도구로 바로 접근할 수 있음
+//
+// 연결마다 고루틴 하나가 요청을 순서대로 처리하고,
+// 파이프라이닝으로 한꺼번에 온 요청은 응답을 모았다가 한 번에 보냄
+type Server struct {
+	node  *cluster.Node
+	level cluster.Consistency
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewServer: level 은 모든 명령에 쓸 일관성 수준 (Default 면 노드의 R/W)
+func NewServer(node *cluster.Node, level cluster.Consistency) *Server {
+	return &Server{
+		node:      node,
+		level:     level,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe: addr 에서 TCP 연결을 받음
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve: l 로 들어오는 연결을 처리 (Close 전까지 반환하지 않음)
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l, true) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.track(l, false)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.trackConn(conn, true) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.trackConn(conn, false)
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close: 리스너와 모든 연결을 닫고 처리 중인 연결 고루틴이 끝날 때까지 대기
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) track(l net.Listener, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if add {
+		if s.closed {
+			return false
+		}
+		s.listeners[l] = struct{}{}
+	} else {
+		delete(s.listeners, l)
+	}
+	return true
+}
+
+func (s *Server) trackConn(conn net.Conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if add {
+		if s.closed {
+			return false
+		}
+		s.conns[conn] = struct{}{}
+	} else {
+		delete(s.conns, conn)
+	}
+	return true
+}
+
+// serveConn: 연결 하나의 요청 처리 루프
+// 읽기 버퍼에 다음 요청이 남아 있으면(파이프라이닝) 응답을 바로 보내지 않고 모아 둠
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := bufio.NewReader(conn)
+	w := writer{bufio.NewWriter(conn)}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				w.error("ERR " + err.Error())
+				w.Flush()
+			}
+			return
+		}
+
+		if quit := s.execute(ctx, w, args); quit {
+			w.Flush()
+			return
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package respapi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kv-store/cluster"
+	"kv-store/ring"
+)
+
+// startServer: 노드 1대짜리 클러스터 앞에 RESP 서버를 띄움
+func startServer(t *testing.T) string {
+	t.Helper()
+
+	r := ring.New(10)
+	r.Add("node-1")
+	cfg := cluster.Config{N: 1, R: 1, W: 1, Timeout: time.Second}
+	node, err := cluster.NewNode("node-1", cfg, r, cluster.NewHTTPTransport())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(node, cluster.Default)
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	return l.Addr().String()
+}
+
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &client{conn: conn, r: bufio.NewReader(conn)}
+}
+
+// write: 명령들을 한 번의 쓰기로 보냄 (파이프라이닝)
+func (c *client) write(cmds ...[]string) error {
+	var b strings.Builder
+	for _, args := range cmds {
+		fmt.Fprintf(&b, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	_, err := c.conn.Write([]byte(b.String()))
+	return err
+}
+
+// read: 응답 하나를 읽어 문자열로 (배열은 [a b], null 은 <nil>)
+func (c *client) read() (string, error) {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	switch line[0] {
+	case '+', '-', ':':
+		return line, nil
+	case '$':
+		size, _ := strconv.Atoi(line[1:])
+		if size < 0 {
+			return "<nil>", nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, _ := strconv.Atoi(line[1:])
+		items := make([]string, count)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return "", err
+			}
+		}
+		return "[" + strings.Join(items, " ") + "]", nil
+	}
+	return "", fmt.Errorf("unexpected reply %q", line)
+}
+
+func (c *client) send(t *testing.T, cmds ...[]string) {
+	t.Helper()
+	if err := c.write(cmds...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *client) reply(t *testing.T) string {
+	t.Helper()
+	got, err := c.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func (c *client) do(t *testing.T, args ...string) string {
+	t.Helper()
+	c.send(t, args)
+	return c.reply(t)
+}
+
+func TestCommands(t *testing.T) {
+	c := dial(t, startServer(t))
+
+	steps := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"PING"}, "+PONG"},
+		{[]string{"ping", "hi"}, "hi"},
+		{[]string{"GET", "a"}, "<nil>"},
+		{[]string{"SET", "a", "1"}, "+OK"},
+		{[]string{"GET", "a"}, "1"},
+		{[]string{"SET", "a", "2", "NX"}, "<nil>"},
+		{[]string{"SET", "b", "2", "XX"}, "<nil>"},
+		{[]string{"SET", "a", "3", "XX"}, "+OK"},
+		{[]string{"GET", "a"}, "3"},
+		{[]string{"SET", "a", "x", "NX", "XX"}, "-ERR syntax error"},
+		{[]string{"SET", "a", "x", "EX", "0"}, "-ERR invalid expire time in 'set' command"},
+		{[]string{"SET", "a", "x", "EX", "9223372036"}, "-ERR invalid expire time in 'set' command"},
+		{[]string{"SET", "a", "x", "PX", "9223372036854775807"}, "-ERR invalid expire time in 'set' command"},
+		{[]string{"TTL", "a"}, ":-1"},
+		{[]string{"TTL", "missing"}, ":-2"},
+		{[]string{"SET", "s", "v", "EX", "100"}, "+OK"},
+		{[]string{"TTL", "s"}, ":100"},
+		{[]string{"MSET", "k1", "v1", "k2", "v2"}, "+OK"},
+		{[]string{"MGET", "k1", "missing", "k2"}, "[v1 <nil> v2]"},
+		{[]string{"EXISTS", "k1", "k1", "missing"}, ":2"},
+		{[]string{"DEL", "k1", "missing"}, ":1"},
+		{[]string{"GET", "k1"}, "<nil>"},
+		{[]string{"EXISTS", "k1"}, ":0"},
+		{[]string{"SET", "k1", "again"}, "+OK"},
+		{[]string{"GET", "k1"}, "again"},
+		{[]string{"GET"}, "-ERR wrong number of arguments for 'get' command"},
+		{[]string{"HELLO", "3"}, "-ERR unknown command 'HELLO'"},
+	}
+	for _, step := range steps {
+		if got := c.do(t, step.args...); got != step.want {
+			t.Fatalf("%v = %q, want %q", step.args, got, step.want)
+		}
+	}
+}
+
+func TestExpiredKeyIsNotReturned(t *testing.T) {
+	c := dial(t, startServer(t))
+
+	if got := c.do(t, "SET", "short", "v", "PX", "50"); got != "+OK" {
+		t.Fatalf("SET PX = %q", got)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := c.do(t, "GET", "short"); got != "<nil>" {
+		t.Fatalf("GET after expiry = %q, want <nil>", got)
+	}
+	if got := c.do(t, "TTL", "short"); got != ":-2" {
+		t.Fatalf("TTL after expiry = %q, want :-2", got)
+	}
+}
+
+func TestPipelining(t *testing.T) {
+	c := dial(t, startServer(t))
+
+	var cmds [][]string
+	for i := range 100 {
+		cmds = append(cmds, []string{"SET", fmt.Sprintf("key:%d", i), strconv.Itoa(i)})
+		cmds = append(cmds, []string{"GET", fmt.Sprintf("key:%d", i)})
+	}
+	c.send(t, cmds...)
+	for i := range 100 {
+		if got := c.reply(t); got != "+OK" {
+			t.Fatalf("reply to SET %d = %q", i, got)
+		}
+		if got := c.reply(t); got != strconv.Itoa(i) {
+			t.Fatalf("reply to GET %d = %q", i, got)
+		}
+	}
+
+	// 인라인 명령도 파이프라이닝 가능
+	if _, err := c.conn.Write([]byte("PING\r\nGET key:7\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.reply(t); got != "+PONG" {
+		t.Fatalf("inline PING = %q", got)
+	}
+	if got := c.reply(t); got != "7" {
+		t.Fatalf("inline GET = %q", got)
+	}
+}
+
+func TestBlankInlineCommandIsIgnored(t *testing.T) {
+	c := dial(t, startServer(t))
+
+	// 공백뿐인 줄은 명령이 아니므로 응답 없이 건너뛰고 연결도 유지
+	if _, err := c.conn.Write([]byte("   \r\n\t\r\nPING\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.reply(t); got != "+PONG" {
+		t.Fatalf("PING after blank lines = %q", got)
+	}
+}
+
+func TestScanVisitsEveryKey(t *testing.T) {
+	c := dial(t, startServer(t))
+
+	var want []string
+	for i := range 250 {
+		key := fmt.Sprintf("user:%d", i)
+		want = append(want, key)
+		c.send(t, []string{"SET", key, "v"})
+	}
+	c.send(t, []string{"SET", "other", "v"})
+	for range 251 {
+		c.reply(t)
+	}
+
+	var got []string
+	cursor := "0"
+	for {
+		reply := c.do(t, "SCAN", cursor, "MATCH", "user:*", "COUNT", "20")
+		fields := strings.Fields(strings.NewReplacer("[", " ", "]", " ").Replace(reply))
+		cursor = fields[0]
+		got = append(got, fields[1:]...)
+		if cursor == "0" {
+			break
+		}
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("scan returned %d keys, want %d", len(got), len(want))
+	}
+}
+
+func TestConcurrentClients(t *testing.T) {
+	addr := startServer(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		c := dial(t, addr)
+		wg.Go(func() {
+			for j := range 20 {
+				key := fmt.Sprintf("c%d:%d", i, j)
+				if err := c.write([]string{"SET", key, key}, []string{"GET", key}); err != nil {
+					errs <- err
+					return
+				}
+				if got, err := c.read(); got != "+OK" {
+					errs <- fmt.Errorf("SET %s = %q (%v)", key, got, err)
+					return
+				}
+				if got, err := c.read(); got != key {
+					errs <- fmt.Errorf("GET %s = %q (%v)", key, got, err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "anything/with/slash", true},
+		{"user:*", "user:1", true},
+		{"user:*", "order:1", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+	}
+	for _, tc := range cases {
+		if got := matchGlob(tc.pattern, tc.s); got != tc.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tc.pattern, tc.s, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package ring
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Ring: 가상 노드를 사용하는 안정 해시 링 (5장 ConsistentHash 와 같은 해시 방식)
+// 키를 링 위에 올려놓고 시계 방향으로 만나는 서버들이 그 키의 복제본을 맡음
+type Ring struct {
+	mu       sync.RWMutex
+	vnodes   int               // 서버 1대당 가상 노드 개수
+	hashes   []uint32          // 가상 노드 해시 (오름차순 정렬)
+	owners   map[uint32]string // 가상 노드 해시 → 서버 이름
+	physical map[string]bool   // 링에 참여 중인 서버 목록
+}
+
+// New: 서버 1대당 vnodes 개의 가상 노드를 두는 빈 링 생성
+func New(vnodes int) *Ring {
+	return &Ring{
+		vnodes:   vnodes,
+		owners:   make(map[uint32]string),
+		physical: make(map[string]bool),
+	}
+}
+
+// Hash: 키(또는 가상 노드 이름)의 링 위 위치
+// SHA-256 결과의 앞 4 Byte 를 빅 엔디안 uint32 로 사용
+func Hash(key string) uint32 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+// Add: 서버를 링에 추가 (이미 있으면 무시)
+func (r *Ring) Add(server string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.physical[server] {
+		return
+	}
+	r.physical[server] = true
+	for i := 0; i < r.vnodes; i++ {
+		h := Hash(server + "-" + strconv.Itoa(i))
+		r.owners[h] = server
+		r.hashes = append(r.hashes, h)
+	}
+	slices.Sort(r.hashes)
+}
+
+// Remove: 서버와 그 서버의 가상 노드를 링에서 제거
+func (r *Ring) Remove(server string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.physical[server] {
+		return
+	}
+	delete(r.physical, server)
+	hashes := r.hashes[:0]
+	for _, h := range r.hashes {
+		if r.owners[h] == server {
+			delete(r.owners, h)
+			continue
+		}
+		hashes = append(hashes, h)
+	}
+	r.hashes = hashes
+}
+
+// Servers: 링에 참여 중인 서버 목록 (이름순)
+func (r *Ring) Servers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	servers := make([]string, 0, len(r.physical))
+	for s := range r.physical {
+		servers = append(servers, s)
+	}
+	slices.Sort(servers)
+	return servers
+}
+
+// PreferenceList: 키를 저장할 서버 n 대 (다이나모의 "선호 목록")
+// 키 위치에서 시계 방향으로 돌며 만나는 서로 다른 물리 서버를 순서대로 고름
+// (같은 서버의 가상 노드를 또 만나면 건너뜀)
+func (r *Ring) PreferenceList(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n = min(n, len(r.physical))
+	if n <= 0 {
+		return nil
+	}
+
+	list := make([]string, 0, n)
+	start := r.search(Hash(key))
+	for i := 0; i < len(r.hashes) && len(list) < n; i++ {
+		server := r.owners[r.hashes[(start+i)%len(r.hashes)]]
+		if !slices.Contains(list, server) {
+			list = append(list, server)
+		}
+	}
+	return list
+}
+
+// search: 해시 값보다 크거나 같은 첫 가상 노드 위치 (없으면 링을 한 바퀴 돌아 0번)
+func (r *Ring) search(hash uint32) int {
+	idx := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= hash
+	})
+	if idx == len(r.hashes) {
+		idx = 0
+	}
+	return idx
+}
+
+// Clone: 같은 서버 구성의 링 복사본 (복사본을 바꿔도 원래 링은 그대로)
+func (r *Ring) Clone() *Ring {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return &Ring{
+		vnodes:   r.vnodes,
+		hashes:   slices.Clone(r.hashes),
+		owners:   maps.Clone(r.owners),
+		physical: maps.Clone(r.physical),
+	}
+}
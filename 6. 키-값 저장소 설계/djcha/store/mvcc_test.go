@@ -0,0 +1,110 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func data(siblings []Value, ok bool) string {
+	if !ok {
+		return "<none>"
+	}
+	return string(siblings[0].Data)
+}
+
+func TestSnapshotAtSequence(t *testing.T) {
+	s := New()
+	put(s, "a", "a1", 1)
+	put(s, "b", "b1", 1)
+	seq := s.Seq()
+	sn := s.Snapshot()
+	defer sn.Close()
+
+	put(s, "a", "a2", 2)
+	put(s, "a", "a2", 2) // 같은 버전 재전송은 순번을 쓰지 않음
+	cur, _ := s.Get("b")
+	s.Purge("b", cur)
+	if got := s.Seq(); got != seq+2 {
+		t.Fatalf("seq = %d, want %d", got, seq+2)
+	}
+
+	// 지나간 순번도 열린 스냅샷이 잡고 있는 순번 이후면 다시 열 수 있음
+	old, err := s.SnapshotAt(seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	mid, err := s.SnapshotAt(seq + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mid.Close()
+
+	for _, tc := range []struct {
+		sn   *Snapshot
+		a, b string
+	}{{old, "a1", "b1"}, {mid, "a2", "b1"}} {
+		if got := data(tc.sn.Get("a")); got != tc.a {
+			t.Errorf("a at seq %d = %s, want %s", tc.sn.Seq(), got, tc.a)
+		}
+		if got := data(tc.sn.Get("b")); got != tc.b {
+			t.Errorf("b at seq %d = %s, want %s", tc.sn.Seq(), got, tc.b)
+		}
+	}
+	if got := data(s.Get("b")); got != "<none>" {
+		t.Errorf("current b = %s, want <none>", got)
+	}
+}
+
+func TestRetentionAndCompaction(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := NewWithOptions(Options{Retention: 5 * time.Minute, Now: func() time.Time { return now }})
+
+	put(s, "cfg", "v1", 1)
+	now = now.Add(time.Minute)
+	put(s, "cfg", "v2", 2)
+	now = now.Add(time.Minute)
+	put(s, "cfg", "v3", 3)
+
+	// 5분 보존 → 30초 전 상태를 읽을 수 있음
+	if got := data(must(s.GetAsOf("cfg", now.Add(-30*time.Second)))); got != "v2" {
+		t.Fatalf("as of 30s ago = %s, want v2", got)
+	}
+	if got := data(must(s.GetAsOf("cfg", now.Add(-time.Hour)))); got != "<none>" {
+		t.Fatalf("as of an hour ago = %s, want <none> (before the first write)", got)
+	}
+
+	// 보존 기간이 지나면 정리되어 읽을 수 없음. 다만 스냅샷이 잡고 있는 상태는 남음
+	sn, err := s.SnapshotAsOf(now.Add(-30 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	s.Compact()
+	if got := data(sn.Get("cfg")); got != "v2" {
+		t.Fatalf("pinned snapshot = %s, want v2", got)
+	}
+	if _, _, err := s.GetAsOf("cfg", now.Add(-time.Hour-2*time.Minute)); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("read before pinned snapshot: err = %v, want ErrCompacted", err)
+	}
+
+	sn.Close()
+	s.Compact()
+	if n := len(s.history["cfg"]); n != 1 {
+		t.Fatalf("history after compaction has %d versions, want 1", n)
+	}
+	if _, err := s.SnapshotAsOf(now.Add(-time.Hour - time.Second)); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("SnapshotAsOf before retention: err = %v, want ErrCompacted", err)
+	}
+	if got := data(s.Get("cfg")); got != "v3" {
+		t.Fatalf("current = %s, want v3", got)
+	}
+}
+
+func must(siblings []Value, ok bool, err error) ([]Value, bool) {
+	if err != nil {
+		panic(err)
+	}
+	return siblings, ok
+}
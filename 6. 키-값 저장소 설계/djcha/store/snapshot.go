@@ -0,0 +1,209 @@
+package store
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"time"
+)
+
+// Snapshot: 어느 순번 시점의 저장소 내용을 그대로 보여 주는 읽기 전용 뷰
+//
+// 데이터를 복사하지 않고 순번만 기억함. 키마다 그 순번 이하에서 마지막 상태를 읽음
+// 스냅샷이 열려 있는 동안은 그 순번 이후에 덮어써진 상태가 정리되지 않으므로
+// 다 쓰면 반드시 Close 해야 함
+type Snapshot struct {
+	s      *Store
+	seq    uint64
+	closed bool
+}
+
+// Snapshot: 지금 시점(마지막 순번)의 스냅샷을 엶
+func (s *Store) Snapshot() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.open(s.seq)
+}
+
+// SnapshotAt: 순번 seq 시점의 스냅샷을 엶 (이미 정리된 순번이면 ErrCompacted)
+func (s *Store) SnapshotAt(seq uint64) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seq < s.floor || seq > s.seq {
+		return nil, ErrCompacted
+	}
+	return s.open(seq), nil
+}
+
+// SnapshotAsOf: at 시각에 마지막으로 적용된 순번의 스냅샷을 엶 ("5분 전 상태")
+func (s *Store) SnapshotAsOf(at time.Time) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seq := s.seqAt(at.UnixNano())
+	if seq < s.floor {
+		return nil, ErrCompacted
+	}
+	return s.open(seq), nil
+}
+
+func (s *Store) open(seq uint64) *Snapshot {
+	sn := &Snapshot{s: s, seq: seq}
+	s.snapshots[sn] = struct{}{}
+	return sn
+}
+
+// GetAsOf: 스냅샷을 따로 열지 않고 at 시각의 형제 값 목록을 읽음
+func (s *Store) GetAsOf(key string, at time.Time) ([]Value, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seq := s.seqAt(at.UnixNano())
+	if seq < s.floor {
+		return nil, false, ErrCompacted
+	}
+	v := versionAt(s.history[key], seq)
+	return slices.Clone(v.siblings), v.ok, nil
+}
+
+// versionAt: seq 이하 순번 중 마지막 상태 (없으면 그때는 없던 키)
+func versionAt(versions []version, seq uint64) version {
+	i, _ := slices.BinarySearchFunc(versions, seq+1, func(v version, seq uint64) int {
+		return cmp.Compare(v.seq, seq)
+	})
+	if i == 0 {
+		return version{}
+	}
+	return versions[i-1]
+}
+
+// Seq: 스냅샷의 순번
+func (sn *Snapshot) Seq() uint64 {
+	return sn.seq
+}
+
+// Close: 스냅샷을 닫음 (여러 번 불러도 됨)
+// 이 스냅샷 때문에 남겨 둔 상태는 다음 쓰기나 Compact 때 정리됨
+func (sn *Snapshot) Close() {
+	sn.s.mu.Lock()
+	defer sn.s.mu.Unlock()
+
+	delete(sn.s.snapshots, sn)
+	sn.closed = true
+}
+
+// Get: 스냅샷 시점의 형제 값 목록
+func (sn *Snapshot) Get(key string) ([]Value, bool) {
+	sn.s.mu.RLock()
+	defer sn.s.mu.RUnlock()
+
+	v := versionAt(sn.s.history[key], sn.seq)
+	return slices.Clone(v.siblings), v.ok
+}
+
+// Range: 스냅샷 시점에 [start, end) 에 있던 키를 키 순서로 (reverse 면 역순으로) 돌려줌
+// end 가 비어 있으면 끝까지. 스냅샷이 닫히면 멈춤
+//
+// 한 키를 읽을 때만 잠그므로 오래 걸리는 순회도 쓰기를 막지 않음
+// 순회 도중 들어온 쓰기는 스냅샷 순번보다 뒤라서 보이지 않음
+func (sn *Snapshot) Range(start, end string, reverse bool) iter.Seq2[string, []Value] {
+	return func(yield func(string, []Value) bool) {
+		if reverse {
+			sn.backward(start, end, yield)
+		} else {
+			sn.forward(start, end, yield)
+		}
+	}
+}
+
+// Prefix: 스냅샷 시점에 prefix 로 시작하던 키
+func (sn *Snapshot) Prefix(prefix string, reverse bool) iter.Seq2[string, []Value] {
+	return sn.Range(prefix, PrefixEnd(prefix), reverse)
+}
+
+func (sn *Snapshot) forward(from, end string, yield func(string, []Value) bool) {
+	for {
+		key, siblings, ok, more := sn.next(from, end)
+		if !more {
+			return
+		}
+		if ok && !yield(key, siblings) {
+			return
+		}
+		from = key + "\x00" // key 바로 다음 문자열
+	}
+}
+
+func (sn *Snapshot) backward(start, before string, yield func(string, []Value) bool) {
+	for {
+		key, siblings, ok, more := sn.prev(start, before)
+		if !more {
+			return
+		}
+		if ok && !yield(key, siblings) {
+			return
+		}
+		before = key
+	}
+}
+
+// next: from 이상 end 미만인 가장 작은 키
+// more 가 false 면 더 없음, ok 가 false 면 스냅샷 시점에는 없던 키 (건너뜀)
+func (sn *Snapshot) next(from, end string) (key string, siblings []Value, ok, more bool) {
+	sn.s.mu.RLock()
+	defer sn.s.mu.RUnlock()
+
+	keys := sn.s.keys
+	i, _ := slices.BinarySearch(keys, from)
+	if sn.closed || i == len(keys) || (end != "" && keys[i] >= end) {
+		return "", nil, false, false
+	}
+	v := versionAt(sn.s.history[keys[i]], sn.seq)
+	return keys[i], slices.Clone(v.siblings), v.ok, true
+}
+
+// prev: start 이상 before 미만인 가장 큰 키 (before 가 비어 있으면 상한 없음)
+func (sn *Snapshot) prev(start, before string) (key string, siblings []Value, ok, more bool) {
+	sn.s.mu.RLock()
+	defer sn.s.mu.RUnlock()
+
+	keys := sn.s.keys
+	i := len(keys)
+	if before != "" {
+		i, _ = slices.BinarySearch(keys, before)
+	}
+	if sn.closed || i == 0 || keys[i-1] < start {
+		return "", nil, false, false
+	}
+	v := versionAt(sn.s.history[keys[i-1]], sn.seq)
+	return keys[i-1], slices.Clone(v.siblings), v.ok, true
+}
+
+// Range: 지금 시점의 스냅샷으로 [start, end) 를 순회 (순회가 끝나면 스냅샷을 닫음)
+func (s *Store) Range(start, end string, reverse bool) iter.Seq2[string, []Value] {
+	return func(yield func(string, []Value) bool) {
+		sn := s.Snapshot()
+		defer sn.Close()
+		sn.Range(start, end, reverse)(yield)
+	}
+}
+
+// Prefix: 지금 시점의 스냅샷으로 prefix 로 시작하는 키를 순회
+func (s *Store) Prefix(prefix string, reverse bool) iter.Seq2[string, []Value] {
+	return s.Range(prefix, PrefixEnd(prefix), reverse)
+}
+
+// PrefixEnd: prefix 로 시작하는 모든 키보다 큰 가장 작은 문자열 (범위의 끝으로 사용)
+// prefix 가 비었거나 0xff 로만 되어 있으면 상한이 없으므로 빈 문자열
+func PrefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
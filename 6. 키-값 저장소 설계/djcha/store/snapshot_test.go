@@ -0,0 +1,79 @@
+package store
+
+import (
+	"slices"
+	"testing"
+
+	"kv-store/vclock"
+)
+
+func put(s *Store, key, data string, counter uint64) {
+	s.Put(key, Value{Data: []byte(data), Clock: vclock.Clock{"n1": {Counter: counter}}})
+}
+
+func collect(seq func(func(string, []Value) bool)) []string {
+	var out []string
+	for key, siblings := range seq {
+		out = append(out, key+"="+string(siblings[0].Data))
+	}
+	return out
+}
+
+func TestRangeOrderAndBounds(t *testing.T) {
+	s := New()
+	for _, key := range []string{"user:2", "order:1", "user:1", "user:10", "users", "a"} {
+		put(s, key, "v", 1)
+	}
+
+	if got, want := collect(s.Range("order:", "user:2", false)), []string{"order:1=v", "user:1=v", "user:10=v"}; !slices.Equal(got, want) {
+		t.Errorf("Range = %v, want %v", got, want)
+	}
+	if got, want := collect(s.Prefix("user:", true)), []string{"user:2=v", "user:10=v", "user:1=v"}; !slices.Equal(got, want) {
+		t.Errorf("reverse Prefix = %v, want %v", got, want)
+	}
+	if got, want := collect(s.Range("", "", true)), []string{"users=v", "user:2=v", "user:10=v", "user:1=v", "order:1=v", "a=v"}; !slices.Equal(got, want) {
+		t.Errorf("reverse full Range = %v, want %v", got, want)
+	}
+}
+
+func TestSnapshotHidesLaterWrites(t *testing.T) {
+	s := New()
+	put(s, "k1", "old", 1)
+	put(s, "k3", "old", 1)
+	put(s, "k5", "old", 1)
+
+	sn := s.Snapshot()
+	defer sn.Close()
+
+	var got []string
+	for key, siblings := range sn.Range("", "", false) {
+		got = append(got, key+"="+string(siblings[0].Data))
+		if key == "k1" {
+			// 순회 도중 쓰기: 새 키 추가, 뒤쪽 키 변경, 키 정리
+			put(s, "k2", "new", 1)
+			put(s, "k4", "new", 1)
+			put(s, "k5", "new", 2)
+			cur, _ := s.Get("k3")
+			s.Purge("k3", cur)
+		}
+	}
+	if want := []string{"k1=old", "k3=old", "k5=old"}; !slices.Equal(got, want) {
+		t.Fatalf("snapshot range = %v, want %v", got, want)
+	}
+	if got, want := collect(s.Range("", "", false)), []string{"k1=old", "k2=new", "k4=new", "k5=new"}; !slices.Equal(got, want) {
+		t.Fatalf("current range = %v, want %v", got, want)
+	}
+
+	sn.Close()
+	if len(s.snapshots) != 0 {
+		t.Fatal("closed snapshot still registered")
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	for prefix, want := range map[string]string{"": "", "a": "b", "user:": "user;", "a\xff": "b", "\xff\xff": ""} {
+		if got := PrefixEnd(prefix); got != want {
+			t.Errorf("PrefixEnd(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
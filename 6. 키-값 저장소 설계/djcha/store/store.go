@@ -0,0 +1,352 @@
+package store
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"kv-store/crdt"
+	"kv-store/vclock"
+)
+
+// Value: 저장되는 값과 그 버전
+//   - Clock: 벡터 시계. 두 버전의 선후 관계(이전/이후/동시)를 판단
+//   - Timestamp: 쓰기 시각. 선후 판단에는 쓰지 않고, 마지막 쓰기 우선(LWW) 충돌 해결에만 사용
+//   - Deleted: 삭제 표시(tombstone). 값은 없지만 버전으로 남아 이전 값들을 덮어씀
+//   - Expires: 만료 시각. 지나면 읽을 때 없는 키로 취급
+//   - Type: CRDT 자료형 (비어 있으면 일반 값). Data 에 crdt.Encode 한 상태를 담음
+type Value struct {
+	Data      []byte       `json:"data"`
+	Timestamp int64        `json:"timestamp"` // UnixNano
+	Clock     vclock.Clock `json:"clock"`
+	Deleted   bool         `json:"deleted,omitempty"`
+	Expires   int64        `json:"expires,omitempty"` // UnixNano, 0 이면 만료 없음
+	Type      crdt.Type    `json:"type,omitempty"`
+}
+
+// Live: now 시점에 읽을 수 있는 값인지 (삭제되지 않았고 만료되지 않음)
+func (v Value) Live(now int64) bool {
+	return !v.Deleted && (v.Expires == 0 || now < v.Expires)
+}
+
+// sameVersion: 같은 쓰기가 다시 도착한 것인지 (재전송, 복구 등)
+func (v Value) sameVersion(other Value) bool {
+	return vclock.Compare(v.Clock, other.Clock) == vclock.Equal &&
+		v.Timestamp == other.Timestamp && v.Deleted == other.Deleted && bytes.Equal(v.Data, other.Data)
+}
+
+// Reconcile: 형제 값 목록(siblings)에 새 버전 v 를 합친 결과
+//   - 기존 값 중 하나라도 v 이후 버전이면 v 는 이미 덮어써진 옛날 값 → 무시
+//   - v 가 포함하는(이전인) 기존 값은 제거
+//   - v 와 동시에 쓰인 기존 값은 형제로 함께 보관
+//     단, 둘 다 같은 자료형의 CRDT 값이면 형제로 두지 않고 상태를 합친 값 하나로 대체
+//
+// 목록이 바뀌었으면 true
+func Reconcile(siblings []Value, v Value) ([]Value, bool) {
+	for _, s := range siblings {
+		if s.sameVersion(v) || vclock.Compare(v.Clock, s.Clock) == vclock.Before {
+			return siblings, false
+		}
+	}
+
+	merged := make([]Value, 0, len(siblings)+1)
+	for _, s := range siblings {
+		if m, ok := mergeCRDT(s, v); ok {
+			v = m
+			continue
+		}
+		// 시계가 완전히 같은데 내용이 다르면 (같은 문맥으로 동시에 쓴 경우) 둘 다 보관
+		if vclock.Compare(v.Clock, s.Clock) != vclock.After {
+			merged = append(merged, s)
+		}
+	}
+	// 합친 값이 남은 형제 값까지 포함하게 됐을 수 있으므로 한 번 더 거름
+	merged = slices.DeleteFunc(merged, func(s Value) bool {
+		return vclock.Compare(v.Clock, s.Clock) == vclock.After
+	})
+	return append(merged, v), true
+}
+
+// mergeCRDT: 동시에 쓰인 같은 자료형의 CRDT 값 두 개를 합친 값
+// 시계는 둘을 합친 것(두 버전을 모두 본 버전)이고, 시각/만료/상태는 어느 노드에서 합쳐도 같게 정함
+// 삭제 표시는 상태가 없으므로 합치지 않고 형제로 둠 (읽을 때 걸러짐)
+func mergeCRDT(a, b Value) (Value, bool) {
+	if a.Type == "" || a.Type != b.Type || a.Deleted || b.Deleted ||
+		vclock.Compare(a.Clock, b.Clock) == vclock.Before || vclock.Compare(a.Clock, b.Clock) == vclock.After {
+		return Value{}, false
+	}
+	data, err := crdt.MergeEncoded(a.Type, a.Data, b.Data)
+	if err != nil {
+		return Value{}, false
+	}
+	expires := max(a.Expires, b.Expires)
+	if a.Expires == 0 || b.Expires == 0 {
+		expires = 0
+	}
+	return Value{
+		Data:      data,
+		Timestamp: max(a.Timestamp, b.Timestamp),
+		Clock:     a.Clock.Merge(b.Clock),
+		Expires:   expires,
+		Type:      a.Type,
+	}, true
+}
+
+// Merge: 여러 복제본이 돌려준 형제 값 목록을 하나로 합침
+func Merge(lists ...[]Value) []Value {
+	var merged []Value
+	for _, list := range lists {
+		for _, v := range list {
+			merged, _ = Reconcile(merged, v)
+		}
+	}
+	return merged
+}
+
+// Context: 형제 값들의 시계를 모두 합친 시계
+// 클라이언트가 이 시계를 들고 쓰면 새 값이 모든 형제 값 이후 버전이 되어 충돌이 정리됨
+func Context(siblings []Value) vclock.Clock {
+	c := vclock.Clock{}
+	for _, v := range siblings {
+		c = c.Merge(v.Clock)
+	}
+	return c
+}
+
+// Store: 노드 한 대가 로컬에 들고 있는 메모리 키-값 저장소
+// 키마다 동시에 쓰인 버전(형제 값)을 모두 보관
+//
+// 저장 내용이 바뀔 때마다(쓰기, 정리) 단조 증가하는 순번(seq)을 붙이고 키마다 이전 상태를 남겨 둠 (MVCC)
+// 그래서 어느 순번/시각의 스냅샷을 열어도 그 시점 내용을 그대로 읽을 수 있음
+// 열린 스냅샷 중 가장 오래된 것과 보존 기간(Retention)보다 이전에 덮어써진 상태는 Compact 로 지움
+type Store struct {
+	mu        sync.RWMutex
+	history   map[string][]version   // 키 → 상태 목록 (오래된 순, 마지막이 현재 상태)
+	keys      []string               // history 에 있는 키 (정렬, 범위 조회용)
+	present   int                    // 지금 있는 키 수
+	seq       uint64                 // 마지막으로 붙인 순번
+	floor     uint64                 // 이 순번 이전 상태는 지워졌을 수 있음 (스냅샷을 열 수 있는 가장 오래된 순번)
+	marks     []seqMark              // 순번 → 시각, 키 (시각으로 스냅샷을 열 때, 바뀐 키를 순서대로 볼 때 사용)
+	snapshots map[*Snapshot]struct{} // 열려 있는 스냅샷
+	retention time.Duration
+	now       func() time.Time
+	changed   chan struct{} // 다음 순번이 붙을 때 닫힘 (기다리는 Watcher 가 있을 때만 만듦)
+}
+
+// version: 키 하나의 어느 순번 이후 상태
+type version struct {
+	seq      uint64
+	siblings []Value // ok 가 false 면 nil
+	ok       bool    // false 면 이 순번에 정리되어 키가 없어짐
+}
+
+type seqMark struct {
+	seq uint64
+	at  int64  // UnixNano
+	key string // 이 순번에 바뀐 키
+}
+
+// Options: 저장소 설정
+type Options struct {
+	// Retention: 덮어써진 상태를 최소한 이 시간 동안 남겨 둠 (0 이면 열린 스냅샷이 필요로 하는 것만)
+	Retention time.Duration
+	// Now: 순번에 붙일 시각 (nil 이면 time.Now)
+	Now func() time.Time
+}
+
+// ErrCompacted: 요청한 순번/시각의 상태가 이미 정리되어 읽을 수 없음
+var ErrCompacted = errors.New("store: requested version has been compacted")
+
+// New: 빈 저장소 생성 (이전 상태는 열린 스냅샷이 있을 때만 남김)
+func New() *Store {
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions: 설정을 지정해서 빈 저장소 생성
+func NewWithOptions(opts Options) *Store {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Store{
+		history:   make(map[string][]version),
+		snapshots: make(map[*Snapshot]struct{}),
+		retention: opts.Retention,
+		now:       opts.Now,
+	}
+}
+
+// Get: 키의 형제 값 목록 조회
+func (s *Store) Get(key string) ([]Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v := s.latest(key)
+	return slices.Clone(v.siblings), v.ok
+}
+
+func (s *Store) latest(key string) version {
+	versions := s.history[key]
+	if len(versions) == 0 {
+		return version{}
+	}
+	return versions[len(versions)-1]
+}
+
+// Put: 새 버전을 기존 형제 값들과 합쳐 저장 (옛날 버전이 늦게 도착해도 최신 값을 덮어쓰지 않음)
+// 저장된 내용이 바뀌었으면 true
+func (s *Store) Put(key string, v Value) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur := s.latest(key)
+	siblings, changed := Reconcile(cur.siblings, v)
+	if !changed {
+		return false
+	}
+	s.record(key, siblings, true)
+	return true
+}
+
+// Purge: 키의 형제 값이 expected 와 그대로 같을 때만 키를 완전히 지움 (삭제 표시 정리용)
+// 확인하는 사이에 새 버전이 들어왔으면 지우지 않고 false
+func (s *Store) Purge(key string, expected []Value) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur := s.latest(key)
+	if !cur.ok || len(cur.siblings) != len(expected) {
+		return false
+	}
+	for i := range cur.siblings {
+		if !cur.siblings[i].sameVersion(expected[i]) {
+			return false
+		}
+	}
+	s.record(key, nil, false)
+	return true
+}
+
+// record: 새 순번으로 키의 상태를 남기고, 더 이상 아무도 볼 수 없는 이전 상태를 지움
+// s.mu 를 쓰기 잠금한 상태에서 불러야 함
+func (s *Store) record(key string, siblings []Value, ok bool) {
+	s.seq++
+	s.marks = append(s.marks, seqMark{seq: s.seq, at: s.now().UnixNano(), key: key})
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+
+	versions, known := s.history[key]
+	if !known {
+		i, _ := slices.BinarySearch(s.keys, key)
+		s.keys = slices.Insert(s.keys, i, key)
+	}
+	switch {
+	case ok && !s.latest(key).ok:
+		s.present++
+	case !ok:
+		s.present--
+	}
+	s.history[key] = append(versions, version{seq: s.seq, siblings: siblings, ok: ok})
+
+	s.raiseFloor()
+	s.prune(key)
+	for len(s.marks) > 1 && s.marks[1].seq <= s.floor {
+		s.marks = s.marks[1:]
+	}
+}
+
+// raiseFloor: 열린 스냅샷과 보존 기간을 보고 지워도 되는 순번의 경계를 올림 (경계는 내려가지 않음)
+func (s *Store) raiseFloor() {
+	floor := s.seq
+	for sn := range s.snapshots {
+		floor = min(floor, sn.seq)
+	}
+	if s.retention > 0 {
+		floor = min(floor, s.seqAt(s.now().Add(-s.retention).UnixNano()))
+	}
+	s.floor = max(s.floor, floor)
+}
+
+// prune: floor 시점에 이미 덮어써진 상태를 지움
+// 남은 상태가 floor 이전에 정리된 것 하나뿐이면 키 자체를 지움
+func (s *Store) prune(key string) {
+	versions := s.history[key]
+	drop := 0
+	for drop+1 < len(versions) && versions[drop+1].seq <= s.floor {
+		drop++
+	}
+	versions = versions[drop:]
+	if len(versions) == 1 && !versions[0].ok && versions[0].seq <= s.floor {
+		delete(s.history, key)
+		if i, found := slices.BinarySearch(s.keys, key); found {
+			s.keys = slices.Delete(s.keys, i, i+1)
+		}
+		return
+	}
+	s.history[key] = slices.Clip(versions)
+}
+
+// seqAt: at 시각까지 붙인 마지막 순번
+// 남아 있는 기록보다 이전 시각이면 가장 오래된 기록 바로 앞 순번 (floor 보다 작으면 이미 정리된 시점)
+func (s *Store) seqAt(at int64) uint64 {
+	// at 이하인 마지막 위치를 찾기 위해 at 초과인 첫 위치를 찾음
+	i, _ := slices.BinarySearchFunc(s.marks, at, func(m seqMark, at int64) int {
+		return cmp.Compare(m.at, at+1)
+	})
+	switch {
+	case i > 0:
+		return s.marks[i-1].seq
+	case len(s.marks) > 0:
+		return s.marks[0].seq - 1
+	}
+	return s.seq
+}
+
+// Compact: 열린 스냅샷과 보존 기간이 더 이상 필요로 하지 않는 이전 상태를 모두 지움
+// 쓰기 때마다 그 키는 정리되므로, 스냅샷이 닫힌 뒤 다시 쓰이지 않은 키를 위해 주기적으로 부름
+func (s *Store) Compact() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.raiseFloor()
+	for _, key := range slices.Clone(s.keys) {
+		s.prune(key)
+	}
+	for len(s.marks) > 1 && s.marks[1].seq <= s.floor {
+		s.marks = s.marks[1:]
+	}
+}
+
+// Seq: 마지막으로 붙인 순번
+func (s *Store) Seq() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.seq
+}
+
+// Keys: 지금 있는 모든 키 (키 순서)
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, s.present)
+	for _, key := range s.keys {
+		if s.latest(key).ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Len: 저장된 키 개수
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.present
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// EventType: 바뀐 키의 종류
+type EventType string
+
+const (
+	EventPut    EventType = "put"    // 값이 생기거나 바뀜 (형제 값이 늘어난 경우 포함)
+	EventDelete EventType = "delete" // 삭제 표시로 덮어써짐
+)
+
+// Event: 순번 Seq 에 키가 바뀐 내용
+// 클라이언트 쓰기, 다른 복제본/힌트에서 받은 쓰기, 읽기 복구, 안티 엔트로피가 모두 같은 Put 을 거치므로 모두 이벤트가 됨
+// 삭제 표시를 지우는 정리(Purge)와 TTL 만료는 읽는 쪽에서 보이는 값이 바뀌지 않으므로 이벤트가 아님
+type Event struct {
+	Seq      uint64    `json:"seq"`
+	Key      string    `json:"key"`
+	Type     EventType `json:"type"`
+	Siblings []Value   `json:"siblings"` // 바뀐 뒤의 형제 값 전체 (삭제 표시 포함)
+}
+
+// Watcher: 어느 순번 다음부터 바뀐 키를 순서대로 돌려줌
+// 아직 돌려주지 않은 순번의 상태가 정리되지 않도록 스냅샷처럼 자기 위치를 붙잡고 있으므로 다 쓰면 Close 해야 함
+type Watcher struct {
+	s     *Store
+	match func(key string) bool
+	pin   *Snapshot
+}
+
+// Watch: 순번 from 다음부터 match 가 true 인 키의 변경을 보는 Watcher 를 엶
+// from 이 이미 정리된 순번이면 ErrCompacted (보존 기간 Retention 안의 순번만 이어 볼 수 있음)
+func (s *Store) Watch(from uint64, match func(key string) bool) (*Watcher, error) {
+	pin, err := s.SnapshotAt(from)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{s: s, match: match, pin: pin}, nil
+}
+
+// Rev: 지금까지 돌려준 마지막 순번 (이어서 볼 때 Watch 의 from)
+func (w *Watcher) Rev() uint64 {
+	return w.pin.seq
+}
+
+// Next: 아직 돌려주지 않은 변경을 최대 limit 개 돌려줌
+// 없으면 새 변경이 생기거나 ctx 가 끝날 때까지 기다림 (ctx 가 끝나면 빈 목록과 ctx 에러)
+func (w *Watcher) Next(ctx context.Context, limit int) ([]Event, error) {
+	for {
+		events, changed := w.poll(limit)
+		if len(events) > 0 {
+			return events, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// poll: 지금 있는 변경을 읽고 위치를 옮김
+// 변경이 없으면 다음 순번이 붙을 때 닫히는 채널을 같이 돌려줌 (읽은 뒤 기다리기 전에 들어온 쓰기를 놓치지 않도록 같은 잠금 안에서 만듦)
+func (w *Watcher) poll(limit int) ([]Event, <-chan struct{}) {
+	s := w.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// marks 는 floor 이후 순번을 빠짐없이 순서대로 갖고 있고, Watcher 가 붙잡은 순번은 floor 이상
+	i, _ := slices.BinarySearchFunc(s.marks, w.pin.seq+1, func(m seqMark, seq uint64) int {
+		return cmp.Compare(m.seq, seq)
+	})
+	var events []Event
+	last := w.pin.seq
+	for ; i < len(s.marks) && len(events) < limit; i++ {
+		m := s.marks[i]
+		last = m.seq
+		if !w.match(m.key) {
+			continue
+		}
+		v := versionAt(s.history[m.key], m.seq)
+		if v.seq != m.seq || !v.ok {
+			continue // 정리(Purge)된 순번
+		}
+		typ := EventDelete
+		if slices.ContainsFunc(v.siblings, func(v Value) bool { return !v.Deleted }) {
+			typ = EventPut
+		}
+		events = append(events, Event{Seq: m.seq, Key: m.key, Type: typ, Siblings: slices.Clone(v.siblings)})
+	}
+
+	if last != w.pin.seq {
+		delete(s.snapshots, w.pin)
+		w.pin.closed = true
+		w.pin = s.open(last)
+	}
+	if len(events) > 0 || i < len(s.marks) {
+		return events, closedChan
+	}
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return nil, s.changed
+}
+
+// Close: Watcher 를 닫음 (붙잡고 있던 상태는 다음 쓰기나 Compact 때 정리됨)
+func (w *Watcher) Close() {
+	w.pin.Close()
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"kv-store/vclock"
+)
+
+func TestWatchResumesAndReportsCompaction(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := NewWithOptions(Options{Retention: 5 * time.Minute, Now: func() time.Time { return now }})
+	config := func(key string) bool { return strings.HasPrefix(key, "cfg/") }
+
+	put(s, "cfg/a", "v1", 1)
+	start := s.Seq()
+	put(s, "other", "x", 1)
+	put(s, "cfg/a", "v2", 2)
+	s.Put("cfg/b", Value{Deleted: true, Clock: vclock.Clock{"n1": {Counter: 1}}})
+
+	w, err := s.Watch(start, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	events, err := w.Next(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Key != "cfg/a" || events[0].Type != EventPut || string(events[0].Siblings[0].Data) != "v2" ||
+		events[1].Key != "cfg/b" || events[1].Type != EventDelete {
+		t.Fatalf("events = %+v, want put cfg/a=v2 then delete cfg/b", events)
+	}
+	if w.Rev() != s.Seq() {
+		t.Fatalf("Rev = %d, want %d", w.Rev(), s.Seq())
+	}
+
+	// 변경이 없으면 다음 쓰기까지 기다림 (관련 없는 키는 건너뜀)
+	done := make(chan []Event)
+	go func() {
+		events, _ := w.Next(context.Background(), 10)
+		done <- events
+	}()
+	put(s, "other", "y", 2)
+	put(s, "cfg/c", "v1", 1)
+	select {
+	case events := <-done:
+		if len(events) != 1 || events[0].Key != "cfg/c" {
+			t.Fatalf("events after wait = %+v, want cfg/c", events)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Next did not wake up after a write")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := w.Next(ctx, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("idle Next: err = %v, want deadline exceeded", err)
+	}
+
+	// 열려 있는 Watcher 가 있으면 보존 기간이 지나도 그 위치 이후는 남음
+	old := w.Rev()
+	now = now.Add(time.Hour)
+	put(s, "cfg/a", "v3", 3)
+	if again, err := s.Watch(old, config); err != nil {
+		t.Fatalf("Watch from pinned rev: %v", err)
+	} else {
+		again.Close()
+	}
+
+	// 닫힌 뒤에는 정리되어 처음 위치에서 이어 볼 수 없음
+	w.Close()
+	s.Compact()
+	if _, err := s.Watch(start, config); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("Watch from compacted rev: err = %v, want ErrCompacted", err)
+	}
+}
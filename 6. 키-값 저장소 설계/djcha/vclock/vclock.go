@@ -0,0 +1,157 @@
+package vclock
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Entry: 노드 하나의 카운터와 그 카운터를 마지막으로 올린 시각
+// 시각은 순서 판단에는 쓰지 않고, 오래된 항목을 잘라낼(Prune) 때만 사용
+type Entry struct {
+	Counter uint64 `json:"n"`
+	Updated int64  `json:"t"` // UnixNano
+}
+
+// Clock: 벡터 시계 (노드 ID → 항목)
+// 어떤 노드를 거쳐 몇 번 수정된 값인지 기록해서, 두 버전 중 어느 쪽이 최신인지 또는 동시에 쓰였는지 판단
+type Clock map[string]Entry
+
+// Order: 두 벡터 시계의 관계
+type Order int
+
+const (
+	Equal      Order = iota // 완전히 같음
+	Before                  // a 가 b 보다 이전 (b 가 a 를 포함)
+	After                   // a 가 b 보다 이후 (a 가 b 를 포함)
+	Concurrent              // 서로 모르는 사이에 따로 쓰임 → 충돌 (형제 값으로 보관)
+)
+
+func (o Order) String() string {
+	switch o {
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	case Concurrent:
+		return "concurrent"
+	}
+	return "equal"
+}
+
+// Compare: a 와 b 의 선후 관계
+// 모든 노드 카운터가 a <= b 이면 Before, a >= b 이면 After, 섞여 있으면 Concurrent
+func Compare(a, b Clock) Order {
+	aBigger, bBigger := false, false
+	for node, ea := range a {
+		switch eb := b[node]; {
+		case ea.Counter > eb.Counter:
+			aBigger = true
+		case ea.Counter < eb.Counter:
+			bBigger = true
+		}
+	}
+	for node, eb := range b {
+		if _, ok := a[node]; !ok && eb.Counter > 0 {
+			bBigger = true
+		}
+	}
+
+	switch {
+	case aBigger && bBigger:
+		return Concurrent
+	case aBigger:
+		return After
+	case bBigger:
+		return Before
+	}
+	return Equal
+}
+
+// Descends: a 가 b 와 같거나 b 이후 버전인지 (a 가 b 를 덮어써도 되는지)
+func (a Clock) Descends(b Clock) bool {
+	o := Compare(a, b)
+	return o == Equal || o == After
+}
+
+// Copy: 깊은 복사 (Clock 은 map 이므로 공유하면 한쪽 수정이 다른 쪽에 보임)
+func (a Clock) Copy() Clock {
+	c := make(Clock, len(a))
+	for node, e := range a {
+		c[node] = e
+	}
+	return c
+}
+
+// Merge: 노드별로 큰 카운터를 골라 합친 시계 (두 버전을 모두 본 상태)
+func (a Clock) Merge(b Clock) Clock {
+	c := a.Copy()
+	for node, eb := range b {
+		if ea, ok := c[node]; !ok || eb.Counter > ea.Counter ||
+			(eb.Counter == ea.Counter && eb.Updated > ea.Updated) {
+			c[node] = eb
+		}
+	}
+	return c
+}
+
+// Increment: node 의 카운터를 1 올린 새 시계 (쓰기를 처리한 코디네이터가 호출)
+func (a Clock) Increment(node string, now int64) Clock {
+	c := a.Copy()
+	e := c[node]
+	c[node] = Entry{Counter: e.Counter + 1, Updated: now}
+	return c
+}
+
+// Prune: 항목이 maxEntries 개를 넘으면 가장 오래전에 갱신된 항목부터 잘라냄 (다이나모 방식)
+// 잘라낸 뒤에는 실제로는 이전 버전인 값이 동시 버전으로 보일 수 있지만 (불필요한 형제 값),
+// 최신 값을 잃어버리지는 않음
+func (a Clock) Prune(maxEntries int) Clock {
+	if maxEntries <= 0 || len(a) <= maxEntries {
+		return a
+	}
+
+	nodes := make([]string, 0, len(a))
+	for node := range a {
+		nodes = append(nodes, node)
+	}
+	slices.SortFunc(nodes, func(x, y string) int {
+		if c := cmp.Compare(a[y].Updated, a[x].Updated); c != 0 {
+			return c // 최근 갱신 순
+		}
+		return strings.Compare(x, y)
+	})
+
+	c := make(Clock, maxEntries)
+	for _, node := range nodes[:maxEntries] {
+		c[node] = a[node]
+	}
+	return c
+}
+
+// Encode: HTTP 헤더 등에 실어 보낼 수 있는 문자열 (base64url(JSON))
+func (a Clock) Encode() string {
+	if len(a) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(a)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode: Encode 로 만든 문자열을 시계로 복원 (빈 문자열은 빈 시계)
+func Decode(s string) (Clock, error) {
+	if s == "" {
+		return Clock{}, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c Clock
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
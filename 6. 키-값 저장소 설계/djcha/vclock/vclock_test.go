@@ -0,0 +1,55 @@
+package vclock
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	a := Clock{}.Increment("n1", 1)    // {n1:1}
+	b := a.Increment("n1", 2)          // {n1:2}
+	c := a.Increment("n2", 3)          // {n1:1, n2:1}
+	d := b.Merge(c).Increment("n3", 4) // {n1:2, n2:1, n3:1}
+
+	tests := []struct {
+		x, y Clock
+		want Order
+	}{
+		{a, a.Copy(), Equal},
+		{a, b, Before},
+		{b, a, After},
+		{b, c, Concurrent},
+		{c, d, Before},
+		{Clock{}, a, Before},
+		{Clock{"n1": {Counter: 0}}, Clock{}, Equal},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.x, tt.y); got != tt.want {
+			t.Errorf("Compare(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestPruneKeepsMostRecentEntries(t *testing.T) {
+	c := Clock{}
+	for i, node := range []string{"a", "b", "c", "d", "e"} {
+		c = c.Increment(node, int64(i))
+	}
+	pruned := c.Prune(3)
+	if len(pruned) != 3 {
+		t.Fatalf("len = %d, want 3", len(pruned))
+	}
+	for _, node := range []string{"c", "d", "e"} {
+		if _, ok := pruned[node]; !ok {
+			t.Fatalf("pruned clock %v lost recent entry %s", pruned, node)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	c := Clock{}.Increment("127.0.0.1:7001", 42)
+	got, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Compare(c, got) != Equal || got["127.0.0.1:7001"].Updated != 42 {
+		t.Fatalf("round trip = %v, want %v", got, c)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -9,144 +8,230 @@ import (
 )
 
 // ==========================================
-// 1. Snowflake 설정 상수 (총 64비트 설계)
+// 1. 기준 시간과 비트 배치 (Layout)
 // ==========================================
-const (
-	// Epoch: 기준 시간 (사용자 정의 시작일)
-	// 이 값이 너무 옛날이면 타임스탬프 공간(41비트) 낭비가 심하므로,
-	// 보통 서비스 오픈 시점이나 현재 시점(예: 2024-01-01)을 기준으로 잡습니다.
-	epoch = int64(1704067200000)
-
-	// 각 구역이 차지할 비트 수 (총합 1 + 41 + 10 + 12 = 64비트)
-	// - Sign Bit(1): 양수 표현을 위해 0으로 고정 (코드엔 상수 선언 불필요)
-	// - Timestamp(41): 밀리초 단위 시간 기록
-	nodeBits = uint(10) // Node ID: 서버/인스턴스 식별 (2^10 = 1024개 노드 가능)
-	stepBits = uint(12) // Sequence: 같은 밀리초 내 순서 (2^12 = 4096개 ID/ms 가능)
-
-	// 각 구역의 최대값 (비트 마스킹용)
-	// 예: nodeMax는 10비트가 모두 1인 값(1023)이 됨.
-	nodeMax = int64(-1 ^ (-1 << nodeBits))
-	stepMax = int64(-1 ^ (-1 << stepBits))
-
-	// 비트 이동(Shift) 량 계산
-	// ID 구조: [Sign:1][Timestamp:41][Node:10][Sequence:12]
-	// 따라서 Timestamp는 왼쪽으로 (10+12) = 22칸 이동해야 함.
-	timeShift = nodeBits + stepBits // 22
-	// Node ID는 왼쪽으로 12칸 이동해야 함.
-	nodeShift = stepBits // 12
-)
+
+// Layout, 시계 역전 정책(ClockPolicy), Decode 는 layout.go 에 있음 (swma 와 같은 파일)
+// 실행: go run main.go layout.go / 테스트: go test main.go layout.go main_test.go
+
+// Epoch: 기준 시간 (사용자 정의 시작일)
+// 이 값이 너무 옛날이면 타임스탬프 공간(41비트) 낭비가 심하므로,
+// 보통 서비스 오픈 시점이나 현재 시점(예: 2024-01-01)을 기준으로 잡습니다.
+const epoch = int64(1704067200000)
+
+// DefaultLayout: 이 프로그램의 기본 배치 (총합 1 + 41 + 10 + 12 = 64비트, 2024-01-01 기준, 1ms)
+//   - Sign Bit(1): 양수 표현을 위해 0으로 고정
+//   - Timestamp(41): 밀리초 단위 시간 기록
+//   - Node ID(10): 서버/인스턴스 식별 (2^10 = 1024개 노드 가능, 데이터센터 구역은 없음)
+//   - Sequence(12): 같은 밀리초 내 순서 (2^12 = 4096개 ID/ms 가능)
+var DefaultLayout = Layout{
+	TimestampBits: 41,
+	WorkerBits:    10,
+	SequenceBits:  12,
+	TimeUnit:      time.Millisecond,
+	Epoch:         time.UnixMilli(epoch),
+}
 
 // ==========================================
-// 2. Node 구조체 (ID 생성기)
+// 2. 생성기 설정
+// ==========================================
+
+// Options: Node 설정
+type Options struct {
+	Layout  Layout        // 비트 배치 (비워 두면 DefaultLayout)
+	Policy  ClockPolicy   // 시계 역전 정책 (기본값 ClockError)
+	MaxWait time.Duration // ClockWait 에서 기다릴 최대 시간
+	Clock   Clock         // nil 이면 시스템 시계
+}
+
+// ==========================================
+// 3. Node 구조체 (ID 생성기)
 // ==========================================
 type Node struct {
-	mu        sync.Mutex // 동시성 관리를 위한 락 (여러 고루틴 접근 방어)
-	timestamp int64      // 마지막으로 ID를 생성한 시간(밀리초)
-	nodeID    int64      // 이 서버의 고유 번호 (0 ~ 1023)
-	step      int64      // 같은 밀리초 내에서의 순번 (0 ~ 4095)
+	mu           sync.Mutex // 동시성 관리를 위한 락 (여러 고루틴 접근 방어)
+	timestamp    int64      // 마지막으로 ID를 생성한 시간(Epoch 부터 TimeUnit 단위, 논리 시계면 실제 시각보다 클 수 있음)
+	datacenterID int64      // 데이터센터 번호 (배치에 데이터센터 구역이 없으면 0)
+	nodeID       int64      // 이 서버의 고유 번호 (기본 배치에서 0 ~ 1023)
+	step         int64      // 같은 시간 단위 내에서의 순번 (기본 배치에서 0 ~ 4095)
+	opts         Options
 }
 
-// NewNode: 생성기 초기화 함수
+// NewNode: 기본 배치로 생성기 초기화 (시계가 뒤로 가면 에러를 돌려줌)
 func NewNode(nodeID int64) (*Node, error) {
-	// 노드 ID가 허용 범위를 넘는지 체크 (0 ~ 1023)
-	if nodeID < 0 || nodeID > nodeMax {
-		return nil, fmt.Errorf("node ID must be between 0 and %d", nodeMax)
+	return NewNodeWithOptions(0, nodeID, Options{})
+}
+
+// NewNodeWithOptions: 배치, 시계 역전 정책, 시계를 정해서 생성기 초기화
+// 배치가 틀렸거나 데이터센터/서버 번호가 배치에 들어가지 않으면 에러 (잘라서 쓰면 다른 서버와 번호가 겹침)
+func NewNodeWithOptions(datacenterID, nodeID int64, opts Options) (*Node, error) {
+	if opts.Layout == (Layout{}) {
+		opts.Layout = DefaultLayout
+	}
+	if err := opts.Layout.Validate(); err != nil {
+		return nil, err
+	}
+	// 번호가 허용 범위를 넘는지 체크 (기본 배치는 서버 0 ~ 1023, 데이터센터 0)
+	if datacenterID < 0 || datacenterID > opts.Layout.MaxDatacenter() {
+		return nil, fmt.Errorf("datacenter ID must be between 0 and %d", opts.Layout.MaxDatacenter())
+	}
+	if nodeID < 0 || nodeID > opts.Layout.MaxWorker() {
+		return nil, fmt.Errorf("node ID must be between 0 and %d", opts.Layout.MaxWorker())
+	}
+	if opts.Policy < ClockError || opts.Policy > ClockLogical {
+		return nil, fmt.Errorf("unknown clock policy %v", opts.Policy)
+	}
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	// Epoch 가 아직 오지 않았으면 Timestamp 가 음수가 됨
+	if now := opts.Clock.Now(); now.Before(opts.Layout.Epoch) {
+		return nil, fmt.Errorf("layout epoch %v is after current time %v", opts.Layout.Epoch, now)
 	}
 
 	// 초기화된 Node 반환
 	return &Node{
-		timestamp: 0,
-		nodeID:    nodeID,
-		step:      0,
+		timestamp:    0,
+		datacenterID: datacenterID,
+		nodeID:       nodeID,
+		step:         0,
+		opts:         opts,
 	}, nil
 }
 
 // Generate: 고유 ID 생성 (핵심 로직)
-func (n *Node) Generate() int64 {
+// 시계가 뒤로 갔는데 정책상 만들 수 없으면 ErrClockMovedBackwards, 배치의 수명이 끝났으면 ErrTimestampOverflow
+func (n *Node) Generate() (int64, error) {
 	// 1. 락을 걸어 다른 고루틴이 동시에 상태를 변경하지 못하게 함
 	n.mu.Lock()
 	defer n.mu.Unlock() // 함수 종료 시 락 해제
 
-	// 2. 현재 시간 가져오기 (밀리초)
-	now := time.Now().UnixMilli()
+	// 2. 현재 시간 가져오기 (Epoch 부터 TimeUnit 단위)
+	layout := n.opts.Layout
+	now := n.now()
 
-	// 3. 시간 역전 체크 (시스템 시계 오류 등)
+	// 3. 시간 역전 체크 (시스템 시계 오류 등) → 정책에 따라 처리
 	if now < n.timestamp {
-		panic(errors.New("clock moved backwards"))
+		behind := time.Duration(n.timestamp-now) * layout.TimeUnit
+		switch n.opts.Policy {
+		case ClockWait:
+			// 뒤로 간 만큼 기다림 (기다리는 동안 다시 뒤로 가면 그만큼 더 기다림)
+			for now < n.timestamp {
+				behind = time.Duration(n.timestamp-now) * layout.TimeUnit
+				if behind > n.opts.MaxWait {
+					return 0, fmt.Errorf("%w by %v (max wait %v)", ErrClockMovedBackwards, behind, n.opts.MaxWait)
+				}
+				n.opts.Clock.Sleep(behind)
+				now = n.now()
+			}
+		case ClockLogical:
+			// 마지막 시각에서 계속 이어감 (아래 같은 시간 단위 처리로 넘어감)
+			now = n.timestamp
+		default:
+			return 0, fmt.Errorf("%w by %v", ErrClockMovedBackwards, behind)
+		}
 	}
 
-	// 4. 같은 밀리초 내에 요청이 들어온 경우 (충돌 방지 로직)
+	// 4. 같은 시간 단위 내에 요청이 들어온 경우 (충돌 방지 로직)
+	step := n.step
 	if now == n.timestamp {
 		// 시퀀스(step)를 1 증가시킴
-		n.step = (n.step + 1) & stepMax
+		step = (step + 1) & layout.MaxSequence()
 
-		// 시퀀스가 꽉 찼다면 (4096번째 요청), 다음 밀리초가 될 때까지 대기
-		if n.step == 0 {
-			for now <= n.timestamp {
-				now = time.Now().UnixMilli()
+		// 시퀀스가 꽉 찼다면 (기본 배치에서 4096번째 요청)
+		if step == 0 {
+			if n.opts.Policy == ClockLogical {
+				// 논리 시계: 기다리지 않고 다음 시간 단위로 넘어감
+				now = n.timestamp + 1
+			} else {
+				// 다음 시간 단위가 될 때까지 대기
+				// 기다리는 동안 시계가 뒤로 가면 3번과 같은 정책으로 처리 (뒤로 간 만큼 계속 자지 않음)
+				for now <= n.timestamp {
+					if behind := time.Duration(n.timestamp-now) * layout.TimeUnit; behind > 0 &&
+						(n.opts.Policy != ClockWait || behind > n.opts.MaxWait) {
+						return 0, fmt.Errorf("%w by %v while waiting for the next %v (max wait %v)", ErrClockMovedBackwards, behind, layout.TimeUnit, n.opts.MaxWait)
+					}
+					n.opts.Clock.Sleep(time.Duration(n.timestamp-now+1) * layout.TimeUnit)
+					now = n.now()
+				}
 			}
 		}
 	} else {
-		// 새로운 밀리초(시간이 흐름)라면 시퀀스를 0으로 초기화
-		n.step = 0
+		// 새로운 시간 단위(시간이 흐름)라면 시퀀스를 0으로 초기화
+		step = 0
+	}
+
+	// 5. Timestamp 구역을 넘으면 잘려서 옛 ID 와 겹치므로 만들지 않음
+	if now >= 1<<layout.TimestampBits {
+		return 0, fmt.Errorf("%w: %d %v units since %v", ErrTimestampOverflow, now, layout.TimeUnit, layout.Epoch)
 	}
 
-	// 5. 마지막 생성 시간 업데이트
+	// 6. 마지막 생성 시간 업데이트
 	n.timestamp = now
+	n.step = step
+
+	// 7. 비트 연산으로 최종 ID 조립
+	// 기본 배치: Timestamp를 왼쪽으로 22비트 밀고, NodeID를 왼쪽으로 12비트 밀고, Step을 마지막에 붙임 (OR 연산)
+	return layout.Compose(now, n.datacenterID, n.nodeID, step), nil
+}
 
-	// 6. 비트 연산으로 최종 ID 조립
-	// (현재시간 - 기준시간)을 왼쪽으로 22비트 밀고
-	// NodeID를 왼쪽으로 12비트 밀고
-	// Step을 마지막에 붙임 (OR 연산)
-	return ((now - epoch) << timeShift) | (n.nodeID << nodeShift) | n.step
+// now: 시계의 현재 시각 (Epoch 부터 TimeUnit 단위)
+func (n *Node) now() int64 {
+	return n.opts.Layout.Ticks(n.opts.Clock.Now())
 }
 
 // ==========================================
-// 3. 시각화 함수 (비트 구조 분석용)
+// 4. 시각화 함수 (비트 구조 분석용)
 // ==========================================
-func VisualizeID(caseName string, id int64) {
-	// --- [비트 역추적 로직] ---
-
-	// 1. Sign Bit (1 bit): 맨 앞 1비트 추출
-	// (항상 0이어야 함, 양수)
-	sign := (id >> 63) & 1
 
-	// 2. Timestamp (41 bits): 상위 41비트 추출
-	// 실제 의미: (생성시간 - Epoch)
-	timeRaw := (id >> timeShift) & ((1 << 41) - 1)
+// VisualizeID: ID 를 배치에 따라 나눠서 구역별 2진수(10진수) 표로 출력
+func VisualizeID(caseName string, id int64, layout Layout) {
+	// --- [비트 역추적 로직] ---
+	d, err := Decode(id, layout)
+	if err != nil {
+		fmt.Printf("\n[%s]\n해석 실패: %v\n", caseName, err)
+		return
+	}
 
-	// 3. Node ID (10 bits): 중간 10비트 추출
-	// 실제 의미: ID를 생성한 서버 번호
-	nodeVal := (id >> nodeShift) & nodeMax
+	// 구역별 (제목, 값, 비트 수) — 0비트 구역은 표에서 뺌
+	// Sign Bit 는 항상 0 (양수)
+	type field struct {
+		name  string
+		value int64
+		bits  int
+	}
+	fields := []field{
+		{"Sign", 0, 1},
+		{"Timestamp", layout.Ticks(d.Timestamp), layout.TimestampBits},
+		{"Datacenter", d.DatacenterId, layout.DatacenterBits},
+		{"Node ID", d.WorkerId, layout.WorkerBits},
+		{"Sequence", d.Sequence, layout.SequenceBits},
+	}
 
-	// 4. Sequence (12 bits): 하위 12비트 추출
-	// 실제 의미: 동시간대 생성 순서
-	stepVal := id & stepMax
+	// 각 칸: 2진수(10진수), 칸 너비는 제목과 값 중 긴 쪽
+	var header, row []string
+	for _, f := range fields {
+		if f.bits == 0 {
+			continue
+		}
+		title := fmt.Sprintf("%s(%d)", f.name, f.bits)
+		// %0*b: 2진수를 비트 수만큼의 자리로 표현 (빈자리는 0)
+		cell := fmt.Sprintf("%0*b(%d)", f.bits, f.value, f.value)
+		width := max(len(title), len(cell))
+		header = append(header, fmt.Sprintf(" %-*s ", width, title))
+		row = append(row, fmt.Sprintf(" %-*s ", width, cell))
+	}
+	line := strings.Repeat("-", len(strings.Join(row, "|"))+2)
 
 	// --- [출력] ---
 	fmt.Printf("\n[%s]\n", caseName)
 	fmt.Printf("최종 ID (10진수): %d\n", id)
+	fmt.Printf("생성 시각: %s\n", d.Timestamp.Format("2006-01-02 15:04:05.000 MST"))
 
-	// 헤더 출력
-	fmt.Println(strings.Repeat("-", 110))
-	// 각 필드 설명 헤더
-	fmt.Printf("| %-12s | %-48s | %-16s | %-16s |\n",
-		"Sign(1)",       // 부호 비트
-		"Timestamp(41)", // 시간값 (Epoch 이후 경과 시간)
-		"Node ID(10)",   // 서버 번호
-		"Sequence(12)")  // 일련 번호
-	fmt.Println(strings.Repeat("-", 110))
-
-	// 데이터 출력: 2진수(10진수) 형태로 출력
-	// %01b: 2진수 1자리로 표현 (빈자리는 0)
-	// %041b: 2진수 41자리로 표현
-	fmt.Printf("| %01b(%d)         | %041b(%d)      | %010b(%d)        | %012b(%d)         |\n",
-		sign, sign,
-		timeRaw, timeRaw,
-		nodeVal, nodeVal,
-		stepVal, stepVal)
-
-	fmt.Println(strings.Repeat("-", 110))
+	fmt.Println(line)
+	fmt.Printf("|%s|\n", strings.Join(header, "|"))
+	fmt.Println(line)
+	fmt.Printf("|%s|\n", strings.Join(row, "|"))
+	fmt.Println(line)
 }
 
 func main() {
@@ -155,21 +240,61 @@ func main() {
 	node1, _ := NewNode(1)
 	fmt.Println("=== TEST 1: 연속 생성 (Sequence 증가 확인) ===")
 	for i := 0; i < 3; i++ {
-		id := node1.Generate()
-		VisualizeID(fmt.Sprintf("Node 1 - 반복회차 %d", i+1), id)
+		id, _ := node1.Generate()
+		VisualizeID(fmt.Sprintf("Node 1 - 반복회차 %d", i+1), id, DefaultLayout)
 	}
 
 	// [시나리오 2] 1023번 서버(Node 1023)에서 생성
 	// 목표: Node ID 영역(중간)이 꽉 찬 비트(1111111111)로 나오는지 확인
 	nodeMaxVal, _ := NewNode(1023)
 	fmt.Println("\n=== TEST 2: 다른 서버 (Node ID 변경 확인) ===")
-	id2 := nodeMaxVal.Generate()
-	VisualizeID("Node 1023 - 단건 생성", id2)
+	id2, _ := nodeMaxVal.Generate()
+	VisualizeID("Node 1023 - 단건 생성", id2, DefaultLayout)
 
 	// [시나리오 3] 시간을 약간(0.1초) 두고 생성
 	// 목표: Timestamp 영역(앞쪽)의 비트값이 변하는지 확인
 	time.Sleep(100 * time.Millisecond)
 	fmt.Println("\n=== TEST 3: 시간 경과 후 (Timestamp 변경 확인) ===")
-	id3 := node1.Generate()
-	VisualizeID("Node 1 - 0.1초 후 생성", id3)
+	id3, _ := node1.Generate()
+	VisualizeID("Node 1 - 0.1초 후 생성", id3, DefaultLayout)
+
+	// [시나리오 4] 가짜 시계로 시계를 5ms 되돌림 (NTP 보정 상황)
+	// 목표: 정책마다 에러 / 대기 후 생성 / 논리 시계로 생성 중 무엇을 하는지, 그리고 ID 가 겹치지 않는지 확인
+	fmt.Println("\n=== TEST 4: 시계 역전 (정책별 처리 확인) ===")
+	for _, policy := range []ClockPolicy{ClockError, ClockWait, ClockLogical} {
+		clock := &fakeClock{now: time.UnixMilli(epoch + 1000)}
+		node, _ := NewNodeWithOptions(0, 1, Options{Policy: policy, MaxWait: 10 * time.Millisecond, Clock: clock})
+		before, _ := node.Generate()
+		clock.now = clock.now.Add(-5 * time.Millisecond)
+		after, err := node.Generate()
+		if err != nil {
+			fmt.Printf("\n[%s] 에러: %v (대기한 시간 %v)\n", policy, err, clock.slept)
+			continue
+		}
+		fmt.Printf("\n[%s] 대기한 시간 %v, 이전 ID보다 큼: %v\n", policy, clock.slept, after > before)
+		VisualizeID(fmt.Sprintf("%s - 시계 역전 후 생성", policy), after, DefaultLayout)
+	}
+
+	// [시나리오 5] 다른 비트 배치 (Sonyflake: 시간 39비트 10ms 단위, 서버 16비트, 순번 8비트)
+	// 목표: 배치에 맞게 조립되는지, 배치에 들어가지 않는 서버 번호는 잘리지 않고 거부되는지 확인
+	fmt.Println("\n=== TEST 5: 비트 배치 변경 (Sonyflake) ===")
+	sony, _ := NewNodeWithOptions(0, 40000, Options{Layout: SonyflakeLayout})
+	id5, _ := sony.Generate()
+	VisualizeID("Sonyflake 서버 40000", id5, SonyflakeLayout)
+	if _, err := NewNode(40000); err != nil {
+		fmt.Printf("기본 배치에 서버 40000: %v\n", err)
+	}
+}
+
+// fakeClock: 시각을 직접 옮기는 시계 (Sleep 하면 그만큼 시각이 흐름)
+type fakeClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.slept += d
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// rewindClock: 처음 Sleep 할 때 시각을 rewind 만큼 되돌리는 시계 (대기 중에 NTP 가 시계를 되돌린 상황)
+type rewindClock struct {
+	fakeClock
+	rewind time.Duration
+}
+
+func (c *rewindClock) Sleep(d time.Duration) {
+	if c.rewind > 0 {
+		c.now = c.now.Add(-c.rewind)
+		c.rewind = 0
+		return
+	}
+	c.fakeClock.Sleep(d)
+}
+
+func TestClockMovedBackwards(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ClockPolicy
+		maxWait time.Duration
+		back    time.Duration
+		wantErr bool
+	}{
+		{"error", ClockError, 0, 5 * time.Millisecond, true},
+		{"wait within max", ClockWait, 10 * time.Millisecond, 5 * time.Millisecond, false},
+		{"wait beyond max", ClockWait, 10 * time.Millisecond, 50 * time.Millisecond, true},
+		{"logical", ClockLogical, 0, time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.UnixMilli(epoch + 1000)}
+			node, err := NewNodeWithOptions(0, 1, Options{Policy: tt.policy, MaxWait: tt.maxWait, Clock: clock})
+			if err != nil {
+				t.Fatal(err)
+			}
+			before, _ := node.Generate()
+			clock.now = clock.now.Add(-tt.back)
+
+			after, err := node.Generate()
+			if tt.wantErr {
+				if !errors.Is(err, ErrClockMovedBackwards) {
+					t.Fatalf("err = %v, want ErrClockMovedBackwards", err)
+				}
+				if clock.slept > tt.maxWait {
+					t.Fatalf("slept %v before failing, max wait %v", clock.slept, tt.maxWait)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after <= before {
+				t.Fatalf("ID after rollback %d is not greater than %d", after, before)
+			}
+			if tt.policy == ClockWait && clock.slept < tt.back {
+				t.Fatalf("slept %v, want at least %v", clock.slept, tt.back)
+			}
+			if tt.policy == ClockLogical && clock.slept != 0 {
+				t.Fatalf("logical clock slept %v", clock.slept)
+			}
+		})
+	}
+}
+
+// 시계가 멈춘 채로 순번을 여러 번 다 써도 논리 시계는 기다리지 않고 계속 커지는 ID 를 만듦
+func TestLogicalClockSequenceOverflow(t *testing.T) {
+	clock := &fakeClock{now: time.UnixMilli(epoch + 1000)}
+	node, err := NewNodeWithOptions(0, 1, Options{Policy: ClockLogical, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev, _ := node.Generate()
+	clock.now = clock.now.Add(-time.Second)
+	for i := range 3*(DefaultLayout.MaxSequence()+1) + 10 {
+		id, err := node.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= prev {
+			t.Fatalf("ID %d after %d is not increasing (call %d)", id, prev, i)
+		}
+		prev = id
+	}
+	if clock.slept != 0 {
+		t.Fatalf("logical clock slept %v", clock.slept)
+	}
+}
+
+// 순번이 다 차서 다음 시간 단위를 기다리는 동안 시계가 뒤로 가도 정책대로 처리 (뒤로 간 만큼 자지 않음)
+func TestClockMovedBackwardsWhileWaitingForNextTick(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ClockPolicy
+		maxWait time.Duration
+		rewind  time.Duration
+		wantErr bool
+	}{
+		{"error", ClockError, 0, time.Hour, true},
+		{"wait within max", ClockWait, 10 * time.Millisecond, 5 * time.Millisecond, false},
+		{"wait beyond max", ClockWait, 10 * time.Millisecond, time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &rewindClock{fakeClock: fakeClock{now: time.UnixMilli(epoch + 1000)}}
+			node, err := NewNodeWithOptions(0, 1, Options{Policy: tt.policy, MaxWait: tt.maxWait, Clock: clock})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var last int64
+			for range DefaultLayout.MaxSequence() + 1 {
+				if last, err = node.Generate(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			clock.rewind = tt.rewind
+			id, err := node.Generate()
+			if tt.wantErr {
+				if !errors.Is(err, ErrClockMovedBackwards) {
+					t.Fatalf("err = %v, want ErrClockMovedBackwards", err)
+				}
+				if clock.slept > tt.maxWait+time.Millisecond {
+					t.Fatalf("slept %v before failing, max wait %v", clock.slept, tt.maxWait)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id <= last {
+				t.Fatalf("ID %d after waiting is not greater than %d", id, last)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+// Bit layouts, clock policies and ID decoding shared by the snowflake
+// generators. This file is kept identical (apart from the package line) in
+// "7. 분산 시스템을 위한 유일 ID 생성기 설계/djcha", ".../swma" and
+// "8. URL 단축기 설계/swma/internal/idgen"; change all copies together.
+// Run the single-file programs with it, e.g. go run main.go layout.go.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// X(Twitter) epoch in Unix milliseconds (2010-11-04 01:42:54.657 UTC)
+const xEpoch = 1288834974657
+
+// Layout describes how the 63 value bits of an ID are split:
+// [Sign:1][Timestamp][Datacenter][Worker][Sequence], packed from the lowest bit.
+// The timestamp counts TimeUnits since Epoch. Unused parts have 0 bits.
+type Layout struct {
+	TimestampBits  int
+	DatacenterBits int
+	WorkerBits     int
+	SequenceBits   int
+	TimeUnit       time.Duration
+	Epoch          time.Time
+}
+
+var (
+	// X(Twitter) snowflake: 41/5/5/12 bits, 1ms, X epoch
+	TwitterLayout = Layout{
+		TimestampBits:  41,
+		DatacenterBits: 5,
+		WorkerBits:     5,
+		SequenceBits:   12,
+		TimeUnit:       time.Millisecond,
+		Epoch:          time.UnixMilli(xEpoch),
+	}
+	// Sonyflake: 39 bits of 10ms, 16 bits machine ID, 8 bits sequence
+	SonyflakeLayout = Layout{
+		TimestampBits: 39,
+		WorkerBits:    16,
+		SequenceBits:  8,
+		TimeUnit:      10 * time.Millisecond,
+		Epoch:         time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC),
+	}
+)
+
+// ErrTimestampOverflow is returned once the time since the epoch no longer
+// fits in the timestamp bits of the layout.
+var ErrTimestampOverflow = errors.New("timestamp overflows layout")
+
+func LayoutByName(name string) (Layout, error) {
+	switch name {
+	case "twitter":
+		return TwitterLayout, nil
+	case "sonyflake":
+		return SonyflakeLayout, nil
+	}
+	return Layout{}, fmt.Errorf("unknown layout %q", name)
+}
+
+func (l Layout) TotalBits() int {
+	return l.TimestampBits + l.DatacenterBits + l.WorkerBits + l.SequenceBits
+}
+
+func (l Layout) Validate() error {
+	if l.TimestampBits <= 0 || l.SequenceBits <= 0 {
+		return errors.New("layout needs timestamp and sequence bits")
+	}
+	if l.DatacenterBits < 0 || l.WorkerBits < 0 {
+		return errors.New("layout bits must not be negative")
+	}
+	if total := l.TotalBits(); total > 63 {
+		return fmt.Errorf("layout uses %d bits, at most 63 fit in a positive int64", total)
+	}
+	if l.TimeUnit <= 0 {
+		return errors.New("layout time unit must be positive")
+	}
+	if l.Epoch.IsZero() {
+		return errors.New("layout epoch is not set")
+	}
+	return nil
+}
+
+func (l Layout) MaxDatacenter() int64 { return (int64(1) << l.DatacenterBits) - 1 }
+func (l Layout) MaxWorker() int64     { return (int64(1) << l.WorkerBits) - 1 }
+func (l Layout) MaxSequence() int64   { return (int64(1) << l.SequenceBits) - 1 }
+
+// Number of TimeUnits from the epoch to t
+func (l Layout) Ticks(t time.Time) int64 {
+	return int64(t.Sub(l.Epoch) / l.TimeUnit)
+}
+
+// Compose packs the parts into an ID; they must already fit their bits.
+func (l Layout) Compose(ticks, datacenter, worker, sequence int64) int64 {
+	workerShift := l.SequenceBits
+	datacenterShift := workerShift + l.WorkerBits
+	timestampShift := datacenterShift + l.DatacenterBits
+	return ticks<<timestampShift | datacenter<<datacenterShift | worker<<workerShift | sequence
+}
+
+// ErrClockMovedBackwards is returned when the clock is behind the last issued
+// timestamp and the clock policy does not allow issuing an ID.
+var ErrClockMovedBackwards = errors.New("clock moved backwards")
+
+// Clock is the time source of a generator.
+// Tests can inject a fake clock to simulate clock jumps deterministically.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// ClockPolicy decides what to do when the clock moves backwards (e.g. NTP step).
+// Issuing with the current timestamp would reuse (timestamp, sequence) pairs.
+type ClockPolicy int
+
+const (
+	// Return ErrClockMovedBackwards
+	ClockError ClockPolicy = iota
+	// Wait until the clock catches up, up to MaxWait
+	ClockWait
+	// Keep issuing from the last timestamp as a logical clock running ahead of
+	// the wall clock; on sequence overflow advance it instead of waiting
+	ClockLogical
+)
+
+func (p ClockPolicy) String() string {
+	switch p {
+	case ClockError:
+		return "error"
+	case ClockWait:
+		return "wait"
+	case ClockLogical:
+		return "logical"
+	}
+	return fmt.Sprintf("ClockPolicy(%d)", int(p))
+}
+
+func ParseClockPolicy(s string) (ClockPolicy, error) {
+	for _, p := range []ClockPolicy{ClockError, ClockWait, ClockLogical} {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown clock policy %q", s)
+}
+
+type DecodedID struct {
+	Id           int64     `json:"id"`
+	Timestamp    time.Time `json:"timestamp"` // truncated to the layout time unit
+	DatacenterId int64     `json:"datacenterId"`
+	WorkerId     int64     `json:"workerId"`
+	Sequence     int64     `json:"sequence"`
+}
+
+// Decode splits an ID back into its fields (reverse of Compose).
+// IDs wider than the layout were made with another layout and are rejected.
+func Decode(id int64, layout Layout) (DecodedID, error) {
+	if err := layout.Validate(); err != nil {
+		return DecodedID{}, err
+	}
+	if id < 0 || id>>layout.TotalBits() != 0 {
+		return DecodedID{}, fmt.Errorf("ID %d does not fit in a %d-bit layout", id, layout.TotalBits())
+	}
+
+	decoded := DecodedID{Id: id}
+	remain := id
+
+	// Pop (Sequence)
+	decoded.Sequence = remain & layout.MaxSequence()
+	remain >>= layout.SequenceBits
+
+	// Pop (Worker ID)
+	decoded.WorkerId = remain & layout.MaxWorker()
+	remain >>= layout.WorkerBits
+
+	// Pop (Datacenter ID)
+	decoded.DatacenterId = remain & layout.MaxDatacenter()
+	remain >>= layout.DatacenterBits
+
+	// Pop (timestamp), which may not fit in time.Duration with large time units
+	if remain > math.MaxInt64/int64(layout.TimeUnit) {
+		return DecodedID{}, fmt.Errorf("timestamp %d of %v does not fit in time.Duration", remain, layout.TimeUnit)
+	}
+	decoded.Timestamp = layout.Epoch.Add(time.Duration(remain) * layout.TimeUnit)
+
+	return decoded, nil
+}
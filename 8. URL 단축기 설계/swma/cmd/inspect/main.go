@@ -0,0 +1,163 @@
+// Command inspect decodes snowflake IDs and short codes back into
+// "created at X by server Y".
+//
+//	go run ./cmd/inspect 2W1IGIr9lxo
+//	go run ./cmd/inspect -json 0x1d4f92cc4f867000 2112068156465246208
+//	cat codes.txt | go run ./cmd/inspect -in base62
+//
+// With -in auto, a 0x prefix means hex, digits longer than a short code
+// (11 chars) mean decimal, and anything else is a base62 short code.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/urlshortener/internal/base62"
+	"example.com/urlshortener/internal/idgen"
+)
+
+// maxCode is the base62 code of the largest int64 ID. The alphabet is in
+// ASCII order, so codes of the same length compare like their values.
+var (
+	maxCode    = base62.Encode(1<<63 - 1)
+	maxCodeLen = len(maxCode)
+)
+
+type result struct {
+	Input string `json:"input"`
+	Code  string `json:"code,omitempty"`
+	*idgen.DecodedID
+	Error string `json:"error,omitempty"`
+}
+
+func main() {
+	in := flag.String("in", "auto", "input format: auto, dec, hex or base62")
+	layoutName := flag.String("layout", "twitter", "ID layout: twitter or sonyflake")
+	asJSON := flag.Bool("json", false, "print one JSON object per ID")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [ID...]\nReads IDs from stdin when none are given.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	layout, err := idgen.LayoutByName(*layoutName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch *in {
+	case "auto", "dec", "hex", "base62":
+	default:
+		log.Fatalf("unknown input format %q", *in)
+	}
+
+	inputs := flag.Args()
+	if len(inputs) == 0 {
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			if s := strings.TrimSpace(sc.Text()); s != "" {
+				inputs = append(inputs, s)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	failed := false
+	enc := json.NewEncoder(os.Stdout)
+	for _, s := range inputs {
+		r := inspect(s, *in, layout)
+		if r.Error != "" {
+			failed = true
+		}
+		if *asJSON {
+			_ = enc.Encode(r)
+		} else {
+			printHuman(os.Stdout, r)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func inspect(s, format string, layout idgen.Layout) result {
+	r := result{Input: s}
+	id, err := parseID(s, format)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	decoded, err := idgen.Decode(id, layout)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	decoded.Timestamp = decoded.Timestamp.UTC()
+	r.DecodedID = &decoded
+	r.Code = base62.Encode(uint64(id))
+	return r
+}
+
+func parseID(s, format string) (int64, error) {
+	if format == "auto" {
+		lower := strings.ToLower(s)
+		switch {
+		case strings.HasPrefix(lower, "0x"):
+			format = "hex"
+		case len(s) > maxCodeLen && isDigits(s):
+			format = "dec"
+		default:
+			format = "base62"
+		}
+	}
+
+	var (
+		n   uint64
+		err error
+	)
+	switch format {
+	case "dec":
+		n, err = strconv.ParseUint(s, 10, 63)
+	case "hex":
+		n, err = strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 63)
+	case "base62":
+		var ok bool
+		n, ok = base62.Decode(s)
+		if !ok || len(s) > maxCodeLen || (len(s) == maxCodeLen && s > maxCode) {
+			err = errors.New("not a base62 short code")
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("parse %s ID %q: %w", format, s, err)
+	}
+	return int64(n), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func printHuman(w io.Writer, r result) {
+	if r.Error != "" {
+		fmt.Fprintf(w, "%s: %s\n", r.Input, r.Error)
+		return
+	}
+	fmt.Fprintf(w, "%s: id %d (code %s) created at %s by datacenter %d server %d, sequence %d\n",
+		r.Input, r.Id, r.Code, r.Timestamp.Format(time.RFC3339Nano), r.DatacenterId, r.WorkerId, r.Sequence)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -12,7 +14,9 @@ import (
 	"example.com/urlshortener/internal/config"
 	"example.com/urlshortener/internal/db"
 	"example.com/urlshortener/internal/handlers"
+	"example.com/urlshortener/internal/idgen"
 	"example.com/urlshortener/internal/migrate"
+	"example.com/urlshortener/internal/workerid"
 )
 
 func main() {
@@ -28,9 +32,26 @@ func main() {
 		log.Fatalf("schema migration failed: %v", err)
 	}
 
+	store, err := leaseStore(cfg, database)
+	if err != nil {
+		log.Fatalf("worker lease store: %v", err)
+	}
+	leaseCtx, cancelLease := context.WithTimeout(context.Background(), 30*time.Second)
+	lease, err := workerid.Acquire(leaseCtx, store, idgen.TwitterLayout, workerid.Options{TTL: cfg.WorkerLeaseTTL})
+	cancelLease()
+	if err != nil {
+		log.Fatalf("worker lease: %v", err)
+	}
+	log.Printf("leased datacenter %d worker %d (%s)", lease.DatacenterID, lease.WorkerID, cfg.WorkerLeaseBackend)
+
+	ids, err := workerid.NewGenerator(lease, idgen.Options{Policy: idgen.ClockWait, MaxWait: time.Second})
+	if err != nil {
+		log.Fatalf("id generator: %v", err)
+	}
+
 	srv := &http.Server{
 		Addr:              cfg.HTTPAddr,
-		Handler:           handlers.NewRouter(cfg, database),
+		Handler:           handlers.NewRouter(cfg, database, ids),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
@@ -43,9 +64,29 @@ func main() {
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-	<-stop
+	select {
+	case <-stop:
+	case <-lease.Done():
+		// Can't issue IDs anymore; exit so the instance restarts with a new lease
+		log.Printf("%v, shutting down", lease.Err())
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	_ = srv.Shutdown(ctx)
+	if err := lease.Close(ctx); err != nil {
+		log.Printf("release worker lease: %v", err)
+	}
+}
+
+func leaseStore(cfg config.Config, database *sql.DB) (workerid.Store, error) {
+	switch cfg.WorkerLeaseBackend {
+	case "sql":
+		return workerid.NewSQLStore(database), nil
+	case "redis":
+		return workerid.NewRedisStore(cfg.RedisAddr, cfg.RedisPassword), nil
+	case "file":
+		return workerid.NewFileStore(cfg.WorkerLeaseDir), nil
+	}
+	return nil, fmt.Errorf("unknown WORKER_LEASE_BACKEND %q (want sql, redis or file)", cfg.WorkerLeaseBackend)
 }
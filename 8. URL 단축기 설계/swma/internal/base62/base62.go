@@ -17,7 +17,12 @@ func Encode(n uint64) string {
 	return string(b[i:])
 }
 
+// Decode reports false for an empty string or a character outside the alphabet
+// (Encode never returns "", zero is "0").
 func Decode(s string) (uint64, bool) {
+	if s == "" {
+		return 0, false
+	}
 	var n uint64
 	for i := 0; i < len(s); i++ {
 		c := s[i]
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +24,12 @@ type Config struct {
 	DBMaxOpen int
 	DBMaxIdle int
 	DBMaxLife time.Duration
+
+	WorkerLeaseBackend string // sql, redis or file
+	WorkerLeaseTTL     time.Duration
+	WorkerLeaseDir     string // for the file backend
+	RedisAddr          string
+	RedisPassword      string
 }
 
 func FromEnv() Config {
@@ -42,6 +49,12 @@ func FromEnv() Config {
 		DBMaxOpen: atoi(getenv("DB_MAX_OPEN_CONNS", "50"), 50),
 		DBMaxIdle: atoi(getenv("DB_MAX_IDLE_CONNS", "25"), 25),
 		DBMaxLife: parseDuration(getenv("DB_CONN_MAX_LIFETIME", "5m"), 5*time.Minute),
+
+		WorkerLeaseBackend: getenv("WORKER_LEASE_BACKEND", "sql"),
+		WorkerLeaseTTL:     parseDuration(getenv("WORKER_LEASE_TTL", "30s"), 30*time.Second),
+		WorkerLeaseDir:     getenv("WORKER_LEASE_DIR", filepath.Join(os.TempDir(), "urlshortener-workers")),
+		RedisAddr:          getenv("REDIS_ADDR", "redis:6379"),
+		RedisPassword:      os.Getenv("REDIS_PASSWORD"),
 	}
 	return c
 }
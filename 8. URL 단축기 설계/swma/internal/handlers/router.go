@@ -10,14 +10,14 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-func NewRouter(cfg config.Config, db *sql.DB) http.Handler {
+func NewRouter(cfg config.Config, db *sql.DB, ids IDGenerator) http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.RealIP)
 	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
-	h := &Handler{Cfg: cfg, DB: db}
+	h := &Handler{Cfg: cfg, DB: db, IDs: ids}
 
 	r.Route("/api/v1", func(api chi.Router) {
 		api.Post("/data/shorten", h.Shorten)
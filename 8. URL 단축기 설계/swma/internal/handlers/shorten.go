@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"example.com/urlshortener/internal/base62"
-	"example.com/urlshortener/internal/idgen"
 )
 
 type shortenReq struct {
@@ -26,8 +25,6 @@ type shortenResp struct {
 	Reused      bool       `json:"reused"`
 }
 
-var generator = idgen.NewGenerator(0, 0)
-
 func (h *Handler) Shorten(w http.ResponseWriter, r *http.Request) {
 	var req shortenReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -110,7 +107,12 @@ LIMIT 1
 	}
 
 	// Create a new record
-	newID := uint64(generator.Next())
+	nextID, err := h.IDs.Next()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "id_unavailable", "cannot generate id, try again")
+		return
+	}
+	newID := uint64(nextID)
 	newCode := base62.Encode(newID)
 
 	ins := `
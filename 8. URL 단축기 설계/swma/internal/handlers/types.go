@@ -6,7 +6,13 @@ import (
 	"example.com/urlshortener/internal/config"
 )
 
+// IDGenerator issues unique IDs for new short URLs.
+type IDGenerator interface {
+	Next() (int64, error)
+}
+
 type Handler struct {
 	Cfg config.Config
 	DB  *sql.DB
+	IDs IDGenerator
 }
@@ -6,28 +6,26 @@ import (
 	"os"
 	"sync"
 	"time"
-	"unsafe"
 )
 
-const (
-	timestampBits = 41
-	datacenterBits = 5
-	serverBits = 5
-	sequenceBits = 12
-
-	xEpoch = 1288834974657
-)
+type Options struct {
+	Policy ClockPolicy
+	MaxWait time.Duration  // used by ClockWait
+	Clock Clock  // defaults to the system clock
+	Layout Layout  // defaults to TwitterLayout
+	// Never issue at or before this timestamp (TimeUnits since the layout epoch),
+	// e.g. the last one a previous holder of the same worker ID issued at.
+	// A clock still behind it counts as moved backwards and follows Policy.
+	After int64
+}
 
 type SnowflakeIdGenerator struct {
 	mutex sync.Mutex
-	timestamp int64
-	datacenterId uint16
-	serverId uint16
-	sequence uint16
-}
-
-func clampBitMaxU16(value uint16, bits int) uint16 {
-	return (value & ((uint16(1) << bits) - 1))
+	timestamp int64  // TimeUnits since the layout epoch
+	datacenterId int64
+	serverId int64
+	sequence int64
+	options Options
 }
 
 func clampBitMax64(value int64, bits int) int64 {
@@ -38,57 +36,121 @@ func GetTimestampOfX() int64 {
 	return time.Now().UnixMilli() - xEpoch
 }
 
-func NewGenerator(datacenterId uint16, serverId uint16) *SnowflakeIdGenerator {
-	return &SnowflakeIdGenerator {
+func NewGenerator(datacenterId int64, serverId int64) (*SnowflakeIdGenerator, error) {
+	return NewGeneratorWithOptions(datacenterId, serverId, Options {})
+}
+
+func NewGeneratorWithOptions(datacenterId int64, serverId int64, options Options) (*SnowflakeIdGenerator, error) {
+	if options.Layout == (Layout {}) {
+		options.Layout = TwitterLayout
+	}
+	if err := options.Layout.Validate(); err != nil {
+		return nil, err
+	}
+	// Out-of-range IDs would be truncated into another node's ID
+	if datacenterId < 0 || datacenterId > options.Layout.MaxDatacenter() {
+		return nil, fmt.Errorf("datacenter ID %d out of range [0, %d]", datacenterId, options.Layout.MaxDatacenter())
+	}
+	if serverId < 0 || serverId > options.Layout.MaxWorker() {
+		return nil, fmt.Errorf("server ID %d out of range [0, %d]", serverId, options.Layout.MaxWorker())
+	}
+	if options.Clock == nil {
+		options.Clock = systemClock {}
+	}
+	if now := options.Clock.Now(); now.Before(options.Layout.Epoch) {
+		return nil, fmt.Errorf("layout epoch %v is after current time %v", options.Layout.Epoch, now)
+	}
+	if options.After < 0 || options.After >= (int64(1) << options.Layout.TimestampBits) {
+		return nil, fmt.Errorf("after timestamp %d out of range [0, %d)", options.After, int64(1) << options.Layout.TimestampBits)
+	}
+	g := &SnowflakeIdGenerator {
 		datacenterId: datacenterId,
 		serverId: serverId,
+		options: options,
 	}
+	if options.After > 0 {
+		// Start as if the whole sequence of that timestamp was used
+		g.timestamp = options.After
+		g.sequence = clampBitMax64(-1, options.Layout.SequenceBits)
+	}
+	return g, nil
 }
 
-func (g *SnowflakeIdGenerator) Update() {
-	// Get current timestamp (from X epoch)
-	now := GetTimestampOfX()
+// Get current timestamp (from layout epoch) of the generator's clock
+func (g *SnowflakeIdGenerator) now() int64 {
+	return g.options.Layout.Ticks(g.options.Clock.Now())
+}
+
+func (g *SnowflakeIdGenerator) Update() error {
+	now := g.now()
+
+	// Check for clock rollback
+	if now < g.timestamp {
+		behind := time.Duration(g.timestamp - now) * g.options.Layout.TimeUnit
+		switch g.options.Policy {
+		case ClockWait:
+			// Clock may move back again while waiting
+			for ; now < g.timestamp; now = g.now() {
+				behind = time.Duration(g.timestamp - now) * g.options.Layout.TimeUnit
+				if behind > g.options.MaxWait {
+					return fmt.Errorf("%w by %v (max wait %v)", ErrClockMovedBackwards, behind, g.options.MaxWait)
+				}
+				g.options.Clock.Sleep(behind)
+			}
+		case ClockLogical:
+			now = g.timestamp
+		default:
+			return fmt.Errorf("%w by %v", ErrClockMovedBackwards, behind)
+		}
+	}
 
 	if g.timestamp != now {
 		g.sequence = 0
 		g.timestamp = now
 	} else {
-		g.sequence = clampBitMaxU16(g.sequence + 1, sequenceBits)
+		g.sequence = clampBitMax64(g.sequence + 1, g.options.Layout.SequenceBits)
 		// Check for sequence conflicts
 		if g.sequence == 0 {
-			// Waiting for timestamp change
-			for ; now == g.timestamp; now = GetTimestampOfX() {}
+			if g.options.Policy == ClockLogical {
+				// Move the logical clock ahead instead of waiting
+				g.timestamp++
+				return nil
+			}
+			// Waiting for timestamp change; the clock may move back while sleeping,
+			// which is handled by the same policy as above instead of sleeping it out
+			for ; now <= g.timestamp; now = g.now() {
+				if behind := time.Duration(g.timestamp - now) * g.options.Layout.TimeUnit; behind > 0 &&
+					(g.options.Policy != ClockWait || behind > g.options.MaxWait) {
+					// Keep the sequence exhausted so the next call waits again instead of reusing it
+					g.sequence = clampBitMax64(-1, g.options.Layout.SequenceBits)
+					return fmt.Errorf("%w by %v while waiting for the next tick (max wait %v)", ErrClockMovedBackwards, behind, g.options.MaxWait)
+				}
+				g.options.Clock.Sleep(time.Duration(g.timestamp - now + 1) * g.options.Layout.TimeUnit)
+			}
+			g.timestamp = now
 		}
 	}
+	return nil
 }
 
-func (g *SnowflakeIdGenerator) Next() int64 {
-	var id int64 = 0
-	remain := int((unsafe.Sizeof(id) * 8) - 1)  // excludes sign bit(1)
+func (g *SnowflakeIdGenerator) Next() (int64, error) {
+	layout := g.options.Layout
 
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
 
 	// Update generator
-	g.Update()
-
-	// Push (current timestamp)
-	remain -= timestampBits
-	id |= clampBitMax64(int64(g.timestamp), timestampBits) << remain
-
-	// Push (Datacenter ID)
-	remain -= datacenterBits
-	id |= clampBitMax64(int64(g.datacenterId), datacenterBits) << remain
-
-	// Push (Server ID)
-	remain -= serverBits
-	id |= clampBitMax64(int64(g.serverId), serverBits) << remain
+	if err := g.Update(); err != nil {
+		return 0, err
+	}
 
-	// Push (Sequence)
-	remain -= sequenceBits
-	id |= clampBitMax64(int64(g.sequence), sequenceBits) << remain
+	// Clamping an overflowed timestamp would reissue old IDs
+	if g.timestamp >= (int64(1) << layout.TimestampBits) {
+		return 0, fmt.Errorf("%w: %d ticks of %v since %v", ErrTimestampOverflow, g.timestamp, layout.TimeUnit, layout.Epoch)
+	}
 
-	return id
+	// Push (current timestamp, Datacenter ID, Server ID, Sequence)
+	return layout.Compose(g.timestamp, g.datacenterId, g.serverId, g.sequence), nil
 }
 
 func main() {
@@ -123,14 +185,48 @@ func main() {
 		return
 	}
 
-	idGen := NewGenerator(uint16(datacenterId), uint16(serverId))
+	options := Options {}
+	if len(args) > 4 {
+		options.Policy, err = ParseClockPolicy(args[4])
+		if err != nil {
+			usage()
+			return
+		}
+	}
+	if len(args) > 5 {
+		maxWait, err := strconv.Atoi(args[5])
+		if err != nil {
+			usage()
+			return
+		}
+		options.MaxWait = time.Duration(maxWait) * time.Millisecond
+	}
+
+	if len(args) > 6 {
+		options.Layout, err = LayoutByName(args[6])
+		if err != nil {
+			usage()
+			return
+		}
+	}
+
+	idGen, err := NewGeneratorWithOptions(int64(datacenterId), int64(serverId), options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create generator: %v\n", err)
+		os.Exit(1)
+	}
 
 	for i := range count {
-		fmt.Printf("New unique ID(%d/%d): %d\n", i + 1, count, idGen.Next())
+		id, err := idGen.Next()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate ID(%d/%d): %v\n", i + 1, count, err)
+			os.Exit(1)
+		}
+		fmt.Printf("New unique ID(%d/%d): %d\n", i + 1, count, id)
 		time.Sleep(time.Duration(delay) * time.Millisecond)
 	}
 }
 
 func usage() {
-	fmt.Printf("Usage: %s [DELAY(ms)] [COUNT] [DATACENTER-ID] [SERVER-ID]\n", os.Args[0])
+	fmt.Printf("Usage: %s [DELAY(ms)] [COUNT] [DATACENTER-ID] [SERVER-ID] [CLOCK-POLICY(error|wait|logical)] [MAX-WAIT(ms)] [LAYOUT(twitter|sonyflake)]\n", os.Args[0])
 }
@@ -0,0 +1,155 @@
+package idgen
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when told to; Sleep advances it by the slept duration.
+// If rewind is set, the next Sleep moves it back by that much instead
+// (an NTP step while the generator waits).
+type fakeClock struct {
+	now    time.Time
+	slept  time.Duration
+	rewind time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	if c.rewind > 0 {
+		c.now = c.now.Add(-c.rewind)
+		c.rewind = 0
+		return
+	}
+	c.now = c.now.Add(d)
+	c.slept += d
+}
+
+func newTestGenerator(t *testing.T, policy ClockPolicy, maxWait time.Duration) (*SnowflakeIdGenerator, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.UnixMilli(xEpoch + 1000)}
+	g, err := NewGeneratorWithOptions(1, 1, Options{Policy: policy, MaxWait: maxWait, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, clock
+}
+
+func TestClockMovedBackwards(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ClockPolicy
+		maxWait time.Duration
+		back    time.Duration
+		wantErr bool
+	}{
+		{"error", ClockError, 0, 5 * time.Millisecond, true},
+		{"wait within max", ClockWait, 10 * time.Millisecond, 5 * time.Millisecond, false},
+		{"wait beyond max", ClockWait, 10 * time.Millisecond, 50 * time.Millisecond, true},
+		{"logical", ClockLogical, 0, time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, clock := newTestGenerator(t, tt.policy, tt.maxWait)
+			before, _ := g.Next()
+			clock.now = clock.now.Add(-tt.back)
+
+			after, err := g.Next()
+			if tt.wantErr {
+				if !errors.Is(err, ErrClockMovedBackwards) {
+					t.Fatalf("err = %v, want ErrClockMovedBackwards", err)
+				}
+				if clock.slept > tt.maxWait {
+					t.Fatalf("slept %v before failing, max wait %v", clock.slept, tt.maxWait)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after <= before {
+				t.Fatalf("ID after rollback %d is not greater than %d", after, before)
+			}
+			if tt.policy == ClockWait && clock.slept < tt.back {
+				t.Fatalf("slept %v, want at least %v", clock.slept, tt.back)
+			}
+			if tt.policy == ClockLogical && clock.slept != 0 {
+				t.Fatalf("logical clock slept %v", clock.slept)
+			}
+		})
+	}
+}
+
+// With the clock frozen behind, the logical clock keeps issuing increasing IDs
+// across several sequence overflows without sleeping.
+func TestLogicalClockSequenceOverflow(t *testing.T) {
+	g, clock := newTestGenerator(t, ClockLogical, 0)
+	prev, _ := g.Next()
+	clock.now = clock.now.Add(-time.Second)
+	for i := range 3<<TwitterLayout.SequenceBits + 10 {
+		id, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= prev {
+			t.Fatalf("ID %d after %d is not increasing (call %d)", id, prev, i)
+		}
+		prev = id
+	}
+	if clock.slept != 0 {
+		t.Fatalf("logical clock slept %v", clock.slept)
+	}
+}
+
+// A rollback while waiting out a sequence overflow follows the clock policy
+// instead of sleeping through it, and a failed wait never reuses a sequence.
+func TestClockMovedBackwardsWhileWaitingForNextTick(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ClockPolicy
+		maxWait time.Duration
+		rewind  time.Duration
+		wantErr bool
+	}{
+		{"error", ClockError, 0, time.Hour, true},
+		{"wait within max", ClockWait, 10 * time.Millisecond, 5 * time.Millisecond, false},
+		{"wait beyond max", ClockWait, 10 * time.Millisecond, time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, clock := newTestGenerator(t, tt.policy, tt.maxWait)
+			issued := make(map[int64]bool)
+			var last int64
+			for range 1 << TwitterLayout.SequenceBits {
+				id, err := g.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				issued[id], last = true, id
+			}
+
+			clock.rewind = tt.rewind
+			id, err := g.Next()
+			if tt.wantErr {
+				if !errors.Is(err, ErrClockMovedBackwards) {
+					t.Fatalf("err = %v, want ErrClockMovedBackwards", err)
+				}
+				if clock.slept > tt.maxWait+time.Millisecond {
+					t.Fatalf("slept %v before failing, max wait %v", clock.slept, tt.maxWait)
+				}
+				// Once the clock is back, the next ID must still be new
+				clock.now = clock.now.Add(tt.rewind)
+				if id, err = g.Next(); err != nil {
+					t.Fatal(err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if issued[id] || id <= last {
+				t.Fatalf("ID %d after waiting was already issued or is not greater than %d", id, last)
+			}
+		})
+	}
+}
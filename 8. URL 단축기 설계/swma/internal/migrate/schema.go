@@ -21,7 +21,22 @@ CREATE TABLE IF NOT EXISTS urls (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 `
 
+// Snowflake worker ID leases (see internal/workerid)
+const workerLeasesSQL = `
+CREATE TABLE IF NOT EXISTS worker_leases (
+  slot       INT UNSIGNED NOT NULL,
+  owner      VARCHAR(128) NOT NULL,
+  expires_at DATETIME(6) NOT NULL,
+  last_tick  BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (slot)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`
+
 func EnsureSchema(db *sql.DB) error {
-	_, err := db.Exec(schemaSQL)
-	return err
+	for _, stmt := range []string{schemaSQL, workerLeasesSQL} {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
 }
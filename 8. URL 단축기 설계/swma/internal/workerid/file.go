@@ -0,0 +1,138 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package workerid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileStore claims slots with flock on Dir/worker-<slot>.lock. It only keeps
+// instances on the same host apart, and the OS drops the lock when the
+// process dies, so the TTL is not used. The file holds the owner and the last
+// tick, which stays behind for the next holder.
+type FileStore struct {
+	Dir string
+
+	mu    sync.Mutex
+	files map[int64]*os.File
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{Dir: dir, files: make(map[int64]*os.File)}
+}
+
+func (s *FileStore) path(slot int64) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("worker-%04d.lock", slot))
+}
+
+func (s *FileStore) Acquire(ctx context.Context, owner string, slots int64, ttl time.Duration) (int64, int64, error) {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return 0, 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for slot := int64(0); slot < slots; slot++ {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		if _, held := s.files[slot]; held {
+			continue
+		}
+		f, err := os.OpenFile(s.path(slot), os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			f.Close()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				continue
+			}
+			return 0, 0, err
+		}
+		lastTick, err := readLastTick(f)
+		if err == nil {
+			err = writeClaim(f, owner, lastTick)
+		}
+		if err != nil {
+			f.Close()
+			return 0, 0, err
+		}
+		s.files[slot] = f
+		return slot, lastTick, nil
+	}
+	return 0, 0, ErrNoFreeSlot
+}
+
+// readLastTick reads the tick a previous holder left on the second line
+// (an older or empty file has none)
+func readLastTick(f *os.File) (int64, error) {
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<16))
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) < 2 || lines[1] == "" {
+		return 0, nil
+	}
+	tick, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: malformed last tick %q", f.Name(), lines[1])
+	}
+	return tick, nil
+}
+
+// writeClaim replaces the file with the owner (only informational, for
+// whoever looks at the lock files) and the last tick, synced so it survives
+// a crash of the host
+func writeClaim(f *os.File, owner string, lastTick int64) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(fmt.Appendf(nil, "%s\n%d\n", owner, lastTick), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Renew checks that the locked file is still the one at the path: if it was
+// deleted or replaced, another process can lock the new file.
+func (s *FileStore) Renew(ctx context.Context, slot int64, owner string, lastTick int64, ttl time.Duration) error {
+	s.mu.Lock()
+	f, ok := s.files[slot]
+	s.mu.Unlock()
+	if !ok {
+		return ErrLeaseLost
+	}
+	held, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	current, err := os.Stat(s.path(slot))
+	if err != nil || !os.SameFile(held, current) {
+		return ErrLeaseLost
+	}
+	return writeClaim(f, owner, lastTick)
+}
+
+func (s *FileStore) Release(ctx context.Context, slot int64, owner string, lastTick int64) error {
+	s.mu.Lock()
+	f, ok := s.files[slot]
+	delete(s.files, slot)
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	// Closing drops the lock; the file stays so lockers never race on deletion
+	err := writeClaim(f, owner, lastTick)
+	return errors.Join(err, f.Close())
+}
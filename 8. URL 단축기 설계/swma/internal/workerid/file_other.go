@@ -0,0 +1,32 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package workerid
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var errFileLockUnsupported = errors.New("file lock worker leases are not supported on this platform")
+
+// FileStore is only available where flock is.
+type FileStore struct {
+	Dir string
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{Dir: dir}
+}
+
+func (s *FileStore) Acquire(ctx context.Context, owner string, slots int64, ttl time.Duration) (int64, int64, error) {
+	return 0, 0, errFileLockUnsupported
+}
+
+func (s *FileStore) Renew(ctx context.Context, slot int64, owner string, lastTick int64, ttl time.Duration) error {
+	return errFileLockUnsupported
+}
+
+func (s *FileStore) Release(ctx context.Context, slot int64, owner string, lastTick int64) error {
+	return errFileLockUnsupported
+}
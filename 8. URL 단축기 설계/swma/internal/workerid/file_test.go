@@ -0,0 +1,44 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package workerid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/urlshortener/internal/idgen"
+)
+
+func TestFileStoresGetDifferentSlots(t *testing.T) {
+	dir := t.TempDir()
+	a := acquire(t, NewFileStore(dir), Options{})
+	b := acquire(t, NewFileStore(dir), Options{})
+	if a.DatacenterID == b.DatacenterID && a.WorkerID == b.WorkerID {
+		t.Fatalf("both leases got datacenter %d worker %d", a.DatacenterID, a.WorkerID)
+	}
+}
+
+func TestFileStoreCloseReleasesSlot(t *testing.T) {
+	dir := t.TempDir()
+	layout := idgen.TwitterLayout
+	now := layout.Epoch.Add(time.Hour)
+
+	first := acquire(t, NewFileStore(dir), Options{})
+	g := newGenerator(t, first, idgen.Options{Clock: &fixedClock{now: now}})
+	if _, err := g.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	second := acquire(t, NewFileStore(dir), Options{})
+	if second.DatacenterID != first.DatacenterID || second.WorkerID != first.WorkerID {
+		t.Fatalf("got datacenter %d worker %d, want the released %d/%d",
+			second.DatacenterID, second.WorkerID, first.DatacenterID, first.WorkerID)
+	}
+	if got, want := second.LastTick(), layout.Ticks(now); got != want {
+		t.Fatalf("LastTick = %d, want %d", got, want)
+	}
+}
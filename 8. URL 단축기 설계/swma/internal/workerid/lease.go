@@ -0,0 +1,258 @@
+// Package workerid leases a unique datacenter/worker pair for the snowflake
+// generator from a coordination store, so replicas never share a node ID.
+//
+// A lease is taken at startup and renewed by heartbeats. It stays valid for
+// TTL after the start of the last successful renewal, measured on the local
+// monotonic clock. The store keeps the claim for TTL after it receives the
+// renewal, which is never earlier, so another instance can only take the pair
+// after this one has stopped using it.
+//
+// Renewals and the release also record the last timestamp the holder issued
+// IDs at. The next holder of the pair does not issue at or before it, so a
+// clock behind the previous holder's (skew, or a logical clock that ran ahead)
+// can't reissue its IDs.
+package workerid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"example.com/urlshortener/internal/idgen"
+)
+
+var (
+	// ErrLeaseLost is returned once the lease has expired, was taken by
+	// another instance or was closed.
+	ErrLeaseLost = errors.New("worker ID lease lost")
+	// ErrNoFreeSlot is returned when every datacenter/worker pair is leased.
+	ErrNoFreeSlot = errors.New("no free worker ID")
+)
+
+// Store is a coordination store holding worker ID claims. Slots number the
+// datacenter/worker pairs of a layout from 0.
+//
+// Ticks are the generator timestamps (layout TimeUnits since its epoch) that
+// IDs were issued at; stores keep the highest one recorded per slot.
+type Store interface {
+	// Acquire claims a free or expired slot in [0, slots) for owner and
+	// returns the last tick recorded for it (0 if none).
+	Acquire(ctx context.Context, owner string, slots int64, ttl time.Duration) (slot, lastTick int64, err error)
+	// Renew extends owner's claim on slot and records lastTick, or returns
+	// ErrLeaseLost if another owner holds it.
+	Renew(ctx context.Context, slot int64, owner string, lastTick int64, ttl time.Duration) error
+	// Release records lastTick and gives the slot up so another instance can
+	// take it at once.
+	Release(ctx context.Context, slot int64, owner string, lastTick int64) error
+}
+
+type Options struct {
+	Owner     string        // unique per instance, defaults to host-pid-random
+	TTL       time.Duration // defaults to 30s
+	Heartbeat time.Duration // renewal interval, defaults to TTL/3
+}
+
+type Lease struct {
+	DatacenterID int64
+	WorkerID     int64
+
+	store     Store
+	slot      int64
+	owner     string
+	layout    idgen.Layout
+	ttl       time.Duration
+	heartbeat time.Duration
+
+	mu         sync.Mutex
+	validUntil time.Time
+	lastTick   int64 // highest tick issued with this pair, starting at the previous holder's
+	err        error // set once the lease is lost for good
+	done       chan struct{}
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// Acquire leases a datacenter/worker pair of layout and starts renewing it.
+func Acquire(ctx context.Context, store Store, layout idgen.Layout, opts Options) (*Lease, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = opts.TTL / 3
+	}
+	if opts.Heartbeat >= opts.TTL {
+		return nil, fmt.Errorf("heartbeat %v must be shorter than TTL %v", opts.Heartbeat, opts.TTL)
+	}
+	if opts.Owner == "" {
+		opts.Owner = defaultOwner()
+	}
+
+	slots := int64(1) << (layout.DatacenterBits + layout.WorkerBits)
+	start := time.Now()
+	slot, lastTick, err := store.Acquire(ctx, opts.Owner, slots, opts.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("acquire worker ID: %w", err)
+	}
+
+	hbCtx, stop := context.WithCancel(context.Background())
+	l := &Lease{
+		DatacenterID: slot >> layout.WorkerBits,
+		WorkerID:     slot & layout.MaxWorker(),
+		store:        store,
+		slot:         slot,
+		owner:        opts.Owner,
+		layout:       layout,
+		ttl:          opts.TTL,
+		heartbeat:    opts.Heartbeat,
+		validUntil:   start.Add(opts.TTL),
+		lastTick:     lastTick,
+		done:         make(chan struct{}),
+		stop:         stop,
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.renewLoop(hbCtx)
+	}()
+	return l, nil
+}
+
+func (l *Lease) renewLoop(ctx context.Context) {
+	t := time.NewTicker(l.heartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		start := time.Now()
+		rctx, cancel := context.WithTimeout(ctx, l.heartbeat)
+		err := l.store.Renew(rctx, l.slot, l.owner, l.LastTick(), l.ttl)
+		cancel()
+		switch {
+		case err == nil:
+			l.mu.Lock()
+			l.validUntil = start.Add(l.ttl)
+			l.mu.Unlock()
+		case errors.Is(err, ErrLeaseLost):
+			l.lose(fmt.Errorf("%w: datacenter %d worker %d taken by another instance", ErrLeaseLost, l.DatacenterID, l.WorkerID))
+			return
+		case ctx.Err() != nil:
+			return
+		default:
+			// Keep trying; Valid fails on its own once the TTL runs out
+			log.Printf("worker ID lease renew failed: %v", err)
+		}
+	}
+}
+
+func (l *Lease) lose(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = err
+		close(l.done)
+	}
+}
+
+// Valid returns nil while the lease is held.
+func (l *Lease) Valid() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	if !time.Now().Before(l.validUntil) {
+		return fmt.Errorf("%w: not renewed since %v", ErrLeaseLost, l.validUntil.Add(-l.ttl).Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Done is closed when the store reports the slot taken by another instance
+// or the lease is closed. A lease that only ran out of time may come back
+// with the next successful renewal.
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err returns why Done was closed.
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// LastTick returns the highest tick issued with the pair, or the previous
+// holder's if this lease has not issued any later one yet.
+func (l *Lease) LastTick() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastTick
+}
+
+func (l *Lease) issued(tick int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastTick = max(l.lastTick, tick)
+}
+
+// Close stops the heartbeats and releases the slot.
+func (l *Lease) Close(ctx context.Context) error {
+	l.stop()
+	l.wg.Wait()
+	l.lose(fmt.Errorf("%w: closed", ErrLeaseLost))
+	return l.store.Release(ctx, l.slot, l.owner, l.LastTick())
+}
+
+// Generator issues IDs with the leased datacenter/worker pair and refuses to
+// once the lease is lost.
+type Generator struct {
+	lease *Lease
+	gen   *idgen.SnowflakeIdGenerator
+}
+
+// NewGenerator creates a generator on the lease's layout that only issues
+// after the last tick recorded for the pair; until the clock passes it,
+// options.Policy applies as if the clock had moved backwards.
+func NewGenerator(lease *Lease, options idgen.Options) (*Generator, error) {
+	options.Layout = lease.layout
+	options.After = max(options.After, lease.LastTick())
+	gen, err := idgen.NewGeneratorWithOptions(lease.DatacenterID, lease.WorkerID, options)
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{lease: lease, gen: gen}, nil
+}
+
+func (g *Generator) Next() (int64, error) {
+	id, err := g.gen.Next()
+	if err != nil {
+		return 0, err
+	}
+	layout := g.lease.layout
+	g.lease.issued(id >> (layout.DatacenterBits + layout.WorkerBits + layout.SequenceBits))
+	// Checked after issuing: an ID made while the lease was still valid is safe
+	if err := g.lease.Valid(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func defaultOwner() string {
+	host, _ := os.Hostname()
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b[:]))
+}
@@ -0,0 +1,200 @@
+package workerid
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/urlshortener/internal/idgen"
+)
+
+// memStore keeps claims in memory. renewErr, when set, is returned by every
+// Renew instead of extending the claim.
+type memStore struct {
+	mu       sync.Mutex
+	owners   map[int64]string
+	ticks    map[int64]int64
+	renewErr error
+}
+
+func newMemStore() *memStore {
+	return &memStore{owners: make(map[int64]string), ticks: make(map[int64]int64)}
+}
+
+func (s *memStore) Acquire(ctx context.Context, owner string, slots int64, ttl time.Duration) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for slot := range slots {
+		if _, taken := s.owners[slot]; !taken {
+			s.owners[slot] = owner
+			return slot, s.ticks[slot], nil
+		}
+	}
+	return 0, 0, ErrNoFreeSlot
+}
+
+func (s *memStore) Renew(ctx context.Context, slot int64, owner string, lastTick int64, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.renewErr != nil {
+		return s.renewErr
+	}
+	if s.owners[slot] != owner {
+		return ErrLeaseLost
+	}
+	s.ticks[slot] = max(s.ticks[slot], lastTick)
+	return nil
+}
+
+func (s *memStore) Release(ctx context.Context, slot int64, owner string, lastTick int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owners[slot] == owner {
+		delete(s.owners, slot)
+	}
+	s.ticks[slot] = max(s.ticks[slot], lastTick)
+	return nil
+}
+
+func (s *memStore) setRenewErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renewErr = err
+}
+
+// fixedClock stays where it is set; Sleep moves it forward.
+type fixedClock struct{ now time.Time }
+
+func (c *fixedClock) Now() time.Time        { return c.now }
+func (c *fixedClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+func acquire(t *testing.T, store Store, opts Options) *Lease {
+	t.Helper()
+	lease, err := Acquire(context.Background(), store, idgen.TwitterLayout, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lease.Close(context.Background()) })
+	return lease
+}
+
+func newGenerator(t *testing.T, lease *Lease, options idgen.Options) *Generator {
+	t.Helper()
+	g, err := NewGenerator(lease, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestNextFailsAfterTTLWithoutRenewal(t *testing.T) {
+	store := newMemStore()
+	store.setRenewErr(errors.New("store unreachable"))
+	lease := acquire(t, store, Options{TTL: 60 * time.Millisecond, Heartbeat: 20 * time.Millisecond})
+	g := newGenerator(t, lease, idgen.Options{})
+	if _, err := g.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := g.Next(); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("err = %v, want ErrLeaseLost", err)
+	}
+	select {
+	case <-lease.Done():
+		t.Fatal("Done closed although the slot was never reported taken")
+	default:
+	}
+
+	// The next successful renewal brings the lease back
+	store.setRenewErr(nil)
+	deadline := time.Now().Add(time.Second)
+	for lease.Valid() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("lease not valid again after renewals resumed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := g.Next(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNextFailsAfterSlotTaken(t *testing.T) {
+	store := newMemStore()
+	lease := acquire(t, store, Options{TTL: time.Second, Heartbeat: 10 * time.Millisecond})
+	g := newGenerator(t, lease, idgen.Options{})
+	if _, err := g.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	store.setRenewErr(ErrLeaseLost)
+	select {
+	case <-lease.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed after Renew reported the slot taken")
+	}
+	if !errors.Is(lease.Err(), ErrLeaseLost) {
+		t.Fatalf("Err = %v, want ErrLeaseLost", lease.Err())
+	}
+	if _, err := g.Next(); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("err = %v, want ErrLeaseLost", err)
+	}
+}
+
+// A new holder of the pair gets the last tick the previous one issued at and
+// treats a clock still behind it as moved backwards.
+func TestLastTickHandedToNextHolder(t *testing.T) {
+	layout := idgen.TwitterLayout
+	start := layout.Epoch.Add(time.Hour)
+	store := newMemStore()
+
+	first := acquire(t, store, Options{})
+	g := newGenerator(t, first, idgen.Options{Clock: &fixedClock{now: start}})
+	if _, err := g.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	last := layout.Ticks(start)
+
+	tests := []struct {
+		name    string
+		policy  idgen.ClockPolicy
+		wantErr bool
+	}{
+		{"error", idgen.ClockError, true},
+		{"logical", idgen.ClockLogical, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := acquire(t, store, Options{})
+			if lease.LastTick() != last {
+				t.Fatalf("LastTick = %d, want %d", lease.LastTick(), last)
+			}
+			clock := &fixedClock{now: start.Add(-time.Second)}
+			g := newGenerator(t, lease, idgen.Options{Policy: tt.policy, Clock: clock})
+
+			id, err := g.Next()
+			if tt.wantErr {
+				if !errors.Is(err, idgen.ErrClockMovedBackwards) {
+					t.Fatalf("err = %v, want ErrClockMovedBackwards", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := idgen.Decode(id, layout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tick := layout.Ticks(decoded.Timestamp); tick <= last {
+				t.Fatalf("issued at tick %d, previous holder's last tick %d", tick, last)
+			}
+		})
+	}
+}
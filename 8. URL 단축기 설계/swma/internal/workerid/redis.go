@@ -0,0 +1,206 @@
+package workerid
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisStore keeps each claim in its own key (Prefix + slot) holding the
+// owner, with the TTL as the key expiry. The last tick lives in a separate
+// key without expiry (Prefix + slot + ":tick") so it outlasts the claim.
+//
+// It speaks just enough of the Redis protocol for SET NX and EVAL over a
+// single connection, which is all leasing needs.
+type RedisStore struct {
+	Addr     string
+	Password string
+	Prefix   string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func NewRedisStore(addr, password string) *RedisStore {
+	return &RedisStore{Addr: addr, Password: password, Prefix: "urlshortener:worker:"}
+}
+
+// Extends the claim and raises the last tick only while owner still holds it.
+// Once the key expired or was released, another owner may have held the slot
+// and issued later ticks in between, so the lease is lost and has to go back
+// through Acquire to read the tick again.
+const renewScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	if tonumber(ARGV[3]) > tonumber(redis.call("GET", KEYS[2]) or "0") then
+		redis.call("SET", KEYS[2], ARGV[3])
+	end
+	return 1
+end
+return 0
+`
+
+const releaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	if tonumber(ARGV[2]) > tonumber(redis.call("GET", KEYS[2]) or "0") then
+		redis.call("SET", KEYS[2], ARGV[2])
+	end
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+func (s *RedisStore) key(slot int64) string {
+	return s.Prefix + strconv.FormatInt(slot, 10)
+}
+
+func (s *RedisStore) tickKey(slot int64) string {
+	return s.key(slot) + ":tick"
+}
+
+func (s *RedisStore) Acquire(ctx context.Context, owner string, slots int64, ttl time.Duration) (int64, int64, error) {
+	// Start at a random slot so instances starting together don't race for slot 0
+	start := rand.Int63n(slots)
+	for i := int64(0); i < slots; i++ {
+		slot := (start + i) % slots
+		reply, err := s.do(ctx, "SET", s.key(slot), owner, "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+		if err != nil {
+			return 0, 0, err
+		}
+		if reply != "OK" {
+			continue
+		}
+		// Read after claiming: the previous owner can't record a later tick anymore
+		tick, err := s.do(ctx, "GET", s.tickKey(slot))
+		if err != nil {
+			return 0, 0, err
+		}
+		var lastTick int64
+		if tick != nil {
+			if lastTick, err = strconv.ParseInt(tick.(string), 10, 64); err != nil {
+				return 0, 0, fmt.Errorf("redis: malformed last tick %q", tick)
+			}
+		}
+		return slot, lastTick, nil
+	}
+	return 0, 0, ErrNoFreeSlot
+}
+
+func (s *RedisStore) Renew(ctx context.Context, slot int64, owner string, lastTick int64, ttl time.Duration) error {
+	reply, err := s.do(ctx, "EVAL", renewScript, "2", s.key(slot), s.tickKey(slot), owner, strconv.FormatInt(ttl.Milliseconds(), 10), strconv.FormatInt(lastTick, 10))
+	if err != nil {
+		return err
+	}
+	if reply != int64(1) {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, slot int64, owner string, lastTick int64) error {
+	_, err := s.do(ctx, "EVAL", releaseScript, "2", s.key(slot), s.tickKey(slot), owner, strconv.FormatInt(lastTick, 10))
+	return err
+}
+
+// do sends one command and returns its reply: string for simple and bulk
+// strings, int64 for integers, nil for a nil bulk string.
+func (s *RedisStore) do(ctx context.Context, args ...string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := s.roundTrip(ctx, args)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// Connection state is unknown after an I/O error
+		s.conn.Close()
+		s.conn = nil
+	}
+	return reply, err
+}
+
+func (s *RedisStore) dial(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	s.conn, s.r = conn, bufio.NewReader(conn)
+	if s.Password != "" {
+		if _, err := s.roundTrip(ctx, []string{"AUTH", s.Password}); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return fmt.Errorf("redis auth: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *RedisStore) roundTrip(ctx context.Context, args []string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, a := range args {
+		buf = fmt.Appendf(buf, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := s.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return s.readReply()
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (s *RedisStore) readReply() (any, error) {
+	line, err := s.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(s.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
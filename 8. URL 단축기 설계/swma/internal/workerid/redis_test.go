@@ -0,0 +1,227 @@
+package workerid
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestRedisStore returns a store on the Redis at WORKERID_TEST_REDIS_ADDR
+// (with a prefix of its own), or on an in-process fake speaking the commands
+// RedisStore sends.
+func newTestRedisStore(t *testing.T) func() *RedisStore {
+	t.Helper()
+	addr := os.Getenv("WORKERID_TEST_REDIS_ADDR")
+	if addr == "" {
+		addr = startFakeRedis(t)
+	}
+	prefix := fmt.Sprintf("urlshortener:test:%d:%s:", time.Now().UnixNano(), t.Name())
+	return func() *RedisStore {
+		s := NewRedisStore(addr, os.Getenv("WORKERID_TEST_REDIS_PASSWORD"))
+		s.Prefix = prefix
+		t.Cleanup(func() {
+			if s.conn != nil {
+				s.conn.Close()
+			}
+		})
+		return s
+	}
+}
+
+func TestRedisRenewFailsAfterExpiry(t *testing.T) {
+	newStore := newTestRedisStore(t)
+	ctx := context.Background()
+	a, b := newStore(), newStore()
+	const ttl = 50 * time.Millisecond
+
+	slot, _, err := a.Acquire(ctx, "a", 1, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Renew(ctx, slot, "a", 10, ttl); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expired and nobody took it: still lost, the tick must be read again
+	time.Sleep(2 * ttl)
+	if err := a.Renew(ctx, slot, "a", 20, ttl); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("renew after expiry: err = %v, want ErrLeaseLost", err)
+	}
+
+	// Expired and taken by another owner in between
+	slot, lastTick, err := b.Acquire(ctx, "b", 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastTick != 10 {
+		t.Fatalf("b got last tick %d, want 10", lastTick)
+	}
+	if err := a.Renew(ctx, slot, "a", 30, ttl); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("renew after takeover: err = %v, want ErrLeaseLost", err)
+	}
+	if err := b.Renew(ctx, slot, "b", 15, time.Minute); err != nil {
+		t.Fatalf("b renew: %v", err)
+	}
+
+	// The stale owner neither released b's claim nor recorded its tick
+	if err := a.Release(ctx, slot, "a", 40); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := newStore().Acquire(ctx, "c", 1, ttl); !errors.Is(err, ErrNoFreeSlot) {
+		t.Fatalf("acquire while b holds the slot: err = %v, want ErrNoFreeSlot", err)
+	}
+	if err := b.Release(ctx, slot, "b", 15); err != nil {
+		t.Fatal(err)
+	}
+	if _, lastTick, err = newStore().Acquire(ctx, "c", 1, ttl); err != nil {
+		t.Fatal(err)
+	}
+	if lastTick != 15 {
+		t.Fatalf("c got last tick %d, want 15", lastTick)
+	}
+}
+
+// fakeRedis keeps string keys with optional expiry and runs the lease scripts
+// by name, following the Lua in redis.go step by step.
+type fakeRedis struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func startFakeRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	f := &fakeRedis{values: make(map[string]string), expires: make(map[string]time.Time)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		// SET key value NX PX ms
+		if _, ok := f.get(args[1]); ok {
+			return "$-1\r\n"
+		}
+		ms, _ := strconv.Atoi(args[5])
+		f.set(args[1], args[2], time.Duration(ms)*time.Millisecond)
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.get(args[1])
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "EVAL":
+		script, keys, argv := args[1], args[3:5], args[5:]
+		switch script {
+		case renewScript:
+			if v, ok := f.get(keys[0]); !ok || v != argv[0] {
+				return ":0\r\n"
+			}
+			ms, _ := strconv.Atoi(argv[1])
+			f.set(keys[0], argv[0], time.Duration(ms)*time.Millisecond)
+			f.raiseTick(keys[1], argv[2])
+			return ":1\r\n"
+		case releaseScript:
+			if v, ok := f.get(keys[0]); !ok || v != argv[0] {
+				return ":0\r\n"
+			}
+			f.raiseTick(keys[1], argv[1])
+			delete(f.values, keys[0])
+			return ":1\r\n"
+		}
+	}
+	return "-ERR unsupported command\r\n"
+}
+
+func (f *fakeRedis) get(key string) (string, bool) {
+	if exp, ok := f.expires[key]; ok && !time.Now().Before(exp) {
+		delete(f.values, key)
+		delete(f.expires, key)
+	}
+	v, ok := f.values[key]
+	return v, ok
+}
+
+func (f *fakeRedis) set(key, value string, ttl time.Duration) {
+	f.values[key] = value
+	delete(f.expires, key)
+	if ttl > 0 {
+		f.expires[key] = time.Now().Add(ttl)
+	}
+}
+
+func (f *fakeRedis) raiseTick(key, tick string) {
+	cur, _ := f.get(key)
+	old, _ := strconv.ParseInt(cur, 10, 64)
+	if n, _ := strconv.ParseInt(tick, 10, 64); n > old {
+		f.set(key, tick, 0)
+	}
+}
@@ -0,0 +1,118 @@
+package workerid
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SQLStore keeps claims in the worker_leases table (see migrate.EnsureSchema).
+// Expiry is compared against the database clock so instance clocks don't matter.
+type SQLStore struct {
+	DB *sql.DB
+}
+
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{DB: db}
+}
+
+func (s *SQLStore) Acquire(ctx context.Context, owner string, slots int64, ttl time.Duration) (int64, int64, error) {
+	// Every step is a compare-and-set, so losing a race just means trying again
+	for attempt := 0; attempt < 10; attempt++ {
+		// Take over an expired claim first so slots get reused
+		rows, err := s.DB.QueryContext(ctx, `
+SELECT slot FROM worker_leases
+WHERE slot < ? AND expires_at < NOW(6)
+ORDER BY slot
+LIMIT 16
+`, slots)
+		if err != nil {
+			return 0, 0, err
+		}
+		var expired []int64
+		for rows.Next() {
+			var slot int64
+			if err := rows.Scan(&slot); err != nil {
+				rows.Close()
+				return 0, 0, err
+			}
+			expired = append(expired, slot)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, 0, err
+		}
+
+		for _, slot := range expired {
+			res, err := s.DB.ExecContext(ctx, `
+UPDATE worker_leases
+SET owner = ?, expires_at = NOW(6) + INTERVAL ? MICROSECOND
+WHERE slot = ? AND expires_at < NOW(6)
+`, owner, ttl.Microseconds(), slot)
+			if err != nil {
+				return 0, 0, err
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return 0, 0, err
+			} else if n == 1 {
+				// Read after taking it over: the previous owner can't record a later tick anymore
+				var lastTick int64
+				err := s.DB.QueryRowContext(ctx, `SELECT last_tick FROM worker_leases WHERE slot = ?`, slot).Scan(&lastTick)
+				return slot, lastTick, err
+			}
+		}
+
+		// Otherwise claim the next never-used slot
+		var next int64
+		err = s.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(slot) + 1, 0) FROM worker_leases`).Scan(&next)
+		if err != nil {
+			return 0, 0, err
+		}
+		if next >= slots {
+			if len(expired) == 0 {
+				return 0, 0, ErrNoFreeSlot
+			}
+			continue
+		}
+		res, err := s.DB.ExecContext(ctx, `
+INSERT IGNORE INTO worker_leases (slot, owner, expires_at)
+VALUES (?, ?, NOW(6) + INTERVAL ? MICROSECOND)
+`, next, owner, ttl.Microseconds())
+		if err != nil {
+			return 0, 0, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return 0, 0, err
+		} else if n == 1 {
+			return next, 0, nil
+		}
+	}
+	return 0, 0, ErrNoFreeSlot
+}
+
+func (s *SQLStore) Renew(ctx context.Context, slot int64, owner string, lastTick int64, ttl time.Duration) error {
+	res, err := s.DB.ExecContext(ctx, `
+UPDATE worker_leases
+SET expires_at = NOW(6) + INTERVAL ? MICROSECOND, last_tick = GREATEST(last_tick, ?)
+WHERE slot = ? AND owner = ?
+`, ttl.Microseconds(), lastTick, slot, owner)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *SQLStore) Release(ctx context.Context, slot int64, owner string, lastTick int64) error {
+	_, err := s.DB.ExecContext(ctx, `
+UPDATE worker_leases SET expires_at = NOW(6), last_tick = GREATEST(last_tick, ?)
+WHERE slot = ? AND owner = ?
+`, lastTick, slot, owner)
+	return err
+}